@@ -0,0 +1,82 @@
+package googleSS
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Every Sheets failure is returned as a *SheetError whose Kind is one of these,
+// so callers can use errors.Is to tell a transient failure from a broken config
+var (
+	ErrSheetsDisabled = errors.New("sheets disabled")
+	ErrAuth           = errors.New("sheets authentication failed")
+	ErrQuota          = errors.New("sheets quota exceeded")
+	ErrBadRange       = errors.New("invalid sheet range")
+	ErrNotFound       = errors.New("spreadsheet not found")
+	ErrUnavailable    = errors.New("sheets unavailable")
+)
+
+// SheetError describes a failed Sheets call
+type SheetError struct {
+	Op    string // "append", "update", "get" or "clear"
+	Range string
+	Kind  error
+	Err   error
+}
+
+func (e *SheetError) Error() string {
+	return fmt.Sprintf("sheets %s %s: %v: %v", e.Op, e.Range, e.Kind, e.Err)
+}
+
+// Unwrap exposes both the kind and the underlying API error to errors.Is/As
+func (e *SheetError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// IsTransient reports whether retrying the same call later can succeed
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrQuota) || errors.Is(err, ErrUnavailable) || errors.Is(err, ErrAuth)
+}
+
+// sheetError classifies an error returned by the Sheets client
+func sheetError(op string, sheetRange string, err error) error {
+	return &SheetError{Op: op, Range: sheetRange, Kind: errorKind(err), Err: err}
+}
+
+func errorKind(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return ErrAuth
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		// Network errors, timeouts, etc.
+		return ErrUnavailable
+	}
+
+	switch apiErr.Code {
+	case http.StatusUnauthorized:
+		return ErrAuth
+	case http.StatusForbidden:
+		for _, item := range apiErr.Errors {
+			// rateLimitExceeded / userRateLimitExceeded
+			if strings.Contains(strings.ToLower(item.Reason), "ratelimitexceeded") {
+				return ErrQuota
+			}
+		}
+		return ErrAuth
+	case http.StatusTooManyRequests:
+		return ErrQuota
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrBadRange
+	}
+	return ErrUnavailable
+}
@@ -0,0 +1,485 @@
+package googleSS
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// OutboxStore persists sheet jobs so pending writes survive a restart.
+// EnqueueSheetJob, RequeueSheetJob, DiscardSheetJob and ListSheetJobs act for
+// the household scoped in ctx; a claimed job carries the spreadsheet of the
+// household it was queued for. Implemented by postgres.SheetOutbox.
+type OutboxStore interface {
+	EnqueueSheetJob(ctx context.Context, kind string, payload []byte) (types.SheetJob, error)
+	ClaimSheetJob(ctx context.Context, lease time.Duration) (*types.SheetJob, error)
+	CompleteSheetJob(ctx context.Context, id int64) error
+	RetrySheetJob(ctx context.Context, id int64, attempts int, nextAttempt time.Time, lastError string) error
+	FailSheetJob(ctx context.Context, id int64, attempts int, lastError string) error
+	RequeueSheetJob(ctx context.Context, id int64) (types.SheetJob, error)
+	DiscardSheetJob(ctx context.Context, id int64) (types.SheetJob, error)
+	ListSheetJobs(ctx context.Context, statuses []string, limit int) ([]types.SheetJob, error)
+}
+
+// Job kinds stored in sheet_outbox.kind
+const (
+	JobAppendRows          = "append_rows"
+	JobUpdateRange         = "update_range"
+	JobUpdateCell          = "update_cell"
+	JobUpdateExpenseRow    = "update_expense_row"
+	JobClearExpenseRow     = "clear_expense_row"
+	JobUpdateIncomeRow     = "update_income_row"
+	JobClearIncomeRow      = "clear_income_row"
+	JobUpdateInvestmentRow = "update_investment_row"
+	JobClearInvestmentRow  = "clear_investment_row"
+	JobUpdateCapitalCell   = "update_capital_cell"
+)
+
+const (
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+	outboxPollEvery   = 2 * time.Second
+	outboxLease       = 2 * time.Minute
+)
+
+// ErrOutboxNotStarted is returned when a handler runs without an Outbox
+var ErrOutboxNotStarted = errors.New("sheet outbox not started")
+
+// Outbox queues sheet writes in a store and replays them against a sink.
+// With a nil store every write goes straight to the sink, which is what the
+// tests use to assert on a RecordingSink.
+type Outbox struct {
+	store OutboxStore
+	sink  SheetSink
+	wake  chan struct{}
+}
+
+func NewOutbox(store OutboxStore, sink SheetSink) *Outbox {
+	return &Outbox{store: store, sink: sink, wake: make(chan struct{}, 1)}
+}
+
+// rangeJob is the payload of append_rows and update_range jobs.
+// Rows are built when the job is enqueued, so a retry writes exactly what the
+// handler saw when it committed to the database.
+type rangeJob struct {
+	Range string          `json:"range"`
+	Rows  [][]interface{} `json:"rows"`
+}
+
+// cellJob is the payload of update_cell jobs
+type cellJob struct {
+	Range string      `json:"range"`
+	Value interface{} `json:"value"`
+}
+
+// expenseRowJob is the payload of update_expense_row and clear_expense_row jobs
+type expenseRowJob struct {
+	Config      types.Config   `json:"config"`
+	Match       types.Expense  `json:"match"`
+	Replacement *types.Expense `json:"replacement,omitempty"`
+}
+
+// incomeRowJob is the payload of update_income_row and clear_income_row jobs
+type incomeRowJob struct {
+	Config      types.Config  `json:"config"`
+	Match       types.Income  `json:"match"`
+	Replacement *types.Income `json:"replacement,omitempty"`
+}
+
+// investmentRowJob is the payload of update_investment_row and clear_investment_row jobs
+type investmentRowJob struct {
+	Config      types.Config      `json:"config"`
+	Match       types.Investment  `json:"match"`
+	Replacement *types.Investment `json:"replacement,omitempty"`
+}
+
+// capitalJob is the payload of update_capital_cell jobs
+type capitalJob struct {
+	Config      types.Config `json:"config"`
+	AccountName string       `json:"account_name"`
+	Capital     float64      `json:"capital"`
+}
+
+// Disabled returns why sheets are disabled, or nil when writes can reach the sink
+func (o *Outbox) Disabled() error {
+	if o == nil {
+		return ErrOutboxNotStarted
+	}
+	if d, ok := o.sink.(DisabledSink); ok {
+		return d.Reason
+	}
+	return nil
+}
+
+// Start runs the goroutine that drains the store until ctx is cancelled.
+// While sheets are disabled jobs are only stored; they are written once the
+// server restarts with working credentials.
+func (o *Outbox) Start(ctx context.Context) {
+	if o.store == nil {
+		return
+	}
+	if err := o.Disabled(); err != nil {
+		log.Printf("[sheets] Outbox worker not started: %v", err)
+		return
+	}
+	go o.run(ctx)
+}
+
+func (o *Outbox) run(ctx context.Context) {
+	log.Println("[sheets] Outbox worker started")
+	for {
+		if o.processNext(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[sheets] Outbox worker stopped")
+			return
+		case <-o.wake:
+		case <-time.After(outboxPollEvery):
+		}
+	}
+}
+
+// Drain runs every job that is due right now and returns how many it ran
+func (o *Outbox) Drain(ctx context.Context) int {
+	count := 0
+	for o.store != nil && o.processNext(ctx) {
+		count++
+	}
+	return count
+}
+
+// processNext claims and runs one job; it returns false when none was due
+func (o *Outbox) processNext(ctx context.Context) bool {
+	job, err := o.store.ClaimSheetJob(ctx, outboxLease)
+	if err != nil {
+		log.Printf("[sheets] Error claiming sheet job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	err = o.runJob(*job)
+	if err == nil {
+		if err := o.store.CompleteSheetJob(ctx, job.Id); err != nil {
+			log.Printf("[sheets] Error completing job %d: %v", job.Id, err)
+		}
+		return true
+	}
+
+	// Quota, network and auth failures are retried; a missing row, a bad range
+	// or a malformed job will fail the same way every time
+	attempts := job.Attempts + 1
+	if !IsTransient(err) || attempts >= outboxMaxAttempts {
+		log.Printf("[sheets] Job %d (%s) failed after %d attempts: %v", job.Id, job.Kind, attempts, err)
+		if err := o.store.FailSheetJob(ctx, job.Id, attempts, err.Error()); err != nil {
+			log.Printf("[sheets] Error failing job %d: %v", job.Id, err)
+		}
+		return true
+	}
+
+	backoff := outboxBackoff(attempts)
+	log.Printf("[sheets] Job %d (%s) attempt %d failed, retrying in %s: %v", job.Id, job.Kind, attempts, backoff, err)
+	if err := o.store.RetrySheetJob(ctx, job.Id, attempts, time.Now().Add(backoff), err.Error()); err != nil {
+		log.Printf("[sheets] Error rescheduling job %d: %v", job.Id, err)
+	}
+	return true
+}
+
+// outboxBackoff doubles the delay on every attempt: 2s, 4s, 8s ... capped at outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+var errBadSheetJob = errors.New("bad sheet job")
+
+// runJob decodes a stored job and writes it to its household's spreadsheet
+func (o *Outbox) runJob(job types.SheetJob) error {
+	var payload interface{}
+	switch job.Kind {
+	case JobAppendRows, JobUpdateRange:
+		payload = &rangeJob{}
+	case JobUpdateCell:
+		payload = &cellJob{}
+	case JobUpdateExpenseRow, JobClearExpenseRow:
+		payload = &expenseRowJob{}
+	case JobUpdateIncomeRow, JobClearIncomeRow:
+		payload = &incomeRowJob{}
+	case JobUpdateInvestmentRow, JobClearInvestmentRow:
+		payload = &investmentRowJob{}
+	case JobUpdateCapitalCell:
+		payload = &capitalJob{}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", errBadSheetJob, job.Kind)
+	}
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		return fmt.Errorf("%w: %v", errBadSheetJob, err)
+	}
+	return write(o.sinkFor(job.SpreadsheetId), job.Kind, payload)
+}
+
+// sinkFor returns the sink writing to spreadsheetID; an empty id, or a sink
+// that can't switch spreadsheets, means the default one
+func (o *Outbox) sinkFor(spreadsheetID string) SheetSink {
+	if spreadsheetID == "" {
+		return o.sink
+	}
+	if s, ok := o.sink.(SpreadsheetSink); ok {
+		return s.ForSpreadsheet(spreadsheetID)
+	}
+	return o.sink
+}
+
+func write(sink SheetSink, kind string, payload interface{}) error {
+	switch p := payload.(type) {
+	case *rangeJob:
+		if kind == JobAppendRows {
+			return sink.AppendRows(p.Range, p.Rows)
+		}
+		return sink.UpdateRange(p.Range, p.Rows)
+	case *cellJob:
+		return sink.UpdateCell(p.Range, p.Value)
+	case *expenseRowJob:
+		if kind == JobClearExpenseRow {
+			return DeleteExpenseRow(sink, p.Match, p.Config)
+		}
+		if p.Replacement == nil {
+			return fmt.Errorf("%w: update_expense_row without replacement", errBadSheetJob)
+		}
+		return UpdateExpenseRow(sink, p.Match, *p.Replacement, p.Config)
+	case *incomeRowJob:
+		if kind == JobClearIncomeRow {
+			return DeleteIncomeRow(sink, p.Match, p.Config)
+		}
+		if p.Replacement == nil {
+			return fmt.Errorf("%w: update_income_row without replacement", errBadSheetJob)
+		}
+		return UpdateIncomeRow(sink, p.Match, *p.Replacement, p.Config)
+	case *investmentRowJob:
+		if kind == JobClearInvestmentRow {
+			return DeleteInvestmentRow(sink, p.Match, p.Config)
+		}
+		if p.Replacement == nil {
+			return fmt.Errorf("%w: update_investment_row without replacement", errBadSheetJob)
+		}
+		return UpdateInvestmentRow(sink, p.Match, *p.Replacement, p.Config)
+	case *capitalJob:
+		return UpdateInvestmentCapitalCell(sink, p.Config, p.AccountName, p.Capital)
+	}
+	return fmt.Errorf("%w: unexpected payload %T", errBadSheetJob, payload)
+}
+
+// enqueue stores the job for the household scoped in ctx, or writes it right
+// away to the default spreadsheet when there is no store
+func (o *Outbox) enqueue(ctx context.Context, kind string, payload interface{}) error {
+	if o == nil {
+		return ErrOutboxNotStarted
+	}
+	if o.store == nil {
+		return write(o.sink, kind, payload)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding %s job: %w", kind, err)
+	}
+	// Not cancelled with the request: the database write this job mirrors has
+	// already committed, so the job must be stored even if the client has gone
+	// away. The values (the household scope) are kept.
+	if _, err := o.store.EnqueueSheetJob(context.WithoutCancel(ctx), kind, data); err != nil {
+		return err
+	}
+
+	o.notify()
+	return nil
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) enqueueAppend(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	return o.enqueue(ctx, JobAppendRows, &rangeJob{Range: sheetRange, Rows: rows})
+}
+
+func (o *Outbox) enqueueUpdate(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	return o.enqueue(ctx, JobUpdateRange, &rangeJob{Range: sheetRange, Rows: rows})
+}
+
+// ========== ENQUEUE ==========
+// Handlers commit to the database first and then enqueue the matching sheet write
+
+func (o *Outbox) EnqueueExpenseRow(ctx context.Context, expense types.Expense, config types.Config) error {
+	return o.enqueueAppend(ctx, expenseSheetRange(config), [][]interface{}{expenseRowValues(expense)})
+}
+
+// EnqueueExpenseRows appends the rows of several expenses as a single write
+func (o *Outbox) EnqueueExpenseRows(ctx context.Context, expenses []types.Expense, config types.Config) error {
+	rows := make([][]interface{}, len(expenses))
+	for i, expense := range expenses {
+		rows[i] = expenseRowValues(expense)
+	}
+	return o.enqueueAppend(ctx, expenseSheetRange(config), rows)
+}
+
+// EnqueueExpenseRowUpdate rewrites the row that was written for previous
+func (o *Outbox) EnqueueExpenseRowUpdate(ctx context.Context, previous types.Expense, updated types.Expense, config types.Config) error {
+	return o.enqueue(ctx, JobUpdateExpenseRow, &expenseRowJob{Config: config, Match: previous, Replacement: &updated})
+}
+
+// EnqueueExpenseRowDelete clears the row that was written for expense
+func (o *Outbox) EnqueueExpenseRowDelete(ctx context.Context, expense types.Expense, config types.Config) error {
+	return o.enqueue(ctx, JobClearExpenseRow, &expenseRowJob{Config: config, Match: expense})
+}
+
+func (o *Outbox) EnqueueBudget(ctx context.Context, budgets []types.Budget, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), budgetRows(budgets))
+}
+
+func (o *Outbox) EnqueueInvestment(ctx context.Context, investment types.Investment, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{investmentRowValues(investment)})
+}
+
+// EnqueueInvestmentRowUpdate rewrites the row that was written for previous
+func (o *Outbox) EnqueueInvestmentRowUpdate(ctx context.Context, previous types.Investment, updated types.Investment, config types.Config) error {
+	return o.enqueue(ctx, JobUpdateInvestmentRow, &investmentRowJob{Config: config, Match: previous, Replacement: &updated})
+}
+
+// EnqueueInvestmentRowDelete clears the row that was written for investment
+func (o *Outbox) EnqueueInvestmentRowDelete(ctx context.Context, investment types.Investment, config types.Config) error {
+	return o.enqueue(ctx, JobClearInvestmentRow, &investmentRowJob{Config: config, Match: investment})
+}
+
+func (o *Outbox) EnqueueIncome(ctx context.Context, income types.Income, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{incomeRowValues(income)})
+}
+
+// EnqueueIncomes appends the rows of several incomes as a single write
+func (o *Outbox) EnqueueIncomes(ctx context.Context, incomes []types.Income, config types.Config) error {
+	rows := make([][]interface{}, len(incomes))
+	for i, income := range incomes {
+		rows[i] = incomeRowValues(income)
+	}
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), rows)
+}
+
+// EnqueueIncomeRowUpdate rewrites the row that was written for previous
+func (o *Outbox) EnqueueIncomeRowUpdate(ctx context.Context, previous types.Income, updated types.Income, config types.Config) error {
+	return o.enqueue(ctx, JobUpdateIncomeRow, &incomeRowJob{Config: config, Match: previous, Replacement: &updated})
+}
+
+// EnqueueIncomeRowDelete clears the row that was written for income
+func (o *Outbox) EnqueueIncomeRowDelete(ctx context.Context, income types.Income, config types.Config) error {
+	return o.enqueue(ctx, JobClearIncomeRow, &incomeRowJob{Config: config, Match: income})
+}
+
+func (o *Outbox) EnqueueDebt(ctx context.Context, debt types.Debt, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{debtRowValues(debt)})
+}
+
+func (o *Outbox) EnqueueAccount(ctx context.Context, account types.Account, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{accountRowValues(account)})
+}
+
+func (o *Outbox) EnqueueInvestmentAccount(ctx context.Context, investmentAccount types.InvestmentAccount, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{investmentAccountRowValues(investmentAccount)})
+}
+
+func (o *Outbox) EnqueueDebtor(ctx context.Context, debtor types.Debtor, config types.Config) error {
+	return o.enqueueAppend(ctx, fmt.Sprint(config.Sheet, config.A1Range), [][]interface{}{debtorRowValues(debtor)})
+}
+
+func (o *Outbox) EnqueueAccountBalances(ctx context.Context, accounts []types.Account, config types.Config) error {
+	return o.enqueueUpdate(ctx, fmt.Sprint(config.Sheet, config.A1Range), accountBalanceRows(accounts))
+}
+
+func (o *Outbox) EnqueueInvestmentAccountBalances(ctx context.Context, accounts []types.InvestmentAccount, config types.Config) error {
+	return o.enqueueUpdate(ctx, fmt.Sprint(config.Sheet, config.A1Range), investmentAccountBalanceRows(accounts))
+}
+
+// EnqueueInvestmentCapital rewrites the capital cell of the account named
+// accountName in the config tab's investment_capital range
+func (o *Outbox) EnqueueInvestmentCapital(ctx context.Context, accountName string, capital types.Money, config types.Config) error {
+	return o.enqueue(ctx, JobUpdateCapitalCell, &capitalJob{Config: config, AccountName: accountName, Capital: capital.Float64()})
+}
+
+// EnqueueSheetCell queues a single cell update
+// sheetRange should be in format "SheetName!A1" (e.g., "2026!D3")
+func (o *Outbox) EnqueueSheetCell(ctx context.Context, sheetRange string, value interface{}) error {
+	return o.enqueue(ctx, JobUpdateCell, &cellJob{Range: sheetRange, Value: value})
+}
+
+// ========== ADMIN ==========
+
+// Check reads sheetRange in spreadsheetID (empty for the default spreadsheet)
+// to verify the credentials, the spreadsheet and the range
+func (o *Outbox) Check(spreadsheetID string, sheetRange string) error {
+	if err := o.Disabled(); err != nil {
+		return err
+	}
+	_, _, err := o.sinkFor(spreadsheetID).ReadRange(sheetRange)
+	return err
+}
+
+// ListSheetJobs returns the jobs still in the outbox with the given statuses
+func (o *Outbox) ListSheetJobs(ctx context.Context, statuses []string, limit int) ([]types.SheetJob, error) {
+	if o == nil {
+		return nil, ErrOutboxNotStarted
+	}
+	if o.store == nil {
+		return []types.SheetJob{}, nil
+	}
+	return o.store.ListSheetJobs(ctx, statuses, limit)
+}
+
+// RetrySheetJob puts a failed job back in the queue and wakes the worker.
+// It returns ErrSheetsDisabled when there is no worker to pick the job up.
+func (o *Outbox) RetrySheetJob(ctx context.Context, id int64) (types.SheetJob, error) {
+	if err := o.Disabled(); err != nil {
+		return types.SheetJob{}, err
+	}
+	if o.store == nil {
+		return types.SheetJob{}, ErrOutboxNotStarted
+	}
+	job, err := o.store.RequeueSheetJob(ctx, id)
+	if err != nil {
+		return types.SheetJob{}, err
+	}
+
+	o.notify()
+	return job, nil
+}
+
+// DiscardSheetJob drops a failed job that will never reach the sheet, and
+// wakes the worker for the household's jobs it was holding back
+func (o *Outbox) DiscardSheetJob(ctx context.Context, id int64) (types.SheetJob, error) {
+	if o == nil || o.store == nil {
+		return types.SheetJob{}, ErrOutboxNotStarted
+	}
+	job, err := o.store.DiscardSheetJob(ctx, id)
+	if err != nil {
+		return types.SheetJob{}, err
+	}
+
+	o.notify()
+	return job, nil
+}
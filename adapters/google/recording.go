@@ -0,0 +1,137 @@
+package googleSS
+
+import "sync"
+
+// SinkCall is one write received by a RecordingSink
+type SinkCall struct {
+	Spreadsheet string // empty for the default spreadsheet
+	Op          string // "append", "update", "cell" or "clear"
+	Range       string
+	Rows        [][]interface{}
+}
+
+// RecordingSink is an in-memory SheetSink. It records every write in order and
+// keeps appended rows per range, so rows it appended can be found, updated and
+// cleared again like in a real sheet. The sinks returned by ForSpreadsheet share
+// its record and keep their rows apart.
+type RecordingSink struct {
+	*recording
+	spreadsheet string
+}
+
+type recording struct {
+	mu     sync.Mutex
+	calls  []SinkCall
+	tables map[tableRef][][]interface{}
+}
+
+// tableRef is a table of one spreadsheet
+type tableRef struct {
+	spreadsheet string
+	key         string
+}
+
+func NewRecordingSink() *RecordingSink {
+	return &RecordingSink{recording: &recording{tables: map[tableRef][][]interface{}{}}}
+}
+
+// ForSpreadsheet returns a sink recording into the same log for spreadsheetID
+func (s *RecordingSink) ForSpreadsheet(spreadsheetID string) SheetSink {
+	return &RecordingSink{recording: s.recording, spreadsheet: spreadsheetID}
+}
+
+// Calls returns the writes recorded so far, for every spreadsheet
+func (s *RecordingSink) Calls() []SinkCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkCall(nil), s.calls...)
+}
+
+// Reset forgets every recorded write and appended row
+func (s *RecordingSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+	s.tables = map[tableRef][][]interface{}{}
+}
+
+// SeedRows stores rows as if they were already in sheetRange, without recording a write
+func (s *RecordingSink) SeedRows(sheetRange string, rows [][]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ref := s.table(sheetRange)
+	s.tables[ref] = append(s.tables[ref], rows...)
+}
+
+func (s *RecordingSink) AppendRows(sheetRange string, rows [][]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record("append", sheetRange, rows)
+	ref := s.table(sheetRange)
+	s.tables[ref] = append(s.tables[ref], rows...)
+	return nil
+}
+
+func (s *RecordingSink) UpdateRange(sheetRange string, rows [][]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record("update", sheetRange, rows)
+	s.writeRows(sheetRange, rows)
+	return nil
+}
+
+func (s *RecordingSink) UpdateCell(sheetRange string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := [][]interface{}{{value}}
+	s.record("cell", sheetRange, rows)
+	s.writeRows(sheetRange, rows)
+	return nil
+}
+
+func (s *RecordingSink) ClearRange(sheetRange string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record("clear", sheetRange, nil)
+	s.writeRows(sheetRange, [][]interface{}{{}})
+	return nil
+}
+
+// ReadRange returns the rows appended to sheetRange, starting at row 1
+func (s *RecordingSink) ReadRange(sheetRange string) (string, [][]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ref := s.table(sheetRange)
+	return ref.key + "1", append([][]interface{}(nil), s.tables[ref]...), nil
+}
+
+func (s *RecordingSink) record(op string, sheetRange string, rows [][]interface{}) {
+	s.calls = append(s.calls, SinkCall{Spreadsheet: s.spreadsheet, Op: op, Range: sheetRange, Rows: rows})
+}
+
+func (s *RecordingSink) table(sheetRange string) tableRef {
+	return tableRef{spreadsheet: s.spreadsheet, key: tableKey(sheetRange)}
+}
+
+// writeRows replaces the appended rows that sheetRange points at, if any
+func (s *RecordingSink) writeRows(sheetRange string, rows [][]interface{}) {
+	table, ok := s.tables[s.table(sheetRange)]
+	if !ok {
+		return
+	}
+	_, _, startRow := splitA1Range(sheetRange)
+	for i, row := range rows {
+		index := startRow - 1 + i
+		if index >= len(table) {
+			return
+		}
+		table[index] = row
+	}
+}
+
+// tableKey identifies a table by sheet and first column ("Sheet!A:I" and
+// "Sheet!A12:I12" both become "Sheet!A")
+func tableKey(sheetRange string) string {
+	sheet, col, _ := splitA1Range(sheetRange)
+	return sheet + "!" + col
+}
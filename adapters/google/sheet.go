@@ -2,10 +2,16 @@ package googleSS
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/carlosdimatteo/fintrack-backend-go/types"
 	"google.golang.org/api/option"
@@ -19,402 +25,559 @@ const (
 )
 
 // IsDevMode returns true if GO_ENV is set to dev, development, or test
-// In dev mode, sheet operations are skipped
+// In dev mode, sheet writes go to an in-memory RecordingSink
 func IsDevMode() bool {
 	env := os.Getenv("GO_ENV")
 	return env == "dev" || env == "development" || env == "test"
 }
 
-func getSheetService() (*sheets.SpreadsheetsValuesService, error) {
-	// Skip in dev mode
+// SheetSink is where sheet writes end up. SheetsSink talks to the Google Sheets
+// API; RecordingSink keeps everything in memory for dev mode and tests.
+type SheetSink interface {
+	AppendRows(sheetRange string, rows [][]interface{}) error
+	UpdateRange(sheetRange string, rows [][]interface{}) error
+	UpdateCell(sheetRange string, value interface{}) error
+	// ReadRange returns the resolved range (e.g. "Sheet!A1:I40") and its unformatted values
+	ReadRange(sheetRange string) (string, [][]interface{}, error)
+	ClearRange(sheetRange string) error
+}
+
+// SpreadsheetSink is a SheetSink that can also write to other spreadsheets, one
+// per household
+type SpreadsheetSink interface {
+	SheetSink
+	ForSpreadsheet(spreadsheetID string) SheetSink
+}
+
+// SheetsSink writes to the spreadsheet identified by SPREADSHEET_ID
+type SheetsSink struct {
+	values        *sheets.SpreadsheetsValuesService
+	spreadsheetID string
+}
+
+// ForSpreadsheet returns a sink writing to spreadsheetID with the same client
+func (s *SheetsSink) ForSpreadsheet(spreadsheetID string) SheetSink {
+	return &SheetsSink{values: s.values, spreadsheetID: spreadsheetID}
+}
+
+// NewSheetsSink loads credentials.json and creates the Sheets client once at startup.
+// In dev mode it returns a RecordingSink instead. A missing or unusable credentials
+// file is reported as ErrSheetsDisabled so the caller can keep serving without sheets.
+func NewSheetsSink(ctx context.Context) (SheetSink, error) {
 	if IsDevMode() {
-		return nil, nil
+		log.Println("[sheets] Dev mode: sheet writes are only kept in memory")
+		return NewRecordingSink(), nil
 	}
-	ctx := context.Background()
+
 	data, err := os.ReadFile(credentialsFile)
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return nil, fmt.Errorf("%w: reading %s: %v", ErrSheetsDisabled, credentialsFile, err)
 	}
 	client := option.WithCredentialsJSON(data)
 	sheetService, err := sheets.NewService(ctx, client)
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return nil, fmt.Errorf("%w: creating sheets client: %v", ErrSheetsDisabled, err)
 	}
 
-	sheetValueService := sheets.NewSpreadsheetsValuesService(sheetService)
-	return sheetValueService, err
+	return &SheetsSink{
+		values:        sheets.NewSpreadsheetsValuesService(sheetService),
+		spreadsheetID: os.Getenv("SPREADSHEET_ID"),
+	}, nil
 }
 
-func SubmitExpenseRow(expensedata types.Expense, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
+// AppendRows appends rows after the table found in sheetRange
+func (s *SheetsSink) AppendRows(sheetRange string, rows [][]interface{}) error {
+	_, err := s.values.Append(
+		s.spreadsheetID,
+		sheetRange,
+		&sheets.ValueRange{Values: rows},
+	).ValueInputOption("USER_ENTERED").Do()
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		// Dev mode - skip sheet operations
-		log.Println("[sheets] Skipping SubmitExpenseRow (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{expensedata.Date,
-				expensedata.Category,
-				expensedata.Expense,
-				expensedata.Description,
-				expensedata.Method,
-				expensedata.OriginalAmount,
-				expensedata.CategoryId,
-				expensedata.AccountId,
-				expensedata.AccountType},
-		},
+		log.Printf("Unable to append to %s: %v", sheetRange, err)
+		return sheetError("append", sheetRange, err)
 	}
-	configString := fmt.Sprint(config.Sheet, config.A1Range)
-	sheetAndRange := func() string {
-		if configString != "" {
-			return configString
-		}
-		return "2024 Fintrack!A:I"
-	}()
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		sheetAndRange,
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+	return nil
+}
+
+// UpdateRange overwrites the values starting at sheetRange
+func (s *SheetsSink) UpdateRange(sheetRange string, rows [][]interface{}) error {
+	_, err := s.values.Update(
+		s.spreadsheetID,
+		sheetRange,
+		&sheets.ValueRange{Values: rows},
+	).ValueInputOption("USER_ENTERED").Do()
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		log.Printf("Unable to update %s: %v", sheetRange, err)
+		return sheetError("update", sheetRange, err)
 	}
-	return nil, nil
+	return nil
 }
-func SubmitBudget(budgets []types.Budget, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
+
+// UpdateCell updates a single cell with a value (uses Update, not Append)
+// sheetRange should be in format "SheetName!A1" (e.g., "2026!D3")
+func (s *SheetsSink) UpdateCell(sheetRange string, value interface{}) error {
+	if err := s.UpdateRange(sheetRange, [][]interface{}{{value}}); err != nil {
+		return err
 	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitBudget (dev mode)")
-		return nil, nil
+	log.Printf("Updated cell %s with value: %v", sheetRange, value)
+	return nil
+}
+
+func (s *SheetsSink) ReadRange(sheetRange string) (string, [][]interface{}, error) {
+	resp, err := s.values.Get(
+		s.spreadsheetID,
+		sheetRange,
+	).ValueRenderOption("UNFORMATTED_VALUE").Do()
+	if err != nil {
+		log.Printf("Unable to read %s: %v", sheetRange, err)
+		return "", nil, sheetError("get", sheetRange, err)
 	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{},
+	return resp.Range, resp.Values, nil
+}
+
+func (s *SheetsSink) ClearRange(sheetRange string) error {
+	_, err := s.values.Clear(
+		s.spreadsheetID,
+		sheetRange,
+		&sheets.ClearValuesRequest{},
+	).Do()
+	if err != nil {
+		log.Printf("Unable to clear %s: %v", sheetRange, err)
+		return sheetError("clear", sheetRange, err)
 	}
-	for _, budget := range budgets {
-		dataToWrite.Values = append(dataToWrite.Values, []interface{}{budget.Amount})
+	return nil
+}
+
+// DisabledSink stands in for SheetsSink when the server runs without sheets;
+// every call returns Reason
+type DisabledSink struct {
+	Reason error
+}
+
+func (d DisabledSink) AppendRows(string, [][]interface{}) error  { return d.Reason }
+func (d DisabledSink) UpdateRange(string, [][]interface{}) error { return d.Reason }
+func (d DisabledSink) UpdateCell(string, interface{}) error      { return d.Reason }
+func (d DisabledSink) ClearRange(string) error                   { return d.Reason }
+func (d DisabledSink) ReadRange(string) (string, [][]interface{}, error) {
+	return "", nil, d.Reason
+}
+
+// ErrRowNotFound is returned when no sheet row matches the transaction being reconciled
+var ErrRowNotFound = errors.New("sheet row not found")
+
+// expenseRowValues is the column layout shared by every expense row operation (A:I)
+func expenseRowValues(expensedata types.Expense) []interface{} {
+	return []interface{}{expensedata.Date,
+		expensedata.Category,
+		expensedata.Expense.Float64(),
+		expensedata.Description,
+		expensedata.Method,
+		expensedata.OriginalAmount.Float64(),
+		expensedata.CategoryId,
+		expensedata.AccountId,
+		expensedata.AccountType}
+}
+
+func expenseSheetRange(config types.Config) string {
+	configString := fmt.Sprint(config.Sheet, config.A1Range)
+	if configString != "" {
+		return configString
 	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+	return "2024 Fintrack!A:I"
+}
+
+// UpdateExpenseRow finds the row written for previous and overwrites it with updated
+func UpdateExpenseRow(sink SheetSink, previous types.Expense, updated types.Expense, config types.Config) error {
+	rowRange, err := findExpenseRow(sink, config, previous)
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return err
+	}
+
+	if err := sink.UpdateRange(rowRange, [][]interface{}{expenseRowValues(updated)}); err != nil {
+		return err
 	}
-	return nil, nil
+
+	log.Printf("Updated expense %d in row %s", updated.Id, rowRange)
+	return nil
 }
-func SubmitInvestment(investment types.Investment, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitInvestment (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{investment.Date,
-				investment.AccountId,
-				investment.AccountName,
-				investment.Description,
-				investment.Amount,
-				investment.Type,
-			},
-		},
-	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+
+// DeleteExpenseRow finds the row written for expense and clears its values
+func DeleteExpenseRow(sink SheetSink, expense types.Expense, config types.Config) error {
+	rowRange, err := findExpenseRow(sink, config, expense)
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return err
+	}
+
+	if err := sink.ClearRange(rowRange); err != nil {
+		return err
 	}
-	return nil, nil
+
+	log.Printf("Cleared expense %d from row %s", expense.Id, rowRange)
+	return nil
 }
 
-func SubmitIncome(income types.Income, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitIncome (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{income.Date,
-				income.AccountId,
-				income.AccountName,
-				income.Description,
-				income.Amount,
-			},
-		},
-	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+// findExpenseRow scans the expense range and returns the A1 range of the row
+// matching the expense's date, amount, description, category and account. Rows
+// alike in all of these are the same expense recorded twice, so the last one is taken.
+func findExpenseRow(sink SheetSink, config types.Config, expense types.Expense) (string, error) {
+	rowRange, err := findRow(sink, expenseSheetRange(config), len(expenseRowValues(expense)), func(row []interface{}) bool {
+		return expenseRowMatches(row, expense)
+	})
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return "", fmt.Errorf("expense %d: %w", expense.Id, err)
 	}
-	return nil, nil
+	return rowRange, nil
 }
 
-func SubmitDebt(debt types.Debt, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
+// findRow scans sheetRange from the end and returns the A1 range, width
+// columns wide, of the last row matches accepts
+func findRow(sink SheetSink, sheetRange string, width int, matches func(row []interface{}) bool) (string, error) {
+	resolvedRange, values, err := sink.ReadRange(sheetRange)
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return "", err
 	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitDebt (dev mode)")
-		return nil, nil
+
+	// resolvedRange is e.g. "'2024 Fintrack'!A1:I812", so the first returned
+	// row is startRow even when the config range has no row number
+	sheet, startCol, startRow := splitA1Range(resolvedRange)
+	for i := len(values) - 1; i >= 0; i-- {
+		if !matches(values[i]) {
+			continue
+		}
+		row := startRow + i
+		endCol := columnName(columnIndex(startCol) + width - 1)
+		return fmt.Sprintf("%s!%s%d:%s%d", sheet, startCol, row, endCol, row), nil
 	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	typeString := "Borrowed"
-	if debt.Outbound {
-		typeString = "Lent"
+
+	return "", ErrRowNotFound
+}
+
+func expenseRowMatches(row []interface{}, expense types.Expense) bool {
+	if len(row) < 8 || cellDate(row[0]) != expense.Date {
+		return false
+	}
+	amount, ok := cellFloat(row[2])
+	if !ok || !types.MoneyFromFloat(amount).Equal(expense.Expense) {
+		return false
+	}
+	categoryId, ok := cellFloat(row[6])
+	if !ok || int32(categoryId) != expense.CategoryId {
+		return false
 	}
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{debt.Date,
-				debt.DebtorId,
-				debt.DebtorName,
-				debt.Description,
-				debt.Amount,
-				typeString,
-				debt.Outbound,
-			},
-		},
-	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+	accountId, ok := cellFloat(row[7])
+	if !ok || int32(accountId) != expense.AccountId {
+		return false
+	}
+	return fmt.Sprint(row[3]) == expense.Description
+}
+
+// UpdateIncomeRow finds the row written for previous and overwrites it with updated
+func UpdateIncomeRow(sink SheetSink, previous types.Income, updated types.Income, config types.Config) error {
+	rowRange, err := findIncomeRow(sink, config, previous)
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return err
+	}
+
+	if err := sink.UpdateRange(rowRange, [][]interface{}{incomeRowValues(updated)}); err != nil {
+		return err
 	}
-	return nil, nil
+
+	log.Printf("Updated income %d in row %s", updated.Id, rowRange)
+	return nil
 }
 
-func SubmitAccount(account types.Account, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
+// DeleteIncomeRow finds the row written for income and clears its values
+func DeleteIncomeRow(sink SheetSink, income types.Income, config types.Config) error {
+	rowRange, err := findIncomeRow(sink, config, income)
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitAccount (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{account.Id,
-				account.Name,
-				account.Type,
-				account.Currency,
-			},
-		},
-	}
-	sheetRange := fmt.Sprint(config.Sheet, config.A1Range)
-	fmt.Println(sheetRange)
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		sheetRange,
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
+		return err
+	}
+
+	if err := sink.ClearRange(rowRange); err != nil {
+		return err
+	}
+
+	log.Printf("Cleared income %d from row %s", income.Id, rowRange)
+	return nil
+}
+
+// findIncomeRow returns the A1 range of the last row matching the income's
+// date, account, description and amount. The account name is left out: it is
+// whatever the client sent when the row was written.
+func findIncomeRow(sink SheetSink, config types.Config, income types.Income) (string, error) {
+	rowRange, err := findRow(sink, fmt.Sprint(config.Sheet, config.A1Range), len(incomeRowValues(income)), func(row []interface{}) bool {
+		return len(row) >= 5 && transactionRowMatches(row, income.Date, income.AccountId, income.Description, income.Amount)
+	})
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return "", fmt.Errorf("income %d: %w", income.Id, err)
 	}
-	return nil, nil
+	return rowRange, nil
 }
-func UpdateAccountBalances(accounts []types.Account, config types.Config) (*sheets.SpreadsheetsValuesUpdateCall, error) {
-	sheetValueService, err := getSheetService()
+
+// UpdateInvestmentRow finds the row written for previous and overwrites it with updated
+func UpdateInvestmentRow(sink SheetSink, previous types.Investment, updated types.Investment, config types.Config) error {
+	rowRange, err := findInvestmentRow(sink, config, previous)
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return err
 	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping UpdateAccountBalances (dev mode)")
-		return nil, nil
+
+	if err := sink.UpdateRange(rowRange, [][]interface{}{investmentRowValues(updated)}); err != nil {
+		return err
 	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
 
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{},
-		Range:  fmt.Sprint(config.Sheet, config.A1Range),
+	log.Printf("Updated investment %d in row %s", updated.Id, rowRange)
+	return nil
+}
+
+// DeleteInvestmentRow finds the row written for investment and clears its values
+func DeleteInvestmentRow(sink SheetSink, investment types.Investment, config types.Config) error {
+	rowRange, err := findInvestmentRow(sink, config, investment)
+	if err != nil {
+		return err
 	}
-	sort.Slice(accounts, func(i, j int) bool {
-		return accounts[i].Id < accounts[j].Id
+
+	if err := sink.ClearRange(rowRange); err != nil {
+		return err
+	}
+
+	log.Printf("Cleared investment %d from row %s", investment.Id, rowRange)
+	return nil
+}
+
+// findInvestmentRow returns the A1 range of the last row matching the
+// investment's date, account, description, amount and type
+func findInvestmentRow(sink SheetSink, config types.Config, investment types.Investment) (string, error) {
+	rowRange, err := findRow(sink, fmt.Sprint(config.Sheet, config.A1Range), len(investmentRowValues(investment)), func(row []interface{}) bool {
+		return len(row) >= 6 && transactionRowMatches(row, investment.Date, investment.AccountId, investment.Description, investment.Amount) &&
+			strings.TrimSpace(fmt.Sprint(row[5])) == investment.Type
 	})
-	for _, account := range accounts {
+	if err != nil {
+		return "", fmt.Errorf("investment %d: %w", investment.Id, err)
+	}
+	return rowRange, nil
+}
 
-		dataToWrite.Values = append(dataToWrite.Values, []interface{}{account.Balance})
+// UpdateInvestmentCapitalCell writes capital to an investment account's row of
+// the config tab. The investment_capital range runs from the column naming the
+// accounts to their capital column ("!I3:L"): the row is found by the account's
+// name and the cell is its last column. An account the range doesn't list is
+// skipped: there is no cell to write until it is added.
+func UpdateInvestmentCapitalCell(sink SheetSink, config types.Config, accountName string, capital float64) error {
+	first, last, _ := strings.Cut(strings.TrimPrefix(config.A1Range, "!"), ":")
+	firstCol, _ := splitCell(first)
+	lastCol, _ := splitCell(last)
+	if firstCol == "" || lastCol == "" || columnIndex(lastCol) < columnIndex(firstCol) {
+		return fmt.Errorf("%w: investment_capital range %q needs a name and a capital column", ErrBadRange, config.A1Range)
 	}
-	batchUpdateValueReq := sheets.BatchUpdateValuesRequest{
-		Data:             []*sheets.ValueRange{&dataToWrite},
-		ValueInputOption: "USER_ENTERED",
+
+	width := columnIndex(lastCol) - columnIndex(firstCol) + 1
+	rowRange, err := findRow(sink, fmt.Sprint(config.Sheet, config.A1Range), width, func(row []interface{}) bool {
+		return len(row) > 0 && strings.EqualFold(strings.TrimSpace(fmt.Sprint(row[0])), strings.TrimSpace(accountName))
+	})
+	if errors.Is(err, ErrRowNotFound) {
+		log.Printf("Skipped capital of %s: the investment_capital range doesn't list it", accountName)
+		return nil
 	}
-	_, err = sheetValueService.BatchUpdate(
-		spreadsheetID,
-		&batchUpdateValueReq).Do()
 	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+		return fmt.Errorf("investment account %q: %w", accountName, err)
+	}
+
+	// rowRange is "Sheet!I5:L5"; the capital cell is its end
+	sheet, _, _ := splitA1Range(rowRange)
+	cellRange := fmt.Sprintf("%s!%s", sheet, rowRange[strings.LastIndex(rowRange, ":")+1:])
+	if err := sink.UpdateCell(cellRange, capital); err != nil {
+		return err
 	}
-	return nil, nil
 
+	log.Printf("Updated capital of %s in cell %s", accountName, cellRange)
+	return nil
 }
-func SubmitInvestmentAccount(investmentAccount types.InvestmentAccount, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitInvestmentAccount (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{investmentAccount.Id,
-				investmentAccount.Name,
-				investmentAccount.Type,
-				investmentAccount.Currency,
-			},
-		},
-	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
-	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+
+// transactionRowMatches checks the columns income and investment rows share:
+// date, account id, description and amount
+func transactionRowMatches(row []interface{}, date string, accountId int32, description string, amount types.Money) bool {
+	if cellDate(row[0]) != date {
+		return false
 	}
-	return nil, nil
+	id, ok := cellFloat(row[1])
+	if !ok || int32(id) != accountId {
+		return false
+	}
+	value, ok := cellFloat(row[4])
+	if !ok || !types.MoneyFromFloat(value).Equal(amount) {
+		return false
+	}
+	return fmt.Sprint(row[3]) == description
 }
 
-func UpdateInvestmentAccountBalances(accounts []types.InvestmentAccount, config types.Config) (*sheets.SpreadsheetsValuesUpdateCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
+// sheetEpoch is day 0 of the serial numbers Sheets reads dates back as
+var sheetEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// cellDate is a date cell in the stored form of a transaction date: Sheets
+// reads the dates it recognised back as serial numbers, the rest as written
+func cellDate(cell interface{}) string {
+	if serial, ok := cell.(float64); ok {
+		return types.FormatDate(sheetEpoch.Add(time.Duration(math.Round(serial*86400)) * time.Second))
+	}
+	return strings.TrimSpace(fmt.Sprint(cell))
+}
+
+func cellFloat(cell interface{}) (float64, bool) {
+	switch v := cell.(type) {
+	case float64:
+		return v, true
+	case int32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// splitA1Range splits "Sheet!B2:I" into ("Sheet", "B", 2); the row defaults to 1
+func splitA1Range(sheetAndRange string) (string, string, int) {
+	sheet := ""
+	ref := sheetAndRange
+	if idx := strings.LastIndex(sheetAndRange, "!"); idx >= 0 {
+		sheet = sheetAndRange[:idx]
+		ref = sheetAndRange[idx+1:]
 	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping UpdateInvestmentAccountBalances (dev mode)")
-		return nil, nil
+	if idx := strings.Index(ref, ":"); idx >= 0 {
+		ref = ref[:idx]
 	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
 
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{},
-		Range:  fmt.Sprint(config.Sheet, config.A1Range),
+	col := strings.TrimRightFunc(ref, unicode.IsDigit)
+	row, err := strconv.Atoi(ref[len(col):])
+	if err != nil || row < 1 {
+		row = 1
 	}
-	sort.Slice(accounts, func(i, j int) bool {
-		return accounts[i].Id < accounts[j].Id
-	})
-	for _, account := range accounts {
+	if col == "" {
+		col = "A"
+	}
+	return sheet, strings.ToUpper(col), row
+}
 
-		dataToWrite.Values = append(dataToWrite.Values, []interface{}{account.Balance})
+// columnIndex converts a column name to a 1-based index ("A" -> 1, "AA" -> 27)
+func columnIndex(col string) int {
+	index := 0
+	for _, c := range col {
+		index = index*26 + int(c-'A'+1)
 	}
-	batchUpdateValueReq := sheets.BatchUpdateValuesRequest{
-		Data:             []*sheets.ValueRange{&dataToWrite},
-		ValueInputOption: "USER_ENTERED",
+	return index
+}
+
+// columnName converts a 1-based index back to a column name (27 -> "AA")
+func columnName(index int) string {
+	name := ""
+	for index > 0 {
+		index--
+		name = string(rune('A'+index%26)) + name
+		index /= 26
+	}
+	return name
+}
+
+// ========== ROW LAYOUTS ==========
+
+// splitCell splits "X3" into ("X", "3") and "X" into ("X", "")
+func splitCell(ref string) (string, string) {
+	col := strings.ToUpper(strings.TrimRightFunc(ref, unicode.IsDigit))
+	for _, c := range col {
+		if c < 'A' || c > 'Z' {
+			return "", ""
+		}
 	}
-	_, err = sheetValueService.BatchUpdate(
-		spreadsheetID,
-		&batchUpdateValueReq).Do()
-	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+	return col, ref[len(col):]
+}
+
+func budgetRows(budgets []types.Budget) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, budget := range budgets {
+		rows = append(rows, []interface{}{budget.Amount.Float64()})
 	}
-	return nil, nil
+	return rows
+}
 
+func investmentRowValues(investment types.Investment) []interface{} {
+	return []interface{}{investment.Date,
+		investment.AccountId,
+		investment.AccountName,
+		investment.Description,
+		investment.Amount.Float64(),
+		investment.Type,
+	}
 }
-func SubmitDebtor(debtor types.Debtor, config types.Config) (*sheets.SpreadsheetsValuesAppendCall, error) {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping SubmitDebtor (dev mode)")
-		return nil, nil
-	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{
-			{debtor.Id,
-				debtor.Name,
-			},
-		},
-	}
-	_, err = sheetValueService.Append(
-		spreadsheetID,
-		fmt.Sprint(config.Sheet, config.A1Range),
-		&dataToWrite).ValueInputOption("USER_ENTERED").Do()
-	if err != nil {
-		log.Fatalf("Unable to write data to sheet: %v", err)
-		return nil, err
+
+func incomeRowValues(income types.Income) []interface{} {
+	return []interface{}{income.Date,
+		income.AccountId,
+		income.AccountName,
+		income.Description,
+		income.Amount.Float64(),
 	}
-	return nil, nil
 }
 
-// UpdateSheetCell updates a single cell with a value (uses Update, not Append)
-// sheetRange should be in format "SheetName!A1" (e.g., "2026!D3")
-func UpdateSheetCell(sheetRange string, value interface{}) error {
-	sheetValueService, err := getSheetService()
-	if err != nil {
-		return err
+func debtRowValues(debt types.Debt) []interface{} {
+	typeString := "Borrowed"
+	if debt.Outbound {
+		typeString = "Lent"
 	}
-	if sheetValueService == nil {
-		log.Println("[sheets] Skipping UpdateSheetCell (dev mode)")
-		return nil
+	return []interface{}{debt.Date,
+		debt.DebtorId,
+		debt.DebtorName,
+		debt.Description,
+		debt.Amount.Float64(),
+		typeString,
+		debt.Outbound,
 	}
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
+}
 
-	dataToWrite := sheets.ValueRange{
-		Values: [][]interface{}{{value}},
+func accountRowValues(account types.Account) []interface{} {
+	return []interface{}{account.Id,
+		account.Name,
+		account.Type,
+		account.Currency,
 	}
+}
 
-	_, err = sheetValueService.Update(
-		spreadsheetID,
-		sheetRange,
-		&dataToWrite,
-	).ValueInputOption("USER_ENTERED").Do()
+func investmentAccountRowValues(investmentAccount types.InvestmentAccount) []interface{} {
+	return []interface{}{investmentAccount.Id,
+		investmentAccount.Name,
+		investmentAccount.Type,
+		investmentAccount.Currency,
+	}
+}
 
-	if err != nil {
-		log.Printf("Unable to update cell %s: %v", sheetRange, err)
-		return err
+func debtorRowValues(debtor types.Debtor) []interface{} {
+	return []interface{}{debtor.Id,
+		debtor.Name,
 	}
+}
 
-	log.Printf("Updated cell %s with value: %v", sheetRange, value)
-	return nil
+// accountBalanceRows is one balance per row, ordered by account id like the accounting sheet
+func accountBalanceRows(accounts []types.Account) [][]interface{} {
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Id < accounts[j].Id
+	})
+	rows := [][]interface{}{}
+	for _, account := range accounts {
+		rows = append(rows, []interface{}{account.Balance.Float64()})
+	}
+	return rows
+}
+
+func investmentAccountBalanceRows(accounts []types.InvestmentAccount) [][]interface{} {
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Id < accounts[j].Id
+	})
+	rows := [][]interface{}{}
+	for _, account := range accounts {
+		rows = append(rows, []interface{}{account.Balance.Float64()})
+	}
+	return rows
 }
 
 // CalculateMonthlyCellRange calculates the cell range for a given month
@@ -0,0 +1,2236 @@
+// Package memory is an in-memory implementation of api.Store. It reproduces the
+// postgres tables and views closely enough to run handler tests without a database.
+// Like the postgres store, every call runs for the scope in its context: it sees
+// its household's rows that are shared or owned by the scope's user, and writes
+// into that household. New starts with household 1 and its first user, 1, like
+// a fresh database after migration 0004.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// investmentAccountTypes are expense account types that draw on an investment
+// account rather than a fiat one
+var investmentAccountTypes = map[string]bool{"Investment": true, "Crypto": true, "Broker": true}
+
+// Owner is the scope of the household and user New starts with
+var Owner = types.Scope{HouseholdId: 1, UserId: 1}
+
+// Transactions keep the owner_id postgres gives them: private to a user, or
+// nil when shared with the household
+
+type expenseRow struct {
+	types.Expense
+	owner     *int64
+	createdAt time.Time
+}
+
+type incomeRow struct {
+	types.Income
+	owner *int64
+}
+
+type investmentRow struct {
+	types.Investment
+	owner     *int64
+	createdAt time.Time
+}
+
+type debtRow struct {
+	types.Debt
+	owner *int64
+}
+
+type transferRow struct {
+	types.Transfer
+	owner *int64
+}
+
+// snapshotRow is a net worth snapshot, which always has an owner
+type snapshotRow struct {
+	types.NetWorthSnapshot
+	owner int64
+}
+
+// household holds the tables of one household
+type household struct {
+	types.Household
+
+	configs            map[string]types.Config
+	categories         []types.Category
+	budgets            []types.Budget
+	budgetMoves        []types.BudgetMove
+	expenses           []expenseRow
+	incomes            []incomeRow
+	investments        []investmentRow
+	debts              []debtRow
+	debtors            []types.Debtor
+	accounts           []types.Account
+	investmentAccounts []types.InvestmentAccount
+	transfers          []transferRow
+	goals              map[int]types.YearlyGoals
+	snapshots          []snapshotRow
+	fxRates            types.FXRates
+	importMappings     map[int32]types.ImportMapping
+}
+
+// Store keeps every table in memory behind a single mutex. The zero value is not
+// usable; call New.
+type Store struct {
+	mu  sync.Mutex
+	ids map[string]int32
+
+	households map[int64]*household
+	users      []types.User
+	apiKeys    []types.APIKey
+}
+
+// New returns an empty store with household 1 and its first user, 1
+func New() *Store {
+	s := &Store{ids: map[string]int32{}, households: map[int64]*household{}}
+	s.AddHousehold("Household")
+	s.AddUser(1, "Owner")
+	return s
+}
+
+// nextId works like a serial column: ids start at 1 and are never reused
+func (s *Store) nextId(table string) int32 {
+	s.ids[table]++
+	return s.ids[table]
+}
+
+// view is a household as the scope of one call sees it
+type view struct {
+	*household
+	scope types.Scope
+}
+
+// in returns the household of ctx's scope; the caller holds s.mu
+func (s *Store) in(ctx context.Context) (view, error) {
+	scope, ok := types.ScopeFrom(ctx)
+	if !ok {
+		return view{}, types.ErrNoScope
+	}
+	h, ok := s.households[scope.HouseholdId]
+	if !ok {
+		return view{}, fmt.Errorf("household %d: %w", scope.HouseholdId, types.ErrNotFound)
+	}
+	return view{household: h, scope: scope}, nil
+}
+
+// sees tells whether a row owned by owner is visible to the scope: shared, its
+// user's, or anyone's for a household-wide scope
+func (v view) sees(owner *int64) bool {
+	return owner == nil || v.scope.AllUsers || *owner == v.scope.UserId
+}
+
+// accountRef is an account a transaction draws on or pays into
+type accountRef struct {
+	id         int32
+	investment bool // investmentAccounts rather than accounts
+}
+
+func fiatAccount(id int32) accountRef {
+	return accountRef{id: id}
+}
+
+func investmentAccount(id int32) accountRef {
+	return accountRef{id: id, investment: true}
+}
+
+// optionalAccount is "no account" for nil
+func optionalAccount(id *int32) accountRef {
+	if id == nil {
+		return accountRef{}
+	}
+	return fiatAccount(*id)
+}
+
+// expenseAccount is the account an expense is charged to; AccountType tells
+// the two tables apart
+func expenseAccount(expense types.Expense) accountRef {
+	if investmentAccountTypes[expense.AccountType] {
+		return investmentAccount(expense.AccountId)
+	}
+	return fiatAccount(expense.AccountId)
+}
+
+// transactionOwner checks that every referenced account is visible to the
+// scope and returns the owner a transaction on them gets: private when any of
+// them is private, shared otherwise. Zero ids are "no account" and are skipped.
+func (v view) transactionOwner(refs ...accountRef) (*int64, error) {
+	var owner *int64
+	for _, ref := range refs {
+		if ref.id == 0 {
+			continue
+		}
+		var accountOwner *int64
+		found := false
+		if ref.investment {
+			if i := v.investmentAccountIndex(ref.id); i >= 0 {
+				accountOwner, found = v.investmentAccounts[i].OwnerId, true
+			}
+		} else if i := v.accountIndex(ref.id); i >= 0 {
+			accountOwner, found = v.accounts[i].OwnerId, true
+		}
+		if !found || !v.sees(accountOwner) {
+			return nil, fmt.Errorf("account %d: %w", ref.id, types.ErrNotFound)
+		}
+		if accountOwner != nil {
+			owner = accountOwner
+		}
+	}
+	return owner, nil
+}
+
+// privateOwner is the owner of a new account: the caller when it asked for a
+// private account, shared otherwise
+func (v view) privateOwner(requested *int64) (*int64, error) {
+	if requested == nil {
+		return nil, nil
+	}
+	if *requested != v.scope.UserId {
+		return nil, types.Invalid("an account can only be private to the user creating it")
+	}
+	owner := v.scope.UserId
+	return &owner, nil
+}
+
+// page applies LIMIT/OFFSET to rows already in query order
+func page[T any](rows []T, limit int, offset int) []T {
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// now formats the current time in the caller's timezone the way date columns are returned
+func now(ctx context.Context) string {
+	return types.FormatDate(types.Now(ctx))
+}
+
+// inPeriod tells whether a stored transaction date falls in period
+func inPeriod(date string, period types.Period) bool {
+	return date >= period.Start && date < period.End
+}
+
+// inYear tells whether a stored transaction date falls in year
+func inYear(date string, year int) bool {
+	from, to := types.YearRange(year)
+	return date >= from && date < to
+}
+
+// currency is the currency of the account a transaction was recorded against;
+// accountType tells investment accounts apart as it does for expenses
+func (h *household) currency(accountType string, accountId int32) string {
+	if investmentAccountTypes[accountType] {
+		if i := h.investmentAccountIndex(accountId); i >= 0 {
+			return h.investmentAccounts[i].Currency
+		}
+		return ""
+	}
+	if i := h.accountIndex(accountId); i >= 0 {
+		return h.accounts[i].Currency
+	}
+	return ""
+}
+
+// toBase converts amount from currency to the household's base currency at the
+// rate in effect on date
+func (h *household) toBase(amount types.Money, currency string, date string) (types.Money, error) {
+	return h.fxRates.Convert(amount, currency, h.BaseCurrency, date)
+}
+
+// ========== CONFIG ==========
+
+func (s *Store) GetConfigByType(ctx context.Context, configType string) (types.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Config{}, err
+	}
+	config, ok := h.configs[configType]
+	if !ok {
+		return types.Config{}, fmt.Errorf("config not found for type: %s", configType)
+	}
+	return config, nil
+}
+
+func (s *Store) GetConfig(ctx context.Context) ([]types.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.Config
+	for _, c := range h.configs {
+		results = append(results, c)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Type < results[j].Type })
+	return results, nil
+}
+
+func (s *Store) InsertConfigIntoDatabase(ctx context.Context, configs []types.Config) ([]types.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		h.configs[c.Type] = c
+	}
+	return configs, nil
+}
+
+// ========== CATEGORIES ==========
+
+func (s *Store) InsertCategory(ctx context.Context, category types.Category) (types.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Category{}, err
+	}
+	category.Id = s.nextId("categories")
+	category.CreatedAt = time.Now()
+	h.categories = append(h.categories, category)
+	return category, nil
+}
+
+func (s *Store) GetCategories(ctx context.Context) ([]types.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := append([]types.Category(nil), h.categories...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+func (h *household) hasCategory(id int32) bool {
+	for _, c := range h.categories {
+		if c.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== EXPENSES ==========
+
+func (s *Store) InsertExpense(ctx context.Context, expense types.Expense) (types.Expense, error) {
+	// Validate amount
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	owner, err := h.transactionOwner(expenseAccount(expense))
+	if err != nil {
+		return types.Expense{}, err
+	}
+	return s.insertExpense(ctx, h, expense, owner), nil
+}
+
+func (s *Store) insertExpense(ctx context.Context, h view, expense types.Expense, owner *int64) types.Expense {
+	expense.Id = s.nextId("expenses")
+	if expense.Date == "" {
+		expense.Date = now(ctx)
+	}
+	h.expenses = append(h.expenses, expenseRow{Expense: expense, owner: owner, createdAt: time.Now()})
+	return expense
+}
+
+// GetExpenses returns the newest expenses first
+func (s *Store) GetExpenses(ctx context.Context, limit int, offset int) ([]types.Expense, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := h.newestExpenses()
+	return page(results, limit, offset), len(results), nil
+}
+
+func (s *Store) GetRecentExpenses(ctx context.Context, limit int) ([]types.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return page(h.newestExpenses(), limit, 0), nil
+}
+
+func (v view) newestExpenses() []types.Expense {
+	results := make([]types.Expense, 0, len(v.expenses))
+	for i := len(v.expenses) - 1; i >= 0; i-- {
+		if v.sees(v.expenses[i].owner) {
+			results = append(results, v.expenses[i].Expense)
+		}
+	}
+	return results
+}
+
+func (s *Store) GetExpenseById(ctx context.Context, id int32) (types.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	for _, row := range h.expenses {
+		if row.Id == id && h.sees(row.owner) {
+			return row.Expense, nil
+		}
+	}
+	return types.Expense{}, fmt.Errorf("expense %d: %w", id, types.ErrNotFound)
+}
+
+func (s *Store) UpdateExpense(ctx context.Context, expense types.Expense) (types.Expense, error) {
+	// Validate amount
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	owner, err := h.transactionOwner(expenseAccount(expense))
+	if err != nil {
+		return types.Expense{}, err
+	}
+	for i := range h.expenses {
+		if h.expenses[i].Id == expense.Id && h.sees(h.expenses[i].owner) {
+			h.expenses[i].Expense = expense
+			h.expenses[i].owner = owner
+			return expense, nil
+		}
+	}
+	return types.Expense{}, fmt.Errorf("expense %d: %w", expense.Id, types.ErrNotFound)
+}
+
+// DeleteExpense removes an expense together with any debts created from it
+func (s *Store) DeleteExpense(ctx context.Context, id int32) (types.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	for i, row := range h.expenses {
+		if row.Id == id && h.sees(row.owner) {
+			h.expenses = append(h.expenses[:i], h.expenses[i+1:]...)
+			h.deleteDebts(func(d types.Debt) bool { return d.ExpenseId != nil && *d.ExpenseId == id })
+			return row.Expense, nil
+		}
+	}
+	return types.Expense{}, fmt.Errorf("expense %d: %w", id, types.ErrNotFound)
+}
+
+func (s *Store) GetExpenseSum(ctx context.Context, period types.Period) (types.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Money{}, err
+	}
+	var total types.Money
+	for _, row := range h.expenses {
+		if h.sees(row.owner) && inPeriod(row.Date, period) {
+			amount, err := h.toBase(row.Expense.Expense, h.currency(row.AccountType, row.AccountId), row.Date)
+			if err != nil {
+				return types.Money{}, err
+			}
+			total = total.Add(amount)
+		}
+	}
+	return total, nil
+}
+
+// ========== BUDGETS ==========
+
+// GetBudgets returns, for each of periods, every budget in force in the
+// period's month with the expenses the caller can see recorded against its
+// category in the period
+func (s *Store) GetBudgets(ctx context.Context, periods []types.Period) ([][]types.BudgetByCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]types.BudgetByCategory, len(periods))
+	for i, period := range periods {
+		spent, err := h.spentByCategory(period)
+		if err != nil {
+			return nil, err
+		}
+		inForce := map[int32]types.Budget{}
+		for _, b := range h.budgets {
+			if b.Month <= period.Month() && b.Month >= inForce[b.CategoryId].Month {
+				inForce[b.CategoryId] = b
+			}
+		}
+
+		for _, b := range inForce {
+			row := types.BudgetByCategory{Amount: b.Amount, CategoryId: b.CategoryId, Spent: spent[b.CategoryId]}
+			for _, c := range h.categories {
+				if c.Id == b.CategoryId {
+					row.CategoryName = c.Name
+				}
+			}
+			results[i] = append(results[i], row)
+		}
+		sort.SliceStable(results[i], func(a, b int) bool { return results[i][a].CategoryName < results[i][b].CategoryName })
+	}
+	return results, nil
+}
+
+// GetSpentByCategory returns, for each of periods, the expenses the caller can
+// see recorded in it by category
+func (s *Store) GetSpentByCategory(ctx context.Context, periods []types.Period) ([]map[int32]types.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spent := make([]map[int32]types.Money, len(periods))
+	for i, period := range periods {
+		if spent[i], err = h.spentByCategory(period); err != nil {
+			return nil, err
+		}
+	}
+	return spent, nil
+}
+
+// spentByCategory totals the expenses the caller can see in period by category
+func (v view) spentByCategory(period types.Period) (map[int32]types.Money, error) {
+	spent := map[int32]types.Money{}
+	for _, e := range v.expenses {
+		if v.sees(e.owner) && inPeriod(e.Date, period) {
+			amount, err := v.toBase(e.Expense.Expense, v.currency(e.AccountType, e.AccountId), e.Date)
+			if err != nil {
+				return nil, err
+			}
+			spent[e.CategoryId] = spent[e.CategoryId].Add(amount)
+		}
+	}
+	return spent, nil
+}
+
+// InsertBudgetsIntoDatabase upserts one budget per category and month; only
+// the household's own categories can be budgeted
+func (s *Store) InsertBudgetsIntoDatabase(ctx context.Context, budgets []types.Budget) ([]types.Budget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// All or nothing, like the postgres transaction
+	for _, b := range budgets {
+		if !h.hasCategory(b.CategoryId) {
+			return nil, fmt.Errorf("category %d: %w", b.CategoryId, types.ErrNotFound)
+		}
+	}
+
+	var results []types.Budget
+	for _, b := range budgets {
+		found := false
+		for i := range h.budgets {
+			if h.budgets[i].CategoryId == b.CategoryId && h.budgets[i].Month == b.Month {
+				h.budgets[i].Amount = b.Amount
+				b = h.budgets[i]
+				found = true
+			}
+		}
+		if !found {
+			b.Id = s.nextId("budgets")
+			h.budgets = append(h.budgets, b)
+		}
+		results = append(results, b)
+	}
+	return results, nil
+}
+
+// InsertBudgetMove moves money between two of the household's envelopes
+func (s *Store) InsertBudgetMove(ctx context.Context, move types.BudgetMove) (types.BudgetMove, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.BudgetMove{}, err
+	}
+	if !h.hasCategory(move.FromCategoryId) || !h.hasCategory(move.ToCategoryId) {
+		return types.BudgetMove{}, fmt.Errorf("categories %d and %d: %w", move.FromCategoryId, move.ToCategoryId, types.ErrNotFound)
+	}
+	move.Id = s.nextId("budget_moves")
+	move.CreatedAt = time.Now()
+	h.budgetMoves = append(h.budgetMoves, move)
+	return move, nil
+}
+
+func (s *Store) GetBudgetMoves(ctx context.Context, period types.Period) ([]types.BudgetMove, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.BudgetMove
+	for _, m := range h.budgetMoves {
+		if inPeriod(m.Date, period) {
+			results = append(results, m)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Date < results[j].Date })
+	return results, nil
+}
+
+// ========== INCOMES ==========
+
+func (s *Store) InsertIncome(ctx context.Context, income types.Income) (types.Income, error) {
+	// Validate amount
+	if income.Amount.Sign() <= 0 {
+		return types.Income{}, types.Invalid("income amount must be positive, got: %s", income.Amount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	owner, err := h.transactionOwner(fiatAccount(income.AccountId))
+	if err != nil {
+		return types.Income{}, err
+	}
+	return s.insertIncome(ctx, h, income, owner), nil
+}
+
+func (s *Store) insertIncome(ctx context.Context, h view, income types.Income, owner *int64) types.Income {
+	income.Id = s.nextId("incomes")
+	income.CreatedAt = time.Now()
+	if income.Date == "" {
+		income.Date = now(ctx)
+	}
+	h.incomes = append(h.incomes, incomeRow{Income: income, owner: owner})
+	return income
+}
+
+// GetIncomes returns the newest incomes first
+func (s *Store) GetIncomes(ctx context.Context, limit int, offset int) ([]types.Income, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]types.Income, 0, len(h.incomes))
+	for i := len(h.incomes) - 1; i >= 0; i-- {
+		if h.sees(h.incomes[i].owner) {
+			results = append(results, h.incomes[i].Income)
+		}
+	}
+	return page(results, limit, offset), len(results), nil
+}
+
+func (s *Store) GetIncomeById(ctx context.Context, id int32) (types.Income, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	for _, row := range h.incomes {
+		if row.Id == id && h.sees(row.owner) {
+			return row.Income, nil
+		}
+	}
+	return types.Income{}, fmt.Errorf("income %d: %w", id, types.ErrNotFound)
+}
+
+// UpdateIncome overwrites an income; a linked repayment debt follows the new amount
+func (s *Store) UpdateIncome(ctx context.Context, income types.Income) (types.Income, error) {
+	// Validate amount
+	if income.Amount.Sign() <= 0 {
+		return types.Income{}, types.Invalid("income amount must be positive, got: %s", income.Amount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	owner, err := h.transactionOwner(fiatAccount(income.AccountId))
+	if err != nil {
+		return types.Income{}, err
+	}
+	for i := range h.incomes {
+		if h.incomes[i].Id != income.Id || !h.sees(h.incomes[i].owner) {
+			continue
+		}
+		income.CreatedAt = h.incomes[i].CreatedAt
+		h.incomes[i] = incomeRow{Income: income, owner: owner}
+		for j := range h.debts {
+			if h.debts[j].IncomeId != nil && *h.debts[j].IncomeId == income.Id {
+				accountId := income.AccountId
+				h.debts[j].Amount = income.Amount
+				h.debts[j].OriginalAmount = income.Amount
+				h.debts[j].AccountId = &accountId
+				h.debts[j].owner = owner
+			}
+		}
+		return income, nil
+	}
+	return types.Income{}, fmt.Errorf("income %d: %w", income.Id, types.ErrNotFound)
+}
+
+// DeleteIncome removes an income together with any repayment debt recorded with it
+func (s *Store) DeleteIncome(ctx context.Context, id int32) (types.Income, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	for i, row := range h.incomes {
+		if row.Id == id && h.sees(row.owner) {
+			h.incomes = append(h.incomes[:i], h.incomes[i+1:]...)
+			h.deleteDebts(func(d types.Debt) bool { return d.IncomeId != nil && *d.IncomeId == id })
+			return row.Income, nil
+		}
+	}
+	return types.Income{}, fmt.Errorf("income %d: %w", id, types.ErrNotFound)
+}
+
+func (s *Store) GetIncomeSum(ctx context.Context, period types.Period) (types.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Money{}, err
+	}
+	var total types.Money
+	for _, income := range h.incomes {
+		if h.sees(income.owner) && inPeriod(income.Date, period) {
+			amount, err := h.toBase(income.Amount, h.currency("", income.AccountId), income.Date)
+			if err != nil {
+				return types.Money{}, err
+			}
+			total = total.Add(amount)
+		}
+	}
+	return total, nil
+}
+
+// ========== DEBTS ==========
+
+func (s *Store) InsertDebt(ctx context.Context, debt types.Debt) (types.Debt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Debt{}, err
+	}
+	owner, err := h.transactionOwner(optionalAccount(debt.AccountId))
+	if err != nil {
+		return types.Debt{}, err
+	}
+	return s.insertDebt(h, debt, owner), nil
+}
+
+func (s *Store) insertDebt(h view, debt types.Debt, owner *int64) types.Debt {
+	debt.Id = s.nextId("debts")
+	debt.CreatedAt = time.Now()
+	h.debts = append(h.debts, debtRow{Debt: debt, owner: owner})
+	return debt
+}
+
+func (h *household) deleteDebts(match func(types.Debt) bool) {
+	kept := h.debts[:0]
+	for _, d := range h.debts {
+		if !match(d.Debt) {
+			kept = append(kept, d)
+		}
+	}
+	h.debts = kept
+}
+
+// InsertExpenseWithDebt creates an expense and a linked debt
+// Deprecated: Use InsertExpenseWithDebts for multiple debts support
+func (s *Store) InsertExpenseWithDebt(ctx context.Context, expense types.Expense, debt types.Debt) (types.Expense, types.Debt, error) {
+	expenseResult, debts, err := s.InsertExpenseWithDebts(ctx, expense, []types.Debt{debt})
+	if err != nil {
+		return types.Expense{}, types.Debt{}, err
+	}
+	return expenseResult, debts[0], nil
+}
+
+// InsertExpenseWithDebts creates an expense and multiple debts linked to it
+func (s *Store) InsertExpenseWithDebts(ctx context.Context, expense types.Expense, debts []types.Debt) (types.Expense, []types.Debt, error) {
+	// Validate expense amount
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, nil, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
+	}
+
+	// Validate debts
+	if len(debts) == 0 {
+		return types.Expense{}, nil, types.Invalid("at least one debt is required")
+	}
+
+	for i, debt := range debts {
+		if debt.Amount.Sign() <= 0 {
+			return types.Expense{}, nil, types.Invalid("debt %d amount must be positive, got: %s", i+1, debt.Amount)
+		}
+		if debt.DebtorId == 0 {
+			return types.Expense{}, nil, types.Invalid("debt %d must have a debtor_id", i+1)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Expense{}, nil, err
+	}
+	expenseOwner, err := h.transactionOwner(expenseAccount(expense))
+	if err != nil {
+		return types.Expense{}, nil, err
+	}
+	owners := make([]*int64, len(debts))
+	for i, debt := range debts {
+		if owners[i], err = h.transactionOwner(expenseAccount(expense), optionalAccount(debt.AccountId)); err != nil {
+			return types.Expense{}, nil, err
+		}
+	}
+
+	expenseResult := s.insertExpense(ctx, h, expense, expenseOwner)
+	debtResults := make([]types.Debt, 0, len(debts))
+	for i, debt := range debts {
+		expenseId := expenseResult.Id
+		debt.ExpenseId = &expenseId
+		debtResults = append(debtResults, s.insertDebt(h, debt, owners[i]))
+	}
+	return expenseResult, debtResults, nil
+}
+
+// RecordDebtRepayment creates an income record and the debt it repays
+func (s *Store) RecordDebtRepayment(ctx context.Context, income types.Income, debt types.Debt) (types.Income, types.Debt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Income{}, types.Debt{}, err
+	}
+	owner, err := h.transactionOwner(fiatAccount(income.AccountId), optionalAccount(debt.AccountId))
+	if err != nil {
+		return types.Income{}, types.Debt{}, err
+	}
+	incomeResult := s.insertIncome(ctx, h, income, owner)
+	incomeId := incomeResult.Id
+	debt.IncomeId = &incomeId
+	return incomeResult, s.insertDebt(h, debt, owner), nil
+}
+
+// GetDebts returns the newest debts first, optionally for a single debtor
+func (s *Store) GetDebts(ctx context.Context, limit int, offset int, debtorId *int32) ([]types.Debt, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var results []types.Debt
+	for i := len(h.debts) - 1; i >= 0; i-- {
+		d := h.debts[i]
+		if h.sees(d.owner) && (debtorId == nil || d.DebtorId == *debtorId) {
+			results = append(results, d.Debt)
+		}
+	}
+	return page(results, limit, offset), len(results), nil
+}
+
+func (s *Store) GetDebtors(ctx context.Context) ([]types.Debtor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := append([]types.Debtor(nil), h.debtors...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// GetDebtorsWithDebts reproduces debt_by_debtor over the debts the caller can
+// see: outbound debts are money lent, inbound ones repayments, and net_owed is
+// what the debtor still owes
+func (s *Store) GetDebtorsWithDebts(ctx context.Context) ([]types.DebtByDebtor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byDebtor := map[int32]*types.DebtByDebtor{}
+	var results []*types.DebtByDebtor
+	for _, d := range h.debts {
+		if !h.sees(d.owner) {
+			continue
+		}
+		summary, ok := byDebtor[d.DebtorId]
+		if !ok {
+			summary = &types.DebtByDebtor{DebtorId: d.DebtorId, DebtorName: d.DebtorName}
+			for _, debtor := range h.debtors {
+				if debtor.Id == d.DebtorId {
+					summary.DebtorName = debtor.Name
+				}
+			}
+			byDebtor[d.DebtorId] = summary
+			results = append(results, summary)
+		}
+		if d.Outbound {
+			summary.TotalLent = summary.TotalLent.Add(d.Amount)
+		} else {
+			summary.TotalReceived = summary.TotalReceived.Add(d.Amount)
+		}
+		summary.NetOwed = summary.TotalLent.Sub(summary.TotalReceived)
+		summary.TransactionCount++
+	}
+
+	summaries := make([]types.DebtByDebtor, 0, len(results))
+	for _, summary := range results {
+		summaries = append(summaries, *summary)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].DebtorName < summaries[j].DebtorName })
+	return summaries, nil
+}
+
+func (s *Store) InsertDebtorIntoDatabase(ctx context.Context, debtor types.Debtor) (types.Debtor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Debtor{}, err
+	}
+	debtor.Id = s.nextId("debtors")
+	h.debtors = append(h.debtors, debtor)
+	return debtor, nil
+}
+
+// ========== ACCOUNTS ==========
+
+func (s *Store) GetAccounts(ctx context.Context) ([]types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.Account
+	for _, a := range h.accounts {
+		if h.sees(a.OwnerId) {
+			results = append(results, a)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// InsertAccountIntoDatabase inserts a new account, shared with the household
+// unless OwnerId makes it private to the caller
+func (s *Store) InsertAccountIntoDatabase(ctx context.Context, account types.Account) (types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Account{}, err
+	}
+	if account.OwnerId, err = h.privateOwner(account.OwnerId); err != nil {
+		return types.Account{}, err
+	}
+	account.Id = s.nextId("accounts")
+	if account.Currency == "" {
+		account.Currency = "USD"
+	}
+	if account.StartingDate.IsZero() {
+		account.StartingDate = time.Now()
+	}
+	h.accounts = append(h.accounts, account)
+	return account, nil
+}
+
+// UpdateAccountBalances records reconciled balances for multiple accounts
+func (s *Store) UpdateAccountBalances(ctx context.Context, accounts []types.Account) ([]types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var updated []types.Account
+	for _, account := range accounts {
+		i := h.accountIndex(account.Id)
+		if i < 0 || !h.sees(h.accounts[i].OwnerId) {
+			return nil, fmt.Errorf("error updating account %d: %w", account.Id, types.ErrNotFound)
+		}
+		h.accounts[i].Balance = account.Balance
+		updated = append(updated, h.accounts[i])
+	}
+	return updated, nil
+}
+
+// accountIndex finds an account of the household whoever owns it, like the
+// joins and account_currency do in postgres
+func (h *household) accountIndex(id int32) int {
+	for i, a := range h.accounts {
+		if a.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetAccountExpectedBalances reproduces account_expected_balance: the starting
+// balance plus every transaction that moved money in or out of the account
+func (s *Store) GetAccountExpectedBalances(ctx context.Context) ([]types.AccountExpectedBalance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.expectedBalances(), nil
+}
+
+// expectedBalances covers the accounts the caller can see; like the view, it
+// adds up every transaction on them
+func (v view) expectedBalances() []types.AccountExpectedBalance {
+	var results []types.AccountExpectedBalance
+	for _, a := range v.accounts {
+		if !v.sees(a.OwnerId) {
+			continue
+		}
+		row := types.AccountExpectedBalance{
+			Id:              a.Id,
+			Name:            a.Name,
+			Currency:        a.Currency,
+			StartingBalance: a.StartingBalance,
+			StartingDate:    a.StartingDate,
+			RealBalance:     a.Balance,
+		}
+		for _, income := range v.incomes {
+			if income.AccountId == a.Id {
+				row.TotalIncome = row.TotalIncome.Add(income.Amount)
+			}
+		}
+		for _, e := range v.expenses {
+			if e.AccountId == a.Id && !investmentAccountTypes[e.AccountType] {
+				row.TotalExpenses = row.TotalExpenses.Add(e.Expense.Expense)
+			}
+		}
+		for _, inv := range v.investments {
+			if inv.SourceAccountId == nil || *inv.SourceAccountId != a.Id {
+				continue
+			}
+			if inv.Type == "withdrawal" {
+				row.TotalInvestmentWithdrawals = row.TotalInvestmentWithdrawals.Add(inv.Amount)
+			} else {
+				row.TotalInvestmentDeposits = row.TotalInvestmentDeposits.Add(inv.Amount)
+			}
+		}
+		for _, t := range v.transfers {
+			if t.SourceAccountId == a.Id {
+				row.TotalTransfersOut = row.TotalTransfersOut.Add(t.SourceAmount)
+			}
+			if t.DestAccountId == a.Id {
+				row.TotalTransfersIn = row.TotalTransfersIn.Add(t.DestAmount)
+			}
+		}
+		row.ExpectedBalance = row.StartingBalance.Add(row.TotalIncome).Sub(row.TotalExpenses).
+			Sub(row.TotalInvestmentDeposits).Add(row.TotalInvestmentWithdrawals).
+			Sub(row.TotalTransfersOut).Add(row.TotalTransfersIn)
+		row.Discrepancy = row.RealBalance.Sub(row.ExpectedBalance)
+		results = append(results, row)
+	}
+	return results
+}
+
+// ========== INVESTMENTS ==========
+
+// investmentCapitalChange is the capital effect of an investment: deposits add, withdrawals subtract
+func investmentCapitalChange(investment types.Investment) types.Money {
+	if investment.Type == "withdrawal" {
+		return investment.Amount.Neg()
+	}
+	return investment.Amount
+}
+
+// addCapital adjusts an investment account's capital; unknown accounts are ignored like an UPDATE matching no row
+func (h *household) addCapital(accountId int32, change types.Money) {
+	for i := range h.investmentAccounts {
+		if h.investmentAccounts[i].Id == accountId {
+			h.investmentAccounts[i].Capital = h.investmentAccounts[i].Capital.Add(change)
+		}
+	}
+}
+
+// investmentOwner is the owner of an investment: its account's and its source account's
+func (v view) investmentOwner(investment types.Investment) (*int64, error) {
+	return v.transactionOwner(investmentAccount(investment.AccountId), optionalAccount(investment.SourceAccountId))
+}
+
+// InsertInvestment inserts an investment record and updates account capital
+func (s *Store) InsertInvestment(ctx context.Context, investment types.Investment) (types.Investment, error) {
+	// Validate amount and type
+	if investment.Amount.Sign() <= 0 {
+		return types.Investment{}, types.Invalid("investment amount must be positive, got: %s", investment.Amount)
+	}
+	if investment.Type != "deposit" && investment.Type != "withdrawal" {
+		return types.Investment{}, types.Invalid("invalid investment type: %s (must be 'deposit' or 'withdrawal')", investment.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	owner, err := h.investmentOwner(investment)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	investment.Id = s.nextId("investments")
+	if investment.Date == "" {
+		investment.Date = now(ctx)
+	}
+	h.investments = append(h.investments, investmentRow{Investment: investment, owner: owner, createdAt: time.Now()})
+	h.addCapital(investment.AccountId, investmentCapitalChange(investment))
+	return investment, nil
+}
+
+// GetInvestments returns investments by date, newest first, optionally for a single account
+func (s *Store) GetInvestments(ctx context.Context, limit int, offset int, accountId *int32) ([]types.Investment, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var results []types.Investment
+	for _, row := range h.investments {
+		if h.sees(row.owner) && (accountId == nil || row.AccountId == *accountId) {
+			results = append(results, row.Investment)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Date != results[j].Date {
+			return results[i].Date > results[j].Date
+		}
+		return results[i].Id > results[j].Id
+	})
+	return page(results, limit, offset), len(results), nil
+}
+
+func (s *Store) GetInvestmentById(ctx context.Context, id int32) (types.Investment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	for _, row := range h.investments {
+		if row.Id == id && h.sees(row.owner) {
+			return row.Investment, nil
+		}
+	}
+	return types.Investment{}, fmt.Errorf("investment %d: %w", id, types.ErrNotFound)
+}
+
+// UpdateInvestment overwrites an investment, moving its capital change to the new values
+func (s *Store) UpdateInvestment(ctx context.Context, investment types.Investment) (types.Investment, error) {
+	// Validate amount and type
+	if investment.Amount.Sign() <= 0 {
+		return types.Investment{}, types.Invalid("investment amount must be positive, got: %s", investment.Amount)
+	}
+	if investment.Type != "deposit" && investment.Type != "withdrawal" {
+		return types.Investment{}, types.Invalid("invalid investment type: %s (must be 'deposit' or 'withdrawal')", investment.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	owner, err := h.investmentOwner(investment)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	for i := range h.investments {
+		if h.investments[i].Id != investment.Id || !h.sees(h.investments[i].owner) {
+			continue
+		}
+		previous := h.investments[i].Investment
+		h.investments[i].Investment = investment
+		h.investments[i].owner = owner
+		h.addCapital(previous.AccountId, investmentCapitalChange(previous).Neg())
+		h.addCapital(investment.AccountId, investmentCapitalChange(investment))
+		return investment, nil
+	}
+	return types.Investment{}, fmt.Errorf("investment %d: %w", investment.Id, types.ErrNotFound)
+}
+
+// DeleteInvestment removes an investment and reverses its capital change
+func (s *Store) DeleteInvestment(ctx context.Context, id int32) (types.Investment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	for i, row := range h.investments {
+		if row.Id == id && h.sees(row.owner) {
+			h.investments = append(h.investments[:i], h.investments[i+1:]...)
+			h.addCapital(row.AccountId, investmentCapitalChange(row.Investment).Neg())
+			return row.Investment, nil
+		}
+	}
+	return types.Investment{}, fmt.Errorf("investment %d: %w", id, types.ErrNotFound)
+}
+
+// GetInvestmentSum returns total investment deposits dated in period
+func (s *Store) GetInvestmentSum(ctx context.Context, period types.Period) (types.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Money{}, err
+	}
+	var total types.Money
+	for _, row := range h.investments {
+		if row.Type == "deposit" && h.sees(row.owner) && inPeriod(row.Date, period) {
+			amount, err := h.toBase(row.Amount, h.currency("Investment", row.AccountId), row.Date)
+			if err != nil {
+				return types.Money{}, err
+			}
+			total = total.Add(amount)
+		}
+	}
+	return total, nil
+}
+
+func (s *Store) GetInvestmentAccounts(ctx context.Context) ([]types.InvestmentAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.InvestmentAccount
+	for _, a := range h.investmentAccounts {
+		if h.sees(a.OwnerId) {
+			results = append(results, a)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// InsertInvestmentAccountIntoDatabase inserts a new investment account, shared with
+// the household unless OwnerId makes it private to the caller
+func (s *Store) InsertInvestmentAccountIntoDatabase(ctx context.Context, account types.InvestmentAccount) (types.InvestmentAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.InvestmentAccount{}, err
+	}
+	if account.OwnerId, err = h.privateOwner(account.OwnerId); err != nil {
+		return types.InvestmentAccount{}, err
+	}
+	account.Id = s.nextId("investment_accounts")
+	if account.Currency == "" {
+		account.Currency = "USD"
+	}
+	if account.StartingDate.IsZero() {
+		account.StartingDate = time.Now()
+	}
+	h.investmentAccounts = append(h.investmentAccounts, account)
+	return account, nil
+}
+
+// UpdateInvestmentAccountBalances records reconciled balances for multiple investment accounts
+func (s *Store) UpdateInvestmentAccountBalances(ctx context.Context, accounts []types.InvestmentAccount) ([]types.InvestmentAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var updated []types.InvestmentAccount
+	for _, account := range accounts {
+		i := h.investmentAccountIndex(account.Id)
+		if i < 0 || !h.sees(h.investmentAccounts[i].OwnerId) {
+			return nil, fmt.Errorf("error updating investment account %d: %w", account.Id, types.ErrNotFound)
+		}
+		h.investmentAccounts[i].Balance = account.Balance
+		updated = append(updated, h.investmentAccounts[i])
+	}
+	return updated, nil
+}
+
+// investmentAccountIndex finds an investment account of the household whoever owns it
+func (h *household) investmentAccountIndex(id int32) int {
+	for i, a := range h.investmentAccounts {
+		if a.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) GetInvestmentAccountCapital(ctx context.Context, accountId int32) (types.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Money{}, err
+	}
+	i := h.investmentAccountIndex(accountId)
+	if i < 0 || !h.sees(h.investmentAccounts[i].OwnerId) {
+		return types.Money{}, fmt.Errorf("investment account %d: %w", accountId, types.ErrNotFound)
+	}
+	return h.investmentAccounts[i].Capital, nil
+}
+
+// GetInvestmentAccountSummary reproduces investment_account_summary: PnL is the
+// reconciled balance minus the capital put in
+func (s *Store) GetInvestmentAccountSummary(ctx context.Context) ([]types.InvestmentAccountSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.InvestmentAccountSummary
+	for _, a := range h.investmentAccounts {
+		if !h.sees(a.OwnerId) {
+			continue
+		}
+		summary := types.InvestmentAccountSummary{
+			Id:              a.Id,
+			Name:            a.Name,
+			Type:            a.Type,
+			Currency:        a.Currency,
+			RealBalance:     a.Balance,
+			TotalCapital:    a.Capital,
+			StartingCapital: a.StartingCapital,
+			PnL:             a.Balance.Sub(a.Capital),
+		}
+		if a.Capital.Sign() > 0 {
+			summary.PnLPercent = summary.PnL.Ratio(a.Capital) * 100
+		}
+		results = append(results, summary)
+	}
+	return results, nil
+}
+
+// GetInvestmentAccountExpectedCapital returns expected capital breakdown for investment accounts
+func (s *Store) GetInvestmentAccountExpectedCapital(ctx context.Context) ([]types.InvestmentAccountExpectedCapital, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.InvestmentAccountExpectedCapital
+	for _, a := range h.investmentAccounts {
+		if !h.sees(a.OwnerId) {
+			continue
+		}
+		row := types.InvestmentAccountExpectedCapital{
+			Id:              a.Id,
+			Name:            a.Name,
+			Type:            a.Type,
+			Currency:        a.Currency,
+			StartingCapital: a.StartingCapital,
+			RealBalance:     a.Balance,
+		}
+		for _, inv := range h.investments {
+			if inv.AccountId != a.Id {
+				continue
+			}
+			if inv.Type == "withdrawal" {
+				row.TotalWithdrawals = row.TotalWithdrawals.Add(inv.Amount)
+			} else {
+				row.TotalDeposits = row.TotalDeposits.Add(inv.Amount)
+			}
+		}
+		for _, e := range h.expenses {
+			if e.AccountId == a.Id && investmentAccountTypes[e.AccountType] {
+				row.TotalExpenses = row.TotalExpenses.Add(e.Expense.Expense)
+			}
+		}
+		row.ExpectedCapital = row.StartingCapital.Add(row.TotalDeposits).Sub(row.TotalWithdrawals).Sub(row.TotalExpenses)
+		row.Discrepancy = row.RealBalance.Sub(row.ExpectedCapital)
+		results = append(results, row)
+	}
+	return results, nil
+}
+
+// ========== TRANSFERS ==========
+
+// InsertTransfer inserts a transfer, deriving the exchange rate if not provided
+func (s *Store) InsertTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error) {
+	if transfer.ExchangeRate == 0 && transfer.SourceAmount.Sign() > 0 {
+		transfer.ExchangeRate = transfer.DestAmount.Ratio(transfer.SourceAmount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	owner, err := h.transactionOwner(fiatAccount(transfer.SourceAccountId), fiatAccount(transfer.DestAccountId))
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	transfer.Id = s.nextId("transfers")
+	transfer.CreatedAt = time.Now()
+	if transfer.Date == "" {
+		transfer.Date = now(ctx)
+	}
+	transfer.SourceAccountName = ""
+	transfer.DestAccountName = ""
+	h.costTransfer(&transfer)
+	h.transfers = append(h.transfers, transferRow{Transfer: transfer, owner: owner})
+	return transfer, nil
+}
+
+// costTransfer sets the reference rate of a transfer between two currencies
+// and what it cost against it; both stay zero without a known rate
+func (h *household) costTransfer(transfer *types.Transfer) {
+	transfer.ReferenceRate, transfer.FXCost = 0, types.Money{}
+	from, to := h.currency("", transfer.SourceAccountId), h.currency("", transfer.DestAccountId)
+	if rate, ok := h.fxRates.Lookup(from, to, transfer.Date); ok {
+		transfer.CostAgainst(rate)
+	}
+}
+
+// GetTransfers returns the newest transfers first with account names
+func (s *Store) GetTransfers(ctx context.Context, limit int, offset int) ([]types.Transfer, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]types.Transfer, 0, len(h.transfers))
+	for i := len(h.transfers) - 1; i >= 0; i-- {
+		if h.sees(h.transfers[i].owner) {
+			results = append(results, h.withAccountNames(h.transfers[i].Transfer))
+		}
+	}
+	return page(results, limit, offset), len(results), nil
+}
+
+func (h *household) withAccountNames(t types.Transfer) types.Transfer {
+	if i := h.accountIndex(t.SourceAccountId); i >= 0 {
+		t.SourceAccountName = h.accounts[i].Name
+	}
+	if i := h.accountIndex(t.DestAccountId); i >= 0 {
+		t.DestAccountName = h.accounts[i].Name
+	}
+	return t
+}
+
+func (s *Store) GetTransferById(ctx context.Context, id int32) (types.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	for _, t := range h.transfers {
+		if t.Id == id && h.sees(t.owner) {
+			return h.withAccountNames(t.Transfer), nil
+		}
+	}
+	return types.Transfer{}, fmt.Errorf("transfer %d: %w", id, types.ErrNotFound)
+}
+
+// UpdateTransfer overwrites a transfer, recalculating the exchange rate from the amounts
+func (s *Store) UpdateTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error) {
+	if transfer.SourceAmount.Sign() > 0 {
+		transfer.ExchangeRate = transfer.DestAmount.Ratio(transfer.SourceAmount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	owner, err := h.transactionOwner(fiatAccount(transfer.SourceAccountId), fiatAccount(transfer.DestAccountId))
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	for i := range h.transfers {
+		if h.transfers[i].Id == transfer.Id && h.sees(h.transfers[i].owner) {
+			transfer.CreatedAt = h.transfers[i].CreatedAt
+			transfer.SourceAccountName = ""
+			transfer.DestAccountName = ""
+			h.costTransfer(&transfer)
+			h.transfers[i] = transferRow{Transfer: transfer, owner: owner}
+			return transfer, nil
+		}
+	}
+	return types.Transfer{}, fmt.Errorf("transfer %d: %w", transfer.Id, types.ErrNotFound)
+}
+
+// GetTransferFXCosts totals what the transfers dated in period cost against
+// the reference rate, per source and destination account and calendar month
+func (s *Store) GetTransferFXCosts(ctx context.Context, period types.Period) ([]types.TransferFXCost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.TransferFXCost
+	index := map[[3]string]int{}
+	for _, row := range h.transfers {
+		if row.ReferenceRate == 0 || !h.sees(row.owner) || !inPeriod(row.Date, period) {
+			continue
+		}
+		t := h.withAccountNames(row.Transfer)
+		key := [3]string{t.Date[:len(types.MonthLayout)], fmt.Sprint(t.SourceAccountId), fmt.Sprint(t.DestAccountId)}
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			results = append(results, types.TransferFXCost{
+				Month:             key[0],
+				SourceAccountId:   t.SourceAccountId,
+				SourceAccountName: t.SourceAccountName,
+				SourceCurrency:    h.currency("", t.SourceAccountId),
+				DestAccountId:     t.DestAccountId,
+				DestAccountName:   t.DestAccountName,
+				DestCurrency:      h.currency("", t.DestAccountId),
+			})
+		}
+		c := &results[i]
+		c.Transfers++
+		c.SourceAmount = c.SourceAmount.Add(t.SourceAmount)
+		c.DestAmount = c.DestAmount.Add(t.DestAmount)
+		c.ReferenceAmount = c.ReferenceAmount.Add(t.SourceAmount.Times(t.ReferenceRate))
+		c.Cost = c.Cost.Add(t.FXCost)
+		cost, err := h.toBase(t.FXCost, c.DestCurrency, t.Date)
+		if err != nil {
+			return nil, err
+		}
+		c.BaseCost = c.BaseCost.Add(cost)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Month != results[j].Month {
+			return results[i].Month < results[j].Month
+		}
+		if results[i].SourceAccountName != results[j].SourceAccountName {
+			return results[i].SourceAccountName < results[j].SourceAccountName
+		}
+		return results[i].DestAccountName < results[j].DestAccountName
+	})
+	return results, nil
+}
+
+func (s *Store) DeleteTransfer(ctx context.Context, id int32) (types.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	for i, t := range h.transfers {
+		if t.Id == id && h.sees(t.owner) {
+			h.transfers = append(h.transfers[:i], h.transfers[i+1:]...)
+			return t.Transfer, nil
+		}
+	}
+	return types.Transfer{}, fmt.Errorf("transfer %d: %w", id, types.ErrNotFound)
+}
+
+// ========== YEARLY GOALS ==========
+
+// GetYearlyGoals returns empty goals for a year that has none
+func (s *Store) GetYearlyGoals(ctx context.Context, year int) (types.YearlyGoals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.YearlyGoals{}, err
+	}
+	goals, ok := h.goals[year]
+	if !ok {
+		return types.YearlyGoals{Year: year}, nil
+	}
+	return goals, nil
+}
+
+func (s *Store) UpsertYearlyGoals(ctx context.Context, goals types.YearlyGoals) (types.YearlyGoals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.YearlyGoals{}, err
+	}
+	if existing, ok := h.goals[goals.Year]; ok {
+		goals.Id = existing.Id
+		goals.CreatedAt = existing.CreatedAt
+	} else {
+		goals.Id = s.nextId("yearly_goals")
+		goals.CreatedAt = time.Now()
+	}
+	h.goals[goals.Year] = goals
+	return goals, nil
+}
+
+// ========== NET WORTH SNAPSHOTS ==========
+
+// UpsertNetWorthSnapshot records the caller's snapshot of a month
+func (s *Store) UpsertNetWorthSnapshot(ctx context.Context, snapshot types.NetWorthSnapshot) (types.NetWorthSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.NetWorthSnapshot{}, err
+	}
+	for i, existing := range h.snapshots {
+		if existing.owner == h.scope.UserId && existing.Year == snapshot.Year && existing.Month == snapshot.Month {
+			snapshot.Id = existing.Id
+			snapshot.CreatedAt = existing.CreatedAt
+			h.snapshots[i].NetWorthSnapshot = snapshot
+			return snapshot, nil
+		}
+	}
+	snapshot.Id = s.nextId("net_worth_snapshots")
+	snapshot.CreatedAt = time.Now()
+	h.snapshots = append(h.snapshots, snapshotRow{NetWorthSnapshot: snapshot, owner: h.scope.UserId})
+	return snapshot, nil
+}
+
+// GetNetWorthHistory returns the caller's snapshots ordered by year and month
+func (s *Store) GetNetWorthHistory(ctx context.Context) ([]types.NetWorthSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.NetWorthSnapshot
+	for _, row := range h.snapshots {
+		if row.owner == h.scope.UserId {
+			results = append(results, row.NetWorthSnapshot)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Year != results[j].Year {
+			return results[i].Year < results[j].Year
+		}
+		return results[i].Month < results[j].Month
+	})
+	return results, nil
+}
+
+// CalculateNetWorthSnapshot calculates current net worth from the accounts the
+// caller can see, converting every balance to the base currency at today's rate
+func (s *Store) CalculateNetWorthSnapshot(ctx context.Context, year int, month int) (types.NetWorthSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.NetWorthSnapshot{}, err
+	}
+
+	snapshot := types.NetWorthSnapshot{
+		Date:  types.Now(ctx),
+		Year:  year,
+		Month: month,
+	}
+	today := types.FormatDate(snapshot.Date)
+	add := func(total *types.Money, amount types.Money, currency string) {
+		if err != nil {
+			return
+		}
+		var converted types.Money
+		converted, err = h.toBase(amount, currency, today)
+		*total = total.Add(converted)
+	}
+	for _, a := range h.accounts {
+		if h.sees(a.OwnerId) {
+			add(&snapshot.TotalFiatBalance, a.Balance, a.Currency)
+		}
+	}
+	for _, row := range h.expectedBalances() {
+		add(&snapshot.ExpectedFiatBalance, row.ExpectedBalance, row.Currency)
+	}
+	for _, a := range h.investmentAccounts {
+		if !h.sees(a.OwnerId) {
+			continue
+		}
+		switch a.Type {
+		case "Crypto":
+			add(&snapshot.CryptoBalance, a.Balance, a.Currency)
+			add(&snapshot.CryptoCapital, a.Capital, a.Currency)
+		case "Broker":
+			add(&snapshot.BrokerBalance, a.Balance, a.Currency)
+			add(&snapshot.BrokerCapital, a.Capital, a.Currency)
+		}
+	}
+	if err != nil {
+		return types.NetWorthSnapshot{}, err
+	}
+
+	snapshot.TotalInvestmentBalance = snapshot.CryptoBalance.Add(snapshot.BrokerBalance)
+	snapshot.TotalInvestmentCapital = snapshot.CryptoCapital.Add(snapshot.BrokerCapital)
+	snapshot.TotalRealNetWorth = snapshot.TotalFiatBalance.Add(snapshot.TotalInvestmentBalance)
+	snapshot.TotalPnL = snapshot.TotalInvestmentBalance.Sub(snapshot.TotalInvestmentCapital)
+	snapshot.ExpectedNetWorth = snapshot.ExpectedFiatBalance.Add(snapshot.TotalInvestmentBalance)
+	snapshot.FiatDiscrepancy = snapshot.TotalFiatBalance.Sub(snapshot.ExpectedFiatBalance)
+	snapshot.TotalDiscrepancy = snapshot.TotalRealNetWorth.Sub(snapshot.ExpectedNetWorth)
+
+	if snapshot.TotalRealNetWorth.Sign() > 0 {
+		snapshot.FiatPercent = snapshot.TotalFiatBalance.Ratio(snapshot.TotalRealNetWorth) * 100
+		snapshot.CryptoPercent = snapshot.CryptoBalance.Ratio(snapshot.TotalRealNetWorth) * 100
+		snapshot.BrokerPercent = snapshot.BrokerBalance.Ratio(snapshot.TotalRealNetWorth) * 100
+	}
+
+	return snapshot, nil
+}
+
+// ========== DASHBOARD HELPERS ==========
+
+// GetYTDTotals returns year-to-date totals for income, expenses, and investment deposits
+func (s *Store) GetYTDTotals(ctx context.Context, year int) (income types.Money, expenses types.Money, investments types.Money, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Money{}, types.Money{}, types.Money{}, err
+	}
+	add := func(total *types.Money, amount types.Money, currency string, date string) {
+		if err != nil {
+			return
+		}
+		var converted types.Money
+		converted, err = h.toBase(amount, currency, date)
+		*total = total.Add(converted)
+	}
+	for _, i := range h.incomes {
+		if h.sees(i.owner) && inYear(i.Date, year) {
+			add(&income, i.Amount, h.currency("", i.AccountId), i.Date)
+		}
+	}
+	for _, e := range h.expenses {
+		if h.sees(e.owner) && inYear(e.Date, year) {
+			add(&expenses, e.Expense.Expense, h.currency(e.AccountType, e.AccountId), e.Date)
+		}
+	}
+	for _, inv := range h.investments {
+		if inv.Type == "deposit" && h.sees(inv.owner) && inYear(inv.Date, year) {
+			add(&investments, inv.Amount, h.currency("Investment", inv.AccountId), inv.Date)
+		}
+	}
+	if err != nil {
+		return types.Money{}, types.Money{}, types.Money{}, err
+	}
+	return income, expenses, investments, nil
+}
+
+// ========== EXCHANGE RATES ==========
+
+// UpsertFXRates stores rates, replacing any with the same currency, quote and
+// date, and costs every transfer again against the rates now known
+func (s *Store) UpsertFXRates(ctx context.Context, rates []types.FXRate) ([]types.FXRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]types.FXRate, 0, len(rates))
+	for _, r := range rates {
+		r.CreatedAt = time.Now()
+		replaced := false
+		for i, existing := range h.fxRates {
+			if existing.Currency == r.Currency && existing.Quote == r.Quote && existing.Date == r.Date {
+				r.Id = existing.Id
+				h.fxRates[i] = r
+				replaced = true
+			}
+		}
+		if !replaced {
+			r.Id = s.nextId("fx_rates")
+			h.fxRates = append(h.fxRates, r)
+		}
+		results = append(results, r)
+	}
+	for i := range h.transfers {
+		h.costTransfer(&h.transfers[i].Transfer)
+	}
+	return results, nil
+}
+
+// GetFXRates returns the rates from or into currency (every rate when empty), newest first
+func (s *Store) GetFXRates(ctx context.Context, currency string) ([]types.FXRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.FXRate
+	for _, r := range h.fxRates {
+		if currency == "" || r.Currency == currency || r.Quote == currency {
+			results = append(results, r)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Date > results[j].Date })
+	return results, nil
+}
+
+// ========== STATEMENT IMPORTS ==========
+
+// GetImportMappings returns the mappings saved for the accounts the caller can see
+func (s *Store) GetImportMappings(ctx context.Context) ([]types.ImportMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.ImportMapping
+	for _, m := range h.importMappings {
+		if _, err := h.transactionOwner(fiatAccount(m.AccountId)); err == nil {
+			results = append(results, m)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].AccountId < results[j].AccountId })
+	return results, nil
+}
+
+func (s *Store) GetImportMapping(ctx context.Context, accountId int32) (types.ImportMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.ImportMapping{}, err
+	}
+	m, ok := h.importMappings[accountId]
+	if _, err := h.transactionOwner(fiatAccount(accountId)); !ok || err != nil {
+		return types.ImportMapping{}, fmt.Errorf("import mapping for account %d: %w", accountId, types.ErrNotFound)
+	}
+	return m, nil
+}
+
+func (s *Store) UpsertImportMapping(ctx context.Context, mapping types.ImportMapping) (types.ImportMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.ImportMapping{}, err
+	}
+	if _, err := h.transactionOwner(fiatAccount(mapping.AccountId)); err != nil {
+		return types.ImportMapping{}, err
+	}
+	mapping.UpdatedAt = time.Now()
+	h.importMappings[mapping.AccountId] = mapping
+	return mapping, nil
+}
+
+// GetAccountTransactions returns the expenses charged to a fiat account and the
+// incomes paid into it that are dated in period, oldest first
+func (s *Store) GetAccountTransactions(ctx context.Context, accountId int32, period types.Period) ([]types.Expense, []types.Income, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var expenses []types.Expense
+	for _, row := range h.expenses {
+		if row.AccountId == accountId && !investmentAccountTypes[row.AccountType] && h.sees(row.owner) && inPeriod(row.Date, period) {
+			expenses = append(expenses, row.Expense)
+		}
+	}
+	var incomes []types.Income
+	for _, income := range h.incomes {
+		if income.AccountId == accountId && h.sees(income.owner) && inPeriod(income.Date, period) {
+			incomes = append(incomes, income.Income)
+		}
+	}
+	sort.SliceStable(expenses, func(i, j int) bool { return expenses[i].Date < expenses[j].Date })
+	sort.SliceStable(incomes, func(i, j int) bool { return incomes[i].Date < incomes[j].Date })
+	return expenses, incomes, nil
+}
+
+// ImportTransactions records the expenses and incomes of a statement, all or none
+func (s *Store) ImportTransactions(ctx context.Context, expenses []types.Expense, incomes []types.Income) ([]types.Expense, []types.Income, error) {
+	for i, expense := range expenses {
+		if expense.Expense.Sign() <= 0 {
+			return nil, nil, types.Invalid("expense %d: amount must be positive, got: %s", i+1, expense.Expense)
+		}
+	}
+	for i, income := range incomes {
+		if income.Amount.Sign() <= 0 {
+			return nil, nil, types.Invalid("income %d: amount must be positive, got: %s", i+1, income.Amount)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	expenseOwners := make([]*int64, len(expenses))
+	for i, expense := range expenses {
+		if expenseOwners[i], err = h.transactionOwner(expenseAccount(expense)); err != nil {
+			return nil, nil, err
+		}
+	}
+	incomeOwners := make([]*int64, len(incomes))
+	for i, income := range incomes {
+		if incomeOwners[i], err = h.transactionOwner(fiatAccount(income.AccountId)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	insertedExpenses := make([]types.Expense, 0, len(expenses))
+	for i, expense := range expenses {
+		insertedExpenses = append(insertedExpenses, s.insertExpense(ctx, h, expense, expenseOwners[i]))
+	}
+	insertedIncomes := make([]types.Income, 0, len(incomes))
+	for i, income := range incomes {
+		insertedIncomes = append(insertedIncomes, s.insertIncome(ctx, h, income, incomeOwners[i]))
+	}
+	return insertedExpenses, insertedIncomes, nil
+}
+
+// ImportHistory records transactions read back from the sheet, leaving the
+// capital of investment accounts as it is. Like ImportTransactions it records
+// all or none.
+func (s *Store) ImportHistory(ctx context.Context, history types.SheetHistory) (types.SheetHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.SheetHistory{}, err
+	}
+
+	var owners []*int64
+	own := func(refs ...accountRef) {
+		if err != nil {
+			return
+		}
+		var owner *int64
+		owner, err = h.transactionOwner(refs...)
+		owners = append(owners, owner)
+	}
+	for _, expense := range history.Expenses {
+		own(expenseAccount(expense))
+	}
+	for _, income := range history.Incomes {
+		own(fiatAccount(income.AccountId))
+	}
+	for _, investment := range history.Investments {
+		own(investmentAccount(investment.AccountId), optionalAccount(investment.SourceAccountId))
+	}
+	for _, debt := range history.Debts {
+		own(optionalAccount(debt.AccountId))
+	}
+	if err != nil {
+		return types.SheetHistory{}, err
+	}
+
+	var result types.SheetHistory
+	for _, expense := range history.Expenses {
+		result.Expenses = append(result.Expenses, s.insertExpense(ctx, h, expense, owners[0]))
+		owners = owners[1:]
+	}
+	for _, income := range history.Incomes {
+		result.Incomes = append(result.Incomes, s.insertIncome(ctx, h, income, owners[0]))
+		owners = owners[1:]
+	}
+	for _, investment := range history.Investments {
+		investment.Id = s.nextId("investments")
+		h.investments = append(h.investments, investmentRow{Investment: investment, owner: owners[0], createdAt: time.Now()})
+		owners = owners[1:]
+		result.Investments = append(result.Investments, investment)
+	}
+	for _, debt := range history.Debts {
+		result.Debts = append(result.Debts, s.insertDebt(h, debt, owners[0]))
+		owners = owners[1:]
+	}
+	return result, nil
+}
+
+// ========== EXPORTS ==========
+
+// GetTransactions returns every transaction the caller can see dated in
+// period, oldest first; an empty end is open
+func (s *Store) GetTransactions(ctx context.Context, period types.Period) (types.Transactions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Transactions{}, err
+	}
+	in := func(date string, owner *int64) bool {
+		return h.sees(owner) && date >= period.Start && (period.End == "" || date < period.End)
+	}
+
+	var result types.Transactions
+	for _, row := range h.expenses {
+		if in(row.Date, row.owner) {
+			result.Expenses = append(result.Expenses, row.Expense)
+		}
+	}
+	for _, income := range h.incomes {
+		if in(income.Date, income.owner) {
+			result.Incomes = append(result.Incomes, income.Income)
+		}
+	}
+	for _, transfer := range h.transfers {
+		if in(transfer.Date, transfer.owner) {
+			result.Transfers = append(result.Transfers, h.withAccountNames(transfer.Transfer))
+		}
+	}
+	for _, row := range h.investments {
+		if in(row.Date, row.owner) {
+			result.Investments = append(result.Investments, row.Investment)
+		}
+	}
+	for _, debt := range h.debts {
+		if in(debt.Date, debt.owner) {
+			result.Debts = append(result.Debts, debt.Debt)
+		}
+	}
+	sort.SliceStable(result.Expenses, func(i, j int) bool { return result.Expenses[i].Date < result.Expenses[j].Date })
+	sort.SliceStable(result.Incomes, func(i, j int) bool { return result.Incomes[i].Date < result.Incomes[j].Date })
+	sort.SliceStable(result.Transfers, func(i, j int) bool { return result.Transfers[i].Date < result.Transfers[j].Date })
+	sort.SliceStable(result.Investments, func(i, j int) bool { return result.Investments[i].Date < result.Investments[j].Date })
+	sort.SliceStable(result.Debts, func(i, j int) bool { return result.Debts[i].Date < result.Debts[j].Date })
+	return result, nil
+}
+
+// ========== HOUSEHOLD ==========
+
+func (s *Store) GetHousehold(ctx context.Context) (types.Household, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Household{}, err
+	}
+	return h.Household, nil
+}
+
+func (s *Store) UpdateHousehold(ctx context.Context, household types.Household) (types.Household, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return types.Household{}, err
+	}
+	h.Name = household.Name
+	h.SpreadsheetId = household.SpreadsheetId
+	h.Timezone = household.Timezone
+	h.BudgetPeriod = household.BudgetPeriod
+	h.BudgetMode = household.BudgetMode
+	h.EnvelopeSince = household.EnvelopeSince
+	h.BaseCurrency = household.BaseCurrency
+	return h.Household, nil
+}
+
+func (s *Store) GetHouseholdUsers(ctx context.Context) ([]types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.User
+	for _, u := range s.users {
+		if u.HouseholdId == h.Id {
+			results = append(results, u)
+		}
+	}
+	return results, nil
+}
+
+// RefExists tells whether id is a row of kind the caller can see, for request validation
+func (s *Store) RefExists(ctx context.Context, kind types.RefKind, id int32) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.in(ctx)
+	if err != nil {
+		return false, err
+	}
+	switch kind {
+	case types.RefAccount:
+		_, err := h.transactionOwner(fiatAccount(id))
+		return err == nil, nil
+	case types.RefInvestmentAccount:
+		_, err := h.transactionOwner(investmentAccount(id))
+		return err == nil, nil
+	case types.RefCategory:
+		return h.hasCategory(id), nil
+	case types.RefDebtor:
+		for _, d := range h.debtors {
+			if d.Id == id {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown reference kind: %s", kind)
+}
+
+// AddHousehold adds a household with no users yet. Households are managed
+// from the CLI, so api.Store has no insert for them.
+func (s *Store) AddHousehold(name string) types.Household {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := &household{
+		Household: types.Household{
+			Id: int64(s.nextId("households")), Name: name, Timezone: "UTC",
+			BudgetPeriod: types.BudgetPeriod{Kind: types.PeriodCalendarMonth}, BudgetMode: types.BudgetStandard,
+			BaseCurrency: "USD", CreatedAt: time.Now(),
+		},
+		configs:        map[string]types.Config{},
+		goals:          map[int]types.YearlyGoals{},
+		importMappings: map[int32]types.ImportMapping{},
+	}
+	s.households[h.Id] = h
+	return h.Household
+}
+
+// AddUser adds a member to a household. Users are managed from the CLI, so
+// api.Store has no insert for them.
+func (s *Store) AddUser(householdId int64, name string) types.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := types.User{Id: int64(s.nextId("users")), HouseholdId: householdId, Name: name, CreatedAt: time.Now()}
+	s.users = append(s.users, user)
+	return user
+}
+
+// ========== API KEYS ==========
+// Keys are looked up before there is a scope, so like the users they belong
+// to the store rather than a household
+
+func (s *Store) CreateAPIKey(ctx context.Context, userId int64, name string, prefix string, hash string) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var user *types.User
+	for i := range s.users {
+		if s.users[i].Id == userId {
+			user = &s.users[i]
+		}
+	}
+	if user == nil {
+		return types.APIKey{}, fmt.Errorf("user %d: %w", userId, types.ErrNotFound)
+	}
+	for _, k := range s.apiKeys {
+		if k.Prefix == prefix {
+			return types.APIKey{}, fmt.Errorf("error inserting API key: duplicate prefix %s", prefix)
+		}
+	}
+	key := types.APIKey{
+		Id: int64(s.nextId("api_keys")), UserId: userId, HouseholdId: user.HouseholdId,
+		Name: name, Prefix: prefix, Hash: hash, CreatedAt: time.Now(),
+	}
+	s.apiKeys = append(s.apiKeys, key)
+	return key, nil
+}
+
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.Prefix == prefix {
+			return k, nil
+		}
+	}
+	return types.APIKey{}, fmt.Errorf("API key %s: %w", prefix, types.ErrNotFound)
+}
+
+func (s *Store) GetAPIKey(ctx context.Context, id int64) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.Id == id {
+			return k, nil
+		}
+	}
+	return types.APIKey{}, fmt.Errorf("API key %d: %w", id, types.ErrNotFound)
+}
+
+func (s *Store) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []types.APIKey{}
+	for i := len(s.apiKeys) - 1; i >= 0; i-- {
+		keys = append(keys, s.apiKeys[i])
+	}
+	return keys, nil
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, id int64) (types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.apiKeys {
+		if s.apiKeys[i].Id == id {
+			if s.apiKeys[i].RevokedAt == nil {
+				revokedAt := time.Now()
+				s.apiKeys[i].RevokedAt = &revokedAt
+			}
+			return s.apiKeys[i], nil
+		}
+	}
+	return types.APIKey{}, fmt.Errorf("API key %d: %w", id, types.ErrNotFound)
+}
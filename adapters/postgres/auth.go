@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== API KEYS ==========
+
+// The Store keeps hashed API keys in api_keys (auth.KeyManager). A key acts
+// for the user it was issued to, in that user's household.
+
+const apiKeyColumns = `id, name, prefix, hash, created_at, revoked_at,
+	user_id, (SELECT household_id FROM users WHERE users.id = api_keys.user_id)`
+
+func scanAPIKey(row pgx.Row) (types.APIKey, error) {
+	var key types.APIKey
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt, &key.RevokedAt,
+		&key.UserId, &key.HouseholdId)
+	return key, err
+}
+
+// CreateAPIKey stores a new key for userId by its prefix and hash
+func (s *Store) CreateAPIKey(ctx context.Context, userId int64, name string, prefix string, hash string) (types.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	key, err := scanAPIKey(s.pool.QueryRow(ctx,
+		`INSERT INTO api_keys (name, prefix, hash, user_id)
+		 SELECT $1, $2, $3, id FROM users WHERE id = $4
+		 RETURNING `+apiKeyColumns,
+		name, prefix, hash, userId,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.APIKey{}, fmt.Errorf("user %d: %w", userId, ErrNotFound)
+		}
+		return types.APIKey{}, fmt.Errorf("error inserting API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyByPrefix returns the key with the given prefix, revoked or not
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (types.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	key, err := scanAPIKey(s.pool.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`,
+		prefix,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.APIKey{}, fmt.Errorf("API key %s: %w", prefix, ErrNotFound)
+		}
+		return types.APIKey{}, fmt.Errorf("error querying API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKey returns the key with the given id, revoked or not
+func (s *Store) GetAPIKey(ctx context.Context, id int64) (types.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	key, err := scanAPIKey(s.pool.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`,
+		id,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.APIKey{}, fmt.Errorf("API key %d: %w", id, ErrNotFound)
+		}
+		return types.APIKey{}, fmt.Errorf("error querying API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys returns every key, newest first
+func (s *Store) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []types.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks a key revoked; revoking twice keeps the first timestamp
+func (s *Store) RevokeAPIKey(ctx context.Context, id int64) (types.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	key, err := scanAPIKey(s.pool.QueryRow(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1
+		 RETURNING `+apiKeyColumns,
+		id,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.APIKey{}, fmt.Errorf("API key %d: %w", id, ErrNotFound)
+		}
+		return types.APIKey{}, fmt.Errorf("error revoking API key: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// ========== EXPORTS ==========
+
+// inPeriod filters a date column on the period in $1 and $2; an empty end is open
+const inPeriod = `date >= $1 AND ($2 = '' OR date < $2)`
+
+// GetTransactions returns every transaction the caller can see dated in
+// period, oldest first. They are read in a single read-only snapshot, so a
+// transfer can't be exported without the rows written with it.
+func (s *Store) GetTransactions(ctx context.Context, period types.Period) (types.Transactions, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Transactions{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	args := []any{period.Start, period.End, scope.HouseholdId, viewer(scope)}
+	var result types.Transactions
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type
+		 FROM expenses
+		 WHERE `+inPeriod+` AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY date, id`,
+		args...,
+	)
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error querying expenses: %w", err)
+	}
+	for rows.Next() {
+		var e types.Expense
+		if err := rows.Scan(&e.Id, &e.Date, &e.Category, &e.CategoryId, &e.Expense, &e.Description,
+			&e.Method, &e.OriginalAmount, &e.AccountId, &e.AccountType); err != nil {
+			rows.Close()
+			return types.Transactions{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Expenses = append(result.Expenses, e)
+	}
+	if err := rows.Err(); err != nil {
+		return types.Transactions{}, fmt.Errorf("error reading expenses: %w", err)
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, date, amount, description, account_id, account_name, created_at
+		 FROM incomes
+		 WHERE `+inPeriod+` AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY date, id`,
+		args...,
+	)
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error querying incomes: %w", err)
+	}
+	for rows.Next() {
+		var i types.Income
+		if err := rows.Scan(&i.Id, &i.Date, &i.Amount, &i.Description, &i.AccountId, &i.AccountName, &i.CreatedAt); err != nil {
+			rows.Close()
+			return types.Transactions{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Incomes = append(result.Incomes, i)
+	}
+	if err := rows.Err(); err != nil {
+		return types.Transactions{}, fmt.Errorf("error reading incomes: %w", err)
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT t.id, t.created_at, t.date, COALESCE(t.description, ''),
+			t.source_account_id, COALESCE(sa.name, ''), t.source_amount,
+			t.dest_account_id, COALESCE(da.name, ''), t.dest_amount,
+			COALESCE(t.exchange_rate, 0), COALESCE(t.reference_rate, 0), t.fx_cost
+		 FROM transfers t
+		 LEFT JOIN accounts sa ON t.source_account_id = sa.id AND sa.household_id = t.household_id
+		 LEFT JOIN accounts da ON t.dest_account_id = da.id AND da.household_id = t.household_id
+		 WHERE t.date >= $1 AND ($2 = '' OR t.date < $2)
+		   AND t.household_id = $3 AND (t.owner_id IS NULL OR t.owner_id = $4 OR $4 IS NULL)
+		 ORDER BY t.date, t.id`,
+		args...,
+	)
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error querying transfers: %w", err)
+	}
+	for rows.Next() {
+		var t types.Transfer
+		if err := rows.Scan(&t.Id, &t.CreatedAt, &t.Date, &t.Description,
+			&t.SourceAccountId, &t.SourceAccountName, &t.SourceAmount,
+			&t.DestAccountId, &t.DestAccountName, &t.DestAmount, &t.ExchangeRate, &t.ReferenceRate, &t.FXCost); err != nil {
+			rows.Close()
+			return types.Transactions{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Transfers = append(result.Transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return types.Transactions{}, fmt.Errorf("error reading transfers: %w", err)
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, date, description, amount, account_id, account_name, type, source_account_id
+		 FROM investments
+		 WHERE `+inPeriod+` AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY date, id`,
+		args...,
+	)
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error querying investments: %w", err)
+	}
+	for rows.Next() {
+		var inv types.Investment
+		if err := rows.Scan(&inv.Id, &inv.Date, &inv.Description, &inv.Amount,
+			&inv.AccountId, &inv.AccountName, &inv.Type, &inv.SourceAccountId); err != nil {
+			rows.Close()
+			return types.Transactions{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Investments = append(result.Investments, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return types.Transactions{}, fmt.Errorf("error reading investments: %w", err)
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, description, amount, debtor_id, debtor_name, date, created_at,
+			original_amount, currency, outbound, account_id, expense_id, income_id
+		 FROM debts
+		 WHERE `+inPeriod+` AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY date, id`,
+		args...,
+	)
+	if err != nil {
+		return types.Transactions{}, fmt.Errorf("error querying debts: %w", err)
+	}
+	for rows.Next() {
+		var d types.Debt
+		if err := rows.Scan(&d.Id, &d.Description, &d.Amount, &d.DebtorId, &d.DebtorName,
+			&d.Date, &d.CreatedAt, &d.OriginalAmount, &d.Currency, &d.Outbound,
+			&d.AccountId, &d.ExpenseId, &d.IncomeId); err != nil {
+			rows.Close()
+			return types.Transactions{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Debts = append(result.Debts, d)
+	}
+	if err := rows.Err(); err != nil {
+		return types.Transactions{}, fmt.Errorf("error reading debts: %w", err)
+	}
+
+	return result, nil
+}
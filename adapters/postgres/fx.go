@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== EXCHANGE RATES ==========
+
+// Rates are the household's; totals are converted with them in SQL by the
+// fx_rate, to_base and account_currency functions of the 0010 migration.
+// fx_rate fails a total that needs a rate nobody stored (migration 0013).
+
+// fxRateMissing is the SQLSTATE fx_rate raises without a rate
+const fxRateMissing = "FX001"
+
+// converted is err as a *types.FXRateError when a query failed because
+// fx_rate had no rate for one of its amounts
+func converted(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == fxRateMissing {
+		if currencies := strings.Fields(pgErr.Detail); len(currencies) == 2 {
+			return &types.FXRateError{From: currencies[0], To: currencies[1]}
+		}
+	}
+	return err
+}
+
+// UpsertFXRates stores rates, replacing any the household already has for the
+// same currency, quote and date. Either every rate is stored or none is.
+// Transfers between the currencies of the rates are costed again (see
+// costTransfer), so a rate loaded after a transfer was recorded still counts.
+func (s *Store) UpsertFXRates(ctx context.Context, rates []types.FXRate) ([]types.FXRate, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]types.FXRate, 0, len(rates))
+	for _, r := range rates {
+		var result types.FXRate
+		err := tx.QueryRow(ctx,
+			`INSERT INTO fx_rates (household_id, date, currency, quote, rate, source)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (household_id, currency, quote, date) DO UPDATE SET
+			   rate = EXCLUDED.rate,
+			   source = EXCLUDED.source
+			 RETURNING id, date, currency, quote, rate, source, created_at`,
+			scope.HouseholdId, r.Date, r.Currency, r.Quote, r.Rate, r.Source,
+		).Scan(&result.Id, &result.Date, &result.Currency, &result.Quote, &result.Rate, &result.Source, &result.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error upserting rate %s/%s on %s: %w", r.Currency, r.Quote, r.Date, err)
+		}
+		results = append(results, result)
+	}
+
+	currencies := []string{}
+	for _, r := range rates {
+		currencies = append(currencies, r.Currency, r.Quote)
+	}
+	_, err = tx.Exec(ctx,
+		`WITH costed AS (
+			SELECT id, fx_reference_rate(household_id, account_currency('', source_account_id), account_currency('', dest_account_id), date) AS rate
+			FROM transfers
+			WHERE household_id = $1
+			  AND account_currency('', source_account_id) = ANY($2)
+			  AND account_currency('', dest_account_id) = ANY($2)
+		)
+		UPDATE transfers t SET reference_rate = c.rate, fx_cost = COALESCE(ROUND(t.source_amount * c.rate - t.dest_amount, 2), 0)
+		FROM costed c
+		WHERE t.id = c.id`,
+		scope.HouseholdId, currencies,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error costing transfers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing rates: %w", err)
+	}
+	return results, nil
+}
+
+// GetFXRates returns the household's rates, newest first; a currency limits
+// them to the rates from or into it
+func (s *Store) GetFXRates(ctx context.Context, currency string) ([]types.FXRate, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, date, currency, quote, rate, source, created_at FROM fx_rates
+		 WHERE household_id = $1 AND ($2 = '' OR currency = $2 OR quote = $2)
+		 ORDER BY date DESC, currency, quote`,
+		scope.HouseholdId, currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying rates: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.FXRate
+	for rows.Next() {
+		var r types.FXRate
+		if err := rows.Scan(&r.Id, &r.Date, &r.Currency, &r.Quote, &r.Rate, &r.Source, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
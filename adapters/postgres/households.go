@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== HOUSEHOLDS ==========
+
+// Every query that reads or writes household data runs for the types.Scope in
+// its context: it sees the scope's household, and within it the rows that are
+// shared (owner_id IS NULL) or owned by the scope's user. A call without a
+// scope is refused rather than seeing every household.
+
+// scopeFrom returns the scope the call's queries are limited to
+func scopeFrom(ctx context.Context) (types.Scope, error) {
+	scope, ok := types.ScopeFrom(ctx)
+	if !ok {
+		return types.Scope{}, types.ErrNoScope
+	}
+	return scope, nil
+}
+
+// viewer is the user whose private rows a query sees besides the shared ones;
+// nil, for a household-wide scope, sees every user's
+func viewer(scope types.Scope) *int64 {
+	if scope.AllUsers {
+		return nil
+	}
+	return &scope.UserId
+}
+
+// querier is a pool or a transaction
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// accountRef is an account a transaction draws on or pays into
+type accountRef struct {
+	id         int32
+	investment bool // investment_accounts rather than accounts
+}
+
+func fiatAccount(id int32) accountRef {
+	return accountRef{id: id}
+}
+
+func investmentAccount(id int32) accountRef {
+	return accountRef{id: id, investment: true}
+}
+
+// expenseAccount is the account an expense is charged to; account_type tells
+// the two tables apart
+func expenseAccount(expense types.Expense) accountRef {
+	switch expense.AccountType {
+	case "Investment", "Crypto", "Broker":
+		return investmentAccount(expense.AccountId)
+	}
+	return fiatAccount(expense.AccountId)
+}
+
+// transactionOwner checks that every referenced account is visible to scope
+// and returns the owner_id a transaction on them gets: private when any of
+// them is private (it can only be the caller's), shared otherwise. Zero ids
+// are "no account" and are skipped.
+func transactionOwner(ctx context.Context, q querier, scope types.Scope, refs ...accountRef) (*int64, error) {
+	var owner *int64
+	for _, ref := range refs {
+		if ref.id == 0 {
+			continue
+		}
+		table := "accounts"
+		if ref.investment {
+			table = "investment_accounts"
+		}
+
+		var accountOwner *int64
+		err := q.QueryRow(ctx,
+			`SELECT owner_id FROM `+table+`
+			 WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)`,
+			ref.id, scope.HouseholdId, viewer(scope),
+		).Scan(&accountOwner)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("account %d: %w", ref.id, ErrNotFound)
+			}
+			return nil, fmt.Errorf("error querying account owner: %w", err)
+		}
+		if accountOwner != nil {
+			owner = accountOwner
+		}
+	}
+	return owner, nil
+}
+
+// RefExists tells whether id is a row of kind the caller can see, for request validation
+func (s *Store) RefExists(ctx context.Context, kind types.RefKind, id int32) (bool, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Accounts may be private to someone else; categories and debtors are shared
+	var query string
+	args := []any{id, scope.HouseholdId}
+	switch kind {
+	case types.RefAccount:
+		query = `SELECT EXISTS (SELECT 1 FROM accounts WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL))`
+		args = append(args, viewer(scope))
+	case types.RefInvestmentAccount:
+		query = `SELECT EXISTS (SELECT 1 FROM investment_accounts WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL))`
+		args = append(args, viewer(scope))
+	case types.RefCategory:
+		query = `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND household_id = $2)`
+	case types.RefDebtor:
+		query = `SELECT EXISTS (SELECT 1 FROM debtors WHERE id = $1 AND household_id = $2)`
+	default:
+		return false, fmt.Errorf("unknown reference kind: %s", kind)
+	}
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error querying %s: %w", kind, err)
+	}
+	return exists, nil
+}
+
+// privateOwner is the owner_id of a new account: the caller when it asked for a
+// private account, shared otherwise. Accounts can't be made private to someone else.
+func privateOwner(scope types.Scope, requested *int64) (*int64, error) {
+	if requested == nil {
+		return nil, nil
+	}
+	if *requested != scope.UserId {
+		return nil, types.Invalid("an account can only be private to the user creating it")
+	}
+	owner := scope.UserId
+	return &owner, nil
+}
+
+const householdColumns = `id, name, spreadsheet_id, timezone,
+	budget_period, budget_period_start_day, budget_period_anchor, budget_mode, envelope_since, base_currency, created_at`
+
+// householdFields are the scan targets of householdColumns
+func householdFields(h *types.Household) []any {
+	return []any{&h.Id, &h.Name, &h.SpreadsheetId, &h.Timezone,
+		&h.BudgetPeriod.Kind, &h.BudgetPeriod.StartDay, &h.BudgetPeriod.Anchor, &h.BudgetMode, &h.EnvelopeSince, &h.BaseCurrency, &h.CreatedAt}
+}
+
+// GetHousehold returns the caller's household
+func (s *Store) GetHousehold(ctx context.Context) (types.Household, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Household{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var h types.Household
+	err = s.pool.QueryRow(ctx,
+		`SELECT `+householdColumns+` FROM households WHERE id = $1`,
+		scope.HouseholdId,
+	).Scan(householdFields(&h)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Household{}, fmt.Errorf("household %d: %w", scope.HouseholdId, ErrNotFound)
+		}
+		return types.Household{}, fmt.Errorf("error querying household: %w", err)
+	}
+
+	return h, nil
+}
+
+// UpdateHousehold renames the caller's household, points it at another
+// spreadsheet, moves it to another timezone or changes how it budgets
+func (s *Store) UpdateHousehold(ctx context.Context, household types.Household) (types.Household, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Household{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var h types.Household
+	err = s.pool.QueryRow(ctx,
+		`UPDATE households SET name = $2, spreadsheet_id = $3, timezone = $4,
+			budget_period = $5, budget_period_start_day = $6, budget_period_anchor = $7,
+			budget_mode = $8, envelope_since = $9, base_currency = $10
+		 WHERE id = $1
+		 RETURNING `+householdColumns,
+		scope.HouseholdId, household.Name, household.SpreadsheetId, household.Timezone,
+		household.BudgetPeriod.Kind, household.BudgetPeriod.StartDay, household.BudgetPeriod.Anchor,
+		household.BudgetMode, household.EnvelopeSince, household.BaseCurrency,
+	).Scan(householdFields(&h)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Household{}, fmt.Errorf("household %d: %w", scope.HouseholdId, ErrNotFound)
+		}
+		return types.Household{}, fmt.Errorf("error updating household: %w", err)
+	}
+
+	return h, nil
+}
+
+// GetHouseholdUsers returns the members of the caller's household
+func (s *Store) GetHouseholdUsers(ctx context.Context) ([]types.User, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryUsers(ctx, `WHERE household_id = $1`, scope.HouseholdId)
+}
+
+// ========== HOUSEHOLD ADMIN ==========
+// Used by the CLI, which runs with no scope
+
+// CreateHousehold adds a household with no users yet
+func (s *Store) CreateHousehold(ctx context.Context, name string, spreadsheetId string) (types.Household, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var h types.Household
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO households (name, spreadsheet_id) VALUES ($1, $2)
+		 RETURNING `+householdColumns,
+		name, spreadsheetId,
+	).Scan(householdFields(&h)...)
+	if err != nil {
+		return types.Household{}, fmt.Errorf("error inserting household: %w", err)
+	}
+
+	return h, nil
+}
+
+// ListHouseholds returns every household
+func (s *Store) ListHouseholds(ctx context.Context) ([]types.Household, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+householdColumns+` FROM households ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying households: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.Household
+	for rows.Next() {
+		var h types.Household
+		if err := rows.Scan(householdFields(&h)...); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, h)
+	}
+
+	return results, nil
+}
+
+// CreateUser adds a user to a household
+func (s *Store) CreateUser(ctx context.Context, householdId int64, name string) (types.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var u types.User
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO users (household_id, name)
+		 SELECT id, $2 FROM households WHERE id = $1
+		 RETURNING id, household_id, name, created_at`,
+		householdId, name,
+	).Scan(&u.Id, &u.HouseholdId, &u.Name, &u.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.User{}, fmt.Errorf("household %d: %w", householdId, ErrNotFound)
+		}
+		return types.User{}, fmt.Errorf("error inserting user: %w", err)
+	}
+
+	return u, nil
+}
+
+// ListUsers returns every user of every household
+func (s *Store) ListUsers(ctx context.Context) ([]types.User, error) {
+	return s.queryUsers(ctx, ``)
+}
+
+func (s *Store) queryUsers(ctx context.Context, where string, args ...any) ([]types.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, household_id, name, created_at FROM users `+where+` ORDER BY household_id, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.User
+	for rows.Next() {
+		var u types.User
+		if err := rows.Scan(&u.Id, &u.HouseholdId, &u.Name, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, u)
+	}
+
+	return results, nil
+}
+
+// optionalAccount is a fiat account reference that may be absent
+func optionalAccount(id *int32) accountRef {
+	if id == nil {
+		return accountRef{}
+	}
+	return fiatAccount(*id)
+}
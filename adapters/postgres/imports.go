@@ -0,0 +1,403 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// ========== STATEMENT IMPORTS ==========
+
+const importMappingColumns = `account_id, date_column, date_format, amount_column, debit_column, credit_column,
+	description_column, negate, delimiter, decimal_comma, updated_at`
+
+func scanImportMapping(row pgx.Row) (types.ImportMapping, error) {
+	var m types.ImportMapping
+	err := row.Scan(&m.AccountId, &m.Date, &m.DateFormat, &m.Amount, &m.Debit, &m.Credit,
+		&m.Description, &m.Negate, &m.Delimiter, &m.DecimalComma, &m.UpdatedAt)
+	return m, err
+}
+
+// accountOwners is transactionOwner for imports, which record many rows
+// against the same few accounts: each account is looked up once
+type accountOwners struct {
+	q      querier
+	scope  types.Scope
+	owners map[accountRef]*int64
+}
+
+func newAccountOwners(q querier, scope types.Scope) *accountOwners {
+	return &accountOwners{q: q, scope: scope, owners: map[accountRef]*int64{}}
+}
+
+func (a *accountOwners) of(ctx context.Context, ref accountRef) (*int64, error) {
+	if owner, ok := a.owners[ref]; ok {
+		return owner, nil
+	}
+	owner, err := transactionOwner(ctx, a.q, a.scope, ref)
+	if err != nil {
+		return nil, err
+	}
+	a.owners[ref] = owner
+	return owner, nil
+}
+
+// GetImportMappings returns the CSV mappings saved for the accounts the caller can see
+func (s *Store) GetImportMappings(ctx context.Context) ([]types.ImportMapping, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.`+importMappingColumns+` FROM import_mappings m
+		 JOIN accounts a ON a.id = m.account_id
+		 WHERE m.household_id = $1 AND (a.owner_id IS NULL OR a.owner_id = $2 OR $2 IS NULL)
+		 ORDER BY m.account_id`,
+		scope.HouseholdId, viewer(scope),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying import mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.ImportMapping
+	for rows.Next() {
+		m, err := scanImportMapping(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, m)
+	}
+
+	return results, nil
+}
+
+// GetImportMapping returns the CSV mapping saved for an account
+func (s *Store) GetImportMapping(ctx context.Context, accountId int32) (types.ImportMapping, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.ImportMapping{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	m, err := scanImportMapping(s.pool.QueryRow(ctx,
+		`SELECT m.`+importMappingColumns+` FROM import_mappings m
+		 JOIN accounts a ON a.id = m.account_id
+		 WHERE m.account_id = $1 AND m.household_id = $2 AND (a.owner_id IS NULL OR a.owner_id = $3 OR $3 IS NULL)`,
+		accountId, scope.HouseholdId, viewer(scope),
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.ImportMapping{}, fmt.Errorf("import mapping for account %d: %w", accountId, ErrNotFound)
+		}
+		return types.ImportMapping{}, fmt.Errorf("error querying import mapping: %w", err)
+	}
+	return m, nil
+}
+
+// UpsertImportMapping saves the CSV mapping of an account, replacing the one it had
+func (s *Store) UpsertImportMapping(ctx context.Context, mapping types.ImportMapping) (types.ImportMapping, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.ImportMapping{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := transactionOwner(ctx, s.pool, scope, fiatAccount(mapping.AccountId)); err != nil {
+		return types.ImportMapping{}, err
+	}
+
+	m, err := scanImportMapping(s.pool.QueryRow(ctx,
+		`INSERT INTO import_mappings (household_id, account_id, date_column, date_format, amount_column, debit_column,
+		   credit_column, description_column, negate, delimiter, decimal_comma)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 ON CONFLICT (household_id, account_id) DO UPDATE SET
+		   date_column = EXCLUDED.date_column,
+		   date_format = EXCLUDED.date_format,
+		   amount_column = EXCLUDED.amount_column,
+		   debit_column = EXCLUDED.debit_column,
+		   credit_column = EXCLUDED.credit_column,
+		   description_column = EXCLUDED.description_column,
+		   negate = EXCLUDED.negate,
+		   delimiter = EXCLUDED.delimiter,
+		   decimal_comma = EXCLUDED.decimal_comma,
+		   updated_at = NOW()
+		 RETURNING `+importMappingColumns,
+		scope.HouseholdId, mapping.AccountId, mapping.Date, mapping.DateFormat, mapping.Amount, mapping.Debit,
+		mapping.Credit, mapping.Description, mapping.Negate, mapping.Delimiter, mapping.DecimalComma,
+	))
+	if err != nil {
+		return types.ImportMapping{}, fmt.Errorf("error upserting import mapping: %w", err)
+	}
+	return m, nil
+}
+
+// GetAccountTransactions returns the expenses charged to a fiat account and the
+// incomes paid into it that are dated in period, oldest first
+func (s *Store) GetAccountTransactions(ctx context.Context, accountId int32, period types.Period) ([]types.Expense, []types.Income, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type
+		 FROM expenses
+		 WHERE account_id = $1 AND account_type NOT IN ('Investment', 'Crypto', 'Broker')
+		   AND date >= $2 AND date < $3
+		   AND household_id = $4 AND (owner_id IS NULL OR owner_id = $5 OR $5 IS NULL)
+		 ORDER BY date, id`,
+		accountId, period.Start, period.End, scope.HouseholdId, viewer(scope),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error querying expenses: %w", err)
+	}
+	defer rows.Close()
+
+	var expenses []types.Expense
+	for rows.Next() {
+		var e types.Expense
+		if err := rows.Scan(&e.Id, &e.Date, &e.Category, &e.CategoryId, &e.Expense, &e.Description,
+			&e.Method, &e.OriginalAmount, &e.AccountId, &e.AccountType); err != nil {
+			return nil, nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		expenses = append(expenses, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading expenses: %w", err)
+	}
+
+	rows, err = s.pool.Query(ctx,
+		`SELECT id, date, amount, description, account_id, account_name, created_at
+		 FROM incomes
+		 WHERE account_id = $1 AND date >= $2 AND date < $3
+		   AND household_id = $4 AND (owner_id IS NULL OR owner_id = $5 OR $5 IS NULL)
+		 ORDER BY date, id`,
+		accountId, period.Start, period.End, scope.HouseholdId, viewer(scope),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error querying incomes: %w", err)
+	}
+	defer rows.Close()
+
+	var incomes []types.Income
+	for rows.Next() {
+		var i types.Income
+		if err := rows.Scan(&i.Id, &i.Date, &i.Amount, &i.Description, &i.AccountId, &i.AccountName, &i.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		incomes = append(incomes, i)
+	}
+
+	return expenses, incomes, nil
+}
+
+// ImportTransactions records the expenses and incomes of a statement. Either
+// every one of them is recorded or none is.
+func (s *Store) ImportTransactions(ctx context.Context, expenses []types.Expense, incomes []types.Income) ([]types.Expense, []types.Income, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	owners := newAccountOwners(tx, scope)
+
+	insertedExpenses := make([]types.Expense, 0, len(expenses))
+	for i, expense := range expenses {
+		if expense.Expense.Sign() <= 0 {
+			return nil, nil, types.Invalid("expense %d: amount must be positive, got: %s", i+1, expense.Expense)
+		}
+		owner, err := owners.of(ctx, expenseAccount(expense))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var result types.Expense
+		err = tx.QueryRow(ctx,
+			`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
+			expense.Date, expense.Category, expense.CategoryId, expense.Expense,
+			expense.Description, expense.Method, expense.OriginalAmount,
+			expense.AccountId, expense.AccountType, scope.HouseholdId, owner,
+		).Scan(&result.Id, &result.Date, &result.Category, &result.CategoryId,
+			&result.Expense, &result.Description, &result.Method, &result.OriginalAmount,
+			&result.AccountId, &result.AccountType)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error inserting expense %d: %w", i+1, err)
+		}
+		insertedExpenses = append(insertedExpenses, result)
+	}
+
+	insertedIncomes := make([]types.Income, 0, len(incomes))
+	for i, income := range incomes {
+		if income.Amount.Sign() <= 0 {
+			return nil, nil, types.Invalid("income %d: amount must be positive, got: %s", i+1, income.Amount)
+		}
+		owner, err := owners.of(ctx, fiatAccount(income.AccountId))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var result types.Income
+		err = tx.QueryRow(ctx,
+			`INSERT INTO incomes (date, amount, description, account_id, account_name, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 RETURNING id, date, amount, description, account_id, account_name, created_at`,
+			income.Date, income.Amount, income.Description, income.AccountId, income.AccountName,
+			scope.HouseholdId, owner,
+		).Scan(&result.Id, &result.Date, &result.Amount, &result.Description,
+			&result.AccountId, &result.AccountName, &result.CreatedAt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error inserting income %d: %w", i+1, err)
+		}
+		insertedIncomes = append(insertedIncomes, result)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("error committing import: %w", err)
+	}
+	return insertedExpenses, insertedIncomes, nil
+}
+
+// ========== SHEET HISTORY ==========
+
+// ImportHistory records transactions read back from the sheet, all of them or
+// none. Investments are recorded without changing the capital of their
+// accounts, which already counts them. The inserts go to the database as one
+// batch, so a sheet of years of rows takes a round trip rather than one per row.
+func (s *Store) ImportHistory(ctx context.Context, history types.SheetHistory) (types.SheetHistory, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.SheetHistory{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.SheetHistory{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Owners are looked up before queuing: a batch can't interleave other queries
+	owners := newAccountOwners(tx, scope)
+	batch := &pgx.Batch{}
+	var result types.SheetHistory
+
+	for _, expense := range history.Expenses {
+		owner, err := owners.of(ctx, expenseAccount(expense))
+		if err != nil {
+			return types.SheetHistory{}, err
+		}
+		batch.Queue(
+			`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
+			expense.Date, expense.Category, expense.CategoryId, expense.Expense,
+			expense.Description, expense.Method, expense.OriginalAmount,
+			expense.AccountId, expense.AccountType, scope.HouseholdId, owner,
+		).QueryRow(func(row pgx.Row) error {
+			var e types.Expense
+			err := row.Scan(&e.Id, &e.Date, &e.Category, &e.CategoryId, &e.Expense, &e.Description, &e.Method,
+				&e.OriginalAmount, &e.AccountId, &e.AccountType)
+			if err != nil {
+				return fmt.Errorf("error inserting expense: %w", err)
+			}
+			result.Expenses = append(result.Expenses, e)
+			return nil
+		})
+	}
+
+	for _, income := range history.Incomes {
+		owner, err := owners.of(ctx, fiatAccount(income.AccountId))
+		if err != nil {
+			return types.SheetHistory{}, err
+		}
+		batch.Queue(
+			`INSERT INTO incomes (date, amount, description, account_id, account_name, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 RETURNING id, date, amount, description, account_id, account_name, created_at`,
+			income.Date, income.Amount, income.Description, income.AccountId, income.AccountName,
+			scope.HouseholdId, owner,
+		).QueryRow(func(row pgx.Row) error {
+			var i types.Income
+			if err := row.Scan(&i.Id, &i.Date, &i.Amount, &i.Description, &i.AccountId, &i.AccountName, &i.CreatedAt); err != nil {
+				return fmt.Errorf("error inserting income: %w", err)
+			}
+			result.Incomes = append(result.Incomes, i)
+			return nil
+		})
+	}
+
+	for _, investment := range history.Investments {
+		owner, err := owners.of(ctx, investmentAccount(investment.AccountId))
+		if err != nil {
+			return types.SheetHistory{}, err
+		}
+		batch.Queue(
+			`INSERT INTO investments (date, description, amount, account_id, account_name, type, source_account_id, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			 RETURNING id, date, description, amount, account_id, account_name, type, source_account_id`,
+			investment.Date, investment.Description, investment.Amount,
+			investment.AccountId, investment.AccountName, investment.Type, investment.SourceAccountId,
+			scope.HouseholdId, owner,
+		).QueryRow(func(row pgx.Row) error {
+			var i types.Investment
+			if err := row.Scan(&i.Id, &i.Date, &i.Description, &i.Amount, &i.AccountId, &i.AccountName, &i.Type, &i.SourceAccountId); err != nil {
+				return fmt.Errorf("error inserting investment: %w", err)
+			}
+			result.Investments = append(result.Investments, i)
+			return nil
+		})
+	}
+
+	for _, debt := range history.Debts {
+		owner, err := owners.of(ctx, optionalAccount(debt.AccountId))
+		if err != nil {
+			return types.SheetHistory{}, err
+		}
+		batch.Queue(
+			`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 RETURNING id, description, amount, debtor_id, debtor_name, date, created_at, original_amount, currency, outbound`,
+			debt.Description, debt.Amount, debt.DebtorId, debt.DebtorName, debt.Date,
+			debt.OriginalAmount, debt.Currency, debt.Outbound, debt.AccountId,
+			scope.HouseholdId, owner,
+		).QueryRow(func(row pgx.Row) error {
+			var d types.Debt
+			err := row.Scan(&d.Id, &d.Description, &d.Amount, &d.DebtorId, &d.DebtorName,
+				&d.Date, &d.CreatedAt, &d.OriginalAmount, &d.Currency, &d.Outbound)
+			if err != nil {
+				return fmt.Errorf("error inserting debt: %w", err)
+			}
+			result.Debts = append(result.Debts, d)
+			return nil
+		})
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return types.SheetHistory{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return types.SheetHistory{}, fmt.Errorf("error committing history: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ========== MIGRATIONS ==========
+
+// Migrations live in migrations/ as NNNN_name.up.sql and NNNN_name.down.sql and
+// are compiled into the binary. Applied versions are recorded in schema_migrations.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey serializes concurrent migrate runs (pg_advisory_xact_lock)
+const migrationLockKey = 7262013
+
+// ErrSchemaOutOfDate is returned by CheckSchema when migrations are pending
+var ErrSchemaOutOfDate = errors.New("database schema is out of date")
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied (nil if pending)
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrations returns every embedded migration ordered by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		versionText, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if !ok || !found || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+
+		contents, err := fs.ReadFile(migrationFiles, "migrations/"+file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", file, err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (s *Store) ensureMigrationsTable(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrations returns when each applied version was applied
+func (s *Store) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := s.pool.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists every embedded migration and whether it has been applied
+func (s *Store) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+		results = append(results, status)
+	}
+	return results, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied
+func (s *Store) MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		ran, err := s.runMigration(ctx, m, true)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the most recently applied migration. It returns false if
+// nothing was applied.
+func (s *Store) MigrateDown(ctx context.Context) (Migration, bool, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return Migration{}, false, err
+	}
+	if err := s.ensureMigrationsTable(ctx); err != nil {
+		return Migration{}, false, err
+	}
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+		ran, err := s.runMigration(ctx, migrations[i], false)
+		return migrations[i], ran, err
+	}
+	return Migration{}, false, nil
+}
+
+// runMigration applies (up) or reverts (down) m unless another run already did.
+// The advisory lock makes concurrent runs take turns.
+func (s *Store) runMigration(ctx context.Context, m Migration, up bool) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return false, fmt.Errorf("error locking schema_migrations: %w", err)
+	}
+
+	var version int
+	err = tx.QueryRow(ctx, `SELECT version FROM schema_migrations WHERE version = $1`, m.Version).Scan(&version)
+	isApplied := err == nil
+	if err != nil && err != pgx.ErrNoRows {
+		return false, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	if isApplied == up {
+		return false, nil
+	}
+
+	if up {
+		if _, err := tx.Exec(ctx, m.Up); err != nil {
+			return false, fmt.Errorf("error applying migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return false, fmt.Errorf("error reverting migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return false, fmt.Errorf("error recording migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return true, nil
+}
+
+// CheckSchema returns an error wrapping ErrSchemaOutOfDate if any embedded
+// migration has not been applied
+func (s *Store) CheckSchema(ctx context.Context) error {
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaOutOfDate, strings.Join(pending, ", "))
+	}
+	return nil
+}
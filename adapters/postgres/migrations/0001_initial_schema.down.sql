@@ -0,0 +1,19 @@
+DROP VIEW IF EXISTS account_expected_balance;
+DROP VIEW IF EXISTS investment_account_summary;
+DROP VIEW IF EXISTS debt_by_debtor;
+DROP VIEW IF EXISTS budget_by_category_current_month;
+DROP VIEW IF EXISTS monthly_income_summary;
+
+DROP TABLE IF EXISTS net_worth_snapshots;
+DROP TABLE IF EXISTS yearly_goals;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS debts;
+DROP TABLE IF EXISTS investments;
+DROP TABLE IF EXISTS incomes;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS debtors;
+DROP TABLE IF EXISTS investment_accounts;
+DROP TABLE IF EXISTS accounts;
+DROP TABLE IF EXISTS budgets;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS config;
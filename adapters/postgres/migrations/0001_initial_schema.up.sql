@@ -0,0 +1,246 @@
+-- Baseline schema. Everything is IF NOT EXISTS / OR REPLACE so databases that
+-- were created by hand before migrations existed can be brought under version
+-- control by running this once.
+
+CREATE TABLE IF NOT EXISTS config (
+    type  TEXT PRIMARY KEY,
+    sheet TEXT NOT NULL,
+    range TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id           SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    is_essential BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id          SERIAL PRIMARY KEY,
+    category_id INTEGER NOT NULL UNIQUE,
+    budget      NUMERIC(14, 2) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id               SERIAL PRIMARY KEY,
+    name             TEXT NOT NULL,
+    description      TEXT,
+    type             TEXT,
+    currency         TEXT DEFAULT 'USD',
+    balance          NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    starting_balance NUMERIC(14, 2) DEFAULT 0,
+    starting_date    DATE DEFAULT CURRENT_DATE
+);
+
+CREATE TABLE IF NOT EXISTS investment_accounts (
+    id               SERIAL PRIMARY KEY,
+    name             TEXT NOT NULL,
+    description      TEXT,
+    type             TEXT,
+    currency         TEXT DEFAULT 'USD',
+    balance          NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    capital          NUMERIC(14, 2) DEFAULT 0,
+    starting_capital NUMERIC(14, 2) DEFAULT 0,
+    starting_date    DATE DEFAULT CURRENT_DATE
+);
+
+CREATE TABLE IF NOT EXISTS debtors (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL,
+    first_name  TEXT,
+    last_name   TEXT,
+    description TEXT
+);
+
+-- "account_id" points at accounts or investment_accounts depending on account_type
+CREATE TABLE IF NOT EXISTS expenses (
+    id               SERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    date             TEXT NOT NULL,
+    category         TEXT NOT NULL DEFAULT '',
+    category_id      INTEGER NOT NULL DEFAULT 0,
+    expense          NUMERIC(14, 2) NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    method           TEXT NOT NULL DEFAULT '',
+    "originalAmount" NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    account_id       INTEGER NOT NULL DEFAULT 0,
+    account_type     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS incomes (
+    id           SERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    date         TEXT NOT NULL DEFAULT TO_CHAR(NOW(), 'YYYY-MM-DD HH24:MI:SS'),
+    amount       NUMERIC(14, 2) NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    account_id   INTEGER NOT NULL DEFAULT 0,
+    account_name TEXT NOT NULL DEFAULT ''
+);
+
+-- source_account_id is the fiat account a deposit came from or a withdrawal went to
+CREATE TABLE IF NOT EXISTS investments (
+    id                SERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    date              TEXT NOT NULL,
+    description       TEXT NOT NULL DEFAULT '',
+    amount            NUMERIC(14, 2) NOT NULL,
+    account_id        INTEGER NOT NULL,
+    account_name      TEXT NOT NULL DEFAULT '',
+    type              TEXT NOT NULL CHECK (type IN ('deposit', 'withdrawal')),
+    source_account_id INTEGER
+);
+
+-- outbound debts are money lent, inbound ones are repayments
+CREATE TABLE IF NOT EXISTS debts (
+    id              SERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    description     TEXT NOT NULL DEFAULT '',
+    amount          NUMERIC(14, 2) NOT NULL,
+    debtor_id       INTEGER NOT NULL,
+    debtor_name     TEXT NOT NULL DEFAULT '',
+    date            TEXT NOT NULL,
+    original_amount NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    currency        TEXT NOT NULL DEFAULT '',
+    outbound        BOOLEAN NOT NULL DEFAULT TRUE,
+    account_id      INTEGER,
+    expense_id      INTEGER,
+    income_id       INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS debts_expense_id_idx ON debts (expense_id);
+CREATE INDEX IF NOT EXISTS debts_income_id_idx ON debts (income_id);
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id                SERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    date              TEXT NOT NULL,
+    description       TEXT,
+    source_account_id INTEGER NOT NULL,
+    source_amount     NUMERIC(14, 2) NOT NULL,
+    dest_account_id   INTEGER NOT NULL,
+    dest_amount       NUMERIC(14, 2) NOT NULL,
+    exchange_rate     NUMERIC
+);
+
+CREATE TABLE IF NOT EXISTS yearly_goals (
+    id               SERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    year             INTEGER NOT NULL UNIQUE,
+    savings_goal     NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    investment_goal  NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    ideal_investment NUMERIC(14, 2) NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    id                       SERIAL PRIMARY KEY,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    date                     TIMESTAMPTZ NOT NULL,
+    year                     INTEGER NOT NULL,
+    month                    INTEGER NOT NULL,
+    total_fiat_balance       NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    crypto_balance           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    crypto_capital           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    broker_balance           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    broker_capital           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    total_investment_balance NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    total_investment_capital NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    total_real_net_worth     NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    total_pnl                NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    expected_fiat_balance    NUMERIC(14, 2),
+    expected_net_worth       NUMERIC(14, 2),
+    fiat_discrepancy         NUMERIC(14, 2),
+    total_discrepancy        NUMERIC(14, 2),
+    fiat_percent             NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    crypto_percent           NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    broker_percent           NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    UNIQUE (year, month)
+);
+
+-- ========== VIEWS ==========
+
+CREATE OR REPLACE VIEW monthly_income_summary AS
+SELECT
+    EXTRACT(YEAR FROM created_at)::INTEGER  AS year,
+    EXTRACT(MONTH FROM created_at)::INTEGER AS month,
+    SUM(amount)                             AS total_income
+FROM incomes
+GROUP BY 1, 2;
+
+-- Budgets with what has been spent in their category this month
+CREATE OR REPLACE VIEW budget_by_category_current_month AS
+SELECT
+    b.budget AS amount,
+    COALESCE((
+        SELECT SUM(e.expense) FROM expenses e
+        WHERE e.category_id = b.category_id
+          AND DATE_TRUNC('month', e.created_at) = DATE_TRUNC('month', NOW())
+    ), 0) AS spent,
+    COALESCE(c.name, '') AS category_name,
+    b.category_id
+FROM budgets b
+LEFT JOIN categories c ON c.id = b.category_id;
+
+CREATE OR REPLACE VIEW debt_by_debtor AS
+SELECT
+    d.debtor_id,
+    COALESCE(MAX(dr.name), MAX(d.debtor_name))                        AS debtor_name,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE 0 END)                AS total_lent,
+    SUM(CASE WHEN d.outbound THEN 0 ELSE d.amount END)                AS total_received,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE -d.amount END)        AS net_owed,
+    COUNT(*)::INTEGER                                                 AS transaction_count
+FROM debts d
+LEFT JOIN debtors dr ON dr.id = d.debtor_id
+GROUP BY d.debtor_id;
+
+-- PnL is the reconciled balance minus the capital put in
+CREATE OR REPLACE VIEW investment_account_summary AS
+SELECT
+    id,
+    name,
+    COALESCE(type, '')         AS type,
+    COALESCE(currency, 'USD')  AS currency,
+    balance                    AS real_balance,
+    COALESCE(capital, 0)       AS total_capital,
+    COALESCE(starting_capital, 0) AS starting_capital,
+    balance - COALESCE(capital, 0) AS pnl,
+    CASE WHEN COALESCE(capital, 0) > 0
+        THEN (balance - capital) / capital * 100
+        ELSE 0
+    END AS pnl_percent
+FROM investment_accounts;
+
+-- Starting balance plus every transaction that moved money in or out of a fiat
+-- account. Expenses charged to investment accounts are left out.
+CREATE OR REPLACE VIEW account_expected_balance AS
+WITH totals AS (
+    SELECT
+        a.id,
+        a.name,
+        COALESCE(a.currency, 'USD') AS currency,
+        COALESCE(a.starting_balance, 0) AS starting_balance,
+        COALESCE(a.starting_date, CURRENT_DATE)::TIMESTAMPTZ AS starting_date,
+        a.balance AS real_balance,
+        COALESCE((SELECT SUM(amount) FROM incomes WHERE account_id = a.id), 0) AS total_income,
+        COALESCE((SELECT SUM(expense) FROM expenses
+                  WHERE account_id = a.id AND account_type NOT IN ('Investment', 'Crypto', 'Broker')), 0) AS total_expenses,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'deposit'), 0) AS total_investment_deposits,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'withdrawal'), 0) AS total_investment_withdrawals,
+        COALESCE((SELECT SUM(source_amount) FROM transfers WHERE source_account_id = a.id), 0) AS total_transfers_out,
+        COALESCE((SELECT SUM(dest_amount) FROM transfers WHERE dest_account_id = a.id), 0) AS total_transfers_in
+    FROM accounts a
+), expected AS (
+    SELECT *,
+        starting_balance + total_income - total_expenses
+            - total_investment_deposits + total_investment_withdrawals
+            - total_transfers_out + total_transfers_in AS expected_balance
+    FROM totals
+)
+SELECT
+    id, name, currency, starting_balance, starting_date,
+    total_income, total_expenses, total_investment_deposits, total_investment_withdrawals,
+    total_transfers_out, total_transfers_in, expected_balance, real_balance,
+    real_balance - expected_balance AS discrepancy
+FROM expected;
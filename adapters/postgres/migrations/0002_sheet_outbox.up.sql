@@ -0,0 +1,14 @@
+-- Pending Google Sheets writes, drained in id order by googleSS.Outbox
+CREATE TABLE IF NOT EXISTS sheet_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    kind            TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sheet_outbox_status_id_idx ON sheet_outbox (status, id);
@@ -0,0 +1,10 @@
+-- API keys for the auth middleware. Only a SHA-256 hash of each key is kept;
+-- prefix is stored in clear to look keys up and tell them apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    prefix     TEXT NOT NULL UNIQUE,
+    hash       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,141 @@
+-- Back to a single owner: only the first household's data is kept
+
+DROP VIEW account_expected_balance;
+DROP VIEW investment_account_summary;
+DROP VIEW debt_by_debtor;
+DROP VIEW monthly_income_summary;
+
+DELETE FROM sheet_outbox WHERE household_id <> 1;
+DELETE FROM net_worth_snapshots WHERE household_id <> 1 OR owner_id <> 1;
+DELETE FROM transfers WHERE household_id <> 1;
+DELETE FROM debts WHERE household_id <> 1;
+DELETE FROM investments WHERE household_id <> 1;
+DELETE FROM incomes WHERE household_id <> 1;
+DELETE FROM expenses WHERE household_id <> 1;
+DELETE FROM investment_accounts WHERE household_id <> 1;
+DELETE FROM accounts WHERE household_id <> 1;
+DELETE FROM yearly_goals WHERE household_id <> 1;
+DELETE FROM debtors WHERE household_id <> 1;
+DELETE FROM budgets WHERE household_id <> 1;
+DELETE FROM categories WHERE household_id <> 1;
+DELETE FROM config WHERE household_id <> 1;
+DELETE FROM api_keys WHERE user_id IN (SELECT id FROM users WHERE household_id <> 1);
+
+ALTER TABLE sheet_outbox DROP COLUMN household_id;
+
+ALTER TABLE net_worth_snapshots DROP CONSTRAINT net_worth_snapshots_owner_year_month_key;
+ALTER TABLE net_worth_snapshots DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE net_worth_snapshots ADD CONSTRAINT net_worth_snapshots_year_month_key UNIQUE (year, month);
+
+ALTER TABLE transfers DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE debts DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE investments DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE incomes DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE expenses DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE investment_accounts DROP COLUMN owner_id, DROP COLUMN household_id;
+ALTER TABLE accounts DROP COLUMN owner_id, DROP COLUMN household_id;
+
+ALTER TABLE yearly_goals DROP CONSTRAINT yearly_goals_household_id_year_key;
+ALTER TABLE yearly_goals DROP COLUMN household_id;
+ALTER TABLE yearly_goals ADD CONSTRAINT yearly_goals_year_key UNIQUE (year);
+
+ALTER TABLE debtors DROP COLUMN household_id;
+
+ALTER TABLE budgets DROP CONSTRAINT budgets_household_id_category_id_key;
+ALTER TABLE budgets DROP COLUMN household_id;
+ALTER TABLE budgets ADD CONSTRAINT budgets_category_id_key UNIQUE (category_id);
+
+ALTER TABLE categories DROP COLUMN household_id;
+
+ALTER TABLE config DROP CONSTRAINT config_pkey;
+ALTER TABLE config DROP COLUMN household_id;
+ALTER TABLE config ADD PRIMARY KEY (type);
+
+ALTER TABLE api_keys DROP COLUMN user_id;
+
+DROP TABLE users;
+DROP TABLE households;
+
+-- ========== VIEWS (as in 0001) ==========
+
+CREATE VIEW monthly_income_summary AS
+SELECT
+    EXTRACT(YEAR FROM created_at)::INTEGER  AS year,
+    EXTRACT(MONTH FROM created_at)::INTEGER AS month,
+    SUM(amount)                             AS total_income
+FROM incomes
+GROUP BY 1, 2;
+
+CREATE VIEW budget_by_category_current_month AS
+SELECT
+    b.budget AS amount,
+    COALESCE((
+        SELECT SUM(e.expense) FROM expenses e
+        WHERE e.category_id = b.category_id
+          AND DATE_TRUNC('month', e.created_at) = DATE_TRUNC('month', NOW())
+    ), 0) AS spent,
+    COALESCE(c.name, '') AS category_name,
+    b.category_id
+FROM budgets b
+LEFT JOIN categories c ON c.id = b.category_id;
+
+CREATE VIEW debt_by_debtor AS
+SELECT
+    d.debtor_id,
+    COALESCE(MAX(dr.name), MAX(d.debtor_name))                        AS debtor_name,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE 0 END)                AS total_lent,
+    SUM(CASE WHEN d.outbound THEN 0 ELSE d.amount END)                AS total_received,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE -d.amount END)        AS net_owed,
+    COUNT(*)::INTEGER                                                 AS transaction_count
+FROM debts d
+LEFT JOIN debtors dr ON dr.id = d.debtor_id
+GROUP BY d.debtor_id;
+
+CREATE VIEW investment_account_summary AS
+SELECT
+    id,
+    name,
+    COALESCE(type, '')         AS type,
+    COALESCE(currency, 'USD')  AS currency,
+    balance                    AS real_balance,
+    COALESCE(capital, 0)       AS total_capital,
+    COALESCE(starting_capital, 0) AS starting_capital,
+    balance - COALESCE(capital, 0) AS pnl,
+    CASE WHEN COALESCE(capital, 0) > 0
+        THEN (balance - capital) / capital * 100
+        ELSE 0
+    END AS pnl_percent
+FROM investment_accounts;
+
+CREATE VIEW account_expected_balance AS
+WITH totals AS (
+    SELECT
+        a.id,
+        a.name,
+        COALESCE(a.currency, 'USD') AS currency,
+        COALESCE(a.starting_balance, 0) AS starting_balance,
+        COALESCE(a.starting_date, CURRENT_DATE)::TIMESTAMPTZ AS starting_date,
+        a.balance AS real_balance,
+        COALESCE((SELECT SUM(amount) FROM incomes WHERE account_id = a.id), 0) AS total_income,
+        COALESCE((SELECT SUM(expense) FROM expenses
+                  WHERE account_id = a.id AND account_type NOT IN ('Investment', 'Crypto', 'Broker')), 0) AS total_expenses,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'deposit'), 0) AS total_investment_deposits,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'withdrawal'), 0) AS total_investment_withdrawals,
+        COALESCE((SELECT SUM(source_amount) FROM transfers WHERE source_account_id = a.id), 0) AS total_transfers_out,
+        COALESCE((SELECT SUM(dest_amount) FROM transfers WHERE dest_account_id = a.id), 0) AS total_transfers_in
+    FROM accounts a
+), expected AS (
+    SELECT *,
+        starting_balance + total_income - total_expenses
+            - total_investment_deposits + total_investment_withdrawals
+            - total_transfers_out + total_transfers_in AS expected_balance
+    FROM totals
+)
+SELECT
+    id, name, currency, starting_balance, starting_date,
+    total_income, total_expenses, total_investment_deposits, total_investment_withdrawals,
+    total_transfers_out, total_transfers_in, expected_balance, real_balance,
+    real_balance - expected_balance AS discrepancy
+FROM expected;
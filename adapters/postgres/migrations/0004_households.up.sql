@@ -0,0 +1,188 @@
+-- Households and their users. Every row of data belongs to a household; accounts
+-- and the transactions recorded against them may also belong to one user
+-- (owner_id), in which case only that user sees them. owner_id NULL is shared
+-- with the whole household.
+
+CREATE TABLE households (
+    id             BIGSERIAL PRIMARY KEY,
+    name           TEXT NOT NULL,
+    -- empty falls back to the server's SPREADSHEET_ID
+    spreadsheet_id TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE users (
+    id           BIGSERIAL PRIMARY KEY,
+    household_id BIGINT NOT NULL REFERENCES households (id),
+    name         TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX users_household_id_idx ON users (household_id);
+
+-- Everything recorded so far belonged to the single owner
+INSERT INTO households (id, name) VALUES (1, 'Household');
+INSERT INTO users (id, household_id, name) VALUES (1, 1, 'Owner');
+SELECT setval(pg_get_serial_sequence('households', 'id'), 1);
+SELECT setval(pg_get_serial_sequence('users', 'id'), 1);
+
+ALTER TABLE api_keys ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users (id);
+ALTER TABLE api_keys ALTER COLUMN user_id DROP DEFAULT;
+
+-- ========== HOUSEHOLD DATA ==========
+
+ALTER TABLE config ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE config DROP CONSTRAINT IF EXISTS config_pkey;
+ALTER TABLE config ADD PRIMARY KEY (household_id, type);
+
+ALTER TABLE categories ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+
+ALTER TABLE budgets ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_category_id_key;
+ALTER TABLE budgets ADD CONSTRAINT budgets_household_id_category_id_key UNIQUE (household_id, category_id);
+
+ALTER TABLE debtors ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+
+ALTER TABLE yearly_goals ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE yearly_goals DROP CONSTRAINT IF EXISTS yearly_goals_year_key;
+ALTER TABLE yearly_goals ADD CONSTRAINT yearly_goals_household_id_year_key UNIQUE (household_id, year);
+
+-- ========== OWNED DATA ==========
+
+ALTER TABLE accounts ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE accounts ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE investment_accounts ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE investment_accounts ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE expenses ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE expenses ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE incomes ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE incomes ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE investments ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE investments ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE debts ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE debts ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+ALTER TABLE transfers ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE transfers ADD COLUMN owner_id BIGINT REFERENCES users (id);
+
+-- A snapshot adds up the accounts one user can see, so it always has an owner
+ALTER TABLE net_worth_snapshots ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE net_worth_snapshots ADD COLUMN owner_id BIGINT NOT NULL DEFAULT 1 REFERENCES users (id);
+ALTER TABLE net_worth_snapshots DROP CONSTRAINT IF EXISTS net_worth_snapshots_year_month_key;
+ALTER TABLE net_worth_snapshots ADD CONSTRAINT net_worth_snapshots_owner_year_month_key UNIQUE (household_id, owner_id, year, month);
+
+-- The defaults only backfilled existing rows; new rows must say where they belong
+ALTER TABLE config ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE categories ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE budgets ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE debtors ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE yearly_goals ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE accounts ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE investment_accounts ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE expenses ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE incomes ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE investments ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE debts ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE transfers ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE net_worth_snapshots ALTER COLUMN household_id DROP DEFAULT;
+ALTER TABLE net_worth_snapshots ALTER COLUMN owner_id DROP DEFAULT;
+
+CREATE INDEX expenses_household_id_idx ON expenses (household_id);
+CREATE INDEX incomes_household_id_idx ON incomes (household_id);
+CREATE INDEX investments_household_id_idx ON investments (household_id);
+CREATE INDEX debts_household_id_idx ON debts (household_id);
+CREATE INDEX transfers_household_id_idx ON transfers (household_id);
+
+-- Sheet writes go to the spreadsheet of the household they were queued for
+ALTER TABLE sheet_outbox ADD COLUMN household_id BIGINT NOT NULL DEFAULT 1 REFERENCES households (id);
+ALTER TABLE sheet_outbox ALTER COLUMN household_id DROP DEFAULT;
+
+-- ========== VIEWS ==========
+-- The views keep their columns and add household_id and owner_id, so queries
+-- can keep to the rows the caller may see. Spent-per-budget depends on who is
+-- asking, so the store computes it itself.
+
+DROP VIEW budget_by_category_current_month;
+
+CREATE OR REPLACE VIEW monthly_income_summary AS
+SELECT
+    EXTRACT(YEAR FROM created_at)::INTEGER  AS year,
+    EXTRACT(MONTH FROM created_at)::INTEGER AS month,
+    SUM(amount)                             AS total_income,
+    household_id,
+    owner_id
+FROM incomes
+GROUP BY 1, 2, household_id, owner_id;
+
+CREATE OR REPLACE VIEW debt_by_debtor AS
+SELECT
+    d.debtor_id,
+    COALESCE(MAX(dr.name), MAX(d.debtor_name))                        AS debtor_name,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE 0 END)                AS total_lent,
+    SUM(CASE WHEN d.outbound THEN 0 ELSE d.amount END)                AS total_received,
+    SUM(CASE WHEN d.outbound THEN d.amount ELSE -d.amount END)        AS net_owed,
+    COUNT(*)::INTEGER                                                 AS transaction_count,
+    d.household_id,
+    d.owner_id
+FROM debts d
+LEFT JOIN debtors dr ON dr.id = d.debtor_id AND dr.household_id = d.household_id
+GROUP BY d.debtor_id, d.household_id, d.owner_id;
+
+CREATE OR REPLACE VIEW investment_account_summary AS
+SELECT
+    id,
+    name,
+    COALESCE(type, '')         AS type,
+    COALESCE(currency, 'USD')  AS currency,
+    balance                    AS real_balance,
+    COALESCE(capital, 0)       AS total_capital,
+    COALESCE(starting_capital, 0) AS starting_capital,
+    balance - COALESCE(capital, 0) AS pnl,
+    CASE WHEN COALESCE(capital, 0) > 0
+        THEN (balance - capital) / capital * 100
+        ELSE 0
+    END AS pnl_percent,
+    household_id,
+    owner_id
+FROM investment_accounts;
+
+CREATE OR REPLACE VIEW account_expected_balance AS
+WITH totals AS (
+    SELECT
+        a.id,
+        a.name,
+        COALESCE(a.currency, 'USD') AS currency,
+        COALESCE(a.starting_balance, 0) AS starting_balance,
+        COALESCE(a.starting_date, CURRENT_DATE)::TIMESTAMPTZ AS starting_date,
+        a.balance AS real_balance,
+        COALESCE((SELECT SUM(amount) FROM incomes WHERE account_id = a.id), 0) AS total_income,
+        COALESCE((SELECT SUM(expense) FROM expenses
+                  WHERE account_id = a.id AND account_type NOT IN ('Investment', 'Crypto', 'Broker')), 0) AS total_expenses,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'deposit'), 0) AS total_investment_deposits,
+        COALESCE((SELECT SUM(amount) FROM investments
+                  WHERE source_account_id = a.id AND type = 'withdrawal'), 0) AS total_investment_withdrawals,
+        COALESCE((SELECT SUM(source_amount) FROM transfers WHERE source_account_id = a.id), 0) AS total_transfers_out,
+        COALESCE((SELECT SUM(dest_amount) FROM transfers WHERE dest_account_id = a.id), 0) AS total_transfers_in,
+        a.household_id,
+        a.owner_id
+    FROM accounts a
+), expected AS (
+    SELECT *,
+        starting_balance + total_income - total_expenses
+            - total_investment_deposits + total_investment_withdrawals
+            - total_transfers_out + total_transfers_in AS expected_balance
+    FROM totals
+)
+SELECT
+    id, name, currency, starting_balance, starting_date,
+    total_income, total_expenses, total_investment_deposits, total_investment_withdrawals,
+    total_transfers_out, total_transfers_in, expected_balance, real_balance,
+    real_balance - expected_balance AS discrepancy,
+    household_id, owner_id
+FROM expected;
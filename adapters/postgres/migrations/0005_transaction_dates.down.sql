@@ -0,0 +1,16 @@
+-- Totals go back to when transactions were entered. Dates brought into the
+-- stored form stay in it.
+
+CREATE OR REPLACE VIEW monthly_income_summary AS
+SELECT
+    EXTRACT(YEAR FROM created_at)::INTEGER  AS year,
+    EXTRACT(MONTH FROM created_at)::INTEGER AS month,
+    SUM(amount)                             AS total_income,
+    household_id,
+    owner_id
+FROM incomes
+GROUP BY 1, 2, household_id, owner_id;
+
+DROP INDEX investments_household_id_date_idx;
+DROP INDEX incomes_household_id_date_idx;
+DROP INDEX expenses_household_id_date_idx;
@@ -0,0 +1,53 @@
+-- Monthly and yearly totals go by the date of a transaction rather than when it
+-- was entered. Dates are text in 'YYYY-MM-DD HH24:MI:SS' form, so a month is a
+-- range of strings; rows written in another form are brought into it first,
+-- falling back to when they were entered.
+
+UPDATE expenses SET date = CASE
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' THEN REPLACE(LEFT(date, 19), 'T', ' ')
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}$' THEN date || ' 00:00:00'
+    ELSE TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS')
+END
+WHERE date !~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$';
+
+UPDATE incomes SET date = CASE
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' THEN REPLACE(LEFT(date, 19), 'T', ' ')
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}$' THEN date || ' 00:00:00'
+    ELSE TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS')
+END
+WHERE date !~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$';
+
+UPDATE investments SET date = CASE
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' THEN REPLACE(LEFT(date, 19), 'T', ' ')
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}$' THEN date || ' 00:00:00'
+    ELSE TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS')
+END
+WHERE date !~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$';
+
+UPDATE debts SET date = CASE
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' THEN REPLACE(LEFT(date, 19), 'T', ' ')
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}$' THEN date || ' 00:00:00'
+    ELSE TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS')
+END
+WHERE date !~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$';
+
+UPDATE transfers SET date = CASE
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' THEN REPLACE(LEFT(date, 19), 'T', ' ')
+    WHEN date ~ '^\d{4}-\d{2}-\d{2}$' THEN date || ' 00:00:00'
+    ELSE TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS')
+END
+WHERE date !~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$';
+
+CREATE INDEX expenses_household_id_date_idx ON expenses (household_id, date);
+CREATE INDEX incomes_household_id_date_idx ON incomes (household_id, date);
+CREATE INDEX investments_household_id_date_idx ON investments (household_id, date);
+
+CREATE OR REPLACE VIEW monthly_income_summary AS
+SELECT
+    SUBSTRING(date FROM 1 FOR 4)::INTEGER AS year,
+    SUBSTRING(date FROM 6 FOR 2)::INTEGER AS month,
+    SUM(amount)                           AS total_income,
+    household_id,
+    owner_id
+FROM incomes
+GROUP BY 1, 2, household_id, owner_id;
@@ -0,0 +1,6 @@
+-- The timezone a household counts its months and years in, as an IANA name.
+-- Existing transaction dates are left as they are. They were stamped with the
+-- server's local time when they were submitted, and the migration cannot know
+-- which zone that was, so they stay in the server's zone; only transactions
+-- recorded from here on are dated in the household's.
+ALTER TABLE households ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,5 @@
+ALTER TABLE households
+    DROP CONSTRAINT IF EXISTS households_budget_period_check,
+    DROP COLUMN IF EXISTS budget_period_anchor,
+    DROP COLUMN IF EXISTS budget_period_start_day,
+    DROP COLUMN IF EXISTS budget_period;
@@ -0,0 +1,9 @@
+-- The periods a household's budgets are counted in: calendar months, months
+-- starting on a fixed day (budget_period_start_day) or two-week periods
+-- starting on budget_period_anchor, a 'YYYY-MM-DD' date.
+ALTER TABLE households
+    ADD COLUMN budget_period           TEXT    NOT NULL DEFAULT 'calendar_month',
+    ADD COLUMN budget_period_start_day INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN budget_period_anchor    TEXT    NOT NULL DEFAULT '',
+    ADD CONSTRAINT households_budget_period_check
+        CHECK (budget_period IN ('calendar_month', 'monthly', 'biweekly'));
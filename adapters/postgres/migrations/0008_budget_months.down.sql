@@ -0,0 +1,7 @@
+-- Keep the latest budget of each category
+DELETE FROM budgets b USING budgets newer
+WHERE newer.household_id = b.household_id AND newer.category_id = b.category_id AND newer.month > b.month;
+
+ALTER TABLE budgets DROP CONSTRAINT budgets_household_id_category_id_month_key;
+ALTER TABLE budgets ADD CONSTRAINT budgets_household_id_category_id_key UNIQUE (household_id, category_id);
+ALTER TABLE budgets DROP COLUMN month;
@@ -0,0 +1,8 @@
+-- Budgets are versioned by month: a row is the amount budgeted for a category
+-- from its month ('YYYY-MM') on, until a later month's row replaces it.
+-- Existing budgets have always been in force.
+ALTER TABLE budgets ADD COLUMN month TEXT NOT NULL DEFAULT '0001-01';
+ALTER TABLE budgets ALTER COLUMN month DROP DEFAULT;
+
+ALTER TABLE budgets DROP CONSTRAINT budgets_household_id_category_id_key;
+ALTER TABLE budgets ADD CONSTRAINT budgets_household_id_category_id_month_key UNIQUE (household_id, category_id, month);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS budget_moves;
+
+ALTER TABLE households
+    DROP CONSTRAINT IF EXISTS households_budget_mode_check,
+    DROP COLUMN IF EXISTS envelope_since,
+    DROP COLUMN IF EXISTS budget_mode;
@@ -0,0 +1,20 @@
+-- Envelope budgeting: in envelope mode what is left of a category's budget (or
+-- overspent) rolls into its next period, from envelope_since ('YYYY-MM') on,
+-- and money can be moved from one envelope to another.
+ALTER TABLE households
+    ADD COLUMN budget_mode    TEXT NOT NULL DEFAULT 'standard',
+    ADD COLUMN envelope_since TEXT NOT NULL DEFAULT '',
+    ADD CONSTRAINT households_budget_mode_check CHECK (budget_mode IN ('standard', 'envelope'));
+
+CREATE TABLE budget_moves (
+    id               SERIAL PRIMARY KEY,
+    household_id     BIGINT NOT NULL REFERENCES households (id),
+    date             TEXT NOT NULL,
+    from_category_id INTEGER NOT NULL REFERENCES categories (id),
+    to_category_id   INTEGER NOT NULL REFERENCES categories (id),
+    amount           NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    note             TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX budget_moves_household_id_date_idx ON budget_moves (household_id, date);
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS account_currency(TEXT, INTEGER);
+DROP FUNCTION IF EXISTS to_base(BIGINT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS fx_rate(BIGINT, TEXT, TEXT, TEXT);
+DROP TABLE IF EXISTS fx_rates;
+
+ALTER TABLE households
+    DROP COLUMN IF EXISTS base_currency;
@@ -0,0 +1,53 @@
+-- Exchange rates and the base currency every total is reported in. A rate is
+-- what one unit of currency was worth in quote on date ('YYYY-MM-DD'); an amount
+-- is converted at the latest rate dated on or before its transaction date, or
+-- the earliest rate when there is none before, in either direction.
+ALTER TABLE households
+    ADD COLUMN base_currency TEXT NOT NULL DEFAULT 'USD';
+
+CREATE TABLE fx_rates (
+    id           SERIAL PRIMARY KEY,
+    household_id BIGINT NOT NULL REFERENCES households (id),
+    date         TEXT NOT NULL,
+    currency     TEXT NOT NULL,
+    quote        TEXT NOT NULL,
+    rate         NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    source       TEXT NOT NULL DEFAULT 'manual',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (household_id, currency, quote, date)
+);
+
+-- fx_rate is what one unit of from_currency was worth in to_currency on on_date
+-- (a transaction date); 1 for the same currency or when no rate is known
+CREATE FUNCTION fx_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT CASE WHEN COALESCE(from_currency, '') = '' OR from_currency = to_currency THEN 1 ELSE COALESCE((
+        SELECT r.rate FROM (
+            SELECT date, rate FROM fx_rates
+            WHERE household_id = household AND currency = from_currency AND quote = to_currency
+            UNION ALL
+            SELECT date, 1 / rate FROM fx_rates
+            WHERE household_id = household AND currency = to_currency AND quote = from_currency
+        ) r
+        ORDER BY r.date <= LEFT(on_date, 10) DESC,
+                 CASE WHEN r.date <= LEFT(on_date, 10) THEN r.date END DESC,
+                 r.date
+        LIMIT 1
+    ), 1) END
+$$;
+
+-- to_base is fx_rate into the household's base currency
+CREATE FUNCTION to_base(household BIGINT, from_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT fx_rate(household, from_currency, (SELECT base_currency FROM households WHERE id = household), on_date)
+$$;
+
+-- account_currency is the currency of the account a transaction was recorded
+-- against; account_type tells investment accounts apart as it does for expenses
+CREATE FUNCTION account_currency(account_type TEXT, account_id INTEGER)
+RETURNS TEXT LANGUAGE SQL STABLE AS $$
+    SELECT CASE WHEN account_type IN ('Investment', 'Crypto', 'Broker')
+        THEN (SELECT COALESCE(currency, 'USD') FROM investment_accounts WHERE id = account_id)
+        ELSE (SELECT COALESCE(currency, 'USD') FROM accounts WHERE id = account_id)
+    END
+$$;
@@ -0,0 +1,22 @@
+CREATE OR REPLACE FUNCTION fx_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT CASE WHEN COALESCE(from_currency, '') = '' OR from_currency = to_currency THEN 1 ELSE COALESCE((
+        SELECT r.rate FROM (
+            SELECT date, rate FROM fx_rates
+            WHERE household_id = household AND currency = from_currency AND quote = to_currency
+            UNION ALL
+            SELECT date, 1 / rate FROM fx_rates
+            WHERE household_id = household AND currency = to_currency AND quote = from_currency
+        ) r
+        ORDER BY r.date <= LEFT(on_date, 10) DESC,
+                 CASE WHEN r.date <= LEFT(on_date, 10) THEN r.date END DESC,
+                 r.date
+        LIMIT 1
+    ), 1) END
+$$;
+
+DROP FUNCTION IF EXISTS fx_reference_rate(BIGINT, TEXT, TEXT, TEXT);
+
+ALTER TABLE transfers
+    DROP COLUMN IF EXISTS fx_cost,
+    DROP COLUMN IF EXISTS reference_rate;
@@ -0,0 +1,37 @@
+-- What each transfer between two currencies cost against the reference rate
+-- (the fx_rates rate in effect on its date): reference_rate is NULL when the
+-- currencies are the same or no rate is known, fx_cost is in the destination
+-- account's currency and below zero for a gain.
+ALTER TABLE transfers
+    ADD COLUMN reference_rate NUMERIC(24, 12),
+    ADD COLUMN fx_cost        NUMERIC(14, 2) NOT NULL DEFAULT 0;
+
+-- fx_reference_rate is fx_rate without its fallback: NULL for the same currency
+-- or when no rate between the two is known
+CREATE FUNCTION fx_reference_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT CASE WHEN COALESCE(from_currency, '') = '' OR from_currency = to_currency THEN NULL ELSE (
+        SELECT r.rate FROM (
+            SELECT date, rate FROM fx_rates
+            WHERE household_id = household AND currency = from_currency AND quote = to_currency
+            UNION ALL
+            SELECT date, 1 / rate FROM fx_rates
+            WHERE household_id = household AND currency = to_currency AND quote = from_currency
+        ) r
+        ORDER BY r.date <= LEFT(on_date, 10) DESC,
+                 CASE WHEN r.date <= LEFT(on_date, 10) THEN r.date END DESC,
+                 r.date
+        LIMIT 1
+    ) END
+$$;
+
+CREATE OR REPLACE FUNCTION fx_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT COALESCE(fx_reference_rate(household, from_currency, to_currency, on_date), 1)
+$$;
+
+-- Transfers already recorded are costed against the rates known now
+UPDATE transfers SET reference_rate = fx_reference_rate(household_id,
+    account_currency('', source_account_id), account_currency('', dest_account_id), date);
+UPDATE transfers SET fx_cost = ROUND(source_amount * reference_rate - dest_amount, 2)
+WHERE reference_rate IS NOT NULL;
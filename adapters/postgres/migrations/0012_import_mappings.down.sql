@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS incomes_account_id_date_idx;
+DROP INDEX IF EXISTS expenses_account_id_date_idx;
+DROP TABLE IF EXISTS import_mappings;
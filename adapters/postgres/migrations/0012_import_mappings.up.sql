@@ -0,0 +1,23 @@
+-- Saved CSV column mappings for statement imports, one per account. Columns are
+-- named by their header; a statement has either one signed amount column or
+-- separate debit and credit columns.
+CREATE TABLE import_mappings (
+    id                 SERIAL PRIMARY KEY,
+    household_id       BIGINT NOT NULL REFERENCES households (id),
+    account_id         INTEGER NOT NULL REFERENCES accounts (id),
+    date_column        TEXT NOT NULL,
+    date_format        TEXT NOT NULL DEFAULT '',
+    amount_column      TEXT NOT NULL DEFAULT '',
+    debit_column       TEXT NOT NULL DEFAULT '',
+    credit_column      TEXT NOT NULL DEFAULT '',
+    description_column TEXT NOT NULL,
+    negate             BOOLEAN NOT NULL DEFAULT FALSE,
+    delimiter          TEXT NOT NULL DEFAULT '',
+    decimal_comma      BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (household_id, account_id)
+);
+
+-- Imports look for duplicates among an account's transactions by date
+CREATE INDEX expenses_account_id_date_idx ON expenses (account_id, date);
+CREATE INDEX incomes_account_id_date_idx ON incomes (account_id, date);
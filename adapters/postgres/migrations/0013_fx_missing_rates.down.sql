@@ -0,0 +1,5 @@
+-- fx_rate falls back to 1 again when no rate is known
+CREATE OR REPLACE FUNCTION fx_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE SQL STABLE AS $$
+    SELECT COALESCE(fx_reference_rate(household, from_currency, to_currency, on_date), 1)
+$$;
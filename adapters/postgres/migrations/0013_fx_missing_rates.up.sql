@@ -0,0 +1,19 @@
+-- A total that needs a rate nobody stored fails instead of counting the amount
+-- as if it were already in the base currency. The error's SQLSTATE is FX001 and
+-- its detail the two currencies, 'COP USD', for the store to name them.
+CREATE OR REPLACE FUNCTION fx_rate(household BIGINT, from_currency TEXT, to_currency TEXT, on_date TEXT)
+RETURNS NUMERIC LANGUAGE plpgsql STABLE AS $$
+DECLARE
+    rate NUMERIC;
+BEGIN
+    IF COALESCE(from_currency, '') = '' OR from_currency = to_currency THEN
+        RETURN 1;
+    END IF;
+    rate := fx_reference_rate(household, from_currency, to_currency, on_date);
+    IF rate IS NULL THEN
+        RAISE EXCEPTION 'no exchange rate from % to %', from_currency, to_currency
+            USING ERRCODE = 'FX001', DETAIL = from_currency || ' ' || to_currency;
+    END IF;
+    RETURN rate;
+END
+$$;
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== SHEET OUTBOX ==========
+
+// The Store persists pending Google Sheets writes in sheet_outbox
+// (googleSS.OutboxStore). The table is created by migration 0002. Jobs are
+// queued and listed per household; the worker claims them across households.
+
+const sheetJobColumns = `id, created_at, kind, payload, status, attempts, COALESCE(last_error, ''), next_attempt_at,
+	household_id, (SELECT spreadsheet_id FROM households WHERE households.id = sheet_outbox.household_id)`
+
+func scanSheetJob(row pgx.Row) (types.SheetJob, error) {
+	var job types.SheetJob
+	err := row.Scan(&job.Id, &job.CreatedAt, &job.Kind, &job.Payload, &job.Status,
+		&job.Attempts, &job.LastError, &job.NextAttemptAt, &job.HouseholdId, &job.SpreadsheetId)
+	return job, err
+}
+
+// EnqueueSheetJob stores a new pending job for the caller's household
+func (s *Store) EnqueueSheetJob(ctx context.Context, kind string, payload []byte) (types.SheetJob, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.SheetJob{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	job, err := scanSheetJob(s.pool.QueryRow(ctx,
+		`INSERT INTO sheet_outbox (kind, payload, household_id) VALUES ($1, $2, $3)
+		 RETURNING `+sheetJobColumns,
+		kind, payload, scope.HouseholdId,
+	))
+	if err != nil {
+		return types.SheetJob{}, fmt.Errorf("error enqueuing sheet job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimSheetJob leases the oldest due job among the households' head jobs.
+// Within a household jobs are handed out strictly in id order: while its oldest
+// job is backing off nothing newer of that household runs, so a retried cell
+// write can't overwrite a later one. A failed job blocks them the same way
+// until it is requeued, and runs again ahead of them, or discarded.
+// Households don't wait on each other, so a spreadsheet that keeps failing
+// only stalls its own household's writes.
+// A job whose lease expired (the process died mid-write) is handed out again.
+// A head another worker is claiming is skipped; the recheck in the UPDATE
+// keeps two workers from leasing the same job.
+// Returns nil when there is nothing to do.
+func (s *Store) ClaimSheetJob(ctx context.Context, lease time.Duration) (*types.SheetJob, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	job, err := scanSheetJob(s.pool.QueryRow(ctx,
+		`WITH heads AS (
+			SELECT DISTINCT ON (household_id) id FROM sheet_outbox
+			WHERE status IN ('pending', 'processing', 'failed')
+			ORDER BY household_id, id
+		), due AS (
+			SELECT o.id FROM sheet_outbox o
+			JOIN heads ON heads.id = o.id
+			WHERE o.next_attempt_at <= NOW()
+				AND (o.status = 'pending' OR o.locked_until < NOW())
+			ORDER BY o.id LIMIT 1
+			FOR UPDATE OF o SKIP LOCKED
+		)
+		UPDATE sheet_outbox o SET status = 'processing', locked_until = NOW() + make_interval(secs => $1)
+		FROM due
+		WHERE o.id = due.id
+			AND o.next_attempt_at <= NOW()
+			AND (o.status = 'pending' OR o.locked_until < NOW())
+		RETURNING o.id, o.created_at, o.kind, o.payload, o.status, o.attempts, COALESCE(o.last_error, ''), o.next_attempt_at,
+			o.household_id, (SELECT spreadsheet_id FROM households h WHERE h.id = o.household_id)`,
+		lease.Seconds(),
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error claiming sheet job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// CompleteSheetJob removes a job that was written to the sheet
+func (s *Store) CompleteSheetJob(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx, `DELETE FROM sheet_outbox WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error completing sheet job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// RetrySheetJob puts a job back in the queue to run again at nextAttempt
+func (s *Store) RetrySheetJob(ctx context.Context, id int64, attempts int, nextAttempt time.Time, lastError string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx,
+		`UPDATE sheet_outbox SET status = 'pending', attempts = $2, next_attempt_at = $3, last_error = $4, locked_until = NULL
+		 WHERE id = $1`,
+		id, attempts, nextAttempt, lastError,
+	)
+	if err != nil {
+		return fmt.Errorf("error rescheduling sheet job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// FailSheetJob parks a job as failed; it stays visible until it is requeued
+func (s *Store) FailSheetJob(ctx context.Context, id int64, attempts int, lastError string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx,
+		`UPDATE sheet_outbox SET status = 'failed', attempts = $2, last_error = $3, locked_until = NULL
+		 WHERE id = $1`,
+		id, attempts, lastError,
+	)
+	if err != nil {
+		return fmt.Errorf("error failing sheet job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// RequeueSheetJob resets one of the household's failed jobs so the worker picks
+// it up again. It keeps its id, so it still runs before the jobs queued after it.
+func (s *Store) RequeueSheetJob(ctx context.Context, id int64) (types.SheetJob, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.SheetJob{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	job, err := scanSheetJob(s.pool.QueryRow(ctx,
+		`UPDATE sheet_outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		 WHERE id = $1 AND status = 'failed' AND household_id = $2
+		 RETURNING `+sheetJobColumns,
+		id, scope.HouseholdId,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.SheetJob{}, fmt.Errorf("failed sheet job %d: %w", id, ErrNotFound)
+		}
+		return types.SheetJob{}, fmt.Errorf("error requeuing sheet job %d: %w", id, err)
+	}
+
+	return job, nil
+}
+
+// DiscardSheetJob deletes one of the household's failed jobs, letting the jobs
+// queued after it run
+func (s *Store) DiscardSheetJob(ctx context.Context, id int64) (types.SheetJob, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.SheetJob{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	job, err := scanSheetJob(s.pool.QueryRow(ctx,
+		`DELETE FROM sheet_outbox
+		 WHERE id = $1 AND status = 'failed' AND household_id = $2
+		 RETURNING `+sheetJobColumns,
+		id, scope.HouseholdId,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.SheetJob{}, fmt.Errorf("failed sheet job %d: %w", id, ErrNotFound)
+		}
+		return types.SheetJob{}, fmt.Errorf("error discarding sheet job %d: %w", id, err)
+	}
+
+	return job, nil
+}
+
+// ListSheetJobs returns the household's unfinished jobs with the given statuses, oldest first
+func (s *Store) ListSheetJobs(ctx context.Context, statuses []string, limit int) ([]types.SheetJob, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+sheetJobColumns+` FROM sheet_outbox
+		 WHERE status = ANY($1) AND household_id = $3 ORDER BY id LIMIT $2`,
+		statuses, limit, scope.HouseholdId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sheet jobs: %w", err)
+	}
+	defer rows.Close()
+
+	results := []types.SheetJob{}
+	for rows.Next() {
+		job, err := scanSheetJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, job)
+	}
+
+	return results, nil
+}
@@ -3,8 +3,6 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"os"
-	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -13,62 +11,80 @@ import (
 	types "github.com/carlosdimatteo/fintrack-backend-go/types"
 )
 
-var (
-	pool     *pgxpool.Pool
-	poolOnce sync.Once
-)
+// ErrNotFound is wrapped by lookups, updates and deletes that match no row
+var ErrNotFound = types.ErrNotFound
 
-// GetPool returns a connection pool (singleton)
-func GetPool() (*pgxpool.Pool, error) {
-	var initErr error
-	poolOnce.Do(func() {
-		databaseUrl := os.Getenv("DATABASE_URL")
-		if databaseUrl == "" {
-			initErr = fmt.Errorf("DATABASE_URL environment variable not set")
-			return
-		}
+// DefaultQueryTimeout bounds every Store call unless SetQueryTimeout changes it
+const DefaultQueryTimeout = 10 * time.Second
 
-		var err error
-		pool, err = pgxpool.New(context.Background(), databaseUrl)
-		if err != nil {
-			initErr = fmt.Errorf("unable to create connection pool: %w", err)
-			return
-		}
+// Store runs every query against its own connection pool.
+// It implements api.Store and googleSS.OutboxStore.
+type Store struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
 
-		// Test the connection
-		if err := pool.Ping(context.Background()); err != nil {
-			initErr = fmt.Errorf("unable to ping database: %w", err)
-			return
-		}
+// New connects to databaseUrl and checks the connection
+func New(ctx context.Context, databaseUrl string) (*Store, error) {
+	if databaseUrl == "" {
+		return nil, fmt.Errorf("DATABASE_URL environment variable not set")
+	}
 
-		fmt.Println("[postgres] Connection pool established")
-	})
+	pool, err := pgxpool.New(ctx, databaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
 
-	if initErr != nil {
-		return nil, initErr
+	// Test the connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to ping database: %w", err)
 	}
-	return pool, nil
+
+	fmt.Println("[postgres] Connection pool established")
+	return &Store{pool: pool, queryTimeout: DefaultQueryTimeout}, nil
 }
 
-// ClosePool closes the connection pool (call on shutdown)
-func ClosePool() {
-	if pool != nil {
-		pool.Close()
-		fmt.Println("[postgres] Connection pool closed")
+// NewWithPool wraps an existing pool; the caller keeps ownership of it
+func NewWithPool(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool, queryTimeout: DefaultQueryTimeout}
+}
+
+// SetQueryTimeout changes how long a single Store call may run before its
+// queries are cancelled; zero or less leaves only the caller's ctx. Call it
+// before the Store is shared.
+func (s *Store) SetQueryTimeout(timeout time.Duration) {
+	s.queryTimeout = timeout
+}
+
+// withTimeout derives the context a Store call runs its queries under, so a
+// cancelled request or a hung database aborts in-flight pgx work
+func (s *Store) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// Close closes the connection pool (call on shutdown)
+func (s *Store) Close() {
+	s.pool.Close()
+	fmt.Println("[postgres] Connection pool closed")
 }
 
 // GetConfigByType retrieves a config row by type
-func GetConfigByType(configType string) (types.Config, error) {
-	pool, err := GetPool()
+func (s *Store) GetConfigByType(ctx context.Context, configType string) (types.Config, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Config{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var config types.Config
-	err = pool.QueryRow(context.Background(),
-		`SELECT type, sheet, range FROM config WHERE type = $1`,
-		configType,
+	err = s.pool.QueryRow(ctx,
+		`SELECT type, sheet, range FROM config WHERE type = $1 AND household_id = $2`,
+		configType, scope.HouseholdId,
 	).Scan(&config.Type, &config.Sheet, &config.A1Range)
 
 	if err != nil {
@@ -82,23 +98,31 @@ func GetConfigByType(configType string) (types.Config, error) {
 }
 
 // InsertIncome inserts an income record
-func InsertIncome(income types.Income) (types.Income, error) {
+func (s *Store) InsertIncome(ctx context.Context, income types.Income) (types.Income, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Validate amount
-	if income.Amount <= 0 {
-		return types.Income{}, fmt.Errorf("income amount must be positive, got: %.2f", income.Amount)
+	if income.Amount.Sign() <= 0 {
+		return types.Income{}, types.Invalid("income amount must be positive, got: %s", income.Amount)
 	}
 
-	pool, err := GetPool()
+	owner, err := transactionOwner(ctx, s.pool, scope, fiatAccount(income.AccountId))
 	if err != nil {
 		return types.Income{}, err
 	}
 
 	var result types.Income
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO incomes (date, amount, description, account_id, account_name)
-		 VALUES ($1, $2, $3, $4, $5)
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO incomes (date, amount, description, account_id, account_name, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
 		 RETURNING id, date, amount, description, account_id, account_name, created_at`,
 		income.Date, income.Amount, income.Description, income.AccountId, income.AccountName,
+		scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Date, &result.Amount, &result.Description,
 		&result.AccountId, &result.AccountName, &result.CreatedAt)
 
@@ -109,80 +133,55 @@ func InsertIncome(income types.Income) (types.Income, error) {
 	return result, nil
 }
 
-// GetMonthlyIncomeSum returns the total income for a given year and month
-func GetMonthlyIncomeSum(year int, month int) (float64, error) {
-	pool, err := GetPool()
+// GetIncomeSum returns the total income dated in period, in the base currency
+func (s *Store) GetIncomeSum(ctx context.Context, period types.Period) (types.Money, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
-		return 0, err
+		return types.Money{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	var total float64
-	err = pool.QueryRow(context.Background(),
-		`SELECT COALESCE(total_income, 0) FROM monthly_income_summary 
-		 WHERE year = $1 AND month = $2`,
-		year, month,
+	var total types.Money
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(amount * to_base(household_id, account_currency('', account_id), date)), 2), 0) FROM incomes
+		 WHERE date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		period.Start, period.End, scope.HouseholdId, viewer(scope),
 	).Scan(&total)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return 0, nil // No income for this month yet
-		}
-		return 0, fmt.Errorf("error querying monthly income: %w", err)
+		return types.Money{}, fmt.Errorf("error querying income sum: %w", converted(err))
 	}
 
-	return total, nil
-}
-
-// GetYearlyIncomeSummary returns income totals for all months in a year
-func GetYearlyIncomeSummary(year int) ([]types.MonthlyIncomeSummary, error) {
-	pool, err := GetPool()
-	if err != nil {
-		return nil, err
-	}
-
-	rows, err := pool.Query(context.Background(),
-		`SELECT year, month, total_income FROM monthly_income_summary 
-		 WHERE year = $1 ORDER BY month`,
-		year,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("error querying yearly income: %w", err)
-	}
-	defer rows.Close()
-
-	var results []types.MonthlyIncomeSummary
-	for rows.Next() {
-		var summary types.MonthlyIncomeSummary
-		if err := rows.Scan(&summary.Year, &summary.Month, &summary.TotalIncome); err != nil {
-			return nil, fmt.Errorf("error scanning row: %w", err)
-		}
-		results = append(results, summary)
-	}
-
-	return results, nil
+	return total.In(scope.BaseCurrency), nil
 }
 
 // GetIncomes retrieves incomes with pagination
-func GetIncomes(limit int, offset int) ([]types.Income, int, error) {
-	pool, err := GetPool()
+func (s *Store) GetIncomes(ctx context.Context, limit int, offset int) ([]types.Income, int, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Get total count
 	var count int
-	err = pool.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM incomes`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM incomes WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	).Scan(&count)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting incomes: %w", err)
 	}
 
 	// Get paginated results
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, date, amount, description, account_id, account_name, created_at 
-		 FROM incomes ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
-		limit, offset,
+		 FROM incomes WHERE household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2`,
+		limit, offset, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying incomes: %w", err)
@@ -202,15 +201,146 @@ func GetIncomes(limit int, offset int) ([]types.Income, int, error) {
 	return results, count, nil
 }
 
+// GetIncomeById retrieves a single income
+func (s *Store) GetIncomeById(ctx context.Context, id int32) (types.Income, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var income types.Income
+	err = s.pool.QueryRow(ctx,
+		`SELECT id, date, amount, description, account_id, account_name, created_at
+		 FROM incomes WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&income.Id, &income.Date, &income.Amount, &income.Description,
+		&income.AccountId, &income.AccountName, &income.CreatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Income{}, fmt.Errorf("income %d: %w", id, ErrNotFound)
+		}
+		return types.Income{}, fmt.Errorf("error querying income: %w", err)
+	}
+
+	return income, nil
+}
+
+// UpdateIncome overwrites an income; a linked repayment debt follows the new amount
+func (s *Store) UpdateIncome(ctx context.Context, income types.Income) (types.Income, error) {
+	// Validate amount
+	if income.Amount.Sign() <= 0 {
+		return types.Income{}, types.Invalid("income amount must be positive, got: %s", income.Amount)
+	}
+
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.Income{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	owner, err := transactionOwner(ctx, tx, scope, fiatAccount(income.AccountId))
+	if err != nil {
+		return types.Income{}, err
+	}
+
+	var result types.Income
+	err = tx.QueryRow(ctx,
+		`UPDATE incomes SET date = $2, amount = $3, description = $4, account_id = $5, account_name = $6, owner_id = $7
+		 WHERE id = $1 AND household_id = $8 AND (owner_id IS NULL OR owner_id = $9 OR $9 IS NULL)
+		 RETURNING id, date, amount, description, account_id, account_name, created_at`,
+		income.Id, income.Date, income.Amount, income.Description, income.AccountId, income.AccountName,
+		owner, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.Date, &result.Amount, &result.Description,
+		&result.AccountId, &result.AccountName, &result.CreatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Income{}, fmt.Errorf("income %d: %w", income.Id, ErrNotFound)
+		}
+		return types.Income{}, fmt.Errorf("error updating income: %w", err)
+	}
+
+	// Repayments (see RecordDebtRepayment) record the same amount on the debt
+	_, err = tx.Exec(ctx,
+		`UPDATE debts SET amount = $2, original_amount = $2, account_id = $3, owner_id = $4
+		 WHERE income_id = $1 AND household_id = $5`,
+		result.Id, result.Amount, result.AccountId, owner, scope.HouseholdId,
+	)
+	if err != nil {
+		return types.Income{}, fmt.Errorf("error updating linked debts: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.Income{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteIncome removes an income together with any repayment debt recorded with it
+func (s *Store) DeleteIncome(ctx context.Context, id int32) (types.Income, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Income{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.Income{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Rolled back below if the income isn't the caller's to delete
+	_, err = tx.Exec(ctx, `DELETE FROM debts WHERE income_id = $1 AND household_id = $2`, id, scope.HouseholdId)
+	if err != nil {
+		return types.Income{}, fmt.Errorf("error deleting linked debts: %w", err)
+	}
+
+	var result types.Income
+	err = tx.QueryRow(ctx,
+		`DELETE FROM incomes WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)
+		 RETURNING id, date, amount, description, account_id, account_name, created_at`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.Date, &result.Amount, &result.Description,
+		&result.AccountId, &result.AccountName, &result.CreatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Income{}, fmt.Errorf("income %d: %w", id, ErrNotFound)
+		}
+		return types.Income{}, fmt.Errorf("error deleting income: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.Income{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return result, nil
+}
+
 // GetCategories retrieves all categories
-func GetCategories() ([]types.Category, error) {
-	pool, err := GetPool()
+func (s *Store) GetCategories(ctx context.Context) ([]types.Category, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
-		`SELECT id, name, description, is_essential, created_at FROM categories ORDER BY name`,
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, name, description, is_essential, created_at FROM categories
+		 WHERE household_id = $1 ORDER BY name`,
+		scope.HouseholdId,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying categories: %w", err)
@@ -229,26 +359,57 @@ func GetCategories() ([]types.Category, error) {
 	return results, nil
 }
 
+// InsertCategory adds a category to the caller's household
+func (s *Store) InsertCategory(ctx context.Context, category types.Category) (types.Category, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Category{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var result types.Category
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO categories (name, description, is_essential, household_id)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, name, description, is_essential, created_at`,
+		category.Name, category.Description, category.IsEssential, scope.HouseholdId,
+	).Scan(&result.Id, &result.Name, &result.Description, &result.IsEssential, &result.CreatedAt)
+
+	if err != nil {
+		return types.Category{}, fmt.Errorf("error inserting category: %w", err)
+	}
+
+	return result, nil
+}
+
 // InsertExpense inserts an expense record
-func InsertExpense(expense types.Expense) (types.Expense, error) {
+func (s *Store) InsertExpense(ctx context.Context, expense types.Expense) (types.Expense, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Validate amount
-	if expense.Expense <= 0 {
-		return types.Expense{}, fmt.Errorf("expense amount must be positive, got: %.2f", expense.Expense)
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
 	}
 
-	pool, err := GetPool()
+	owner, err := transactionOwner(ctx, s.pool, scope, expenseAccount(expense))
 	if err != nil {
 		return types.Expense{}, err
 	}
 
 	var result types.Expense
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
 		expense.Date, expense.Category, expense.CategoryId, expense.Expense,
 		expense.Description, expense.Method, expense.OriginalAmount,
-		expense.AccountId, expense.AccountType,
+		expense.AccountId, expense.AccountType, scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Date, &result.Category, &result.CategoryId,
 		&result.Expense, &result.Description, &result.Method, &result.OriginalAmount,
 		&result.AccountId, &result.AccountType)
@@ -261,34 +422,44 @@ func InsertExpense(expense types.Expense) (types.Expense, error) {
 }
 
 // InsertInvestment inserts an investment record and updates account capital
-func InsertInvestment(investment types.Investment) (types.Investment, error) {
-	pool, err := GetPool()
-	if err != nil {
-		return types.Investment{}, err
+func (s *Store) InsertInvestment(ctx context.Context, investment types.Investment) (types.Investment, error) {
+	// Validate amount and type
+	if investment.Amount.Sign() <= 0 {
+		return types.Investment{}, types.Invalid("investment amount must be positive, got: %s", investment.Amount)
 	}
-
-	// Validate type
 	if investment.Type != "deposit" && investment.Type != "withdrawal" {
-		return types.Investment{}, fmt.Errorf("invalid investment type: %s (must be 'deposit' or 'withdrawal')", investment.Type)
+		return types.Investment{}, types.Invalid("invalid investment type: %s (must be 'deposit' or 'withdrawal')", investment.Type)
 	}
 
-	ctx := context.Background()
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Start transaction
-	tx, err := pool.Begin(ctx)
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return types.Investment{}, fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	owner, err := transactionOwner(ctx, tx, scope,
+		investmentAccount(investment.AccountId), optionalAccount(investment.SourceAccountId))
+	if err != nil {
+		return types.Investment{}, err
+	}
+
 	// Insert investment record
 	var result types.Investment
 	err = tx.QueryRow(ctx,
-		`INSERT INTO investments (date, description, amount, account_id, account_name, type, source_account_id)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`INSERT INTO investments (date, description, amount, account_id, account_name, type, source_account_id, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		 RETURNING id, date, description, amount, account_id, account_name, type, source_account_id`,
 		investment.Date, investment.Description, investment.Amount,
 		investment.AccountId, investment.AccountName, investment.Type, investment.SourceAccountId,
+		scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Date, &result.Description, &result.Amount,
 		&result.AccountId, &result.AccountName, &result.Type, &result.SourceAccountId)
 
@@ -298,14 +469,9 @@ func InsertInvestment(investment types.Investment) (types.Investment, error) {
 
 	// Update investment account capital
 	// deposit adds to capital, withdrawal subtracts
-	capitalChange := investment.Amount
-	if investment.Type == "withdrawal" {
-		capitalChange = -investment.Amount
-	}
-
 	_, err = tx.Exec(ctx,
-		`UPDATE investment_accounts SET capital = capital + $1 WHERE id = $2`,
-		capitalChange, investment.AccountId,
+		`UPDATE investment_accounts SET capital = capital + $1 WHERE id = $2 AND household_id = $3`,
+		investmentCapitalChange(investment), investment.AccountId, scope.HouseholdId,
 	)
 	if err != nil {
 		return types.Investment{}, fmt.Errorf("error updating account capital: %w", err)
@@ -320,44 +486,39 @@ func InsertInvestment(investment types.Investment) (types.Investment, error) {
 }
 
 // GetInvestments retrieves investment transactions with pagination
-func GetInvestments(limit int, offset int, accountId *int32) ([]types.Investment, int, error) {
-	pool, err := GetPool()
+func (s *Store) GetInvestments(ctx context.Context, limit int, offset int, accountId *int32) ([]types.Investment, int, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Build query based on filters
-	countQuery := `SELECT COUNT(*) FROM investments`
-	selectQuery := `SELECT id, date, description, amount, account_id, account_name, type, source_account_id FROM investments`
-
-	var args []interface{}
-	argIndex := 1
+	where := ` WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`
+	args := []interface{}{scope.HouseholdId, viewer(scope)}
+	argIndex := 3
 
 	if accountId != nil {
-		countQuery += fmt.Sprintf(" WHERE account_id = $%d", argIndex)
-		selectQuery += fmt.Sprintf(" WHERE account_id = $%d", argIndex)
+		where += fmt.Sprintf(" AND account_id = $%d", argIndex)
 		args = append(args, *accountId)
 		argIndex++
 	}
 
+	countQuery := `SELECT COUNT(*) FROM investments` + where
+	selectQuery := `SELECT id, date, description, amount, account_id, account_name, type, source_account_id FROM investments` + where
 	selectQuery += fmt.Sprintf(" ORDER BY date DESC, id DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
 
 	// Get total count
 	var count int
-	if accountId != nil {
-		err = pool.QueryRow(ctx, countQuery, args...).Scan(&count)
-	} else {
-		err = pool.QueryRow(ctx, countQuery).Scan(&count)
-	}
+	err = s.pool.QueryRow(ctx, countQuery, args...).Scan(&count)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting investments: %w", err)
 	}
 
 	// Get paginated results
 	queryArgs := append(args, limit, offset)
-	rows, err := pool.Query(ctx, selectQuery, queryArgs...)
+	rows, err := s.pool.Query(ctx, selectQuery, queryArgs...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying investments: %w", err)
 	}
@@ -377,38 +538,203 @@ func GetInvestments(limit int, offset int, accountId *int32) ([]types.Investment
 }
 
 // GetInvestmentAccountCapital returns the current capital for an investment account
-func GetInvestmentAccountCapital(accountId int32) (float64, error) {
-	pool, err := GetPool()
+func (s *Store) GetInvestmentAccountCapital(ctx context.Context, accountId int32) (types.Money, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
-		return 0, err
+		return types.Money{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	var capital float64
-	err = pool.QueryRow(context.Background(),
-		`SELECT capital FROM investment_accounts WHERE id = $1`,
-		accountId,
+	var capital types.Money
+	err = s.pool.QueryRow(ctx,
+		`SELECT capital FROM investment_accounts
+		 WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)`,
+		accountId, scope.HouseholdId, viewer(scope),
 	).Scan(&capital)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return 0, fmt.Errorf("investment account not found: %d", accountId)
+			return types.Money{}, fmt.Errorf("investment account %d: %w", accountId, ErrNotFound)
 		}
-		return 0, fmt.Errorf("error querying capital: %w", err)
+		return types.Money{}, fmt.Errorf("error querying capital: %w", err)
 	}
 
 	return capital, nil
 }
 
+// investmentCapitalChange is the capital effect of an investment: deposits add, withdrawals subtract
+func investmentCapitalChange(investment types.Investment) types.Money {
+	if investment.Type == "withdrawal" {
+		return investment.Amount.Neg()
+	}
+	return investment.Amount
+}
+
+// GetInvestmentById retrieves a single investment
+func (s *Store) GetInvestmentById(ctx context.Context, id int32) (types.Investment, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var inv types.Investment
+	err = s.pool.QueryRow(ctx,
+		`SELECT id, date, description, amount, account_id, account_name, type, source_account_id
+		 FROM investments WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&inv.Id, &inv.Date, &inv.Description, &inv.Amount,
+		&inv.AccountId, &inv.AccountName, &inv.Type, &inv.SourceAccountId)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Investment{}, fmt.Errorf("investment %d: %w", id, ErrNotFound)
+		}
+		return types.Investment{}, fmt.Errorf("error querying investment: %w", err)
+	}
+
+	return inv, nil
+}
+
+// UpdateInvestment overwrites an investment, reversing its old capital change and
+// applying the new one in the same transaction (the account may change too)
+func (s *Store) UpdateInvestment(ctx context.Context, investment types.Investment) (types.Investment, error) {
+	// Validate amount and type
+	if investment.Amount.Sign() <= 0 {
+		return types.Investment{}, types.Invalid("investment amount must be positive, got: %s", investment.Amount)
+	}
+	if investment.Type != "deposit" && investment.Type != "withdrawal" {
+		return types.Investment{}, types.Invalid("invalid investment type: %s (must be 'deposit' or 'withdrawal')", investment.Type)
+	}
+
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the current row so concurrent edits can't double-reverse capital
+	var previous types.Investment
+	err = tx.QueryRow(ctx,
+		`SELECT id, amount, account_id, type FROM investments
+		 WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL) FOR UPDATE`,
+		investment.Id, scope.HouseholdId, viewer(scope),
+	).Scan(&previous.Id, &previous.Amount, &previous.AccountId, &previous.Type)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Investment{}, fmt.Errorf("investment %d: %w", investment.Id, ErrNotFound)
+		}
+		return types.Investment{}, fmt.Errorf("error querying investment: %w", err)
+	}
+
+	owner, err := transactionOwner(ctx, tx, scope,
+		investmentAccount(investment.AccountId), optionalAccount(investment.SourceAccountId))
+	if err != nil {
+		return types.Investment{}, err
+	}
+
+	var result types.Investment
+	err = tx.QueryRow(ctx,
+		`UPDATE investments SET date = $2, description = $3, amount = $4, account_id = $5,
+			account_name = $6, type = $7, source_account_id = $8, owner_id = $9
+		 WHERE id = $1
+		 RETURNING id, date, description, amount, account_id, account_name, type, source_account_id`,
+		investment.Id, investment.Date, investment.Description, investment.Amount,
+		investment.AccountId, investment.AccountName, investment.Type, investment.SourceAccountId,
+		owner,
+	).Scan(&result.Id, &result.Date, &result.Description, &result.Amount,
+		&result.AccountId, &result.AccountName, &result.Type, &result.SourceAccountId)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error updating investment: %w", err)
+	}
+
+	// Reverse the old capital change, then apply the new one
+	_, err = tx.Exec(ctx,
+		`UPDATE investment_accounts SET capital = capital - $1 WHERE id = $2 AND household_id = $3`,
+		investmentCapitalChange(previous), previous.AccountId, scope.HouseholdId,
+	)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error reversing account capital: %w", err)
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE investment_accounts SET capital = capital + $1 WHERE id = $2 AND household_id = $3`,
+		investmentCapitalChange(result), result.AccountId, scope.HouseholdId,
+	)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error updating account capital: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.Investment{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteInvestment removes an investment and reverses its capital change
+func (s *Store) DeleteInvestment(ctx context.Context, id int32) (types.Investment, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var result types.Investment
+	err = tx.QueryRow(ctx,
+		`DELETE FROM investments WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)
+		 RETURNING id, date, description, amount, account_id, account_name, type, source_account_id`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.Date, &result.Description, &result.Amount,
+		&result.AccountId, &result.AccountName, &result.Type, &result.SourceAccountId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Investment{}, fmt.Errorf("investment %d: %w", id, ErrNotFound)
+		}
+		return types.Investment{}, fmt.Errorf("error deleting investment: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE investment_accounts SET capital = capital - $1 WHERE id = $2 AND household_id = $3`,
+		investmentCapitalChange(result), result.AccountId, scope.HouseholdId,
+	)
+	if err != nil {
+		return types.Investment{}, fmt.Errorf("error reversing account capital: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.Investment{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return result, nil
+}
+
 // GetInvestmentAccountSummary returns all investment accounts with PnL
-func GetInvestmentAccountSummary() ([]types.InvestmentAccountSummary, error) {
-	pool, err := GetPool()
+func (s *Store) GetInvestmentAccountSummary(ctx context.Context) ([]types.InvestmentAccountSummary, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, name, type, currency, real_balance, total_capital, starting_capital, pnl, pnl_percent 
-		 FROM investment_account_summary`,
+		 FROM investment_account_summary WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying investment summary: %w", err)
@@ -431,17 +757,19 @@ func GetInvestmentAccountSummary() ([]types.InvestmentAccountSummary, error) {
 // ========== YEARLY GOALS ==========
 
 // GetYearlyGoals retrieves goals for a specific year
-func GetYearlyGoals(year int) (types.YearlyGoals, error) {
-	pool, err := GetPool()
+func (s *Store) GetYearlyGoals(ctx context.Context, year int) (types.YearlyGoals, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.YearlyGoals{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var goals types.YearlyGoals
-	err = pool.QueryRow(context.Background(),
+	err = s.pool.QueryRow(ctx,
 		`SELECT id, created_at, year, savings_goal, investment_goal, ideal_investment 
-		 FROM yearly_goals WHERE year = $1`,
-		year,
+		 FROM yearly_goals WHERE year = $1 AND household_id = $2`,
+		year, scope.HouseholdId,
 	).Scan(&goals.Id, &goals.CreatedAt, &goals.Year, &goals.SavingsGoal,
 		&goals.InvestmentGoal, &goals.IdealInvestment)
 
@@ -456,22 +784,24 @@ func GetYearlyGoals(year int) (types.YearlyGoals, error) {
 }
 
 // UpsertYearlyGoals creates or updates goals for a year
-func UpsertYearlyGoals(goals types.YearlyGoals) (types.YearlyGoals, error) {
-	pool, err := GetPool()
+func (s *Store) UpsertYearlyGoals(ctx context.Context, goals types.YearlyGoals) (types.YearlyGoals, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.YearlyGoals{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var result types.YearlyGoals
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO yearly_goals (year, savings_goal, investment_goal, ideal_investment)
-		 VALUES ($1, $2, $3, $4)
-		 ON CONFLICT (year) DO UPDATE SET
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO yearly_goals (year, savings_goal, investment_goal, ideal_investment, household_id)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (household_id, year) DO UPDATE SET
 		   savings_goal = EXCLUDED.savings_goal,
 		   investment_goal = EXCLUDED.investment_goal,
 		   ideal_investment = EXCLUDED.ideal_investment
 		 RETURNING id, created_at, year, savings_goal, investment_goal, ideal_investment`,
-		goals.Year, goals.SavingsGoal, goals.InvestmentGoal, goals.IdealInvestment,
+		goals.Year, goals.SavingsGoal, goals.InvestmentGoal, goals.IdealInvestment, scope.HouseholdId,
 	).Scan(&result.Id, &result.CreatedAt, &result.Year, &result.SavingsGoal,
 		&result.InvestmentGoal, &result.IdealInvestment)
 
@@ -484,24 +814,26 @@ func UpsertYearlyGoals(goals types.YearlyGoals) (types.YearlyGoals, error) {
 
 // ========== NET WORTH SNAPSHOTS ==========
 
-// UpsertNetWorthSnapshot creates or updates a snapshot for a year/month
-func UpsertNetWorthSnapshot(snapshot types.NetWorthSnapshot) (types.NetWorthSnapshot, error) {
-	pool, err := GetPool()
+// UpsertNetWorthSnapshot creates or updates the caller's snapshot for a year/month
+func (s *Store) UpsertNetWorthSnapshot(ctx context.Context, snapshot types.NetWorthSnapshot) (types.NetWorthSnapshot, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.NetWorthSnapshot{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var result types.NetWorthSnapshot
-	err = pool.QueryRow(context.Background(),
+	err = s.pool.QueryRow(ctx,
 		`INSERT INTO net_worth_snapshots (
 			date, year, month, total_fiat_balance,
 			crypto_balance, crypto_capital, broker_balance, broker_capital,
 			total_investment_balance, total_investment_capital,
 			total_real_net_worth, total_pnl,
 			expected_fiat_balance, expected_net_worth, fiat_discrepancy, total_discrepancy,
-			fiat_percent, crypto_percent, broker_percent
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
-		ON CONFLICT (year, month) DO UPDATE SET
+			fiat_percent, crypto_percent, broker_percent, household_id, owner_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		ON CONFLICT (household_id, owner_id, year, month) DO UPDATE SET
 			date = EXCLUDED.date,
 			total_fiat_balance = EXCLUDED.total_fiat_balance,
 			crypto_balance = EXCLUDED.crypto_balance,
@@ -532,6 +864,7 @@ func UpsertNetWorthSnapshot(snapshot types.NetWorthSnapshot) (types.NetWorthSnap
 		snapshot.TotalRealNetWorth, snapshot.TotalPnL,
 		snapshot.ExpectedFiatBalance, snapshot.ExpectedNetWorth, snapshot.FiatDiscrepancy, snapshot.TotalDiscrepancy,
 		snapshot.FiatPercent, snapshot.CryptoPercent, snapshot.BrokerPercent,
+		scope.HouseholdId, scope.UserId,
 	).Scan(&result.Id, &result.CreatedAt, &result.Date, &result.Year, &result.Month,
 		&result.TotalFiatBalance, &result.CryptoBalance, &result.CryptoCapital,
 		&result.BrokerBalance, &result.BrokerCapital, &result.TotalInvestmentBalance,
@@ -546,14 +879,16 @@ func UpsertNetWorthSnapshot(snapshot types.NetWorthSnapshot) (types.NetWorthSnap
 	return result, nil
 }
 
-// GetNetWorthHistory retrieves all snapshots ordered by date
-func GetNetWorthHistory() ([]types.NetWorthSnapshot, error) {
-	pool, err := GetPool()
+// GetNetWorthHistory retrieves the caller's snapshots ordered by date
+func (s *Store) GetNetWorthHistory(ctx context.Context) ([]types.NetWorthSnapshot, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, created_at, date, year, month, total_fiat_balance,
 			crypto_balance, crypto_capital, broker_balance, broker_capital,
 			total_investment_balance, total_investment_capital,
@@ -561,7 +896,8 @@ func GetNetWorthHistory() ([]types.NetWorthSnapshot, error) {
 			COALESCE(expected_fiat_balance, 0), COALESCE(expected_net_worth, 0),
 			COALESCE(fiat_discrepancy, 0), COALESCE(total_discrepancy, 0),
 			fiat_percent, crypto_percent, broker_percent
-		 FROM net_worth_snapshots ORDER BY year, month`,
+		 FROM net_worth_snapshots WHERE household_id = $1 AND owner_id = $2 ORDER BY year, month`,
+		scope.HouseholdId, scope.UserId,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying snapshots: %w", err)
@@ -585,74 +921,90 @@ func GetNetWorthHistory() ([]types.NetWorthSnapshot, error) {
 	return results, nil
 }
 
-// CalculateNetWorthSnapshot calculates current net worth from accounts
-func CalculateNetWorthSnapshot(year int, month int) (types.NetWorthSnapshot, error) {
-	pool, err := GetPool()
-	if err != nil {
-		return types.NetWorthSnapshot{}, err
-	}
-
+// CalculateNetWorthSnapshot calculates current net worth from the accounts the caller can see,
+// converting every balance to the base currency at today's rate
+func (s *Store) CalculateNetWorthSnapshot(ctx context.Context, year int, month int) (types.NetWorthSnapshot, error) {
 	snapshot := types.NetWorthSnapshot{
-		Date:  time.Now(),
+		Date:  types.Now(ctx),
 		Year:  year,
 		Month: month,
 	}
 
-	ctx := context.Background()
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.NetWorthSnapshot{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	today := types.FormatDate(snapshot.Date)
 
 	// Get real fiat balance (from accounting)
-	err = pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(balance), 0) FROM accounts`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(balance * to_base(household_id, COALESCE(currency, 'USD'), $3)), 2), 0) FROM accounts
+		 WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope), today,
 	).Scan(&snapshot.TotalFiatBalance)
 	if err != nil {
-		return types.NetWorthSnapshot{}, fmt.Errorf("error getting fiat balance: %w", err)
+		return types.NetWorthSnapshot{}, fmt.Errorf("error getting fiat balance: %w", converted(err))
 	}
 
 	// Get expected fiat balance (from transactions via account_expected_balance view)
-	err = pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(expected_balance), 0) FROM account_expected_balance`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(expected_balance * to_base(household_id, currency, $3)), 2), 0) FROM account_expected_balance
+		 WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope), today,
 	).Scan(&snapshot.ExpectedFiatBalance)
 	if err != nil {
-		// View might not exist yet, default to 0
-		snapshot.ExpectedFiatBalance = 0
+		return types.NetWorthSnapshot{}, fmt.Errorf("error getting expected fiat balance: %w", converted(err))
 	}
 
 	// Get crypto accounts (type = 'Crypto')
-	err = pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(balance), 0), COALESCE(SUM(capital), 0) 
-		 FROM investment_accounts WHERE type = 'Crypto'`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(balance * to_base(household_id, COALESCE(currency, 'USD'), $3)), 2), 0),
+			COALESCE(ROUND(SUM(capital * to_base(household_id, COALESCE(currency, 'USD'), $3)), 2), 0)
+		 FROM investment_accounts WHERE type = 'Crypto'
+		   AND household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope), today,
 	).Scan(&snapshot.CryptoBalance, &snapshot.CryptoCapital)
 	if err != nil {
-		return types.NetWorthSnapshot{}, fmt.Errorf("error getting crypto: %w", err)
+		return types.NetWorthSnapshot{}, fmt.Errorf("error getting crypto: %w", converted(err))
 	}
 
 	// Get broker accounts (type = 'Broker')
-	err = pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(balance), 0), COALESCE(SUM(capital), 0) 
-		 FROM investment_accounts WHERE type = 'Broker'`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(balance * to_base(household_id, COALESCE(currency, 'USD'), $3)), 2), 0),
+			COALESCE(ROUND(SUM(capital * to_base(household_id, COALESCE(currency, 'USD'), $3)), 2), 0)
+		 FROM investment_accounts WHERE type = 'Broker'
+		   AND household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope), today,
 	).Scan(&snapshot.BrokerBalance, &snapshot.BrokerCapital)
 	if err != nil {
-		return types.NetWorthSnapshot{}, fmt.Errorf("error getting broker: %w", err)
+		return types.NetWorthSnapshot{}, fmt.Errorf("error getting broker: %w", converted(err))
+	}
+
+	for _, total := range []*types.Money{&snapshot.TotalFiatBalance, &snapshot.ExpectedFiatBalance,
+		&snapshot.CryptoBalance, &snapshot.CryptoCapital, &snapshot.BrokerBalance, &snapshot.BrokerCapital} {
+		*total = total.In(scope.BaseCurrency)
 	}
 
 	// Calculate totals
-	snapshot.TotalInvestmentBalance = snapshot.CryptoBalance + snapshot.BrokerBalance
-	snapshot.TotalInvestmentCapital = snapshot.CryptoCapital + snapshot.BrokerCapital
-	snapshot.TotalRealNetWorth = snapshot.TotalFiatBalance + snapshot.TotalInvestmentBalance
-	snapshot.TotalPnL = snapshot.TotalInvestmentBalance - snapshot.TotalInvestmentCapital
+	snapshot.TotalInvestmentBalance = snapshot.CryptoBalance.Add(snapshot.BrokerBalance)
+	snapshot.TotalInvestmentCapital = snapshot.CryptoCapital.Add(snapshot.BrokerCapital)
+	snapshot.TotalRealNetWorth = snapshot.TotalFiatBalance.Add(snapshot.TotalInvestmentBalance)
+	snapshot.TotalPnL = snapshot.TotalInvestmentBalance.Sub(snapshot.TotalInvestmentCapital)
 
 	// Expected net worth = expected fiat + real investment balances (investments are always real from reconciliation)
-	snapshot.ExpectedNetWorth = snapshot.ExpectedFiatBalance + snapshot.TotalInvestmentBalance
+	snapshot.ExpectedNetWorth = snapshot.ExpectedFiatBalance.Add(snapshot.TotalInvestmentBalance)
 
 	// Calculate discrepancies
-	snapshot.FiatDiscrepancy = snapshot.TotalFiatBalance - snapshot.ExpectedFiatBalance
-	snapshot.TotalDiscrepancy = snapshot.TotalRealNetWorth - snapshot.ExpectedNetWorth
+	snapshot.FiatDiscrepancy = snapshot.TotalFiatBalance.Sub(snapshot.ExpectedFiatBalance)
+	snapshot.TotalDiscrepancy = snapshot.TotalRealNetWorth.Sub(snapshot.ExpectedNetWorth)
 
 	// Calculate percentages (based on real net worth)
-	if snapshot.TotalRealNetWorth > 0 {
-		snapshot.FiatPercent = (snapshot.TotalFiatBalance / snapshot.TotalRealNetWorth) * 100
-		snapshot.CryptoPercent = (snapshot.CryptoBalance / snapshot.TotalRealNetWorth) * 100
-		snapshot.BrokerPercent = (snapshot.BrokerBalance / snapshot.TotalRealNetWorth) * 100
+	if snapshot.TotalRealNetWorth.Sign() > 0 {
+		snapshot.FiatPercent = snapshot.TotalFiatBalance.Ratio(snapshot.TotalRealNetWorth) * 100
+		snapshot.CryptoPercent = snapshot.CryptoBalance.Ratio(snapshot.TotalRealNetWorth) * 100
+		snapshot.BrokerPercent = snapshot.BrokerBalance.Ratio(snapshot.TotalRealNetWorth) * 100
 	}
 
 	return snapshot, nil
@@ -660,17 +1012,20 @@ func CalculateNetWorthSnapshot(year int, month int) (types.NetWorthSnapshot, err
 
 // ========== ACCOUNTS ==========
 
-// GetAccounts retrieves all fiat accounts
-func GetAccounts() ([]types.Account, error) {
-	pool, err := GetPool()
+// GetAccounts retrieves the fiat accounts the caller can see
+func (s *Store) GetAccounts(ctx context.Context) ([]types.Account, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance,
-			COALESCE(starting_balance, 0), COALESCE(starting_date, NOW())
-		 FROM accounts ORDER BY name`,
+			COALESCE(starting_balance, 0), COALESCE(starting_date, NOW()), owner_id
+		 FROM accounts WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL) ORDER BY name`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying accounts: %w", err)
@@ -681,7 +1036,7 @@ func GetAccounts() ([]types.Account, error) {
 	for rows.Next() {
 		var a types.Account
 		if err := rows.Scan(&a.Id, &a.Name, &a.Description, &a.Type, &a.Currency, &a.Balance,
-			&a.StartingBalance, &a.StartingDate); err != nil {
+			&a.StartingBalance, &a.StartingDate, &a.OwnerId); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 		results = append(results, a)
@@ -690,17 +1045,20 @@ func GetAccounts() ([]types.Account, error) {
 	return results, nil
 }
 
-// GetInvestmentAccounts retrieves all investment accounts
-func GetInvestmentAccounts() ([]types.InvestmentAccount, error) {
-	pool, err := GetPool()
+// GetInvestmentAccounts retrieves the investment accounts the caller can see
+func (s *Store) GetInvestmentAccounts(ctx context.Context) ([]types.InvestmentAccount, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), 
-			balance, COALESCE(capital, 0), COALESCE(starting_capital, 0), COALESCE(starting_date, NOW())
-		 FROM investment_accounts ORDER BY name`,
+			balance, COALESCE(capital, 0), COALESCE(starting_capital, 0), COALESCE(starting_date, NOW()), owner_id
+		 FROM investment_accounts WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL) ORDER BY name`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying investment accounts: %w", err)
@@ -711,7 +1069,7 @@ func GetInvestmentAccounts() ([]types.InvestmentAccount, error) {
 	for rows.Next() {
 		var a types.InvestmentAccount
 		if err := rows.Scan(&a.Id, &a.Name, &a.Description, &a.Type, &a.Currency, &a.Balance,
-			&a.Capital, &a.StartingCapital, &a.StartingDate); err != nil {
+			&a.Capital, &a.StartingCapital, &a.StartingDate, &a.OwnerId); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 		results = append(results, a)
@@ -721,15 +1079,18 @@ func GetInvestmentAccounts() ([]types.InvestmentAccount, error) {
 }
 
 // UpdateAccountBalance updates the balance for a fiat account
-func UpdateAccountBalance(accountId int32, balance float64) error {
-	pool, err := GetPool()
+func (s *Store) UpdateAccountBalance(ctx context.Context, accountId int32, balance types.Money) error {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	_, err = pool.Exec(context.Background(),
-		`UPDATE accounts SET balance = $1 WHERE id = $2`,
-		balance, accountId,
+	_, err = s.pool.Exec(ctx,
+		`UPDATE accounts SET balance = $1
+		 WHERE id = $2 AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		balance, accountId, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return fmt.Errorf("error updating account balance: %w", err)
@@ -739,15 +1100,18 @@ func UpdateAccountBalance(accountId int32, balance float64) error {
 }
 
 // UpdateInvestmentAccountBalance updates the balance for an investment account
-func UpdateInvestmentAccountBalance(accountId int32, balance float64) error {
-	pool, err := GetPool()
+func (s *Store) UpdateInvestmentAccountBalance(ctx context.Context, accountId int32, balance types.Money) error {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	_, err = pool.Exec(context.Background(),
-		`UPDATE investment_accounts SET balance = $1 WHERE id = $2`,
-		balance, accountId,
+	_, err = s.pool.Exec(ctx,
+		`UPDATE investment_accounts SET balance = $1
+		 WHERE id = $2 AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		balance, accountId, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return fmt.Errorf("error updating investment account balance: %w", err)
@@ -757,24 +1121,28 @@ func UpdateInvestmentAccountBalance(accountId int32, balance float64) error {
 }
 
 // UpdateAccountBalances updates balances for multiple accounts (for accounting)
-func UpdateAccountBalances(accounts []types.Account) ([]types.Account, error) {
-	pool, err := GetPool()
+func (s *Store) UpdateAccountBalances(ctx context.Context, accounts []types.Account) ([]types.Account, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 	var updated []types.Account
 
 	for _, account := range accounts {
 		var result types.Account
-		err := pool.QueryRow(ctx,
-			`UPDATE accounts SET balance = $1 WHERE id = $2
-			 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance`,
-			account.Balance, account.Id,
-		).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance)
+		err := s.pool.QueryRow(ctx,
+			`UPDATE accounts SET balance = $1
+			 WHERE id = $2 AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+			 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance, owner_id`,
+			account.Balance, account.Id, scope.HouseholdId, viewer(scope),
+		).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance, &result.OwnerId)
 
 		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("account %d: %w", account.Id, ErrNotFound)
+			}
 			return nil, fmt.Errorf("error updating account %d: %w", account.Id, err)
 		}
 		updated = append(updated, result)
@@ -784,24 +1152,28 @@ func UpdateAccountBalances(accounts []types.Account) ([]types.Account, error) {
 }
 
 // UpdateInvestmentAccountBalances updates balances for multiple investment accounts (for accounting)
-func UpdateInvestmentAccountBalances(accounts []types.InvestmentAccount) ([]types.InvestmentAccount, error) {
-	pool, err := GetPool()
+func (s *Store) UpdateInvestmentAccountBalances(ctx context.Context, accounts []types.InvestmentAccount) ([]types.InvestmentAccount, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 	var updated []types.InvestmentAccount
 
 	for _, account := range accounts {
 		var result types.InvestmentAccount
-		err := pool.QueryRow(ctx,
-			`UPDATE investment_accounts SET balance = $1 WHERE id = $2
-			 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance, COALESCE(capital, 0)`,
-			account.Balance, account.Id,
-		).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance, &result.Capital)
+		err := s.pool.QueryRow(ctx,
+			`UPDATE investment_accounts SET balance = $1
+			 WHERE id = $2 AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+			 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance, COALESCE(capital, 0), owner_id`,
+			account.Balance, account.Id, scope.HouseholdId, viewer(scope),
+		).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance, &result.Capital, &result.OwnerId)
 
 		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("investment account %d: %w", account.Id, ErrNotFound)
+			}
 			return nil, fmt.Errorf("error updating investment account %d: %w", account.Id, err)
 		}
 		updated = append(updated, result)
@@ -812,20 +1184,28 @@ func UpdateInvestmentAccountBalances(accounts []types.InvestmentAccount) ([]type
 
 // ========== DEBTS ==========
 
-// InsertDebt inserts a debt record
-func InsertDebt(debt types.Debt) (types.Debt, error) {
-	pool, err := GetPool()
+// InsertDebt inserts a debt record
+func (s *Store) InsertDebt(ctx context.Context, debt types.Debt) (types.Debt, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Debt{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	owner, err := transactionOwner(ctx, s.pool, scope, optionalAccount(debt.AccountId))
 	if err != nil {
 		return types.Debt{}, err
 	}
 
 	var result types.Debt
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		 RETURNING id, description, amount, debtor_id, debtor_name, date, created_at, original_amount, currency, outbound`,
 		debt.Description, debt.Amount, debt.DebtorId, debt.DebtorName, debt.Date,
 		debt.OriginalAmount, debt.Currency, debt.Outbound, debt.AccountId, debt.ExpenseId, debt.IncomeId,
+			scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Description, &result.Amount, &result.DebtorId, &result.DebtorName,
 		&result.Date, &result.CreatedAt, &result.OriginalAmount, &result.Currency, &result.Outbound)
 
@@ -839,8 +1219,8 @@ func InsertDebt(debt types.Debt) (types.Debt, error) {
 // InsertExpenseWithDebt creates an expense and a linked debt in a single transaction
 // Use case: "I lent $100 to a friend" - creates expense (affects expected balance) + debt (tracks receivable)
 // Deprecated: Use InsertExpenseWithDebts for multiple debts support
-func InsertExpenseWithDebt(expense types.Expense, debt types.Debt) (types.Expense, types.Debt, error) {
-	expenseResult, debts, err := InsertExpenseWithDebts(expense, []types.Debt{debt})
+func (s *Store) InsertExpenseWithDebt(ctx context.Context, expense types.Expense, debt types.Debt) (types.Expense, types.Debt, error) {
+	expenseResult, debts, err := s.InsertExpenseWithDebts(ctx, expense, []types.Debt{debt})
 	if err != nil {
 		return types.Expense{}, types.Debt{}, err
 	}
@@ -852,47 +1232,52 @@ func InsertExpenseWithDebt(expense types.Expense, debt types.Debt) (types.Expens
 
 // InsertExpenseWithDebts creates an expense and multiple linked debts in a single transaction
 // Use case: "I paid $100 dinner, John owes $30, Sarah owes $30" - creates expense + multiple debts
-func InsertExpenseWithDebts(expense types.Expense, debts []types.Debt) (types.Expense, []types.Debt, error) {
+func (s *Store) InsertExpenseWithDebts(ctx context.Context, expense types.Expense, debts []types.Debt) (types.Expense, []types.Debt, error) {
 	// Validate expense amount
-	if expense.Expense <= 0 {
-		return types.Expense{}, nil, fmt.Errorf("expense amount must be positive, got: %.2f", expense.Expense)
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, nil, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
 	}
 
 	// Validate debts
 	if len(debts) == 0 {
-		return types.Expense{}, nil, fmt.Errorf("at least one debt is required")
+		return types.Expense{}, nil, types.Invalid("at least one debt is required")
 	}
 
 	for i, debt := range debts {
-		if debt.Amount <= 0 {
-			return types.Expense{}, nil, fmt.Errorf("debt %d amount must be positive, got: %.2f", i+1, debt.Amount)
+		if debt.Amount.Sign() <= 0 {
+			return types.Expense{}, nil, types.Invalid("debt %d amount must be positive, got: %s", i+1, debt.Amount)
 		}
 		if debt.DebtorId == 0 {
-			return types.Expense{}, nil, fmt.Errorf("debt %d must have a debtor_id", i+1)
+			return types.Expense{}, nil, types.Invalid("debt %d must have a debtor_id", i+1)
 		}
 	}
 
-	pool, err := GetPool()
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Expense{}, nil, err
 	}
-
-	ctx := context.Background()
-	tx, err := pool.Begin(ctx)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return types.Expense{}, nil, fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	expenseOwner, err := transactionOwner(ctx, tx, scope, expenseAccount(expense))
+	if err != nil {
+		return types.Expense{}, nil, err
+	}
+
 	// Insert expense
 	var expenseResult types.Expense
 	err = tx.QueryRow(ctx,
-		`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`INSERT INTO expenses (date, category, category_id, expense, description, method, "originalAmount", account_id, account_type, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
 		expense.Date, expense.Category, expense.CategoryId, expense.Expense,
 		expense.Description, expense.Method, expense.OriginalAmount,
-		expense.AccountId, expense.AccountType,
+		expense.AccountId, expense.AccountType, scope.HouseholdId, expenseOwner,
 	).Scan(&expenseResult.Id, &expenseResult.Date, &expenseResult.Category, &expenseResult.CategoryId,
 		&expenseResult.Expense, &expenseResult.Description, &expenseResult.Method, &expenseResult.OriginalAmount,
 		&expenseResult.AccountId, &expenseResult.AccountType)
@@ -904,13 +1289,19 @@ func InsertExpenseWithDebts(expense types.Expense, debts []types.Debt) (types.Ex
 	debtResults := make([]types.Debt, 0, len(debts))
 	for _, debt := range debts {
 		debt.ExpenseId = &expenseResult.Id
+		// A debt on a private expense stays private to the same user
+		owner, err := transactionOwner(ctx, tx, scope, expenseAccount(expense), optionalAccount(debt.AccountId))
+		if err != nil {
+			return types.Expense{}, nil, err
+		}
 		var debtResult types.Debt
 		err = tx.QueryRow(ctx,
-			`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id, household_id, owner_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 			 RETURNING id, description, amount, debtor_id, debtor_name, date, created_at, original_amount, currency, outbound`,
 			debt.Description, debt.Amount, debt.DebtorId, debt.DebtorName, debt.Date,
 			debt.OriginalAmount, debt.Currency, debt.Outbound, debt.AccountId, debt.ExpenseId, debt.IncomeId,
+				scope.HouseholdId, owner,
 		).Scan(&debtResult.Id, &debtResult.Description, &debtResult.Amount, &debtResult.DebtorId, &debtResult.DebtorName,
 			&debtResult.Date, &debtResult.CreatedAt, &debtResult.OriginalAmount, &debtResult.Currency, &debtResult.Outbound)
 		if err != nil {
@@ -928,38 +1319,33 @@ func InsertExpenseWithDebts(expense types.Expense, debts []types.Debt) (types.Ex
 }
 
 // GetDebts retrieves debts with optional filters
-func GetDebts(limit int, offset int, debtorId *int32) ([]types.Debt, int, error) {
-	pool, err := GetPool()
+func (s *Store) GetDebts(ctx context.Context, limit int, offset int, debtorId *int32) ([]types.Debt, int, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Build query based on filters
-	countQuery := `SELECT COUNT(*) FROM debts`
-	selectQuery := `SELECT id, description, amount, debtor_id, debtor_name, date, created_at, 
-		original_amount, currency, outbound, account_id, expense_id, income_id FROM debts`
-
-	var args []interface{}
-	argIndex := 1
+	where := ` WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`
+	args := []interface{}{scope.HouseholdId, viewer(scope)}
+	argIndex := 3
 
 	if debtorId != nil {
-		countQuery += fmt.Sprintf(" WHERE debtor_id = $%d", argIndex)
-		selectQuery += fmt.Sprintf(" WHERE debtor_id = $%d", argIndex)
+		where += fmt.Sprintf(" AND debtor_id = $%d", argIndex)
 		args = append(args, *debtorId)
 		argIndex++
 	}
 
-	selectQuery += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	countQuery := `SELECT COUNT(*) FROM debts` + where
+	selectQuery := `SELECT id, description, amount, debtor_id, debtor_name, date, created_at, 
+		original_amount, currency, outbound, account_id, expense_id, income_id FROM debts` + where
+	selectQuery += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
 
 	// Get total count
 	var count int
-	if len(args) > 0 {
-		err = pool.QueryRow(ctx, countQuery, args...).Scan(&count)
-	} else {
-		err = pool.QueryRow(ctx, countQuery).Scan(&count)
-	}
+	err = s.pool.QueryRow(ctx, countQuery, args...).Scan(&count)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting debts: %w", err)
 	}
@@ -968,7 +1354,7 @@ func GetDebts(limit int, offset int, debtorId *int32) ([]types.Debt, int, error)
 	args = append(args, limit, offset)
 
 	// Get paginated results
-	rows, err := pool.Query(ctx, selectQuery, args...)
+	rows, err := s.pool.Query(ctx, selectQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying debts: %w", err)
 	}
@@ -989,16 +1375,19 @@ func GetDebts(limit int, offset int, debtorId *int32) ([]types.Debt, int, error)
 }
 
 // GetRecentExpenses retrieves recent expenses for linking to debts
-func GetRecentExpenses(limit int) ([]types.Expense, error) {
-	pool, err := GetPool()
+func (s *Store) GetRecentExpenses(ctx context.Context, limit int) ([]types.Expense, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type 
-		 FROM expenses ORDER BY created_at DESC LIMIT $1`,
-		limit,
+		 FROM expenses WHERE household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)
+		 ORDER BY created_at DESC, id DESC LIMIT $1`,
+		limit, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying recent expenses: %w", err)
@@ -1019,26 +1408,33 @@ func GetRecentExpenses(limit int) ([]types.Expense, error) {
 }
 
 // RecordDebtRepayment creates an income record and a corresponding debt record in one transaction
-func RecordDebtRepayment(income types.Income, debt types.Debt) (types.Income, types.Debt, error) {
-	pool, err := GetPool()
+func (s *Store) RecordDebtRepayment(ctx context.Context, income types.Income, debt types.Debt) (types.Income, types.Debt, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Income{}, types.Debt{}, err
 	}
-
-	ctx := context.Background()
-	tx, err := pool.Begin(ctx)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return types.Income{}, types.Debt{}, fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	// The repayment and its debt record share the income account's owner
+	owner, err := transactionOwner(ctx, tx, scope, fiatAccount(income.AccountId), optionalAccount(debt.AccountId))
+	if err != nil {
+		return types.Income{}, types.Debt{}, err
+	}
+
 	// Insert income
 	var incomeResult types.Income
 	err = tx.QueryRow(ctx,
-		`INSERT INTO incomes (date, amount, description, account_id, account_name)
-		 VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO incomes (date, amount, description, account_id, account_name, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
 		 RETURNING id, date, amount, description, account_id, account_name, created_at`,
 		income.Date, income.Amount, income.Description, income.AccountId, income.AccountName,
+		scope.HouseholdId, owner,
 	).Scan(&incomeResult.Id, &incomeResult.Date, &incomeResult.Amount, &incomeResult.Description,
 		&incomeResult.AccountId, &incomeResult.AccountName, &incomeResult.CreatedAt)
 	if err != nil {
@@ -1049,11 +1445,12 @@ func RecordDebtRepayment(income types.Income, debt types.Debt) (types.Income, ty
 	debt.IncomeId = &incomeResult.Id
 	var debtResult types.Debt
 	err = tx.QueryRow(ctx,
-		`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`INSERT INTO debts (description, amount, debtor_id, debtor_name, date, original_amount, currency, outbound, account_id, expense_id, income_id, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		 RETURNING id, description, amount, debtor_id, debtor_name, date, created_at, original_amount, currency, outbound`,
 		debt.Description, debt.Amount, debt.DebtorId, debt.DebtorName, debt.Date,
 		debt.OriginalAmount, debt.Currency, debt.Outbound, debt.AccountId, debt.ExpenseId, debt.IncomeId,
+			scope.HouseholdId, owner,
 	).Scan(&debtResult.Id, &debtResult.Description, &debtResult.Amount, &debtResult.DebtorId, &debtResult.DebtorName,
 		&debtResult.Date, &debtResult.CreatedAt, &debtResult.OriginalAmount, &debtResult.Currency, &debtResult.Outbound)
 	if err != nil {
@@ -1071,26 +1468,30 @@ func RecordDebtRepayment(income types.Income, debt types.Debt) (types.Income, ty
 // ========== EXPENSES (READ) ==========
 
 // GetExpenses retrieves expenses with pagination
-func GetExpenses(limit int, offset int) ([]types.Expense, int, error) {
-	pool, err := GetPool()
+func (s *Store) GetExpenses(ctx context.Context, limit int, offset int) ([]types.Expense, int, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Get total count
 	var count int
-	err = pool.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM expenses`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM expenses WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	).Scan(&count)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting expenses: %w", err)
 	}
 
 	// Get paginated results
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type 
-		 FROM expenses ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
-		limit, offset,
+		 FROM expenses WHERE household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)
+		 ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2`,
+		limit, offset, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying expenses: %w", err)
@@ -1110,30 +1511,292 @@ func GetExpenses(limit int, offset int) ([]types.Expense, int, error) {
 	return results, count, nil
 }
 
+// ========== EXPENSES (UPDATE/DELETE) ==========
+
+// GetExpenseById retrieves a single expense
+func (s *Store) GetExpenseById(ctx context.Context, id int32) (types.Expense, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var e types.Expense
+	err = s.pool.QueryRow(ctx,
+		`SELECT id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type
+		 FROM expenses WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&e.Id, &e.Date, &e.Category, &e.CategoryId, &e.Expense,
+		&e.Description, &e.Method, &e.OriginalAmount, &e.AccountId, &e.AccountType)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Expense{}, fmt.Errorf("expense %d: %w", id, ErrNotFound)
+		}
+		return types.Expense{}, fmt.Errorf("error querying expense: %w", err)
+	}
+
+	return e, nil
+}
+
+// UpdateExpense overwrites every editable field of an existing expense
+func (s *Store) UpdateExpense(ctx context.Context, expense types.Expense) (types.Expense, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Validate amount
+	if expense.Expense.Sign() <= 0 {
+		return types.Expense{}, types.Invalid("expense amount must be positive, got: %s", expense.Expense)
+	}
+
+	owner, err := transactionOwner(ctx, s.pool, scope, expenseAccount(expense))
+	if err != nil {
+		return types.Expense{}, err
+	}
+
+	var result types.Expense
+	err = s.pool.QueryRow(ctx,
+		`UPDATE expenses SET date = $2, category = $3, category_id = $4, expense = $5, description = $6,
+			method = $7, "originalAmount" = $8, account_id = $9, account_type = $10, owner_id = $11
+		 WHERE id = $1 AND household_id = $12 AND (owner_id IS NULL OR owner_id = $13 OR $13 IS NULL)
+		 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
+		expense.Id, expense.Date, expense.Category, expense.CategoryId, expense.Expense,
+		expense.Description, expense.Method, expense.OriginalAmount,
+		expense.AccountId, expense.AccountType, owner, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.Date, &result.Category, &result.CategoryId,
+		&result.Expense, &result.Description, &result.Method, &result.OriginalAmount,
+		&result.AccountId, &result.AccountType)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Expense{}, fmt.Errorf("expense %d: %w", expense.Id, ErrNotFound)
+		}
+		return types.Expense{}, fmt.Errorf("error updating expense: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteExpense removes an expense together with any debts created from it
+// Returns the deleted expense so callers can reconcile the sheet
+func (s *Store) DeleteExpense(ctx context.Context, id int32) (types.Expense, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return types.Expense{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Linked debts only exist because of this expense (see InsertExpenseWithDebts);
+	// rolled back below if the expense isn't the caller's to delete
+	_, err = tx.Exec(ctx, `DELETE FROM debts WHERE expense_id = $1 AND household_id = $2`, id, scope.HouseholdId)
+	if err != nil {
+		return types.Expense{}, fmt.Errorf("error deleting linked debts: %w", err)
+	}
+
+	var result types.Expense
+	err = tx.QueryRow(ctx,
+		`DELETE FROM expenses WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)
+		 RETURNING id, date, category, category_id, expense, description, method, "originalAmount", account_id, account_type`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.Date, &result.Category, &result.CategoryId,
+		&result.Expense, &result.Description, &result.Method, &result.OriginalAmount,
+		&result.AccountId, &result.AccountType)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Expense{}, fmt.Errorf("expense %d: %w", id, ErrNotFound)
+		}
+		return types.Expense{}, fmt.Errorf("error deleting expense: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.Expense{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return result, nil
+}
+
 // ========== BUDGETS ==========
 
-// GetBudgets retrieves budget by category from the view
-func GetBudgets() ([]types.BudgetByCategory, error) {
-	pool, err := GetPool()
+// GetBudgets retrieves, for each of periods, the household's budgets in force in
+// the period's month with what the caller can see spent against each in the
+// period, in the base currency. However many periods there are, it takes two
+// queries: one for the spending and one for every budget up to the last month.
+func (s *Store) GetBudgets(ctx context.Context, periods []types.Period) ([][]types.BudgetByCategory, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([][]types.BudgetByCategory, len(periods))
+	if len(periods) == 0 {
+		return results, nil
+	}
+
+	spent, err := s.GetSpentByCategory(ctx, periods)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Ordered by category and month, so the budget in force in a month is the
+	// last row of its category at or before it
+	rows, err := s.pool.Query(ctx,
+		`SELECT b.category_id, COALESCE(c.name, ''), b.month, b.budget
+		 FROM budgets b
+		 LEFT JOIN categories c ON c.id = b.category_id AND c.household_id = b.household_id
+		 WHERE b.household_id = $1 AND b.month <= $2
+		 ORDER BY 2, 1, 3`,
+		scope.HouseholdId, periods[len(periods)-1].Month(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying budgets: %w", converted(err))
+	}
+	defer rows.Close()
+
+	var budgets []types.Budget
+	var names []string
+	for rows.Next() {
+		var b types.Budget
+		var name string
+		if err := rows.Scan(&b.CategoryId, &name, &b.Month, &b.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		budgets = append(budgets, b)
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying budgets: %w", converted(err))
+	}
+
+	for i, period := range periods {
+		for j, b := range budgets {
+			last := j == len(budgets)-1 || budgets[j+1].CategoryId != b.CategoryId || budgets[j+1].Month > period.Month()
+			if b.Month > period.Month() || !last {
+				continue
+			}
+			results[i] = append(results[i], types.BudgetByCategory{
+				Amount: b.Amount, Spent: spent[i][b.CategoryId], CategoryName: names[j], CategoryId: b.CategoryId,
+			})
+		}
+	}
+	return results, nil
+}
+
+// GetSpentByCategory returns, for each of periods, what the caller can see
+// spent in it against each of the household's categories, in the base currency
+func (s *Store) GetSpentByCategory(ctx context.Context, periods []types.Period) ([]map[int32]types.Money, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	starts := make([]string, len(periods))
+	ends := make([]string, len(periods))
+	spent := make([]map[int32]types.Money, len(periods))
+	for i, period := range periods {
+		starts[i], ends[i] = period.Start, period.End
+		spent[i] = map[int32]types.Money{}
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT p.i, e.category_id, ROUND(SUM(e.expense * to_base(e.household_id, account_currency(e.account_type, e.account_id), e.date)), 2)
+		 FROM unnest($3::text[], $4::text[]) WITH ORDINALITY AS p(start, finish, i)
+		 JOIN expenses e ON e.date >= p.start AND e.date < p.finish
+		 WHERE e.household_id = $1 AND (e.owner_id IS NULL OR e.owner_id = $2 OR $2 IS NULL)
+		 GROUP BY p.i, e.category_id`,
+		scope.HouseholdId, viewer(scope), starts, ends,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying spending by category: %w", converted(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var period int64
+		var categoryId int32
+		var amount types.Money
+		if err := rows.Scan(&period, &categoryId, &amount); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		spent[period-1][categoryId] = amount.In(scope.BaseCurrency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying spending by category: %w", converted(err))
+	}
+
+	return spent, nil
+}
+
+// InsertBudgetMove moves money between two of the household's envelopes
+func (s *Store) InsertBudgetMove(ctx context.Context, move types.BudgetMove) (types.BudgetMove, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.BudgetMove{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Both categories must be the household's own
+	var result types.BudgetMove
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO budget_moves (household_id, date, from_category_id, to_category_id, amount, note)
+		 SELECT $1, $2, f.id, t.id, $5, $6 FROM categories f, categories t
+		 WHERE f.id = $3 AND f.household_id = $1 AND t.id = $4 AND t.household_id = $1
+		 RETURNING id, date, from_category_id, to_category_id, amount, note, created_at`,
+		scope.HouseholdId, move.Date, move.FromCategoryId, move.ToCategoryId, move.Amount, move.Note,
+	).Scan(&result.Id, &result.Date, &result.FromCategoryId, &result.ToCategoryId, &result.Amount, &result.Note, &result.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.BudgetMove{}, fmt.Errorf("categories %d and %d: %w", move.FromCategoryId, move.ToCategoryId, ErrNotFound)
+		}
+		return types.BudgetMove{}, fmt.Errorf("error inserting budget move: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetBudgetMoves returns the household's envelope moves dated in period, oldest first
+func (s *Store) GetBudgetMoves(ctx context.Context, period types.Period) ([]types.BudgetMove, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
-		`SELECT amount, spent, category_name, category_id FROM budget_by_category_current_month ORDER BY category_name`,
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, date, from_category_id, to_category_id, amount, note, created_at FROM budget_moves
+		 WHERE household_id = $1 AND date >= $2 AND date < $3
+		 ORDER BY date, id`,
+		scope.HouseholdId, period.Start, period.End,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error querying budgets: %w", err)
+		return nil, fmt.Errorf("error querying budget moves: %w", err)
 	}
 	defer rows.Close()
 
-	var results []types.BudgetByCategory
+	var results []types.BudgetMove
 	for rows.Next() {
-		var b types.BudgetByCategory
-		if err := rows.Scan(&b.Amount, &b.Spent, &b.CategoryName, &b.CategoryId); err != nil {
+		var m types.BudgetMove
+		if err := rows.Scan(&m.Id, &m.Date, &m.FromCategoryId, &m.ToCategoryId, &m.Amount, &m.Note, &m.CreatedAt); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
-		results = append(results, b)
+		results = append(results, m)
 	}
 
 	return results, nil
@@ -1141,16 +1804,19 @@ func GetBudgets() ([]types.BudgetByCategory, error) {
 
 // ========== DEBTORS ==========
 
-// GetDebtors retrieves all debtors
-func GetDebtors() ([]types.Debtor, error) {
-	pool, err := GetPool()
+// GetDebtors retrieves the household's debtors
+func (s *Store) GetDebtors(ctx context.Context) ([]types.Debtor, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, name, COALESCE(first_name, ''), COALESCE(last_name, ''), COALESCE(description, '') 
-		 FROM debtors ORDER BY name`,
+		 FROM debtors WHERE household_id = $1 ORDER BY name`,
+		scope.HouseholdId,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying debtors: %w", err)
@@ -1169,16 +1835,22 @@ func GetDebtors() ([]types.Debtor, error) {
 	return results, nil
 }
 
-// GetDebtorsWithDebts retrieves debt summary by debtor
-func GetDebtorsWithDebts() ([]types.DebtByDebtor, error) {
-	pool, err := GetPool()
+// GetDebtorsWithDebts retrieves debt summary by debtor over the debts the caller can see
+func (s *Store) GetDebtorsWithDebts(ctx context.Context) ([]types.DebtByDebtor, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
-		`SELECT debtor_id, debtor_name, total_lent, total_received, net_owed, transaction_count 
-		 FROM debt_by_debtor`,
+	// The view has a row per debtor and owner
+	rows, err := s.pool.Query(ctx,
+		`SELECT debtor_id, MAX(debtor_name), SUM(total_lent), SUM(total_received), SUM(net_owed),
+			SUM(transaction_count)::INTEGER
+		 FROM debt_by_debtor WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)
+		 GROUP BY debtor_id`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying debt_by_debtor: %w", err)
@@ -1197,15 +1869,18 @@ func GetDebtorsWithDebts() ([]types.DebtByDebtor, error) {
 	return results, nil
 }
 
-// GetConfig retrieves all config entries
-func GetConfig() ([]types.Config, error) {
-	pool, err := GetPool()
+// GetConfig retrieves the household's config entries
+func (s *Store) GetConfig(ctx context.Context) ([]types.Config, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
-		`SELECT type, sheet, range FROM config`,
+	rows, err := s.pool.Query(ctx,
+		`SELECT type, sheet, range FROM config WHERE household_id = $1`,
+		scope.HouseholdId,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying config: %w", err)
@@ -1226,101 +1901,140 @@ func GetConfig() ([]types.Config, error) {
 
 // ========== DASHBOARD HELPERS ==========
 
-// GetMonthlyExpenseSum returns total expenses for a given year and month
-func GetMonthlyExpenseSum(year int, month int) (float64, error) {
-	pool, err := GetPool()
+// GetExpenseSum returns total expenses dated in period, in the base currency
+func (s *Store) GetExpenseSum(ctx context.Context, period types.Period) (types.Money, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
-		return 0, err
+		return types.Money{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	var total float64
-	err = pool.QueryRow(context.Background(),
-		`SELECT COALESCE(SUM(expense), 0) FROM expenses 
-		 WHERE EXTRACT(YEAR FROM created_at) = $1 AND EXTRACT(MONTH FROM created_at) = $2`,
-		year, month,
+	var total types.Money
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(expense * to_base(household_id, account_currency(account_type, account_id), date)), 2), 0) FROM expenses
+		 WHERE date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		period.Start, period.End, scope.HouseholdId, viewer(scope),
 	).Scan(&total)
 
 	if err != nil {
-		return 0, fmt.Errorf("error querying monthly expenses: %w", err)
+		return types.Money{}, fmt.Errorf("error querying expense sum: %w", converted(err))
 	}
 
-	return total, nil
+	return total.In(scope.BaseCurrency), nil
 }
 
-// GetMonthlyInvestmentSum returns total investment deposits for a given year and month
-func GetMonthlyInvestmentSum(year int, month int) (float64, error) {
-	pool, err := GetPool()
+// GetInvestmentSum returns total investment deposits dated in period, in the base currency
+func (s *Store) GetInvestmentSum(ctx context.Context, period types.Period) (types.Money, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
-		return 0, err
+		return types.Money{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	var total float64
-	err = pool.QueryRow(context.Background(),
-		`SELECT COALESCE(SUM(CASE WHEN type = 'deposit' THEN amount ELSE 0 END), 0) FROM investments 
-		 WHERE EXTRACT(YEAR FROM created_at) = $1 AND EXTRACT(MONTH FROM created_at) = $2`,
-		year, month,
+	var total types.Money
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(CASE WHEN type = 'deposit'
+			THEN amount * to_base(household_id, account_currency('Investment', account_id), date) ELSE 0 END), 2), 0)
+		 FROM investments
+		 WHERE date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		period.Start, period.End, scope.HouseholdId, viewer(scope),
 	).Scan(&total)
 
 	if err != nil {
-		return 0, fmt.Errorf("error querying monthly investments: %w", err)
+		return types.Money{}, fmt.Errorf("error querying investment sum: %w", converted(err))
 	}
 
-	return total, nil
+	return total.In(scope.BaseCurrency), nil
 }
 
-// GetYTDTotals returns year-to-date totals for income, expenses, and investments
-func GetYTDTotals(year int) (income float64, expenses float64, investments float64) {
-	pool, err := GetPool()
+// GetYTDTotals returns year-to-date totals for income, expenses, and investments by transaction date
+func (s *Store) GetYTDTotals(ctx context.Context, year int) (income types.Money, expenses types.Money, investments types.Money, err error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
-		return 0, 0, 0
+		return types.Money{}, types.Money{}, types.Money{}, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	from, to := types.YearRange(year)
 
 	// Income YTD
-	pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(amount), 0) FROM incomes WHERE EXTRACT(YEAR FROM created_at) = $1`,
-		year,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(amount * to_base(household_id, account_currency('', account_id), date)), 2), 0)
+		 FROM incomes WHERE date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		from, to, scope.HouseholdId, viewer(scope),
 	).Scan(&income)
+	if err != nil {
+		return types.Money{}, types.Money{}, types.Money{}, fmt.Errorf("error querying income YTD: %w", converted(err))
+	}
 
 	// Expenses YTD
-	pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(expense), 0) FROM expenses WHERE EXTRACT(YEAR FROM created_at) = $1`,
-		year,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(expense * to_base(household_id, account_currency(account_type, account_id), date)), 2), 0)
+		 FROM expenses WHERE date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		from, to, scope.HouseholdId, viewer(scope),
 	).Scan(&expenses)
+	if err != nil {
+		return types.Money{}, types.Money{}, types.Money{}, fmt.Errorf("error querying expenses YTD: %w", converted(err))
+	}
 
 	// Investments YTD (deposits only)
-	pool.QueryRow(ctx,
-		`SELECT COALESCE(SUM(amount), 0) FROM investments WHERE type = 'deposit' AND EXTRACT(YEAR FROM created_at) = $1`,
-		year,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COALESCE(ROUND(SUM(amount * to_base(household_id, account_currency('Investment', account_id), date)), 2), 0)
+		 FROM investments WHERE type = 'deposit' AND date >= $1 AND date < $2
+		   AND household_id = $3 AND (owner_id IS NULL OR owner_id = $4 OR $4 IS NULL)`,
+		from, to, scope.HouseholdId, viewer(scope),
 	).Scan(&investments)
+	if err != nil {
+		return types.Money{}, types.Money{}, types.Money{}, fmt.Errorf("error querying investments YTD: %w", converted(err))
+	}
 
-	return income, expenses, investments
+	return income.In(scope.BaseCurrency), expenses.In(scope.BaseCurrency), investments.In(scope.BaseCurrency), nil
 }
 
 // ========== TRANSFERS ==========
 
 // InsertTransfer inserts a transfer record
-func InsertTransfer(transfer types.Transfer) (types.Transfer, error) {
-	pool, err := GetPool()
+func (s *Store) InsertTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Transfer{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Calculate exchange rate if not provided
-	if transfer.ExchangeRate == 0 && transfer.SourceAmount > 0 {
-		transfer.ExchangeRate = transfer.DestAmount / transfer.SourceAmount
+	if transfer.ExchangeRate == 0 && transfer.SourceAmount.Sign() > 0 {
+		transfer.ExchangeRate = transfer.DestAmount.Ratio(transfer.SourceAmount)
+	}
+
+	owner, err := transactionOwner(ctx, s.pool, scope,
+		fiatAccount(transfer.SourceAccountId), fiatAccount(transfer.DestAccountId))
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	if err := s.costTransfer(ctx, scope, &transfer); err != nil {
+		return types.Transfer{}, err
 	}
 
 	var result types.Transfer
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO transfers (date, description, source_account_id, source_amount, dest_account_id, dest_amount, exchange_rate)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
-		 RETURNING id, created_at, date, description, source_account_id, source_amount, dest_account_id, dest_amount, exchange_rate`,
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO transfers (date, description, source_account_id, source_amount, dest_account_id, dest_amount, exchange_rate,
+			reference_rate, fx_cost, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 RETURNING id, created_at, date, description, source_account_id, source_amount, dest_account_id, dest_amount, exchange_rate,
+			COALESCE(reference_rate, 0), fx_cost`,
 		transfer.Date, transfer.Description, transfer.SourceAccountId, transfer.SourceAmount,
-		transfer.DestAccountId, transfer.DestAmount, transfer.ExchangeRate,
+		transfer.DestAccountId, transfer.DestAmount, transfer.ExchangeRate, referenceRate(transfer), transfer.FXCost,
+		scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.CreatedAt, &result.Date, &result.Description,
-		&result.SourceAccountId, &result.SourceAmount, &result.DestAccountId, &result.DestAmount, &result.ExchangeRate)
+		&result.SourceAccountId, &result.SourceAmount, &result.DestAccountId, &result.DestAmount, &result.ExchangeRate,
+		&result.ReferenceRate, &result.FXCost)
 
 	if err != nil {
 		return types.Transfer{}, fmt.Errorf("error inserting transfer: %w", err)
@@ -1329,33 +2043,63 @@ func InsertTransfer(transfer types.Transfer) (types.Transfer, error) {
 	return result, nil
 }
 
+// costTransfer sets the reference rate of a transfer between two currencies
+// and what it cost against it; both stay zero without a known rate
+func (s *Store) costTransfer(ctx context.Context, scope types.Scope, transfer *types.Transfer) error {
+	var rate *float64
+	err := s.pool.QueryRow(ctx,
+		`SELECT fx_reference_rate($1, account_currency('', $2), account_currency('', $3), $4)::FLOAT8`,
+		scope.HouseholdId, transfer.SourceAccountId, transfer.DestAccountId, transfer.Date,
+	).Scan(&rate)
+	if err != nil {
+		return fmt.Errorf("error getting reference rate: %w", err)
+	}
+	transfer.ReferenceRate, transfer.FXCost = 0, types.Money{}
+	if rate != nil {
+		transfer.CostAgainst(*rate)
+	}
+	return nil
+}
+
+// referenceRate is a transfer's reference rate as stored: NULL when it has none
+func referenceRate(transfer types.Transfer) *float64 {
+	if transfer.ReferenceRate == 0 {
+		return nil
+	}
+	return &transfer.ReferenceRate
+}
+
 // GetTransfers retrieves transfers with pagination
-func GetTransfers(limit int, offset int) ([]types.Transfer, int, error) {
-	pool, err := GetPool()
+func (s *Store) GetTransfers(ctx context.Context, limit int, offset int) ([]types.Transfer, int, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	// Get total count
 	var count int
-	err = pool.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM transfers`,
+	err = s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM transfers WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	).Scan(&count)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting transfers: %w", err)
 	}
 
 	// Get paginated results with account names
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT t.id, t.created_at, t.date, COALESCE(t.description, ''), 
 			t.source_account_id, COALESCE(sa.name, '') as source_account_name, t.source_amount, 
 			t.dest_account_id, COALESCE(da.name, '') as dest_account_name, t.dest_amount, 
-			COALESCE(t.exchange_rate, 0)
+			COALESCE(t.exchange_rate, 0), COALESCE(t.reference_rate, 0), t.fx_cost
 		 FROM transfers t
-		 LEFT JOIN accounts sa ON t.source_account_id = sa.id
-		 LEFT JOIN accounts da ON t.dest_account_id = da.id
-		 ORDER BY t.created_at DESC LIMIT $1 OFFSET $2`,
-		limit, offset,
+		 LEFT JOIN accounts sa ON t.source_account_id = sa.id AND sa.household_id = t.household_id
+		 LEFT JOIN accounts da ON t.dest_account_id = da.id AND da.household_id = t.household_id
+		 WHERE t.household_id = $3 AND (t.owner_id IS NULL OR t.owner_id = $4 OR $4 IS NULL)
+		 ORDER BY t.created_at DESC, t.id DESC LIMIT $1 OFFSET $2`,
+		limit, offset, scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying transfers: %w", err)
@@ -1367,7 +2111,7 @@ func GetTransfers(limit int, offset int) ([]types.Transfer, int, error) {
 		var t types.Transfer
 		if err := rows.Scan(&t.Id, &t.CreatedAt, &t.Date, &t.Description,
 			&t.SourceAccountId, &t.SourceAccountName, &t.SourceAmount, 
-			&t.DestAccountId, &t.DestAccountName, &t.DestAmount, &t.ExchangeRate); err != nil {
+			&t.DestAccountId, &t.DestAccountName, &t.DestAmount, &t.ExchangeRate, &t.ReferenceRate, &t.FXCost); err != nil {
 			return nil, 0, fmt.Errorf("error scanning row: %w", err)
 		}
 		results = append(results, t)
@@ -1376,20 +2120,184 @@ func GetTransfers(limit int, offset int) ([]types.Transfer, int, error) {
 	return results, count, nil
 }
 
+// GetTransferById retrieves a single transfer with account names
+func (s *Store) GetTransferById(ctx context.Context, id int32) (types.Transfer, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var t types.Transfer
+	err = s.pool.QueryRow(ctx,
+		`SELECT t.id, t.created_at, t.date, COALESCE(t.description, ''),
+			t.source_account_id, COALESCE(sa.name, ''), t.source_amount,
+			t.dest_account_id, COALESCE(da.name, ''), t.dest_amount,
+			COALESCE(t.exchange_rate, 0), COALESCE(t.reference_rate, 0), t.fx_cost
+		 FROM transfers t
+		 LEFT JOIN accounts sa ON t.source_account_id = sa.id AND sa.household_id = t.household_id
+		 LEFT JOIN accounts da ON t.dest_account_id = da.id AND da.household_id = t.household_id
+		 WHERE t.id = $1 AND t.household_id = $2 AND (t.owner_id IS NULL OR t.owner_id = $3 OR $3 IS NULL)`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&t.Id, &t.CreatedAt, &t.Date, &t.Description,
+		&t.SourceAccountId, &t.SourceAccountName, &t.SourceAmount,
+		&t.DestAccountId, &t.DestAccountName, &t.DestAmount, &t.ExchangeRate, &t.ReferenceRate, &t.FXCost)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Transfer{}, fmt.Errorf("transfer %d: %w", id, ErrNotFound)
+		}
+		return types.Transfer{}, fmt.Errorf("error querying transfer: %w", err)
+	}
+
+	return t, nil
+}
+
+// UpdateTransfer overwrites a transfer, recalculating the exchange rate from the amounts
+func (s *Store) UpdateTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// The stored rate is derived, so a changed amount must not keep the old one
+	if transfer.SourceAmount.Sign() > 0 {
+		transfer.ExchangeRate = transfer.DestAmount.Ratio(transfer.SourceAmount)
+	}
+
+	owner, err := transactionOwner(ctx, s.pool, scope,
+		fiatAccount(transfer.SourceAccountId), fiatAccount(transfer.DestAccountId))
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	if err := s.costTransfer(ctx, scope, &transfer); err != nil {
+		return types.Transfer{}, err
+	}
+
+	var result types.Transfer
+	err = s.pool.QueryRow(ctx,
+		`UPDATE transfers SET date = $2, description = $3, source_account_id = $4, source_amount = $5,
+			dest_account_id = $6, dest_amount = $7, exchange_rate = $8, owner_id = $9,
+			reference_rate = $12, fx_cost = $13
+		 WHERE id = $1 AND household_id = $10 AND (owner_id IS NULL OR owner_id = $11 OR $11 IS NULL)
+		 RETURNING id, created_at, date, description, source_account_id, source_amount, dest_account_id, dest_amount, exchange_rate,
+			COALESCE(reference_rate, 0), fx_cost`,
+		transfer.Id, transfer.Date, transfer.Description, transfer.SourceAccountId, transfer.SourceAmount,
+		transfer.DestAccountId, transfer.DestAmount, transfer.ExchangeRate, owner, scope.HouseholdId, viewer(scope),
+		referenceRate(transfer), transfer.FXCost,
+	).Scan(&result.Id, &result.CreatedAt, &result.Date, &result.Description,
+		&result.SourceAccountId, &result.SourceAmount, &result.DestAccountId, &result.DestAmount, &result.ExchangeRate,
+		&result.ReferenceRate, &result.FXCost)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Transfer{}, fmt.Errorf("transfer %d: %w", transfer.Id, ErrNotFound)
+		}
+		return types.Transfer{}, fmt.Errorf("error updating transfer: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteTransfer removes a transfer
+func (s *Store) DeleteTransfer(ctx context.Context, id int32) (types.Transfer, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.Transfer{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var result types.Transfer
+	err = s.pool.QueryRow(ctx,
+		`DELETE FROM transfers WHERE id = $1 AND household_id = $2 AND (owner_id IS NULL OR owner_id = $3 OR $3 IS NULL)
+		 RETURNING id, created_at, date, COALESCE(description, ''), source_account_id, source_amount,
+			dest_account_id, dest_amount, COALESCE(exchange_rate, 0), COALESCE(reference_rate, 0), fx_cost`,
+		id, scope.HouseholdId, viewer(scope),
+	).Scan(&result.Id, &result.CreatedAt, &result.Date, &result.Description,
+		&result.SourceAccountId, &result.SourceAmount, &result.DestAccountId, &result.DestAmount, &result.ExchangeRate,
+		&result.ReferenceRate, &result.FXCost)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return types.Transfer{}, fmt.Errorf("transfer %d: %w", id, ErrNotFound)
+		}
+		return types.Transfer{}, fmt.Errorf("error deleting transfer: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetTransferFXCosts totals what the transfers dated in period cost against
+// the reference rate, per source and destination account and calendar month
+func (s *Store) GetTransferFXCosts(ctx context.Context, period types.Period) ([]types.TransferFXCost, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT LEFT(t.date, 7),
+			t.source_account_id, COALESCE(sa.name, ''), COALESCE(sa.currency, 'USD'),
+			t.dest_account_id, COALESCE(da.name, ''), COALESCE(da.currency, 'USD'),
+			COUNT(*), SUM(t.source_amount), SUM(t.dest_amount),
+			ROUND(SUM(t.source_amount * t.reference_rate), 2), SUM(t.fx_cost),
+			ROUND(SUM(t.fx_cost * to_base(t.household_id, COALESCE(da.currency, 'USD'), t.date)), 2)
+		 FROM transfers t
+		 LEFT JOIN accounts sa ON t.source_account_id = sa.id AND sa.household_id = t.household_id
+		 LEFT JOIN accounts da ON t.dest_account_id = da.id AND da.household_id = t.household_id
+		 WHERE t.household_id = $1 AND (t.owner_id IS NULL OR t.owner_id = $2 OR $2 IS NULL)
+		   AND t.date >= $3 AND t.date < $4 AND t.reference_rate IS NOT NULL
+		 GROUP BY 1, 2, 3, 4, 5, 6, 7
+		 ORDER BY 1, 3, 6`,
+		scope.HouseholdId, viewer(scope), period.Start, period.End,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying transfer costs: %w", converted(err))
+	}
+	defer rows.Close()
+
+	var results []types.TransferFXCost
+	for rows.Next() {
+		var c types.TransferFXCost
+		if err := rows.Scan(&c.Month,
+			&c.SourceAccountId, &c.SourceAccountName, &c.SourceCurrency,
+			&c.DestAccountId, &c.DestAccountName, &c.DestCurrency,
+			&c.Transfers, &c.SourceAmount, &c.DestAmount,
+			&c.ReferenceAmount, &c.Cost, &c.BaseCost); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying transfer costs: %w", converted(err))
+	}
+
+	return results, nil
+}
+
 // ========== EXPECTED BALANCE ==========
 
-// GetAccountExpectedBalances retrieves expected balance view for all accounts
-func GetAccountExpectedBalances() ([]types.AccountExpectedBalance, error) {
-	pool, err := GetPool()
+// GetAccountExpectedBalances retrieves expected balance view for the accounts the caller can see
+func (s *Store) GetAccountExpectedBalances(ctx context.Context) ([]types.AccountExpectedBalance, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT id, name, currency, starting_balance, starting_date,
 			total_income, total_expenses, total_investment_deposits, total_investment_withdrawals,
 			total_transfers_out, total_transfers_in, expected_balance, real_balance, discrepancy
-		 FROM account_expected_balance`,
+		 FROM account_expected_balance WHERE household_id = $1 AND (owner_id IS NULL OR owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying expected balances: %w", err)
@@ -1411,13 +2319,15 @@ func GetAccountExpectedBalances() ([]types.AccountExpectedBalance, error) {
 }
 
 // GetInvestmentAccountExpectedCapital returns expected capital breakdown for investment accounts
-func GetInvestmentAccountExpectedCapital() ([]types.InvestmentAccountExpectedCapital, error) {
-	pool, err := GetPool()
+func (s *Store) GetInvestmentAccountExpectedCapital(ctx context.Context) ([]types.InvestmentAccountExpectedCapital, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	rows, err := pool.Query(context.Background(),
+	rows, err := s.pool.Query(ctx,
 		`SELECT 
 			ia.id,
 			ia.name,
@@ -1432,7 +2342,9 @@ func GetInvestmentAccountExpectedCapital() ([]types.InvestmentAccountExpectedCap
 				- COALESCE((SELECT SUM(amount) FROM investments WHERE account_id = ia.id AND type = 'withdrawal'), 0)
 				- COALESCE((SELECT SUM(expense) FROM expenses WHERE account_id = ia.id AND account_type IN ('Investment', 'Crypto', 'Broker')), 0) as expected_capital,
 			ia.balance as real_balance
-		FROM investment_accounts ia`,
+		FROM investment_accounts ia
+		WHERE ia.household_id = $1 AND (ia.owner_id IS NULL OR ia.owner_id = $2 OR $2 IS NULL)`,
+		scope.HouseholdId, viewer(scope),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying investment expected capital: %w", err)
@@ -1446,7 +2358,7 @@ func GetInvestmentAccountExpectedCapital() ([]types.InvestmentAccountExpectedCap
 			&a.TotalDeposits, &a.TotalWithdrawals, &a.TotalExpenses, &a.ExpectedCapital, &a.RealBalance); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
-		a.Discrepancy = a.RealBalance - a.ExpectedCapital // This is effectively PnL
+		a.Discrepancy = a.RealBalance.Sub(a.ExpectedCapital) // This is effectively PnL
 		results = append(results, a)
 	}
 
@@ -1455,53 +2367,69 @@ func GetInvestmentAccountExpectedCapital() ([]types.InvestmentAccountExpectedCap
 
 // ========== INSERT FUNCTIONS (migrated from supabase) ==========
 
-// InsertBudgetsIntoDatabase upserts budget records
-func InsertBudgetsIntoDatabase(budgets []types.Budget) ([]types.Budget, error) {
-	pool, err := GetPool()
+// InsertBudgetsIntoDatabase upserts each category's budget for its month; the
+// batch is saved all or nothing
+func (s *Store) InsertBudgetsIntoDatabase(ctx context.Context, budgets []types.Budget) ([]types.Budget, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	ctx := context.Background()
-	var results []types.Budget
+	// One transaction, so a batch with an unknown category saves nothing
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
+	var results []types.Budget
 	for _, b := range budgets {
+		// Only the household's own categories can be budgeted
 		var result types.Budget
-		err := pool.QueryRow(ctx,
-			`INSERT INTO budgets (category_id, budget)
-			 VALUES ($1, $2)
-			 ON CONFLICT (category_id) DO UPDATE SET budget = EXCLUDED.budget
-			 RETURNING id, category_id, budget`,
-			b.CategoryId, b.Amount,
-		).Scan(&result.Id, &result.CategoryId, &result.Amount)
+		err := tx.QueryRow(ctx,
+			`INSERT INTO budgets (category_id, budget, month, household_id)
+			 SELECT id, $2, $3, household_id FROM categories WHERE id = $1 AND household_id = $4
+			 ON CONFLICT (household_id, category_id, month) DO UPDATE SET budget = EXCLUDED.budget
+			 RETURNING id, category_id, budget, month`,
+			b.CategoryId, b.Amount, b.Month, scope.HouseholdId,
+		).Scan(&result.Id, &result.CategoryId, &result.Amount, &result.Month)
 
 		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("category %d: %w", b.CategoryId, ErrNotFound)
+			}
 			return nil, fmt.Errorf("error upserting budget: %w", err)
 		}
 		results = append(results, result)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
 	return results, nil
 }
 
 // InsertConfigIntoDatabase upserts config records
-func InsertConfigIntoDatabase(configs []types.Config) ([]types.Config, error) {
-	pool, err := GetPool()
+func (s *Store) InsertConfigIntoDatabase(ctx context.Context, configs []types.Config) ([]types.Config, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	ctx := context.Background()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 	var results []types.Config
 
 	for _, c := range configs {
 		var result types.Config
-		err := pool.QueryRow(ctx,
-			`INSERT INTO config (type, sheet, range)
-			 VALUES ($1, $2, $3)
-			 ON CONFLICT (type) DO UPDATE SET sheet = EXCLUDED.sheet, range = EXCLUDED.range
+		err := s.pool.QueryRow(ctx,
+			`INSERT INTO config (type, sheet, range, household_id)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (household_id, type) DO UPDATE SET sheet = EXCLUDED.sheet, range = EXCLUDED.range
 			 RETURNING type, sheet, range`,
-			c.Type, c.Sheet, c.A1Range,
+			c.Type, c.Sheet, c.A1Range, scope.HouseholdId,
 		).Scan(&result.Type, &result.Sheet, &result.A1Range)
 
 		if err != nil {
@@ -1513,23 +2441,30 @@ func InsertConfigIntoDatabase(configs []types.Config) ([]types.Config, error) {
 	return results, nil
 }
 
-// InsertAccountIntoDatabase inserts a new account
-func InsertAccountIntoDatabase(account types.Account) (types.Account, error) {
-	pool, err := GetPool()
+// InsertAccountIntoDatabase inserts a new account, shared with the household
+// unless OwnerId makes it private to the caller
+func (s *Store) InsertAccountIntoDatabase(ctx context.Context, account types.Account) (types.Account, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Account{}, err
 	}
+	owner, err := privateOwner(scope, account.OwnerId)
+	if err != nil {
+		return types.Account{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var result types.Account
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO accounts (name, description, type, currency, balance, starting_balance, starting_date)
-		 VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, NOW()))
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO accounts (name, description, type, currency, balance, starting_balance, starting_date, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, NOW()), $8, $9)
 		 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance,
-			COALESCE(starting_balance, 0), COALESCE(starting_date, NOW())`,
+			COALESCE(starting_balance, 0), COALESCE(starting_date, NOW()), owner_id`,
 		account.Name, account.Description, account.Type, account.Currency, account.Balance,
-		account.StartingBalance, account.StartingDate,
+		account.StartingBalance, account.StartingDate, scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance,
-		&result.StartingBalance, &result.StartingDate)
+		&result.StartingBalance, &result.StartingDate, &result.OwnerId)
 
 	if err != nil {
 		return types.Account{}, fmt.Errorf("error inserting account: %w", err)
@@ -1538,23 +2473,30 @@ func InsertAccountIntoDatabase(account types.Account) (types.Account, error) {
 	return result, nil
 }
 
-// InsertInvestmentAccountIntoDatabase inserts a new investment account
-func InsertInvestmentAccountIntoDatabase(account types.InvestmentAccount) (types.InvestmentAccount, error) {
-	pool, err := GetPool()
+// InsertInvestmentAccountIntoDatabase inserts a new investment account, shared with
+// the household unless OwnerId makes it private to the caller
+func (s *Store) InsertInvestmentAccountIntoDatabase(ctx context.Context, account types.InvestmentAccount) (types.InvestmentAccount, error) {
+	scope, err := scopeFrom(ctx)
+	if err != nil {
+		return types.InvestmentAccount{}, err
+	}
+	owner, err := privateOwner(scope, account.OwnerId)
 	if err != nil {
 		return types.InvestmentAccount{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var result types.InvestmentAccount
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO investment_accounts (name, description, type, currency, balance, capital, starting_capital, starting_date)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, NOW()))
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO investment_accounts (name, description, type, currency, balance, capital, starting_capital, starting_date, household_id, owner_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, NOW()), $9, $10)
 		 RETURNING id, name, COALESCE(description, ''), COALESCE(type, ''), COALESCE(currency, 'USD'), balance,
-			COALESCE(capital, 0), COALESCE(starting_capital, 0), COALESCE(starting_date, NOW())`,
+			COALESCE(capital, 0), COALESCE(starting_capital, 0), COALESCE(starting_date, NOW()), owner_id`,
 		account.Name, account.Description, account.Type, account.Currency, account.Balance,
-		account.Capital, account.StartingCapital, account.StartingDate,
+		account.Capital, account.StartingCapital, account.StartingDate, scope.HouseholdId, owner,
 	).Scan(&result.Id, &result.Name, &result.Description, &result.Type, &result.Currency, &result.Balance,
-		&result.Capital, &result.StartingCapital, &result.StartingDate)
+		&result.Capital, &result.StartingCapital, &result.StartingDate, &result.OwnerId)
 
 	if err != nil {
 		return types.InvestmentAccount{}, fmt.Errorf("error inserting investment account: %w", err)
@@ -1564,18 +2506,20 @@ func InsertInvestmentAccountIntoDatabase(account types.InvestmentAccount) (types
 }
 
 // InsertDebtorIntoDatabase inserts a new debtor
-func InsertDebtorIntoDatabase(debtor types.Debtor) (types.Debtor, error) {
-	pool, err := GetPool()
+func (s *Store) InsertDebtorIntoDatabase(ctx context.Context, debtor types.Debtor) (types.Debtor, error) {
+	scope, err := scopeFrom(ctx)
 	if err != nil {
 		return types.Debtor{}, err
 	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	var result types.Debtor
-	err = pool.QueryRow(context.Background(),
-		`INSERT INTO debtors (name, first_name, last_name, description)
-		 VALUES ($1, $2, $3, $4)
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO debtors (name, first_name, last_name, description, household_id)
+		 VALUES ($1, $2, $3, $4, $5)
 		 RETURNING id, name, COALESCE(first_name, ''), COALESCE(last_name, ''), COALESCE(description, '')`,
-		debtor.Name, debtor.FirstName, debtor.LastName, debtor.Description,
+		debtor.Name, debtor.FirstName, debtor.LastName, debtor.Description, scope.HouseholdId,
 	).Scan(&result.Id, &result.Name, &result.FirstName, &result.LastName, &result.Description)
 
 	if err != nil {
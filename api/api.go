@@ -1,1283 +1,1576 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
-	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
 	types "github.com/carlosdimatteo/fintrack-backend-go/types"
 	"github.com/gorilla/mux"
 )
 
-func greet(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	res := types.Response{
+func (h *Handler) greet(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Fintrack Server up",
+	})
+}
+
+func (h *Handler) getCategories(w http.ResponseWriter, r *http.Request) error {
+	categories, err := h.store.GetCategories(r.Context())
+	if err != nil {
+		return err
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, categoryList{Categories: categories})
 }
 
-func getCategories(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) createCategory(w http.ResponseWriter, r *http.Request) error {
+	var category types.Category
+	if err := decodeJSON(r, &category); err != nil {
+		return err
 	}
-	categories, err := postgres.GetCategories()
-	res := map[string][]types.Category{
-		"categories": categories,
+	if err := h.validate(r, category); err != nil {
+		return err
 	}
+
+	inserted, err := h.store.InsertCategory(r.Context(), category)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting category: %w", err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, inserted)
 }
 
-func submitExpenseRow(w http.ResponseWriter, r *http.Request) {
-	//Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
+func (h *Handler) submitExpenseRow(w http.ResponseWriter, r *http.Request) error {
 	var expense types.Expense
-	json.NewDecoder(r.Body).Decode(&expense)
+	if err := decodeJSON(r, &expense); err != nil {
+		return err
+	}
+	if err := h.validate(r, expense); err != nil {
+		return err
+	}
 	fmt.Println("received: ", expense)
 	fmt.Println("submitting row :  description:", expense.Description, " amount:", expense.OriginalAmount, " expense: ", expense.Expense)
 	fmt.Println("expense : ", expense.Expense)
-	expense.Date = time.Now().Format(time.DateTime)
+	expense.Date = transactionDate(r.Context(), expense.Date)
 
-	// 1. Get config (using postgres)
-	config, err := postgres.GetConfigByType("expenses")
+	// 1. Get config
+	config, err := h.store.GetConfigByType(r.Context(), "expenses")
 	if err != nil {
-		fmt.Println("error getting config: ", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
-	// 2. Append to sheet
-	_, err = googleSS.SubmitExpenseRow(expense, config)
+	// 2. Insert into database (synchronous, fail on error)
+	_, err = h.store.InsertExpense(r.Context(), expense)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting expense to database: %w", err)
 	}
 
-	// 3. Insert into database (synchronous, fail on error)
-	_, err = postgres.InsertExpense(expense)
-	if err != nil {
-		log.Printf("Error inserting expense to database: %v", err)
-		ServerErrorResponse(w, r)
-		return
+	// 3. Queue the sheet row
+	if err := h.sheets.EnqueueExpenseRow(r.Context(), expense, config); err != nil {
+		log.Printf("Error queuing expense row: %v", err)
 	}
 
-	res := types.Response{
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Expense submitted",
+	})
+}
+
+func (h *Handler) getExpenses(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 10)
+	if err != nil {
+		return err
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	expenses, count, err := h.store.GetExpenses(r.Context(), limit, offset)
+	if err != nil {
+		return err
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+
+	return writeJSON(w, expensePage{Expenses: expenses, Limit: limit, Offset: offset, Count: count})
 }
 
-func getExpenses(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) getExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
+	}
 
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+	expense, err := h.store.GetExpenseById(r.Context(), id)
+	if err != nil {
+		return err
 	}
-	// Get query parameters as strings
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	return writeJSON(w, expense)
+}
 
-	// Convert limit to int with error handling
-	limit := 10 // default value
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
-			return
-		}
-		limit = parsedLimit
+// updateExpense handles both PUT (replace) and PATCH (merge) and queues a rewrite
+// of the matching sheet row so the database and the sheet stay in sync
+func (h *Handler) updateExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
 
-	// Convert offset to int with error handling
-	offset := 0 // default value
-	if offsetStr != "" {
-		parsedOffset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
-			return
-		}
-		offset = parsedOffset
+	existing, err := h.store.GetExpenseById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	// PATCH decodes on top of the stored expense so omitted fields keep their value
+	var expense types.Expense
+	if r.Method == "PATCH" {
+		expense = existing
+	}
+	if err := decodeJSON(r, &expense); err != nil {
+		return err
+	}
+	expense.Id = id
+	if expense.Date == "" {
+		expense.Date = existing.Date
+	}
+	if err := h.validate(r, expense); err != nil {
+		return err
+	}
+	expense.Date = transactionDate(r.Context(), expense.Date)
+
+	config, err := h.store.GetConfigByType(r.Context(), "expenses")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
-	expenses, count, err := postgres.GetExpenses(limit, offset)
+	result, err := h.store.UpdateExpense(r.Context(), expense)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		log.Printf("Error updating expense: %v", err)
+		return rejected(err)
 	}
 
-	// Create response structure with all fields
-	res := map[string]interface{}{
-		"expenses": expenses,
-		"limit":    limit,
-		"offset":   offset,
-		"count":    count,
+	sheetQueued := true
+	if err := h.sheets.EnqueueExpenseRowUpdate(r.Context(), existing, result, config); err != nil {
+		log.Printf("Error queuing sheet update for expense %d: %v", id, err)
+		sheetQueued = false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, expenseChange{Success: true, Expense: result, SheetQueued: sheetQueued})
 }
 
-func getBudgets(w http.ResponseWriter, r *http.Request) {
+// deleteExpense removes the expense (and its linked debts) and queues clearing its sheet row
+func (h *Handler) deleteExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
+	}
 
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+	config, err := h.store.GetConfigByType(r.Context(), "expenses")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
 	}
-	budgets, err := postgres.GetBudgets()
+
+	deleted, err := h.store.DeleteExpense(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	res := map[string][]types.BudgetByCategory{
-		"budgets": budgets,
+
+	sheetQueued := true
+	if err := h.sheets.EnqueueExpenseRowDelete(r.Context(), deleted, config); err != nil {
+		log.Printf("Error queuing sheet clear for expense %d: %v", id, err)
+		sheetQueued = false
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
 
+	return writeJSON(w, expenseChange{Success: true, Expense: deleted, SheetQueued: sheetQueued})
 }
 
-func setBudgets(w http.ResponseWriter, r *http.Request) {
-	//Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// getBudgets returns the budgets with what was spent in the budget period
+// containing ?date=, the current one by default, and the income left to assign
+// to them
+func (h *Handler) getBudgets(w http.ResponseWriter, r *http.Request) error {
+	date, err := queryDate(r, "date")
+	if err != nil {
+		return err
+	}
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
 	}
+	period := household.BudgetPeriod.Containing(date)
 
-	var arrayOfBudgets []types.Budget
-	json.NewDecoder(r.Body).Decode(&arrayOfBudgets)
-	config, err := postgres.GetConfigByType(types.ConfigType["budget"])
+	// Envelopes carry over from their first period, which ready to assign also counts from
+	periods := []types.Period{period}
+	if start, ok := envelopeStart(household); ok && start <= period.Start {
+		periods = household.BudgetPeriod.Starting(start, period.End)
+	}
+	budgets, err := h.budgetsIn(r.Context(), household, periods)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	_, err = googleSS.SubmitBudget(arrayOfBudgets, config)
+	readyToAssign, err := h.readyToAssign(r.Context(), periods, budgets)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
+	return writeJSON(w, budgetList{
+		Period:        period,
+		BudgetMode:    household.BudgetMode,
+		Budgets:       budgets[len(budgets)-1],
+		ReadyToAssign: readyToAssign,
+	})
+}
 
-	res := types.Response{
-		Success: true,
-		Message: "Row submitted",
+// maxBudgetHistoryMonths bounds the range of a budget history request
+const maxBudgetHistoryMonths = 36
+
+// BudgetHistoryResponse is budget against spending for every budget period
+// starting in a range of months, and over the whole range
+type BudgetHistoryResponse struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Periods  []BudgetReport `json:"periods"`
+	Amount   types.Money    `json:"amount"`
+	Spent    types.Money    `json:"spent"`
+	Variance types.Money    `json:"variance"` // below zero is over budget
+}
+
+// BudgetReport is budget against spending in one budget period
+type BudgetReport struct {
+	Period     types.Period             `json:"period"`
+	Month      string                   `json:"month"` // the budget month the amounts are in force for
+	Categories []types.BudgetByCategory `json:"categories"`
+	Amount     types.Money              `json:"amount"`
+	Available  types.Money              `json:"available"` // the amount plus what envelopes carried over and moved
+	Spent      types.Money              `json:"spent"`
+	Variance   types.Money              `json:"variance"` // the amount less spent, like the range's
+}
+
+// getBudgetHistory reports budget against spending per category for each
+// budget period starting from ?from= to ?to= (months like 2006-01, both
+// included; the current budget month by default)
+func (h *Handler) getBudgetHistory(w http.ResponseWriter, r *http.Request) error {
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
 	}
-	w.Header().Set("Content-Type", "application/json")
+	current := household.BudgetPeriod.Containing(types.Now(r.Context())).Month()
 
-	json.NewEncoder(w).Encode(res)
+	from, err := queryMonth(r, "from", current)
+	if err != nil {
+		return err
+	}
+	to, err := queryMonth(r, "to", current)
+	if err != nil {
+		return err
+	}
+	fromYear, fromMonth, _ := types.ParseMonth(from)
+	toYear, toMonth, _ := types.ParseMonth(to)
+	months := (toYear-fromYear)*12 + toMonth - fromMonth + 1
+	if months < 1 {
+		return badRequest("from must not be after to")
+	}
+	if months > maxBudgetHistoryMonths {
+		return badRequest("At most %d months can be requested at once", maxBudgetHistoryMonths)
+	}
 
-	go func() {
-		_, err = postgres.InsertBudgetsIntoDatabase(arrayOfBudgets)
-		if err != nil {
-			log.Fatal(err)
-			return
+	start, _ := types.MonthRange(fromYear, fromMonth)
+	_, end := types.MonthRange(toYear, toMonth)
+	res := BudgetHistoryResponse{From: from, To: to, Periods: []BudgetReport{}}
+	periods := household.BudgetPeriod.Starting(start, end)
+	budgets, err := h.budgetsIn(r.Context(), household, periods)
+	if err != nil {
+		return err
+	}
+	for i, period := range periods {
+		report := BudgetReport{Period: period, Month: period.Month(), Categories: budgets[i]}
+		for _, b := range report.Categories {
+			report.Amount = report.Amount.Add(b.Amount)
+			report.Available = report.Available.Add(b.Available)
+			report.Spent = report.Spent.Add(b.Spent)
 		}
-	}()
+		report.Variance = report.Amount.Sub(report.Spent)
 
+		res.Periods = append(res.Periods, report)
+		res.Amount = res.Amount.Add(report.Amount)
+		res.Spent = res.Spent.Add(report.Spent)
+	}
+	res.Variance = res.Amount.Sub(res.Spent)
+	return writeJSON(w, res)
 }
-func getConfig(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+
+func (h *Handler) setBudgets(w http.ResponseWriter, r *http.Request) error {
+	var arrayOfBudgets []types.Budget
+	if err := decodeJSON(r, &arrayOfBudgets); err != nil {
+		return err
 	}
-	config, err := postgres.GetConfig()
+	if err := h.validate(r, types.Budgets(arrayOfBudgets)); err != nil {
+		return err
+	}
+	configs, err := h.store.GetConfig(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	res := map[string][]types.Config{
-		"config": config,
+	budgetConfig, ok := configOfType(configs, types.ConfigType["budget"])
+	if !ok {
+		return fmt.Errorf("config not found for type: %s", types.ConfigType["budget"])
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
-}
-func setConfig(w http.ResponseWriter, r *http.Request) {
-	//Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+	// A budget without a month applies from the current budget month on
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+	current := household.BudgetPeriod.Containing(types.Now(r.Context())).Month()
+	for i := range arrayOfBudgets {
+		if arrayOfBudgets[i].Month == "" {
+			arrayOfBudgets[i].Month = current
+		}
 	}
 
-	var arrayOfConfig []types.Config
-	json.NewDecoder(r.Body).Decode(&arrayOfConfig)
-	_, err := postgres.InsertConfigIntoDatabase(arrayOfConfig)
+	_, err = h.store.InsertBudgetsIntoDatabase(r.Context(), arrayOfBudgets)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting budgets to database: %w", err)
+	}
+
+	// Each month's budgets go to its own budget_<month> range when the sheet
+	// has one, and to the budget range otherwise
+	months := []string{}
+	byMonth := map[string][]types.Budget{}
+	for _, budget := range arrayOfBudgets {
+		if _, ok := byMonth[budget.Month]; !ok {
+			months = append(months, budget.Month)
+		}
+		byMonth[budget.Month] = append(byMonth[budget.Month], budget)
+	}
+	for _, month := range months {
+		config, ok := configOfType(configs, types.ConfigType["budget"]+"_"+month)
+		if !ok {
+			config = budgetConfig
+		}
+		if err := h.sheets.EnqueueBudget(r.Context(), byMonth[month], config); err != nil {
+			log.Printf("Error queuing budget rows: %v", err)
+		}
 	}
 
-	res := types.Response{
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Row submitted",
-	}
-	w.Header().Set("Content-Type", "application/json")
+	})
+}
 
-	json.NewEncoder(w).Encode(res)
+func (h *Handler) getConfig(w http.ResponseWriter, r *http.Request) error {
+	config, err := h.store.GetConfig(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, configList{Config: config})
 }
 
-func submitInvestment(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+func (h *Handler) setConfig(w http.ResponseWriter, r *http.Request) error {
+	var arrayOfConfig []types.Config
+	if err := decodeJSON(r, &arrayOfConfig); err != nil {
+		return err
+	}
+	if err := h.validate(r, types.Configs(arrayOfConfig)); err != nil {
+		return err
+	}
+	_, err := h.store.InsertConfigIntoDatabase(r.Context(), arrayOfConfig)
+	if err != nil {
+		return err
+	}
 
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	return writeJSON(w, types.Response{
+		Success: true,
+		Message: "Row submitted",
+	})
+}
 
-	if r.Method == "OPTIONS" {
-		return
-	}
+func (h *Handler) submitInvestment(w http.ResponseWriter, r *http.Request) error {
 	var investment types.Investment
-	json.NewDecoder(r.Body).Decode(&investment)
+	if err := decodeJSON(r, &investment); err != nil {
+		return err
+	}
+	if err := h.validate(r, investment); err != nil {
+		return err
+	}
 	fmt.Println("received investment: ", investment)
 	fmt.Println("submitting row :  description:", investment.Description, " amount:", investment.Amount, " account: ", investment.AccountName, " type: ", investment.Type)
 
-	// Validate type
-	if investment.Type != "deposit" && investment.Type != "withdrawal" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{
-			Success: false,
-			Message: "Invalid type: must be 'deposit' or 'withdrawal'",
-		})
-		return
-	}
-
-	investment.Date = time.Now().Format(time.DateTime)
+	investment.Date = transactionDate(r.Context(), investment.Date)
 
 	// 1. Get config for investment row append
-	config, err := postgres.GetConfigByType("investments")
+	config, err := h.store.GetConfigByType(r.Context(), "investments")
 	if err != nil {
-		fmt.Println("error getting config: ", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
-	// 2. Append investment row to sheet
-	_, err = googleSS.SubmitInvestment(investment, config)
+	// 2. Insert investment and update capital (fail on error)
+	_, err = h.store.InsertInvestment(r.Context(), investment)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting investment to database: %w", err)
 	}
 
-	// 3. Insert investment and update capital (using postgres, fail on error)
-	_, err = postgres.InsertInvestment(investment)
-	if err != nil {
-		log.Printf("Error inserting investment to database: %v", err)
-		ServerErrorResponse(w, r)
-		return
+	// 3. Queue the investment row and the capital cell
+	if err := h.sheets.EnqueueInvestment(r.Context(), investment, config); err != nil {
+		log.Printf("Error queuing investment row: %v", err)
 	}
+	h.refreshInvestmentCapitalCell(r.Context(), investment.AccountId)
 
-	// 4. Update capital cell in sheet (async)
-	go func() {
-		// Get updated capital
-		capital, err := postgres.GetInvestmentAccountCapital(investment.AccountId)
-		if err != nil {
-			log.Printf("Error getting account capital: %v", err)
-			return
-		}
-
-		// Investment account row in Fintrack Config: L{id+2}
-		// id=1 -> L3, id=2 -> L4, id=3 -> L5
-		row := int(investment.AccountId) + 2
-		cellRange := fmt.Sprintf("Fintrack Config!L%d", row)
-
-		err = googleSS.UpdateSheetCell(cellRange, capital)
-		if err != nil {
-			log.Printf("Error updating capital cell: %v", err)
-			return
-		}
-
-		log.Printf("Updated capital for account %d: %.2f in cell %s", investment.AccountId, capital, cellRange)
-	}()
-
-	res := types.Response{
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Investment submitted",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	})
 }
-func submitDebt(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-	if r.Method == "OPTIONS" {
-		return
-	}
+func (h *Handler) submitDebt(w http.ResponseWriter, r *http.Request) error {
 	var debt types.Debt
-	json.NewDecoder(r.Body).Decode(&debt)
+	if err := decodeJSON(r, &debt); err != nil {
+		return err
+	}
+	if err := h.validate(r, debt); err != nil {
+		return err
+	}
 	fmt.Println("received debt: ", debt)
 	fmt.Println("submitting row :  description:", debt.Description, " amount:", debt.Amount, " debtor: ", debt.DebtorName)
 	fmt.Println("amount : ", debt.Amount)
-	debt.Date = time.Now().Format(time.DateTime)
-	config, err := postgres.GetConfigByType("debt")
+	debt.Date = transactionDate(r.Context(), debt.Date)
+	config, err := h.store.GetConfigByType(r.Context(), "debt")
 	if err != nil {
-		fmt.Println("error getting config: ", err)
-		ServerErrorResponse(w, r)
-		return
-	}
-	_, err = googleSS.SubmitDebt(debt, config)
-	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
 	// Insert into database (fail on error)
-	_, err = postgres.InsertDebt(debt)
+	_, err = h.store.InsertDebt(r.Context(), debt)
 	if err != nil {
-		log.Printf("Error inserting debt to database: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting debt to database: %w", err)
+	}
+
+	if err := h.sheets.EnqueueDebt(r.Context(), debt, config); err != nil {
+		log.Printf("Error queuing debt row: %v", err)
 	}
 
-	res := types.Response{
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Debt submitted",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	})
 }
 
-func submitIncome(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
+func (h *Handler) submitIncome(w http.ResponseWriter, r *http.Request) error {
 	var income types.Income
-	json.NewDecoder(r.Body).Decode(&income)
+	if err := decodeJSON(r, &income); err != nil {
+		return err
+	}
+	if err := h.validate(r, income); err != nil {
+		return err
+	}
 	fmt.Println("received income: ", income)
 	fmt.Println("submitting row :  description:", income.Description, " amount:", income.Amount, " account: ", income.AccountName)
 	fmt.Println("amount : ", income.Amount)
-	income.Date = time.Now().Format(time.DateTime)
+	income.Date = transactionDate(r.Context(), income.Date)
 
-	// 1. Get config for income row append (using postgres now)
-	config, err := postgres.GetConfigByType("income")
+	// 1. Get config for income row append
+	config, err := h.store.GetConfigByType(r.Context(), "income")
 	if err != nil {
-		fmt.Println("error getting config: ", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
-	// 2. Append income row to sheet
-	_, err = googleSS.SubmitIncome(income, config)
+	// 2. Insert income into database (fail on error)
+	_, err = h.store.InsertIncome(r.Context(), income)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error inserting income to database: %w", err)
 	}
 
-	// 3. Insert income into database (using postgres now, fail on error)
-	_, err = postgres.InsertIncome(income)
-	if err != nil {
-		log.Printf("Error inserting income to database: %v", err)
-		ServerErrorResponse(w, r)
-		return
+	// 3. Queue the income row and the monthly income sum
+	if err := h.sheets.EnqueueIncome(r.Context(), income, config); err != nil {
+		log.Printf("Error queuing income row: %v", err)
 	}
+	year, month := transactionMonth(r.Context(), income.Date)
+	h.refreshMonthlyIncomeCell(r.Context(), year, month)
 
-	// 4. Update monthly income sum in sheet (async)
-	go func() {
-		now := time.Now()
-		year := now.Year()
-		month := int(now.Month())
+	return writeJSON(w, types.Response{
+		Success: true,
+		Message: "Row submitted",
+	})
+}
 
-		// Get monthly config (using postgres)
-		monthlyConfig, err := postgres.GetConfigByType("income_monthly")
-		if err != nil {
-			log.Printf("Error getting income_monthly config: %v", err)
-			return
+// configOfType returns the config of configType among configs
+func configOfType(configs []types.Config, configType string) (types.Config, bool) {
+	for _, config := range configs {
+		if config.Type == configType {
+			return config, true
 		}
+	}
+	return types.Config{}, false
+}
 
-		// Get sum for this month (using postgres)
-		sum, err := postgres.GetMonthlyIncomeSum(year, month)
-		if err != nil {
-			log.Printf("Error getting monthly income sum: %v", err)
-			return
-		}
+// refreshMonthlyIncomeCell queues a rewrite of the income_monthly cell for year/month with the current sum
+// and tells whether it was queued. It runs after the income is committed, so it outlives a cancelled request.
+func (h *Handler) refreshMonthlyIncomeCell(ctx context.Context, year int, month int) bool {
+	ctx = context.WithoutCancel(ctx)
 
-		// Calculate the cell for this month
-		cellRange := googleSS.CalculateMonthlyCellRange(monthlyConfig.Sheet, monthlyConfig.A1Range, month)
+	// Get monthly config
+	monthlyConfig, err := h.store.GetConfigByType(ctx, "income_monthly")
+	if err != nil {
+		log.Printf("Error getting income_monthly config: %v", err)
+		return false
+	}
 
-		// Update the cell
-		err = googleSS.UpdateSheetCell(cellRange, sum)
-		if err != nil {
-			log.Printf("Error updating monthly income cell: %v", err)
-			return
-		}
+	// The cells only hold one year's months: leave them alone for another year
+	if sheetYear := monthlySheetYear(ctx, monthlyConfig); year != sheetYear {
+		log.Printf("Skipping monthly income for %d/%d: the income_monthly cells are %d's", month, year, sheetYear)
+		return true
+	}
 
-		log.Printf("Updated monthly income for %d/%d: %.2f in cell %s", month, year, sum, cellRange)
-	}()
+	// Get sum for this month; the sheet has a cell per calendar month
+	sum, err := h.store.GetIncomeSum(ctx, types.MonthPeriod(year, month))
+	if err != nil {
+		log.Printf("Error getting monthly income sum: %v", err)
+		return false
+	}
 
-	res := types.Response{
-		Success: true,
-		Message: "Row submitted",
+	// Calculate the cell for this month
+	cellRange := googleSS.CalculateMonthlyCellRange(monthlyConfig.Sheet, monthlyConfig.A1Range, month)
+
+	// Queue the cell update
+	err = h.sheets.EnqueueSheetCell(ctx, cellRange, sum.Float64())
+	if err != nil {
+		log.Printf("Error queuing monthly income cell: %v", err)
+		return false
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+
+	log.Printf("Queued monthly income for %d/%d: %s in cell %s", month, year, sum, cellRange)
+	return true
 }
 
-func getIncomes(w http.ResponseWriter, r *http.Request) {
+// sheetYearPattern finds the year in a sheet name such as "2026" or "2024 Fintrack"
+var sheetYearPattern = regexp.MustCompile(`\b\d{4}\b`)
 
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// monthlySheetYear is the year a monthly config's cells are for: the one its
+// sheet is named after, or the current year when the name has none
+func monthlySheetYear(ctx context.Context, config types.Config) int {
+	if match := sheetYearPattern.FindString(config.Sheet); match != "" {
+		if year, err := strconv.Atoi(match); err == nil {
+			return year
+		}
 	}
-	// Get query parameters as strings
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	return types.Now(ctx).Year()
+}
 
-	// Convert limit to int with error handling
-	limit := 10 // default value
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
-			return
-		}
-		limit = parsedLimit
+// refreshInvestmentCapitalCell queues a rewrite of an investment account's capital cell, found by
+// the account's name in the household's investment_capital config range, and tells whether it was
+// queued. Like refreshMonthlyIncomeCell it outlives a cancelled request.
+func (h *Handler) refreshInvestmentCapitalCell(ctx context.Context, accountId int32) bool {
+	ctx = context.WithoutCancel(ctx)
+
+	// Get updated capital
+	capital, err := h.store.GetInvestmentAccountCapital(ctx, accountId)
+	if err != nil {
+		log.Printf("Error getting account capital: %v", err)
+		return false
 	}
 
-	// Convert offset to int with error handling
-	offset := 0 // default value
-	if offsetStr != "" {
-		parsedOffset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
-			return
+	// Get capital config
+	configs, err := h.store.GetConfig(ctx)
+	if err != nil {
+		log.Printf("Error getting config: %v", err)
+		return false
+	}
+	capitalConfig, ok := configOfType(configs, "investment_capital")
+
+	// A sheet set up before investment_capital keeps its capital column at
+	// Fintrack Config!L{id+2}: id=1 -> L3, id=2 -> L4
+	if !ok {
+		cellRange := fmt.Sprintf("Fintrack Config!L%d", int(accountId)+2)
+		if err := h.sheets.EnqueueSheetCell(ctx, cellRange, capital.Float64()); err != nil {
+			log.Printf("Error queuing capital cell: %v", err)
+			return false
 		}
-		offset = parsedOffset
+		log.Printf("Queued capital for account %d: %s in cell %s", accountId, capital, cellRange)
+		return true
 	}
 
-	incomes, count, err := postgres.GetIncomes(limit, offset)
+	// Get the account, whose name is its key in the config tab
+	accounts, err := h.store.GetInvestmentAccounts(ctx)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		log.Printf("Error getting investment accounts: %v", err)
+		return false
+	}
+	accountName := ""
+	for _, account := range accounts {
+		if account.Id == accountId {
+			accountName = account.Name
+		}
+	}
+	if accountName == "" {
+		log.Printf("Error queuing capital cell: investment account %d not found", accountId)
+		return false
 	}
 
-	// Create response structure with all fields
-	res := map[string]interface{}{
-		"incomes": incomes,
-		"limit":   limit,
-		"offset":  offset,
-		"count":   count,
+	err = h.sheets.EnqueueInvestmentCapital(ctx, accountName, capital, capitalConfig)
+	if err != nil {
+		log.Printf("Error queuing capital cell: %v", err)
+		return false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	log.Printf("Queued capital for account %s: %s", accountName, capital)
+	return true
 }
 
-func getAccounts(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// transactionDate is the stored form of a validated transaction date: the
+// wall-clock time in the household's timezone, now when the client left it out
+func transactionDate(ctx context.Context, date string) string {
+	now := types.Now(ctx)
+	if date == "" {
+		return types.FormatDate(now)
 	}
-	accounts, err := postgres.GetAccounts()
+	t, err := types.ParseDateIn(date, now.Location())
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return date
 	}
-	res := map[string][]types.Account{
-		"accounts": accounts,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return types.FormatDate(t)
 }
 
-func createAccount(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+// transactionMonth returns the year and month a stored transaction date falls in
+func transactionMonth(ctx context.Context, date string) (int, int) {
+	layouts := []string{time.DateTime, time.RFC3339Nano, "2006-01-02 15:04:05Z07", "2006-01-02 15:04:05.999999Z07", time.DateOnly}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Year(), int(t.Month())
+		}
+	}
+	now := types.Now(ctx)
+	return now.Year(), int(now.Month())
+}
 
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+func (h *Handler) getIncome(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
+	}
 
-	if r.Method == "OPTIONS" {
-		return
+	income, err := h.store.GetIncomeById(r.Context(), id)
+	if err != nil {
+		return err
 	}
-	var accountToInsert types.Account
-	json.NewDecoder(r.Body).Decode(&accountToInsert)
-	fmt.Println("received account: ", accountToInsert)
-	config, err := postgres.GetConfigByType("accounts")
+	return writeJSON(w, income)
+}
+
+// updateIncome handles PUT (replace) and PATCH (merge), queues rewriting its sheet
+// row and refreshes the monthly income cell of both the old and the new month
+func (h *Handler) updateIncome(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	account, err := postgres.InsertAccountIntoDatabase(accountToInsert)
+
+	existing, err := h.store.GetIncomeById(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
+
+	// PATCH decodes on top of the stored income so omitted fields keep their value
+	var income types.Income
+	if r.Method == "PATCH" {
+		income = existing
+	}
+	if err := decodeJSON(r, &income); err != nil {
+		return err
+	}
+	income.Id = id
+	if income.Date == "" {
+		income.Date = existing.Date
+	}
+	if err := h.validate(r, income); err != nil {
+		return err
+	}
+	income.Date = transactionDate(r.Context(), income.Date)
+
+	config, err := h.store.GetConfigByType(r.Context(), "income")
 	if err != nil {
-		fmt.Println("error getting config: ", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
-	_, err = googleSS.SubmitAccount(account, config)
+
+	result, err := h.store.UpdateIncome(r.Context(), income)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		log.Printf("Error updating income: %v", err)
+		return rejected(err)
 	}
 
-	res := types.Response{
-		Success: true,
-		Message: "Row submitted",
+	sheetQueued := true
+	if err := h.sheets.EnqueueIncomeRowUpdate(r.Context(), existing, result, config); err != nil {
+		log.Printf("Error queuing sheet update for income %d: %v", id, err)
+		sheetQueued = false
+	}
+	oldYear, oldMonth := transactionMonth(r.Context(), existing.Date)
+	newYear, newMonth := transactionMonth(r.Context(), result.Date)
+	sheetQueued = h.refreshMonthlyIncomeCell(r.Context(), newYear, newMonth) && sheetQueued
+	if oldYear != newYear || oldMonth != newMonth {
+		sheetQueued = h.refreshMonthlyIncomeCell(r.Context(), oldYear, oldMonth) && sheetQueued
 	}
-	w.Header().Set("Content-Type", "application/json")
 
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, incomeChange{Success: true, Income: result, SheetQueued: sheetQueued})
 }
 
-func getInvestments(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// deleteIncome removes the income (and a linked repayment debt), queues clearing
+// its sheet row and refreshes its monthly cell
+func (h *Handler) deleteIncome(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
 
-	// Parse query params
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-	accountIdStr := r.URL.Query().Get("account_id")
+	config, err := h.store.GetConfigByType(r.Context(), "income")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
+	}
 
-	limit := 50
-	offset := 0
-	var accountId *int32
+	deleted, err := h.store.DeleteIncome(r.Context(), id)
+	if err != nil {
+		return err
+	}
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	sheetQueued := true
+	if err := h.sheets.EnqueueIncomeRowDelete(r.Context(), deleted, config); err != nil {
+		log.Printf("Error queuing sheet clear for income %d: %v", id, err)
+		sheetQueued = false
 	}
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	year, month := transactionMonth(r.Context(), deleted.Date)
+	sheetQueued = h.refreshMonthlyIncomeCell(r.Context(), year, month) && sheetQueued
+
+	return writeJSON(w, incomeChange{Success: true, Income: deleted, SheetQueued: sheetQueued})
+}
+
+func (h *Handler) getIncomes(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 10)
+	if err != nil {
+		return err
 	}
-	if accountIdStr != "" {
-		if id, err := strconv.Atoi(accountIdStr); err == nil {
-			id32 := int32(id)
-			accountId = &id32
-		}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return err
 	}
 
-	investments, count, err := postgres.GetInvestments(limit, offset, accountId)
+	incomes, count, err := h.store.GetIncomes(r.Context(), limit, offset)
 	if err != nil {
-		log.Printf("Error getting investments: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
 
-	res := map[string]interface{}{
-		"investments": investments,
-		"total":       count,
-		"limit":       limit,
-		"offset":      offset,
+	return writeJSON(w, incomePage{Incomes: incomes, Limit: limit, Offset: offset, Count: count})
+}
+
+func (h *Handler) getAccounts(w http.ResponseWriter, r *http.Request) error {
+	accounts, err := h.store.GetAccounts(r.Context())
+	if err != nil {
+		return err
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, accountList{Accounts: accounts})
 }
 
-func getInvestmentAccounts(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) createAccount(w http.ResponseWriter, r *http.Request) error {
+	var accountToInsert types.Account
+	if err := decodeJSON(r, &accountToInsert); err != nil {
+		return err
+	}
+	if err := h.validate(r, accountToInsert); err != nil {
+		return err
 	}
-	accounts, err := postgres.GetInvestmentAccounts()
+	fmt.Println("received account: ", accountToInsert)
+	config, err := h.store.GetConfigByType(r.Context(), "accounts")
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting config: %w", err)
 	}
-	res := map[string][]types.InvestmentAccount{
-		"accounts": accounts,
+	account, err := h.store.InsertAccountIntoDatabase(r.Context(), accountToInsert)
+	if err != nil {
+		return err
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	if err := h.sheets.EnqueueAccount(r.Context(), account, config); err != nil {
+		log.Printf("Error queuing account row: %v", err)
+	}
+
+	return writeJSON(w, types.Response{
+		Success: true,
+		Message: "Row submitted",
+	})
 }
 
-func createInvestmentAccount(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+func (h *Handler) getInvestments(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	var accountId *int32
+	if r.URL.Query().Get("account_id") != "" {
+		id, err := queryInt(r, "account_id", 0)
+		if err != nil {
+			return err
+		}
+		id32 := int32(id)
+		accountId = &id32
+	}
 
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	investments, count, err := h.store.GetInvestments(r.Context(), limit, offset, accountId)
+	if err != nil {
+		return fmt.Errorf("error getting investments: %w", err)
+	}
+
+	return writeJSON(w, investmentPage{Investments: investments, Total: count, Limit: limit, Offset: offset})
+}
 
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) getInvestment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
-	var accountToInsert types.InvestmentAccount
 
-	json.NewDecoder(r.Body).Decode(&accountToInsert)
-	fmt.Println("received account: ", accountToInsert)
-	config, err := postgres.GetConfigByType("investment_accounts")
+	investment, err := h.store.GetInvestmentById(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	account, err := postgres.InsertInvestmentAccountIntoDatabase(accountToInsert)
+	return writeJSON(w, investment)
+}
+
+// updateInvestment handles PUT (replace) and PATCH (merge); capital is moved in the
+// same transaction, its sheet row is rewritten and the capital cell of every
+// touched account is refreshed
+func (h *Handler) updateInvestment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	_, err = googleSS.SubmitInvestmentAccount(account, config)
+
+	existing, err := h.store.GetInvestmentById(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
 
-	res := types.Response{
-		Success: true,
-		Message: "Row submitted",
+	// PATCH decodes on top of the stored investment so omitted fields keep their value
+	var investment types.Investment
+	if r.Method == "PATCH" {
+		investment = existing
+	}
+	if err := decodeJSON(r, &investment); err != nil {
+		return err
+	}
+	investment.Id = id
+	if investment.Date == "" {
+		investment.Date = existing.Date
+	}
+	if err := h.validate(r, investment); err != nil {
+		return err
 	}
-	w.Header().Set("Content-Type", "application/json")
+	investment.Date = transactionDate(r.Context(), investment.Date)
 
-	json.NewEncoder(w).Encode(res)
+	config, err := h.store.GetConfigByType(r.Context(), "investments")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
+	}
+
+	result, err := h.store.UpdateInvestment(r.Context(), investment)
+	if err != nil {
+		log.Printf("Error updating investment: %v", err)
+		return rejected(err)
+	}
+
+	sheetQueued := true
+	if err := h.sheets.EnqueueInvestmentRowUpdate(r.Context(), existing, result, config); err != nil {
+		log.Printf("Error queuing sheet update for investment %d: %v", id, err)
+		sheetQueued = false
+	}
+	sheetQueued = h.refreshInvestmentCapitalCell(r.Context(), result.AccountId) && sheetQueued
+	if existing.AccountId != result.AccountId {
+		sheetQueued = h.refreshInvestmentCapitalCell(r.Context(), existing.AccountId) && sheetQueued
+	}
+
+	return writeJSON(w, investmentChange{Success: true, Investment: result, SheetQueued: sheetQueued})
 }
 
-func getDebtors(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// deleteInvestment removes the investment, reverses its capital change, queues
+// clearing its sheet row and refreshes the capital cell
+func (h *Handler) deleteInvestment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
+	}
+
+	config, err := h.store.GetConfigByType(r.Context(), "investments")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
 	}
-	debtors, err := postgres.GetDebtors()
+
+	deleted, err := h.store.DeleteInvestment(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	res := map[string][]types.Debtor{
-		"debtors": debtors,
+
+	sheetQueued := true
+	if err := h.sheets.EnqueueInvestmentRowDelete(r.Context(), deleted, config); err != nil {
+		log.Printf("Error queuing sheet clear for investment %d: %v", id, err)
+		sheetQueued = false
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	sheetQueued = h.refreshInvestmentCapitalCell(r.Context(), deleted.AccountId) && sheetQueued
+
+	return writeJSON(w, investmentChange{Success: true, Investment: deleted, SheetQueued: sheetQueued})
 }
 
-func getDebtorsWithDebts(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) getInvestmentAccounts(w http.ResponseWriter, r *http.Request) error {
+	accounts, err := h.store.GetInvestmentAccounts(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, investmentAccountList{Accounts: accounts})
+}
 
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) createInvestmentAccount(w http.ResponseWriter, r *http.Request) error {
+	var accountToInsert types.InvestmentAccount
+	if err := decodeJSON(r, &accountToInsert); err != nil {
+		return err
 	}
-	result, err := postgres.GetDebtorsWithDebts()
+	if err := h.validate(r, accountToInsert); err != nil {
+		return err
+	}
+	fmt.Println("received account: ", accountToInsert)
+	config, err := h.store.GetConfigByType(r.Context(), "investment_accounts")
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	res := map[string][]types.DebtByDebtor{
-		"result": result,
+	account, err := h.store.InsertInvestmentAccountIntoDatabase(r.Context(), accountToInsert)
+	if err != nil {
+		return err
+	}
+	if err := h.sheets.EnqueueInvestmentAccount(r.Context(), account, config); err != nil {
+		log.Printf("Error queuing investment account row: %v", err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
-}
 
-func createDebtor(w http.ResponseWriter, r *http.Request) {
-	// Allow CORS here By * or specific origin
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	return writeJSON(w, types.Response{
+		Success: true,
+		Message: "Row submitted",
+	})
+}
 
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+func (h *Handler) getDebtors(w http.ResponseWriter, r *http.Request) error {
+	debtors, err := h.store.GetDebtors(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, debtorList{Debtors: debtors})
+}
 
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) getDebtorsWithDebts(w http.ResponseWriter, r *http.Request) error {
+	result, err := h.store.GetDebtorsWithDebts(r.Context())
+	if err != nil {
+		return err
 	}
+	return writeJSON(w, debtorDebtList{Result: result})
+}
+
+func (h *Handler) createDebtor(w http.ResponseWriter, r *http.Request) error {
 	var debtorToInsert types.Debtor
-	json.NewDecoder(r.Body).Decode(&debtorToInsert)
+	if err := decodeJSON(r, &debtorToInsert); err != nil {
+		return err
+	}
+	if err := h.validate(r, debtorToInsert); err != nil {
+		return err
+	}
 	fmt.Println("received account: ", debtorToInsert)
-	config, err := postgres.GetConfigByType("debtors")
+	config, err := h.store.GetConfigByType(r.Context(), "debtors")
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	debtor, err := postgres.InsertDebtorIntoDatabase(debtorToInsert)
+	debtor, err := h.store.InsertDebtorIntoDatabase(r.Context(), debtorToInsert)
 	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
-	_, err = googleSS.SubmitDebtor(debtor, config)
-	if err != nil {
-		ServerErrorResponse(w, r)
-		return
+	if err := h.sheets.EnqueueDebtor(r.Context(), debtor, config); err != nil {
+		log.Printf("Error queuing debtor row: %v", err)
 	}
-	res := types.Response{
+
+	return writeJSON(w, types.Response{
 		Success: true,
 		Message: "Row submitted",
-	}
-	w.Header().Set("Content-Type", "application/json")
-
-	json.NewEncoder(w).Encode(res)
+	})
 }
 
-func setAccountingForCurrentMonth(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) setAccountingForCurrentMonth(w http.ResponseWriter, r *http.Request) error {
 	// get account array for accounts and for investment_accounts , then do update for each on balance
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
 	var accountToInsert types.RealBalanceByAccounts
 	res := types.RealBalanceByAccounts{Accounts: []types.Account{}, InvestmentAccounts: []types.InvestmentAccount{}}
-	json.NewDecoder(r.Body).Decode(&accountToInsert)
+	if err := decodeJSON(r, &accountToInsert); err != nil {
+		return err
+	}
+	if err := h.validate(r, accountToInsert); err != nil {
+		return err
+	}
 
 	if len(accountToInsert.Accounts) > 0 {
-		accounts, err := postgres.UpdateAccountBalances(accountToInsert.Accounts)
+		accounts, err := h.store.UpdateAccountBalances(r.Context(), accountToInsert.Accounts)
 		if err != nil {
-			log.Printf("Error updating account balances: %v", err)
-			ServerErrorResponse(w, r)
-			return
+			return fmt.Errorf("error updating account balances: %w", err)
 		}
-		accountConfig, err := postgres.GetConfigByType(types.ConfigType["accounting_accounts"])
+		accountConfig, err := h.store.GetConfigByType(r.Context(), types.ConfigType["accounting_accounts"])
 		if err != nil {
-			log.Printf("Error getting accounting_accounts config: %v", err)
-			ServerErrorResponse(w, r)
-			return
+			return fmt.Errorf("error getting accounting_accounts config: %w", err)
 		}
 
-		_, err = googleSS.UpdateAccountBalances(accounts, accountConfig)
-		if err != nil {
-			log.Printf("Error updating sheet account balances: %v", err)
-			ServerErrorResponse(w, r)
-			return
+		if err := h.sheets.EnqueueAccountBalances(r.Context(), accounts, accountConfig); err != nil {
+			log.Printf("Error queuing sheet account balances: %v", err)
 		}
 
 		res.Accounts = accounts
 	}
 
 	if len(accountToInsert.InvestmentAccounts) > 0 {
-		investmentAccounts, err := postgres.UpdateInvestmentAccountBalances(accountToInsert.InvestmentAccounts)
+		investmentAccounts, err := h.store.UpdateInvestmentAccountBalances(r.Context(), accountToInsert.InvestmentAccounts)
 		if err != nil {
-			log.Printf("Error updating investment account balances: %v", err)
-			ServerErrorResponse(w, r)
-			return
+			return fmt.Errorf("error updating investment account balances: %w", err)
 		}
-		investmentAccountConfig, err := postgres.GetConfigByType(types.ConfigType["accounting_investment_accounts"])
+		investmentAccountConfig, err := h.store.GetConfigByType(r.Context(), types.ConfigType["accounting_investment_accounts"])
 		if err != nil {
-			log.Printf("Error getting accounting_investment_accounts config: %v", err)
-			ServerErrorResponse(w, r)
-			return
+			return fmt.Errorf("error getting accounting_investment_accounts config: %w", err)
 		}
 
-		_, err = googleSS.UpdateInvestmentAccountBalances(investmentAccounts, investmentAccountConfig)
-		if err != nil {
-			log.Printf("Error updating sheet investment balances: %v", err)
-			ServerErrorResponse(w, r)
-			return
+		if err := h.sheets.EnqueueInvestmentAccountBalances(r.Context(), investmentAccounts, investmentAccountConfig); err != nil {
+			log.Printf("Error queuing sheet investment balances: %v", err)
 		}
 
 		res.InvestmentAccounts = investmentAccounts
 	}
 
-	// Create net worth snapshot after updating balances
+	// Create net worth snapshot after updating balances; it runs after the
+	// response is sent, so it must not inherit the request's cancellation
+	ctx := context.WithoutCancel(r.Context())
 	go func() {
-		now := time.Now()
-		snapshot, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+		now := types.Now(ctx)
+		snapshot, err := h.store.CalculateNetWorthSnapshot(ctx, now.Year(), int(now.Month()))
 		if err != nil {
 			log.Printf("Error calculating net worth snapshot: %v", err)
 			return
 		}
 
-		_, err = postgres.UpsertNetWorthSnapshot(snapshot)
+		_, err = h.store.UpsertNetWorthSnapshot(ctx, snapshot)
 		if err != nil {
 			log.Printf("Error saving net worth snapshot: %v", err)
 			return
 		}
 
-		log.Printf("Created net worth snapshot for %d/%d: Real $%.2f, Expected $%.2f, Discrepancy $%.2f", now.Month(), now.Year(), snapshot.TotalRealNetWorth, snapshot.ExpectedNetWorth, snapshot.TotalDiscrepancy)
+		log.Printf("Created net worth snapshot for %d/%d: Real $%s, Expected $%s, Discrepancy $%s", now.Month(), now.Year(), snapshot.TotalRealNetWorth, snapshot.ExpectedNetWorth, snapshot.TotalDiscrepancy)
 	}()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
-
+	return writeJSON(w, res)
 }
 
 // ========== GOALS ENDPOINTS ==========
 
-func getGoals(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
+func (h *Handler) getGoals(w http.ResponseWriter, r *http.Request) error {
 	// Get year from query param, default to current year
-	yearStr := r.URL.Query().Get("year")
-	year := time.Now().Year()
-	if yearStr != "" {
-		if parsed, err := strconv.Atoi(yearStr); err == nil {
-			year = parsed
-		}
+	year, err := queryInt(r, "year", types.Now(r.Context()).Year())
+	if err != nil {
+		return err
 	}
 
-	goals, err := postgres.GetYearlyGoals(year)
+	goals, err := h.store.GetYearlyGoals(r.Context(), year)
 	if err != nil {
-		log.Printf("Error getting goals: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting goals: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(goals)
+	return writeJSON(w, goals)
 }
 
-func setGoals(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
+func (h *Handler) setGoals(w http.ResponseWriter, r *http.Request) error {
 	var goals types.YearlyGoals
-	if err := json.NewDecoder(r.Body).Decode(&goals); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Invalid JSON"})
-		return
+	if err := decodeJSON(r, &goals); err != nil {
+		return err
 	}
 
 	if goals.Year == 0 {
-		goals.Year = time.Now().Year()
+		goals.Year = types.Now(r.Context()).Year()
+	}
+	if err := h.validate(r, goals); err != nil {
+		return err
 	}
 
-	result, err := postgres.UpsertYearlyGoals(goals)
+	result, err := h.store.UpsertYearlyGoals(r.Context(), goals)
 	if err != nil {
-		log.Printf("Error saving goals: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error saving goals: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	return writeJSON(w, result)
 }
 
 // ========== NET WORTH ENDPOINTS ==========
 
-func getNetWorthHistory(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
-	history, err := postgres.GetNetWorthHistory()
+func (h *Handler) getNetWorthHistory(w http.ResponseWriter, r *http.Request) error {
+	history, err := h.store.GetNetWorthHistory(r.Context())
 	if err != nil {
-		log.Printf("Error getting net worth history: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting net worth history: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
+	return writeJSON(w, history)
 }
 
 // ========== INVESTMENT ACCOUNT SUMMARY ==========
 
-func getInvestmentAccountsSummary(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
-	summary, err := postgres.GetInvestmentAccountSummary()
+func (h *Handler) getInvestmentAccountsSummary(w http.ResponseWriter, r *http.Request) error {
+	summary, err := h.store.GetInvestmentAccountSummary(r.Context())
 	if err != nil {
-		log.Printf("Error getting investment summary: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting investment summary: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(summary)
+	return writeJSON(w, summary)
 }
 
 // ========== INCOME SUMMARY ==========
 
-func getIncomeSummary(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
+// getIncomeSummary returns the income of each budget period starting in ?year=;
+// periods without income are left out
+func (h *Handler) getIncomeSummary(w http.ResponseWriter, r *http.Request) error {
 	// Get year from query param, default to current year
-	yearStr := r.URL.Query().Get("year")
-	year := time.Now().Year()
-	if yearStr != "" {
-		if parsed, err := strconv.Atoi(yearStr); err == nil {
-			year = parsed
-		}
+	year, err := queryInt(r, "year", types.Now(r.Context()).Year())
+	if err != nil {
+		return err
 	}
 
-	summary, err := postgres.GetYearlyIncomeSummary(year)
+	household, err := h.store.GetHousehold(r.Context())
 	if err != nil {
-		log.Printf("Error getting income summary: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting household: %w", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(summary)
+	summary := []types.MonthlyIncomeSummary{}
+	for _, period := range household.BudgetPeriod.InYear(year) {
+		total, err := h.store.GetIncomeSum(r.Context(), period)
+		if err != nil {
+			return fmt.Errorf("error getting income summary: %w", err)
+		}
+		if total.Sign() == 0 {
+			continue
+		}
+		month, _ := strconv.Atoi(period.Start[5:7])
+		summary = append(summary, types.MonthlyIncomeSummary{
+			Year: year, Month: month, Start: period.Start, End: period.End, TotalIncome: total,
+		})
+	}
+	return writeJSON(w, summary)
 }
 
 // ========== DASHBOARD ==========
 
 type DashboardResponse struct {
+	Currency string `json:"currency"` // the household's base currency, which every amount is in
+	// The budget period containing ?date= (today by default); Year and Month
+	// are when it starts
 	CurrentMonth struct {
-		Year               int     `json:"year"`
-		Month              int     `json:"month"`
-		Income             float64 `json:"income"`
-		Expenses           float64 `json:"expenses"`
-		InvestmentDeposits float64 `json:"investment_deposits"`
-		Savings            float64 `json:"savings"`
-		SavingsRate        float64 `json:"savings_rate"`
+		Year               int         `json:"year"`
+		Month              int         `json:"month"`
+		Start              string      `json:"start"`
+		End                string      `json:"end"`
+		Income             types.Money `json:"income"`
+		Expenses           types.Money `json:"expenses"`
+		InvestmentDeposits types.Money `json:"investment_deposits"`
+		Savings            types.Money `json:"savings"`
+		SavingsRate        float64     `json:"savings_rate"`
 	} `json:"current_month"`
 	YTD struct {
-		Income             float64 `json:"income"`
-		Expenses           float64 `json:"expenses"`
-		InvestmentDeposits float64 `json:"investment_deposits"`
-		Savings            float64 `json:"savings"`
+		Income             types.Money `json:"income"`
+		Expenses           types.Money `json:"expenses"`
+		InvestmentDeposits types.Money `json:"investment_deposits"`
+		Savings            types.Money `json:"savings"`
 	} `json:"ytd"`
 	Goals       types.YearlyGoals                `json:"goals"`
 	NetWorth    types.NetWorthSnapshot           `json:"net_worth"`
 	Investments []types.InvestmentAccountSummary `json:"investments"`
 }
 
-func getDashboard(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) getDashboard(w http.ResponseWriter, r *http.Request) error {
+	date, err := queryDate(r, "date")
+	if err != nil {
+		return err
 	}
-
-	now := time.Now()
-	year := now.Year()
-	month := int(now.Month())
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+	// YTD and the net-worth snapshot follow the month the current period starts in
+	period := household.BudgetPeriod.Containing(date)
+	year, _ := strconv.Atoi(period.Start[:4])
+	month, _ := strconv.Atoi(period.Start[5:7])
 
 	var dashboard DashboardResponse
+	dashboard.Currency = household.BaseCurrency
 	dashboard.CurrentMonth.Year = year
 	dashboard.CurrentMonth.Month = month
+	dashboard.CurrentMonth.Start = period.Start
+	dashboard.CurrentMonth.End = period.End
 
-	// Get current month income
-	monthIncome, _ := postgres.GetMonthlyIncomeSum(year, month)
+	// Get current period income
+	monthIncome, err := h.store.GetIncomeSum(r.Context(), period)
+	if err != nil {
+		return fmt.Errorf("error getting income sum: %w", err)
+	}
 	dashboard.CurrentMonth.Income = monthIncome
 
-	// Get current month expenses
-	monthExpenses, _ := postgres.GetMonthlyExpenseSum(year, month)
+	// Get current period expenses
+	monthExpenses, err := h.store.GetExpenseSum(r.Context(), period)
+	if err != nil {
+		return fmt.Errorf("error getting expense sum: %w", err)
+	}
 	dashboard.CurrentMonth.Expenses = monthExpenses
 
-	// Get current month investment deposits
-	monthInvestments, _ := postgres.GetMonthlyInvestmentSum(year, month)
+	// Get current period investment deposits
+	monthInvestments, err := h.store.GetInvestmentSum(r.Context(), period)
+	if err != nil {
+		return fmt.Errorf("error getting investment sum: %w", err)
+	}
 	dashboard.CurrentMonth.InvestmentDeposits = monthInvestments
 
 	// Calculate savings
-	dashboard.CurrentMonth.Savings = monthIncome - monthExpenses - monthInvestments
-	if monthIncome > 0 {
-		dashboard.CurrentMonth.SavingsRate = (dashboard.CurrentMonth.Savings / monthIncome) * 100
+	dashboard.CurrentMonth.Savings = monthIncome.Sub(monthExpenses).Sub(monthInvestments)
+	if monthIncome.Sign() > 0 {
+		dashboard.CurrentMonth.SavingsRate = dashboard.CurrentMonth.Savings.Ratio(monthIncome) * 100
 	}
 
 	// Get YTD totals
-	ytdIncome, ytdExpenses, ytdInvestments := postgres.GetYTDTotals(year)
+	ytdIncome, ytdExpenses, ytdInvestments, err := h.store.GetYTDTotals(r.Context(), year)
+	if err != nil {
+		return fmt.Errorf("error getting YTD totals: %w", err)
+	}
 	dashboard.YTD.Income = ytdIncome
 	dashboard.YTD.Expenses = ytdExpenses
 	dashboard.YTD.InvestmentDeposits = ytdInvestments
-	dashboard.YTD.Savings = ytdIncome - ytdExpenses - ytdInvestments
+	dashboard.YTD.Savings = ytdIncome.Sub(ytdExpenses).Sub(ytdInvestments)
 
 	// Get goals
-	goals, _ := postgres.GetYearlyGoals(year)
+	goals, err := h.store.GetYearlyGoals(r.Context(), year)
+	if err != nil {
+		return fmt.Errorf("error getting goals: %w", err)
+	}
 	dashboard.Goals = goals
 
 	// Get latest net worth snapshot
-	snapshot, _ := postgres.CalculateNetWorthSnapshot(year, month)
+	snapshot, err := h.store.CalculateNetWorthSnapshot(r.Context(), year, month)
+	if err != nil {
+		return fmt.Errorf("error calculating net worth: %w", err)
+	}
 	dashboard.NetWorth = snapshot
 
 	// Get investment summary
-	investments, _ := postgres.GetInvestmentAccountSummary()
+	investments, err := h.store.GetInvestmentAccountSummary(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting investment summary: %w", err)
+	}
 	dashboard.Investments = investments
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dashboard)
+	return writeJSON(w, dashboard)
 }
 
 // ========== TRANSFERS ==========
 
-func submitTransfer(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) submitTransfer(w http.ResponseWriter, r *http.Request) error {
+	var transfer types.Transfer
+	if err := decodeJSON(r, &transfer); err != nil {
+		return err
+	}
+	if err := h.validate(r, transfer); err != nil {
+		return err
 	}
 
-	var transfer types.Transfer
-	if err := json.NewDecoder(r.Body).Decode(&transfer); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Invalid JSON"})
-		return
+	transfer.Date = transactionDate(r.Context(), transfer.Date)
+
+	result, err := h.store.InsertTransfer(r.Context(), transfer)
+	if err != nil {
+		return fmt.Errorf("error inserting transfer: %w", err)
 	}
+	return writeJSON(w, result)
+}
 
-	transfer.Date = time.Now().Format(time.DateTime)
+func (h *Handler) getTransfers(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil {
+		return err
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return err
+	}
 
-	result, err := postgres.InsertTransfer(transfer)
+	transfers, count, err := h.store.GetTransfers(r.Context(), limit, offset)
 	if err != nil {
-		log.Printf("Error inserting transfer: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting transfers: %w", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	return writeJSON(w, transferPage{Transfers: transfers, Count: count})
 }
 
-func getTransfers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) getTransfer(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
 
-	limit := 50
-	offset := 0
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil {
-			limit = parsed
-		}
+	transfer, err := h.store.GetTransferById(r.Context(), id)
+	if err != nil {
+		return err
 	}
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil {
-			offset = parsed
-		}
+	return writeJSON(w, transfer)
+}
+
+// updateTransfer handles PUT (replace) and PATCH (merge)
+func (h *Handler) updateTransfer(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
 
-	transfers, count, err := postgres.GetTransfers(limit, offset)
+	existing, err := h.store.GetTransferById(r.Context(), id)
 	if err != nil {
-		log.Printf("Error getting transfers: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
 
-	res := map[string]interface{}{
-		"transfers": transfers,
-		"count":     count,
+	// PATCH decodes on top of the stored transfer so omitted fields keep their value
+	var transfer types.Transfer
+	if r.Method == "PATCH" {
+		transfer = existing
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
-}
+	if err := decodeJSON(r, &transfer); err != nil {
+		return err
+	}
+	transfer.Id = id
+	if transfer.Date == "" {
+		transfer.Date = existing.Date
+	}
+	if err := h.validate(r, transfer); err != nil {
+		return err
+	}
+	transfer.Date = transactionDate(r.Context(), transfer.Date)
 
-// ========== EXPECTED BALANCE ==========
+	result, err := h.store.UpdateTransfer(r.Context(), transfer)
+	if err != nil {
+		log.Printf("Error updating transfer: %v", err)
+		return rejected(err)
+	}
+	return writeJSON(w, transferChange{Success: true, Transfer: result})
+}
 
-func getExpectedBalances(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) deleteTransfer(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseIdParam(r)
+	if err != nil {
+		return err
 	}
 
-	balances, err := postgres.GetAccountExpectedBalances()
+	deleted, err := h.store.DeleteTransfer(r.Context(), id)
 	if err != nil {
-		log.Printf("Error getting expected balances: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
+	return writeJSON(w, transferChange{Success: true, Transfer: deleted})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(balances)
+// TransferFXCostReport is what converting between currencies cost against the
+// reference rates over a range of months
+type TransferFXCostReport struct {
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	Currency string                 `json:"currency"` // the base currency base_cost is in
+	Pairs    []types.TransferFXCost `json:"pairs"`
+	BaseCost types.Money            `json:"base_cost"` // below zero is a gain
 }
 
-func getInvestmentExpectedCapital(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+// getTransferFXCosts reports the cost of the transfers between currencies by
+// account pair and month, from ?from= to ?to= (months like 2006-01, both
+// included; January to the current month by default)
+func (h *Handler) getTransferFXCosts(w http.ResponseWriter, r *http.Request) error {
+	now := types.Now(r.Context())
+	from, err := queryMonth(r, "from", types.FormatMonth(now.Year(), 1))
+	if err != nil {
+		return err
+	}
+	to, err := queryMonth(r, "to", types.FormatMonth(now.Year(), int(now.Month())))
+	if err != nil {
+		return err
+	}
+	if from > to {
+		return badRequest("from must not be after to")
+	}
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
 	}
 
-	capital, err := postgres.GetInvestmentAccountExpectedCapital()
+	fromYear, fromMonth, _ := types.ParseMonth(from)
+	toYear, toMonth, _ := types.ParseMonth(to)
+	start, _ := types.MonthRange(fromYear, fromMonth)
+	_, end := types.MonthRange(toYear, toMonth)
+	pairs, err := h.store.GetTransferFXCosts(r.Context(), types.Period{Start: start, End: end})
 	if err != nil {
-		log.Printf("Error getting investment expected capital: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(capital)
+	report := TransferFXCostReport{From: from, To: to, Currency: household.BaseCurrency, Pairs: []types.TransferFXCost{}}
+	for _, pair := range pairs {
+		pair.SpreadPercent = pair.Cost.Ratio(pair.ReferenceAmount) * 100
+		report.Pairs = append(report.Pairs, pair)
+		report.BaseCost = report.BaseCost.Add(pair.BaseCost)
+	}
+	return writeJSON(w, report)
 }
 
-// ========== PHASE 7: DEBT MODULE ENHANCEMENTS ==========
+// ========== EXPECTED BALANCE ==========
 
-func getDebts(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
+func (h *Handler) getExpectedBalances(w http.ResponseWriter, r *http.Request) error {
+	balances, err := h.store.GetAccountExpectedBalances(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting expected balances: %w", err)
 	}
+	return writeJSON(w, balances)
+}
 
-	limit := 50
-	offset := 0
-	var debtorId *int32
+func (h *Handler) getInvestmentExpectedCapital(w http.ResponseWriter, r *http.Request) error {
+	capital, err := h.store.GetInvestmentAccountExpectedCapital(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting investment expected capital: %w", err)
+	}
+	return writeJSON(w, capital)
+}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil {
-			limit = parsed
-		}
+// ========== PHASE 7: DEBT MODULE ENHANCEMENTS ==========
+
+func (h *Handler) getDebts(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil {
+		return err
 	}
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil {
-			offset = parsed
-		}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return err
 	}
-	if debtorIdStr := r.URL.Query().Get("debtor_id"); debtorIdStr != "" {
-		if parsed, err := strconv.Atoi(debtorIdStr); err == nil {
-			id := int32(parsed)
-			debtorId = &id
+	var debtorId *int32
+	if r.URL.Query().Get("debtor_id") != "" {
+		parsed, err := queryInt(r, "debtor_id", 0)
+		if err != nil {
+			return err
 		}
+		id := int32(parsed)
+		debtorId = &id
 	}
 
-	debts, count, err := postgres.GetDebts(limit, offset, debtorId)
+	debts, count, err := h.store.GetDebts(r.Context(), limit, offset, debtorId)
 	if err != nil {
-		log.Printf("Error getting debts: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting debts: %w", err)
 	}
 
-	res := map[string]interface{}{
-		"debts": debts,
-		"count": count,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	return writeJSON(w, debtPage{Debts: debts, Count: count})
 }
 
-func getDebtsByDebtor(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
-	summary, err := postgres.GetDebtorsWithDebts()
+func (h *Handler) getDebtsByDebtor(w http.ResponseWriter, r *http.Request) error {
+	summary, err := h.store.GetDebtorsWithDebts(r.Context())
 	if err != nil {
-		log.Printf("Error getting debts by debtor: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting debts by debtor: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(summary)
+	return writeJSON(w, summary)
 }
 
-func getRecentExpenses(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
-	limit := 10
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil {
-			limit = parsed
-		}
+func (h *Handler) getRecentExpenses(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", 10)
+	if err != nil {
+		return err
 	}
 
-	expenses, err := postgres.GetRecentExpenses(limit)
+	expenses, err := h.store.GetRecentExpenses(r.Context(), limit)
 	if err != nil {
-		log.Printf("Error getting recent expenses: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error getting recent expenses: %w", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(expenses)
+	return writeJSON(w, expenses)
 }
 
 type RepaymentRequest struct {
-	DebtorId    int32   `json:"debtor_id"`
-	DebtorName  string  `json:"debtor_name"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
-	AccountId   int32   `json:"account_id"`
-	Account     string  `json:"account"`
-	Currency    string  `json:"currency"`
+	DebtorId    int32       `json:"debtor_id"`
+	DebtorName  string      `json:"debtor_name"`
+	Amount      types.Money `json:"amount"`
+	Description string      `json:"description"`
+	AccountId   int32       `json:"account_id"`
+	Account     string      `json:"account"`
+	Currency    string      `json:"currency"`
+	Date        string      `json:"date"` // defaults to now
+}
+
+func (req RepaymentRequest) Rules() []types.Rule {
+	return []types.Rule{
+		types.Date("date", req.Date),
+		types.Positive("amount", req.Amount),
+		types.RequiredId("debtor_id", req.DebtorId),
+		types.Ref("debtor_id", types.RefDebtor, req.DebtorId),
+		types.RequiredId("account_id", req.AccountId),
+		types.Ref("account_id", types.RefAccount, req.AccountId),
+	}
 }
 
 // ExpenseDebtRequest is for creating an expense that also creates a linked debt
 // Use case: "I lent $100 to John from my BOFA account"
 // DebtEntry represents a single debt in the expense-debt request
 type DebtEntry struct {
-	DebtorId   int32   `json:"debtor_id"`
-	DebtorName string  `json:"debtor_name"`
-	Amount     float64 `json:"amount"`
-	Currency   string  `json:"currency"`
+	DebtorId   int32       `json:"debtor_id"`
+	DebtorName string      `json:"debtor_name"`
+	Amount     types.Money `json:"amount"`
+	Currency   string      `json:"currency"`
 }
 
 type ExpenseDebtRequest struct {
 	// Expense fields
-	Date           string  `json:"date"`
-	Category       string  `json:"category"`
-	CategoryId     int32   `json:"category_id"`
-	Expense        float64 `json:"expense"` // The expense amount (money that left your account)
-	Description    string  `json:"description"`
-	Method         string  `json:"method"`
-	OriginalAmount float64 `json:"originalAmount"`
-	AccountId      int32   `json:"account_id"`
-	AccountType    string  `json:"account_type"`
+	Date           string      `json:"date"`
+	Category       string      `json:"category"`
+	CategoryId     int32       `json:"category_id"`
+	Expense        types.Money `json:"expense"` // The expense amount (money that left your account)
+	Description    string      `json:"description"`
+	Method         string      `json:"method"`
+	OriginalAmount types.Money `json:"originalAmount"`
+	AccountId      int32       `json:"account_id"`
+	AccountType    string      `json:"account_type"`
 	// Multiple debts (preferred)
 	Debts []DebtEntry `json:"debts"`
 	// Single debt fields (backward compatible)
-	DebtorId   int32   `json:"debtor_id"`
-	DebtorName string  `json:"debtor_name"`
-	DebtAmount float64 `json:"debt_amount"`
-	Currency   string  `json:"currency"`
+	DebtorId   int32       `json:"debtor_id"`
+	DebtorName string      `json:"debtor_name"`
+	DebtAmount types.Money `json:"debt_amount"`
+	Currency   string      `json:"currency"`
 }
 
-func submitExpenseWithDebt(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
-	var req ExpenseDebtRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Invalid JSON"})
-		return
-	}
-
-	// Default date to now if not provided
-	date := req.Date
-	if date == "" {
-		date = time.Now().Format(time.DateTime)
-	}
+// Rules are the expense's plus each debt's; the single debt fields are
+// checked under their own names
+func (req ExpenseDebtRequest) Rules() []types.Rule {
+	rules := req.expense().Rules()
+	if len(req.Debts) == 0 && req.DebtorId == 0 {
+		return append(rules, types.Check("debts", false, "at least one debt is required (use 'debts' array or single debt fields)"))
+	}
+
+	if len(req.Debts) == 0 {
+		debt := req.debts()[0]
+		return append(rules,
+			types.Positive("debt_amount", debt.Amount),
+			types.Ref("debtor_id", types.RefDebtor, debt.DebtorId),
+		)
+	}
+	for i, debt := range req.debts() {
+		rules = append(rules, types.Nested(fmt.Sprintf("debts[%d].", i), []types.Rule{
+			types.Positive("amount", debt.Amount),
+			types.RequiredId("debtor_id", debt.DebtorId),
+			types.Ref("debtor_id", types.RefDebtor, debt.DebtorId),
+		})...)
+	}
+	return rules
+}
 
-	// Create expense record
-	expense := types.Expense{
-		Date:           date,
+// expense is the expense record of the request
+func (req ExpenseDebtRequest) expense() types.Expense {
+	return types.Expense{
+		Date:           req.Date,
 		Category:       req.Category,
 		CategoryId:     req.CategoryId,
 		Expense:        req.Expense,
@@ -1287,95 +1580,95 @@ func submitExpenseWithDebt(w http.ResponseWriter, r *http.Request) {
 		AccountId:      req.AccountId,
 		AccountType:    req.AccountType,
 	}
+}
 
-	// Build debts array - support both new format (debts array) and old format (single debt fields)
-	var debts []types.Debt
+// debts builds the debts array - it supports both the new format (debts array)
+// and the old format (single debt fields)
+func (req ExpenseDebtRequest) debts() []types.Debt {
 	accountId := req.AccountId
 
 	if len(req.Debts) > 0 {
 		// New format: multiple debts
+		var debts []types.Debt
 		for _, d := range req.Debts {
-			debt := types.Debt{
+			debts = append(debts, types.Debt{
 				Description:    req.Description,
 				Amount:         d.Amount,
 				DebtorId:       d.DebtorId,
 				DebtorName:     d.DebtorName,
-				Date:           date,
+				Date:           req.Date,
 				OriginalAmount: d.Amount,
 				Currency:       d.Currency,
 				Outbound:       true,
 				AccountId:      &accountId,
-			}
-			debts = append(debts, debt)
+			})
 		}
-	} else if req.DebtorId != 0 {
-		// Old format: single debt (backward compatible)
-		debtAmount := req.DebtAmount
-		if debtAmount == 0 {
-			debtAmount = req.Expense
-		}
-		debt := types.Debt{
-			Description:    req.Description,
-			Amount:         debtAmount,
-			DebtorId:       req.DebtorId,
-			DebtorName:     req.DebtorName,
-			Date:           date,
-			OriginalAmount: debtAmount,
-			Currency:       req.Currency,
-			Outbound:       true,
-			AccountId:      &accountId,
-		}
-		debts = append(debts, debt)
-	} else {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "At least one debt is required (use 'debts' array or single debt fields)"})
-		return
+		return debts
+	}
+
+	// Old format: single debt (backward compatible)
+	debtAmount := req.DebtAmount
+	if debtAmount.Sign() == 0 {
+		debtAmount = req.Expense
+	}
+	return []types.Debt{{
+		Description:    req.Description,
+		Amount:         debtAmount,
+		DebtorId:       req.DebtorId,
+		DebtorName:     req.DebtorName,
+		Date:           req.Date,
+		OriginalAmount: debtAmount,
+		Currency:       req.Currency,
+		Outbound:       true,
+		AccountId:      &accountId,
+	}}
+}
+
+func (h *Handler) submitExpenseWithDebt(w http.ResponseWriter, r *http.Request) error {
+	var req ExpenseDebtRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return err
+	}
+	if err := h.validate(r, req); err != nil {
+		return err
 	}
 
-	expenseResult, debtResults, err := postgres.InsertExpenseWithDebts(expense, debts)
+	req.Date = transactionDate(r.Context(), req.Date)
+	expense := req.expense()
+	debts := req.debts()
+
+	expenseResult, debtResults, err := h.store.InsertExpenseWithDebts(r.Context(), expense, debts)
 	if err != nil {
 		log.Printf("Error creating expense with debts: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: err.Error()})
-		return
+		return rejected(err)
 	}
 
-	// Update expense sheet asynchronously
-	go func() {
-		config, err := postgres.GetConfigByType("expenses")
-		if err != nil {
-			log.Printf("Error getting expense config: %v", err)
-			return
-		}
-		googleSS.SubmitExpenseRow(expenseResult, config)
-	}()
+	// Queue the expense sheet row
+	config, err := h.store.GetConfigByType(r.Context(), "expenses")
+	if err != nil {
+		log.Printf("Error getting expense config: %v", err)
+	} else if err := h.sheets.EnqueueExpenseRow(r.Context(), expenseResult, config); err != nil {
+		log.Printf("Error queuing expense row: %v", err)
+	}
 
 	// Return expense and all debts
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"expense": expenseResult,
-		"debts":   debtResults,
-	})
+	return writeJSON(w, expenseDebtResult{Success: true, Expense: expenseResult, Debts: debtResults})
 }
 
-func submitDebtRepayment(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == "OPTIONS" {
-		return
-	}
-
+func (h *Handler) submitDebtRepayment(w http.ResponseWriter, r *http.Request) error {
 	var req RepaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Invalid JSON"})
-		return
+	if err := decodeJSON(r, &req); err != nil {
+		return err
+	}
+	if err := h.validate(r, req); err != nil {
+		return err
 	}
 
+	date := transactionDate(r.Context(), req.Date)
+
 	// Create income record
 	income := types.Income{
-		Date:        time.Now().Format(time.DateTime),
+		Date:        date,
 		Amount:      req.Amount,
 		Description: fmt.Sprintf("Debt repayment from %s: %s", req.DebtorName, req.Description),
 		AccountId:   req.AccountId,
@@ -1389,117 +1682,218 @@ func submitDebtRepayment(w http.ResponseWriter, r *http.Request) {
 		Amount:         req.Amount,
 		DebtorId:       req.DebtorId,
 		DebtorName:     req.DebtorName,
-		Date:           time.Now().Format(time.DateTime),
+		Date:           date,
 		OriginalAmount: req.Amount,
 		Currency:       req.Currency,
 		Outbound:       false, // Inbound = they paid us
 		AccountId:      &accountId,
 	}
 
-	incomeResult, debtResult, err := postgres.RecordDebtRepayment(income, debt)
+	incomeResult, debtResult, err := h.store.RecordDebtRepayment(r.Context(), income, debt)
 	if err != nil {
-		log.Printf("Error recording repayment: %v", err)
-		ServerErrorResponse(w, r)
-		return
+		return fmt.Errorf("error recording repayment: %w", err)
 	}
 
-	// Update income sheet asynchronously
-	go func() {
-		config, err := postgres.GetConfigByType("income_monthly")
-		if err != nil {
-			log.Printf("Error getting income config: %v", err)
-			return
-		}
+	// Queue the monthly income cell
+	year, month := transactionMonth(r.Context(), date)
+	h.refreshMonthlyIncomeCell(r.Context(), year, month)
 
-		// Get updated monthly sum and update sheet
-		now := time.Now()
-		sum, err := postgres.GetMonthlyIncomeSum(now.Year(), int(now.Month()))
-		if err != nil {
-			log.Printf("Error getting monthly sum: %v", err)
-			return
-		}
+	return writeJSON(w, repaymentResult{Income: incomeResult, Debt: debtResult})
+}
 
-		cellRange := googleSS.CalculateMonthlyCellRange(config.Sheet, config.A1Range, int(now.Month()))
-		if err := googleSS.UpdateSheetCell(cellRange, sum); err != nil {
-			log.Printf("Error updating sheet: %v", err)
-		}
-	}()
+// ========== SHEET OUTBOX ENDPOINTS ==========
+
+// getSheetJobs lists the sheet writes that have not reached the sheet yet.
+// ?status=pending,failed narrows the list; by default every unfinished job is returned.
+func (h *Handler) getSheetJobs(w http.ResponseWriter, r *http.Request) error {
+	statuses := []string{"pending", "processing", "failed"}
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		statuses = strings.Split(statusStr, ",")
+	}
+
+	limit, err := queryInt(r, "limit", 100)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
 
-	res := map[string]interface{}{
-		"income": incomeResult,
-		"debt":   debtResult,
+	jobs, err := h.sheets.ListSheetJobs(r.Context(), statuses, limit)
+	if err != nil {
+		return fmt.Errorf("error listing sheet jobs: %w", err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+
+	return writeJSON(w, sheetJobList{Jobs: jobs, Count: len(jobs)})
 }
 
-func LoadRoutes(muxRouter *mux.Router) {
+// retrySheetJob puts a failed sheet job back in the queue
+func (h *Handler) retrySheetJob(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return badRequest("Invalid id parameter")
+	}
+
+	job, err := h.sheets.RetrySheetJob(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, job)
+}
+
+// discardSheetJob deletes a failed sheet job, letting the household's later writes through
+func (h *Handler) discardSheetJob(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return badRequest("Invalid id parameter")
+	}
+
+	job, err := h.sheets.DiscardSheetJob(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, job)
+}
+
+// getSheetStatus reports whether sheets are enabled and reads the expenses range
+// to check the credentials and the household's spreadsheet configuration
+func (h *Handler) getSheetStatus(w http.ResponseWriter, r *http.Request) error {
+	config, err := h.store.GetConfigByType(r.Context(), "expenses")
+	if err != nil {
+		return fmt.Errorf("error getting config: %w", err)
+	}
+
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+
+	if err := h.sheets.Check(household.SpreadsheetId, fmt.Sprint(config.Sheet, config.A1Range)); err != nil {
+		return err
+	}
+
+	return writeJSON(w, sheetStatus{Enabled: true, DevMode: googleSS.IsDevMode()})
+}
+
+// Handler serves the /api routes
+type Handler struct {
+	store Store
+	// sheets receives every sheet write; its sink decides where they end up
+	// (the real spreadsheet, or a RecordingSink in tests)
+	sheets *googleSS.Outbox
+	// auth checks the credentials of every /api request except login
+	auth *auth.Authenticator
+	// origins browsers may call the API from; empty allows any
+	origins map[string]bool
+}
+
+func NewHandler(store Store, sheets *googleSS.Outbox, authenticator *auth.Authenticator) *Handler {
+	return &Handler{store: store, sheets: sheets, auth: authenticator}
+}
+
+// LoadRoutes mounts the API on muxRouter. Every response goes through the CORS
+// and JSON middleware, and handlers report failures by returning an error,
+// which is written as an ErrorResponse.
+func LoadRoutes(muxRouter *mux.Router, h *Handler) {
+	muxRouter.Use(recoverPanics, h.cors, jsonContent)
+	muxRouter.NotFoundHandler = h.cors(http.HandlerFunc(NotFoundResponse))
+	muxRouter.MethodNotAllowedHandler = h.cors(http.HandlerFunc(MethodNotAllowedResponse))
+
+	// CORS preflights are answered before authentication
+	muxRouter.PathPrefix("/api").Methods("OPTIONS").HandlerFunc(h.preflight)
+
+	// Login and the API reference (openapi.go) don't need credentials
+	muxRouter.HandleFunc("/api/login", handle(h.login)).Methods("POST")
+	muxRouter.HandleFunc("/api/openapi.json", handle(h.openAPI(muxRouter))).Methods("GET")
+	muxRouter.HandleFunc("/api/docs", h.docs).Methods("GET")
+
 	api := muxRouter.PathPrefix("/api").Subrouter()
-	api.HandleFunc("/", greet).Methods("GET")
-	api.HandleFunc("/submit", submitExpenseRow).Methods("POST", "OPTIONS")
-	api.HandleFunc("/expenses", getExpenses).Methods("GET", "OPTIONS")
-	api.HandleFunc("/budget", setBudgets).Methods("POST", "OPTIONS")
-	api.HandleFunc("/budget", getBudgets).Methods("GET")
-	api.HandleFunc("/categories", getCategories).Methods("GET")
-	api.HandleFunc("/config", getConfig).Methods("GET")
-	api.HandleFunc("/config", setConfig).Methods("POST", "OPTIONS")
-	api.HandleFunc("/investment", submitInvestment).Methods("POST", "OPTIONS")
-	api.HandleFunc("/investments", getInvestments).Methods("GET")
-	api.HandleFunc("/debt", submitDebt).Methods("POST", "OPTIONS")
-	// api.HandleFunc("/debt", getDebts).Methods("GET")
-	api.HandleFunc("/income", submitIncome).Methods("POST", "OPTIONS")
-	api.HandleFunc("/income", getIncomes).Methods("GET")
-	api.HandleFunc("/accounts", getAccounts).Methods("GET")
-	api.HandleFunc("/accounts", createAccount).Methods("POST", "OPTIONS")
-	api.HandleFunc("/investment-accounts", getInvestmentAccounts).Methods("GET")
-	api.HandleFunc("/investment-accounts", createInvestmentAccount).Methods("POST", "OPTIONS")
-	api.HandleFunc("/debtors", getDebtors).Methods("GET")
-	api.HandleFunc("/debtors", createDebtor).Methods("POST", "OPTIONS")
-	api.HandleFunc("/debtors/debt", getDebtorsWithDebts).Methods("GET")
-	api.HandleFunc("/accounting", setAccountingForCurrentMonth).Methods("POST", "OPTIONS")
+	api.Use(h.requireAuth)
+	api.HandleFunc("/", handle(h.greet)).Methods("GET")
+	api.HandleFunc("/submit", handle(h.submitExpenseRow)).Methods("POST")
+	api.HandleFunc("/expenses", handle(h.getExpenses)).Methods("GET")
+	api.HandleFunc("/expenses/{id:[0-9]+}", handle(h.getExpense)).Methods("GET")
+	api.HandleFunc("/expenses/{id:[0-9]+}", handle(h.updateExpense)).Methods("PUT", "PATCH")
+	api.HandleFunc("/expenses/{id:[0-9]+}", handle(h.deleteExpense)).Methods("DELETE")
+	api.HandleFunc("/budget", handle(h.setBudgets)).Methods("POST")
+	api.HandleFunc("/budget", handle(h.getBudgets)).Methods("GET")
+	api.HandleFunc("/budget/history", handle(h.getBudgetHistory)).Methods("GET")
+	api.HandleFunc("/budget/moves", handle(h.submitBudgetMove)).Methods("POST")
+	api.HandleFunc("/budget/moves", handle(h.getBudgetMoves)).Methods("GET")
+	api.HandleFunc("/categories", handle(h.getCategories)).Methods("GET")
+	api.HandleFunc("/categories", handle(h.createCategory)).Methods("POST")
+	api.HandleFunc("/config", handle(h.getConfig)).Methods("GET")
+	api.HandleFunc("/config", handle(h.setConfig)).Methods("POST")
+	api.HandleFunc("/investment", handle(h.submitInvestment)).Methods("POST")
+	api.HandleFunc("/investments", handle(h.getInvestments)).Methods("GET")
+	api.HandleFunc("/investments/{id:[0-9]+}", handle(h.getInvestment)).Methods("GET")
+	api.HandleFunc("/investments/{id:[0-9]+}", handle(h.updateInvestment)).Methods("PUT", "PATCH")
+	api.HandleFunc("/investments/{id:[0-9]+}", handle(h.deleteInvestment)).Methods("DELETE")
+	api.HandleFunc("/debt", handle(h.submitDebt)).Methods("POST")
+	// api.HandleFunc("/debt", handle(h.getDebts)).Methods("GET")
+	api.HandleFunc("/income", handle(h.submitIncome)).Methods("POST")
+	api.HandleFunc("/income", handle(h.getIncomes)).Methods("GET")
+	api.HandleFunc("/income/{id:[0-9]+}", handle(h.getIncome)).Methods("GET")
+	api.HandleFunc("/income/{id:[0-9]+}", handle(h.updateIncome)).Methods("PUT", "PATCH")
+	api.HandleFunc("/income/{id:[0-9]+}", handle(h.deleteIncome)).Methods("DELETE")
+	api.HandleFunc("/accounts", handle(h.getAccounts)).Methods("GET")
+	api.HandleFunc("/accounts", handle(h.createAccount)).Methods("POST")
+	api.HandleFunc("/investment-accounts", handle(h.getInvestmentAccounts)).Methods("GET")
+	api.HandleFunc("/investment-accounts", handle(h.createInvestmentAccount)).Methods("POST")
+	api.HandleFunc("/debtors", handle(h.getDebtors)).Methods("GET")
+	api.HandleFunc("/debtors", handle(h.createDebtor)).Methods("POST")
+	api.HandleFunc("/debtors/debt", handle(h.getDebtorsWithDebts)).Methods("GET")
+	api.HandleFunc("/accounting", handle(h.setAccountingForCurrentMonth)).Methods("POST")
 
 	// Phase 4: Goals & Net Worth
-	api.HandleFunc("/goals", getGoals).Methods("GET")
-	api.HandleFunc("/goals", setGoals).Methods("POST", "OPTIONS")
-	api.HandleFunc("/net-worth/history", getNetWorthHistory).Methods("GET")
+	api.HandleFunc("/goals", handle(h.getGoals)).Methods("GET")
+	api.HandleFunc("/goals", handle(h.setGoals)).Methods("POST")
+	api.HandleFunc("/net-worth/history", handle(h.getNetWorthHistory)).Methods("GET")
 
 	// Phase 5: Investment Account Summary & Dashboard
-	api.HandleFunc("/investment-accounts/summary", getInvestmentAccountsSummary).Methods("GET")
-	api.HandleFunc("/income/summary", getIncomeSummary).Methods("GET")
-	api.HandleFunc("/dashboard", getDashboard).Methods("GET")
+	api.HandleFunc("/investment-accounts/summary", handle(h.getInvestmentAccountsSummary)).Methods("GET")
+	api.HandleFunc("/income/summary", handle(h.getIncomeSummary)).Methods("GET")
+	api.HandleFunc("/dashboard", handle(h.getDashboard)).Methods("GET")
 
 	// Phase 6: Transfers
-	api.HandleFunc("/transfer", submitTransfer).Methods("POST", "OPTIONS")
-	api.HandleFunc("/transfers", getTransfers).Methods("GET")
+	api.HandleFunc("/transfer", handle(h.submitTransfer)).Methods("POST")
+	api.HandleFunc("/transfers", handle(h.getTransfers)).Methods("GET")
+	api.HandleFunc("/transfers/{id:[0-9]+}", handle(h.getTransfer)).Methods("GET")
+	api.HandleFunc("/transfers/{id:[0-9]+}", handle(h.updateTransfer)).Methods("PUT", "PATCH")
+	api.HandleFunc("/transfers/{id:[0-9]+}", handle(h.deleteTransfer)).Methods("DELETE")
+	api.HandleFunc("/transfers/fx-costs", handle(h.getTransferFXCosts)).Methods("GET")
+
+	// Exchange rates
+	api.HandleFunc("/fx-rates", handle(h.getFXRates)).Methods("GET")
+	api.HandleFunc("/fx-rates", handle(h.setFXRates)).Methods("POST")
+
+	// Statement imports
+	api.HandleFunc("/import/preview", handle(h.previewImport)).Methods("POST")
+	api.HandleFunc("/import/commit", handle(h.commitImport)).Methods("POST")
+	api.HandleFunc("/import/mappings", handle(h.getImportMappings)).Methods("GET")
+	api.HandleFunc("/import/mappings", handle(h.setImportMapping)).Methods("POST")
+
+	// Exports
+	api.HandleFunc("/export", handle(h.exportData)).Methods("GET")
 
 	// Expected Balance (Phase 1B view)
-	api.HandleFunc("/accounts/expected-balance", getExpectedBalances).Methods("GET")
-	api.HandleFunc("/investment-accounts/expected-capital", getInvestmentExpectedCapital).Methods("GET")
+	api.HandleFunc("/accounts/expected-balance", handle(h.getExpectedBalances)).Methods("GET")
+	api.HandleFunc("/investment-accounts/expected-capital", handle(h.getInvestmentExpectedCapital)).Methods("GET")
 
 	// Phase 7: Debt Module Enhancements
-	api.HandleFunc("/debts", getDebts).Methods("GET")
-	api.HandleFunc("/debts/by-debtor", getDebtsByDebtor).Methods("GET")
-	api.HandleFunc("/debt/repayment", submitDebtRepayment).Methods("POST", "OPTIONS")
-	api.HandleFunc("/expense-debt", submitExpenseWithDebt).Methods("POST", "OPTIONS")
-	api.HandleFunc("/expenses/recent", getRecentExpenses).Methods("GET")
-}
-
-func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
-	res := types.Response{
-		Success: false,
-		Message: "Not Found",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
-}
-
-func ServerErrorResponse(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusInternalServerError)
-	res := types.Response{
-		Success: false,
-		Message: "Internal Server Error",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+	api.HandleFunc("/debts", handle(h.getDebts)).Methods("GET")
+	api.HandleFunc("/debts/by-debtor", handle(h.getDebtsByDebtor)).Methods("GET")
+	api.HandleFunc("/debt/repayment", handle(h.submitDebtRepayment)).Methods("POST")
+	api.HandleFunc("/expense-debt", handle(h.submitExpenseWithDebt)).Methods("POST")
+	api.HandleFunc("/expenses/recent", handle(h.getRecentExpenses)).Methods("GET")
+
+	// Sheet outbox
+	api.HandleFunc("/admin/sheets", handle(h.getSheetStatus)).Methods("GET")
+	api.HandleFunc("/admin/sheet-jobs", handle(h.getSheetJobs)).Methods("GET")
+	api.HandleFunc("/admin/sheet-jobs/{id:[0-9]+}/retry", handle(h.retrySheetJob)).Methods("POST")
+	api.HandleFunc("/admin/sheet-jobs/{id:[0-9]+}", handle(h.discardSheetJob)).Methods("DELETE")
+
+	// Households
+	api.HandleFunc("/household", handle(h.getHousehold)).Methods("GET")
+	api.HandleFunc("/household", handle(h.updateHousehold)).Methods("PATCH")
 }
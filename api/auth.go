@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== AUTH ==========
+
+// requireAuth rejects requests without a valid API key or session token and
+// stores the caller, and the household data they may see, in the request
+// context. CORS preflights never get here: they are answered before it.
+func (h *Handler) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.auth.Authenticate(r)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				UnauthorizedResponse(w, r)
+				return
+			}
+			writeError(w, r, fmt.Errorf("error authenticating request: %w", err))
+			return
+		}
+
+		ctx := auth.WithPrincipal(r.Context(), principal)
+		ctx, err = h.withScope(ctx, principal.Scope())
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withScope stores scope in ctx along with its household's timezone, which
+// every "this month" and "this year" of the request is counted in, and its
+// base currency, which totals are in
+func (h *Handler) withScope(ctx context.Context, scope types.Scope) (context.Context, error) {
+	household, err := h.store.GetHousehold(types.WithScope(ctx, scope))
+	if err != nil {
+		return nil, fmt.Errorf("error getting household: %w", err)
+	}
+	scope.Location, err = types.LoadTimezone(household.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("error loading timezone of household %d: %w", household.Id, err)
+	}
+	scope.BaseCurrency = household.BaseCurrency
+	return types.WithScope(ctx, scope), nil
+}
+
+type LoginRequest struct {
+	APIKey string `json:"api_key"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// login exchanges an API key for a session token, so the web app doesn't have
+// to send the key itself on every request
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) error {
+	var req LoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return err
+	}
+
+	token, expiresAt, err := h.auth.Login(r.Context(), req.APIKey)
+	if err != nil {
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			UnauthorizedResponse(w, r)
+			return nil
+		}
+		return fmt.Errorf("error logging in: %w", err)
+	}
+
+	return writeJSON(w, LoginResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+func UnauthorizedResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="fintrack"`)
+	writeError(w, r, &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: "Unauthorized"})
+}
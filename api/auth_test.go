@@ -0,0 +1,140 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/api"
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	"github.com/gorilla/mux"
+)
+
+// ========== AUTH ==========
+
+var routeVar = regexp.MustCompile(`\{[^}]+\}`)
+
+// publicRoutes are served without credentials
+var publicRoutes = map[string]bool{"/api/login": true, "/api/openapi.json": true, "/api/docs": true}
+
+// TestRoutesRejectUnauthenticatedCalls walks every route and calls it without credentials
+func TestRoutesRejectUnauthenticatedCalls(t *testing.T) {
+	f := newFixture(t)
+
+	checked := 0
+	err := f.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || publicRoutes[template] {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := routeVar.ReplaceAllString(template, "1")
+		for _, method := range methods {
+			if method == "OPTIONS" {
+				continue
+			}
+			rec := httptest.NewRecorder()
+			f.router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader("{}")))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s without credentials: expected 401, got %d", method, path, rec.Code)
+			}
+			checked++
+		}
+		return nil
+	})
+	assertNoError(t, err, "Walk routes")
+	if checked == 0 {
+		t.Fatal("Expected routes to check")
+	}
+	if len(f.sheet.Calls()) != 0 {
+		t.Errorf("Expected no sheet writes from rejected calls, got %d", len(f.sheet.Calls()))
+	}
+}
+
+// TestCredentialHeaders verifies both the bearer and the X-API-Key forms and rejects bad keys
+func TestCredentialHeaders(t *testing.T) {
+	f := newFixture(t)
+
+	cases := []struct {
+		name     string
+		header   string
+		value    string
+		expected int
+	}{
+		{"bearer key", "Authorization", "Bearer " + f.apiKey, http.StatusOK},
+		{"x-api-key", "X-API-Key", f.apiKey, http.StatusOK},
+		{"wrong secret", "Authorization", "Bearer " + f.apiKey[:len(f.apiKey)-4] + "AAAA", http.StatusUnauthorized},
+		{"unknown prefix", "Authorization", "Bearer ftk_00000000_secret", http.StatusUnauthorized},
+		{"basic scheme", "Authorization", "Basic " + f.apiKey, http.StatusUnauthorized},
+		{"garbage token", "Authorization", "Bearer not.a.token", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/api/accounts", nil)
+		req.Header.Set(c.header, c.value)
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, req)
+		if rec.Code != c.expected {
+			t.Errorf("%s: expected %d, got %d", c.name, c.expected, rec.Code)
+		}
+	}
+}
+
+// TestLoginSession verifies a session token from /api/login works until its key is revoked
+func TestLoginSession(t *testing.T) {
+	f := newFixture(t)
+
+	login := func(key string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(api.LoginRequest{APIKey: key})
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/login", bytes.NewReader(body)))
+		return rec
+	}
+	withToken := func(token string) int {
+		req := httptest.NewRequest("GET", "/api/accounts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if rec := login("ftk_00000000_nope"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Login with unknown key: expected 401, got %d", rec.Code)
+	}
+
+	rec := login(f.apiKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Login: expected 200, got %d", rec.Code)
+	}
+	var session api.LoginResponse
+	assertNoError(t, json.NewDecoder(rec.Body).Decode(&session), "Decode login response")
+
+	if code := withToken(session.Token); code != http.StatusOK {
+		t.Errorf("Session token: expected 200, got %d", code)
+	}
+
+	forged, err := auth.SignToken([]byte("some-other-secret-at-least-32-bytes!"), auth.Claims{KeyId: 1, ExpiresAt: session.ExpiresAt.Unix()})
+	assertNoError(t, err, "Sign forged token")
+	if code := withToken(forged); code != http.StatusUnauthorized {
+		t.Errorf("Token signed with another secret: expected 401, got %d", code)
+	}
+
+	keys, err := f.store.ListAPIKeys(context.Background())
+	assertNoError(t, err, "List keys")
+	_, err = f.store.RevokeAPIKey(context.Background(), keys[0].Id)
+	assertNoError(t, err, "Revoke key")
+
+	if code := withToken(session.Token); code != http.StatusUnauthorized {
+		t.Errorf("Session of a revoked key: expected 401, got %d", code)
+	}
+	if code := withToken(f.apiKey); code != http.StatusUnauthorized {
+		t.Errorf("Revoked key: expected 401, got %d", code)
+	}
+}
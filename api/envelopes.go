@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== ENVELOPES ==========
+
+// In envelope mode every category is an envelope: what it has left at the end
+// of a budget period (or, below zero, what it overspent) carries over to the
+// next one, and money can be moved between envelopes. Carryover starts with the
+// household's envelope_since month; earlier periods are budgeted as standard.
+
+// envelopeStart is the stored-form date envelopes carry over from, when the
+// household budgets by envelope
+func envelopeStart(household types.Household) (string, bool) {
+	if household.BudgetMode != types.BudgetEnvelope {
+		return "", false
+	}
+	year, month, err := types.ParseMonth(household.EnvelopeSince)
+	if err != nil {
+		return "", false
+	}
+	start, _ := types.MonthRange(year, month)
+	return start, true
+}
+
+// budgetsIn returns the budgets of each of periods, which are consecutive and in
+// order. In envelope mode what is available to a category also counts what it
+// had left in every earlier period since envelopes started and the money moved
+// in or out of it, and envelopes money was moved into, or that carry over,
+// are reported even without a budget of their own. Budgets and spending are
+// loaded for all those periods at once and the carryover rolled forward here.
+func (h *Handler) budgetsIn(ctx context.Context, household types.Household, periods []types.Period) ([][]types.BudgetByCategory, error) {
+	results := make([][]types.BudgetByCategory, len(periods))
+	if len(periods) == 0 {
+		return results, nil
+	}
+
+	// The envelopes run from their first period, which may be before the first one asked for
+	start, envelopes := envelopeStart(household)
+	last := periods[len(periods)-1]
+	all := periods
+	var moves []types.BudgetMove
+	if envelopes && start < periods[0].Start {
+		all = append(household.BudgetPeriod.Starting(start, periods[0].Start), periods...)
+	}
+	if envelopes && start < last.End {
+		var err error
+		moves, err = h.store.GetBudgetMoves(ctx, types.Period{Start: start, End: last.End})
+		if err != nil {
+			return nil, fmt.Errorf("error getting budget moves: %w", err)
+		}
+	}
+
+	allBudgets, err := h.store.GetBudgets(ctx, all)
+	if err != nil {
+		return nil, fmt.Errorf("error getting budgets: %w", err)
+	}
+
+	carryover := map[int32]types.Money{}
+	var names map[int32]string
+	var spent []map[int32]types.Money
+	offset := len(all) - len(periods)
+	for i, period := range all {
+		budgets := allBudgets[i]
+
+		moved := map[int32]types.Money{}
+		rolls := envelopes && period.Start >= start
+		if rolls {
+			for _, m := range moves {
+				if m.Date >= period.Start && m.Date < period.End {
+					moved[m.FromCategoryId] = moved[m.FromCategoryId].Sub(m.Amount)
+					moved[m.ToCategoryId] = moved[m.ToCategoryId].Add(m.Amount)
+				}
+			}
+
+			// An envelope money was moved into or out of, or that carries
+			// over from an earlier period, is reported without a budget row
+			budgeted := map[int32]bool{}
+			for _, b := range budgets {
+				budgeted[b.CategoryId] = true
+			}
+			var unbudgeted []int32
+			for _, envelope := range []map[int32]types.Money{moved, carryover} {
+				for id := range envelope {
+					if !budgeted[id] {
+						budgeted[id] = true
+						unbudgeted = append(unbudgeted, id)
+					}
+				}
+			}
+			if len(unbudgeted) > 0 {
+				if names == nil {
+					if names, err = h.categoryNames(ctx); err != nil {
+						return nil, err
+					}
+				}
+				if spent == nil {
+					if spent, err = h.store.GetSpentByCategory(ctx, all); err != nil {
+						return nil, fmt.Errorf("error getting spending by category: %w", err)
+					}
+				}
+				for _, id := range unbudgeted {
+					budgets = append(budgets, types.BudgetByCategory{CategoryId: id, CategoryName: names[id], Spent: spent[i][id]})
+				}
+			}
+		}
+		// Every period lists its categories by name, whatever the store's order
+		// and whether unbudgeted envelopes were added
+		sort.Slice(budgets, func(i, j int) bool {
+			if budgets[i].CategoryName != budgets[j].CategoryName {
+				return budgets[i].CategoryName < budgets[j].CategoryName
+			}
+			return budgets[i].CategoryId < budgets[j].CategoryId
+		})
+		for j := range budgets {
+			b := &budgets[j]
+			if rolls {
+				b.Carryover = carryover[b.CategoryId]
+				b.Moved = moved[b.CategoryId]
+			}
+			b.Available = b.Amount.Add(b.Carryover).Add(b.Moved)
+			b.Variance = b.Available.Sub(b.Spent)
+			if rolls {
+				carryover[b.CategoryId] = b.Variance
+			}
+		}
+
+		if i >= offset {
+			results[i-offset] = budgets
+		}
+	}
+	return results, nil
+}
+
+// categoryNames maps the household's categories by id to their names
+func (h *Handler) categoryNames(ctx context.Context) (map[int32]string, error) {
+	categories, err := h.store.GetCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting categories: %w", err)
+	}
+	names := make(map[int32]string, len(categories))
+	for _, c := range categories {
+		names[c.Id] = c.Name
+	}
+	return names, nil
+}
+
+// readyToAssign is the income of periods, which are consecutive and in order,
+// less what was budgeted across them
+func (h *Handler) readyToAssign(ctx context.Context, periods []types.Period, budgets [][]types.BudgetByCategory) (types.Money, error) {
+	income, err := h.store.GetIncomeSum(ctx, types.Period{Start: periods[0].Start, End: periods[len(periods)-1].End})
+	if err != nil {
+		return types.Money{}, fmt.Errorf("error getting income: %w", err)
+	}
+	for _, period := range budgets {
+		for _, b := range period {
+			income = income.Sub(b.Amount)
+		}
+	}
+	return income, nil
+}
+
+// submitBudgetMove moves money from one envelope to another in the budget
+// period containing the move's date, today by default
+func (h *Handler) submitBudgetMove(w http.ResponseWriter, r *http.Request) error {
+	var move types.BudgetMove
+	if err := decodeJSON(r, &move); err != nil {
+		return err
+	}
+	if err := h.validate(r, move); err != nil {
+		return err
+	}
+	move.Date = transactionDate(r.Context(), move.Date)
+
+	inserted, err := h.store.InsertBudgetMove(r.Context(), move)
+	if err != nil {
+		return fmt.Errorf("error inserting budget move: %w", err)
+	}
+	return writeJSON(w, inserted)
+}
+
+// getBudgetMoves lists the moves between envelopes in the budget period
+// containing ?date=, the current one by default
+func (h *Handler) getBudgetMoves(w http.ResponseWriter, r *http.Request) error {
+	date, err := queryDate(r, "date")
+	if err != nil {
+		return err
+	}
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+	period := household.BudgetPeriod.Containing(date)
+
+	moves, err := h.store.GetBudgetMoves(r.Context(), period)
+	if err != nil {
+		return err
+	}
+	if moves == nil {
+		moves = []types.BudgetMove{}
+	}
+	return writeJSON(w, budgetMoveList{Period: period, Moves: moves})
+}
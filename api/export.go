@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/exporter"
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== EXPORTS ==========
+
+// exportData downloads the household's data in ?format= (csv, json, ledger or
+// beancount; json by default), the transactions dated from ?from= to ?to=
+// (days like 2006-01-02, both included; open when left out). A CSV export is
+// the file of ?entity=, or a zip archive with every entity's.
+func (h *Handler) exportData(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	format, entity := query.Get("format"), query.Get("entity")
+	if format == "" {
+		format = types.ExportJSON
+	}
+	if err := exporter.Valid(format, entity); err != nil {
+		return badRequest("%s", err)
+	}
+	period, err := exporter.Range(query.Get("from"), query.Get("to"))
+	if err != nil {
+		return badRequest("%s", err)
+	}
+
+	export, err := exporter.Load(r.Context(), h.store, period)
+	if err != nil {
+		return err
+	}
+	// Written out whole first, so a failure is still an error response
+	var body bytes.Buffer
+	if err := exporter.Write(&body, format, entity, export); err != nil {
+		return fmt.Errorf("error writing export: %w", err)
+	}
+
+	contentType, name := exporter.File(format, entity)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if _, err := body.WriteTo(w); err != nil {
+		log.Printf("Error writing export: %v", err)
+	}
+	return nil
+}
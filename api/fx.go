@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== EXCHANGE RATES ==========
+
+// FXRatesResponse is the household's base currency and its exchange rates
+type FXRatesResponse struct {
+	BaseCurrency string         `json:"base_currency"`
+	Rates        []types.FXRate `json:"rates"`
+}
+
+// getFXRates lists the household's exchange rates, newest first; ?currency=
+// limits them to the rates from or into one currency
+func (h *Handler) getFXRates(w http.ResponseWriter, r *http.Request) error {
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+	rates, err := h.store.GetFXRates(r.Context(), strings.ToUpper(r.URL.Query().Get("currency")))
+	if err != nil {
+		return err
+	}
+	if rates == nil {
+		rates = []types.FXRate{}
+	}
+	return writeJSON(w, FXRatesResponse{BaseCurrency: household.BaseCurrency, Rates: rates})
+}
+
+// setFXRates enters rates by hand, replacing those for the same currency,
+// quote and date
+func (h *Handler) setFXRates(w http.ResponseWriter, r *http.Request) error {
+	var rates []types.FXRate
+	if err := decodeJSON(r, &rates); err != nil {
+		return err
+	}
+	if err := h.validate(r, types.FXRates(rates)); err != nil {
+		return err
+	}
+	for i := range rates {
+		rates[i].Source = types.FXSourceManual
+	}
+
+	stored, err := h.store.UpsertFXRates(r.Context(), rates)
+	if err != nil {
+		return fmt.Errorf("error storing rates: %w", err)
+	}
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household: %w", err)
+	}
+	return writeJSON(w, FXRatesResponse{BaseCurrency: household.BaseCurrency, Rates: stored})
+}
@@ -0,0 +1,1298 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/memory"
+	"github.com/carlosdimatteo/fintrack-backend-go/api"
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	"github.com/carlosdimatteo/fintrack-backend-go/importer"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/gorilla/mux"
+)
+
+var _ api.Store = (*memory.Store)(nil)
+
+// ========== HELPERS ==========
+
+// fixture is a memory store seeded with one of everything the handlers look up
+type fixture struct {
+	store   *memory.Store
+	router  *mux.Router
+	sheet   *googleSS.RecordingSink
+	bank    types.Account
+	crypto  types.InvestmentAccount
+	food    types.Category
+	john    types.Debtor
+	auth    *auth.Authenticator
+	handler *api.Handler
+	apiKey  string // sent by do on every request
+}
+
+var testSecret = []byte("handler-test-secret-at-least-32-bytes")
+
+// ownerCtx reads and seeds the store as the user the fixture's API key belongs to
+var ownerCtx = types.WithScope(context.Background(), memory.Owner)
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{store: memory.New(), sheet: googleSS.NewRecordingSink()}
+
+	var err error
+	f.bank, err = f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{
+		Name: "Bank", Type: "Fiat", Currency: "USD", Balance: types.MoneyFromFloat(1000), StartingBalance: types.MoneyFromFloat(1000),
+	})
+	assertNoError(t, err, "Insert account")
+	f.crypto, err = f.store.InsertInvestmentAccountIntoDatabase(ownerCtx, types.InvestmentAccount{
+		Name: "Crypto", Type: "Crypto", Currency: "USD", Balance: types.MoneyFromFloat(1200), Capital: types.MoneyFromFloat(800), StartingCapital: types.MoneyFromFloat(800),
+	})
+	assertNoError(t, err, "Insert investment account")
+	f.john, err = f.store.InsertDebtorIntoDatabase(ownerCtx, types.Debtor{Name: "John"})
+	assertNoError(t, err, "Insert debtor")
+	f.food, err = f.store.InsertCategory(ownerCtx, types.Category{Name: "Food"})
+	assertNoError(t, err, "Insert category")
+
+	_, err = f.store.InsertConfigIntoDatabase(ownerCtx, []types.Config{
+		{Type: "expenses", Sheet: "TestSheet", A1Range: "!A:I"},
+		{Type: "income", Sheet: "TestSheet", A1Range: "!K:O"},
+		{Type: "income_monthly", Sheet: "2024", A1Range: "!D3"},
+		{Type: "investments", Sheet: "TestSheet", A1Range: "!Q:V"},
+		{Type: "budget", Sheet: "TestSheet", A1Range: "!X:Y"},
+		{Type: "investment_capital", Sheet: "Fintrack Config", A1Range: "!I:L"},
+	})
+	assertNoError(t, err, "Insert config")
+	// The config tab lists another account first, so the capital row isn't
+	// the one the account's id would give
+	f.sheet.SeedRows("Fintrack Config!I:L", [][]interface{}{{"Stocks"}, {f.crypto.Name}})
+
+	f.auth, err = auth.NewAuthenticator(f.store, testSecret, 0)
+	assertNoError(t, err, "New authenticator")
+	f.apiKey, _, err = auth.IssueAPIKey(context.Background(), f.store, memory.Owner.UserId, "tests")
+	assertNoError(t, err, "Issue API key")
+
+	f.handler = api.NewHandler(f.store, googleSS.NewOutbox(nil, f.sheet), f.auth)
+	f.router = mux.NewRouter()
+	api.LoadRoutes(f.router, f.handler)
+	return f
+}
+
+// do sends body as JSON and decodes a 200 response into out
+func (f *fixture) do(t *testing.T, method string, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		assertNoError(t, err, "Encode request body")
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK && out != nil {
+		assertNoError(t, json.NewDecoder(rec.Body).Decode(out), "Decode response")
+	}
+	return rec.Code
+}
+
+func assertNoError(t *testing.T, err error, msg string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", msg, err)
+	}
+}
+
+// assertMoney compares amounts exactly via their two-decimal form
+func assertMoney(t *testing.T, expected string, actual types.Money, msg string) {
+	t.Helper()
+	if actual.String() != expected {
+		t.Errorf("%s: expected %s, got %s", msg, expected, actual)
+	}
+}
+
+func assertFloat(t *testing.T, expected float64, actual float64, msg string) {
+	t.Helper()
+	if math.Abs(expected-actual) > 0.01 {
+		t.Errorf("%s: expected %.2f, got %.2f", msg, expected, actual)
+	}
+}
+
+// ========== HANDLERS ==========
+
+// TestExpenseLifecycle verifies submit, lookup and delete against the memory store
+func TestExpenseLifecycle(t *testing.T) {
+	f := newFixture(t)
+
+	code := f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category": f.food.Name, "category_id": f.food.Id, "expense": 12.5, "description": "Lunch",
+		"account_id": f.bank.Id, "account_type": f.bank.Type,
+	}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Submit expense: expected 200, got %d", code)
+	}
+
+	var expense types.Expense
+	if code := f.do(t, "GET", "/api/expenses/1", nil, &expense); code != http.StatusOK {
+		t.Fatalf("GET expense: expected 200, got %d", code)
+	}
+	if expense.Description != "Lunch" {
+		t.Errorf("Unexpected expense: %+v", expense)
+	}
+	if len(f.sheet.Calls()) != 1 {
+		t.Errorf("Expected the expense row to be written, got %d sheet calls", len(f.sheet.Calls()))
+	}
+
+	if code := f.do(t, "DELETE", "/api/expenses/1", nil, nil); code != http.StatusOK {
+		t.Fatalf("DELETE expense: expected 200, got %d", code)
+	}
+	if code := f.do(t, "GET", "/api/expenses/1", nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET deleted expense: expected 404, got %d", code)
+	}
+}
+
+// TestChangeResponses verifies updates and deletes of incomes, investments and
+// transfers answer like the expense ones, with the row and whether the sheet was queued
+func TestChangeResponses(t *testing.T) {
+	f := newFixture(t)
+	// Submitted through the API so their sheet rows exist to be rewritten and cleared
+	income := types.Income{Id: 1, Amount: types.MoneyFromFloat(2000), Description: "Salary", AccountId: f.bank.Id, AccountName: f.bank.Name}
+	if code := f.do(t, "POST", "/api/income", income, nil); code != http.StatusOK {
+		t.Fatalf("Submit income: expected 200, got %d", code)
+	}
+	investment := types.Investment{Id: 1, Amount: types.MoneyFromFloat(100), AccountId: f.crypto.Id, AccountName: f.crypto.Name, Type: "deposit"}
+	if code := f.do(t, "POST", "/api/investment", investment, nil); code != http.StatusOK {
+		t.Fatalf("Submit investment: expected 200, got %d", code)
+	}
+	savings, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{Name: "Savings", Type: "Fiat"})
+	assertNoError(t, err, "Insert account")
+	transfer, err := f.store.InsertTransfer(ownerCtx, types.Transfer{SourceAccountId: f.bank.Id, SourceAmount: types.MoneyFromFloat(50), DestAccountId: savings.Id, DestAmount: types.MoneyFromFloat(50)})
+	assertNoError(t, err, "Insert transfer")
+
+	var incomeRes struct {
+		Success     bool         `json:"success"`
+		Income      types.Income `json:"income"`
+		SheetQueued bool         `json:"sheet_queued"`
+	}
+	path := fmt.Sprintf("/api/income/%d", income.Id)
+	if code := f.do(t, "PATCH", path, map[string]interface{}{"amount": 2500}, &incomeRes); code != http.StatusOK {
+		t.Fatalf("PATCH income: expected 200, got %d", code)
+	}
+	if !incomeRes.Success || !incomeRes.SheetQueued || incomeRes.Income.Description != "Salary" {
+		t.Errorf("Unexpected income update: %+v", incomeRes)
+	}
+	assertMoney(t, "2500.00", incomeRes.Income.Amount, "Updated income")
+
+	var investmentRes struct {
+		Success     bool             `json:"success"`
+		Investment  types.Investment `json:"investment"`
+		SheetQueued bool             `json:"sheet_queued"`
+	}
+	path = fmt.Sprintf("/api/investments/%d", investment.Id)
+	if code := f.do(t, "DELETE", path, nil, &investmentRes); code != http.StatusOK {
+		t.Fatalf("DELETE investment: expected 200, got %d", code)
+	}
+	if !investmentRes.Success || !investmentRes.SheetQueued || investmentRes.Investment.Id != investment.Id {
+		t.Errorf("Unexpected investment delete: %+v", investmentRes)
+	}
+
+	var transferRes struct {
+		Success     bool           `json:"success"`
+		Transfer    types.Transfer `json:"transfer"`
+		SheetQueued bool           `json:"sheet_queued"`
+	}
+	path = fmt.Sprintf("/api/transfers/%d", transfer.Id)
+	if code := f.do(t, "DELETE", path, nil, &transferRes); code != http.StatusOK {
+		t.Fatalf("DELETE transfer: expected 200, got %d", code)
+	}
+	if !transferRes.Success || transferRes.SheetQueued || transferRes.Transfer.Id != transfer.Id {
+		t.Errorf("Unexpected transfer delete: %+v", transferRes)
+	}
+
+	investment, err = f.store.InsertInvestment(ownerCtx, types.Investment{Amount: types.MoneyFromFloat(100), AccountId: f.crypto.Id, Type: "deposit"})
+	assertNoError(t, err, "Insert investment")
+	investment.Amount = types.Money{}
+	if _, err := f.store.UpdateInvestment(ownerCtx, investment); !errors.Is(err, types.ErrInvalid) {
+		t.Errorf("A zero investment should be invalid, got %v", err)
+	}
+}
+
+// TestIncomeCellYear verifies editing or deleting an income of a year the
+// income_monthly cells aren't for leaves those cells alone
+func TestIncomeCellYear(t *testing.T) {
+	f := newFixture(t)
+	if code := f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 1000, "account_id": f.bank.Id, "date": "2023-03-10"}, nil); code != http.StatusOK {
+		t.Fatalf("Submit income: expected 200, got %d", code)
+	}
+	incomes, _, err := f.store.GetIncomes(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get incomes")
+	path := fmt.Sprintf("/api/income/%d", incomes[0].Id)
+
+	f.sheet.Reset()
+	if code := f.do(t, "PATCH", path, map[string]interface{}{"amount": 1500}, nil); code != http.StatusOK {
+		t.Fatalf("PATCH income: expected 200, got %d", code)
+	}
+	if code := f.do(t, "DELETE", path, nil, nil); code != http.StatusOK {
+		t.Fatalf("DELETE income: expected 200, got %d", code)
+	}
+	for _, call := range f.sheet.Calls() {
+		if call.Op == "cell" {
+			t.Errorf("A 2023 income shouldn't rewrite the 2024 sheet's cell: %+v", call)
+		}
+	}
+
+	f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 200, "account_id": f.bank.Id, "date": "2024-03-10"}, nil)
+	calls := f.sheet.Calls()
+	if last := calls[len(calls)-1]; last.Op != "cell" || last.Range != "2024!D5" || last.Rows[0][0] != 200.0 {
+		t.Errorf("A 2024 income should rewrite March's cell: %+v", last)
+	}
+}
+
+// TestSheetImportAfterChanges verifies incomes and investments changed through
+// the API leave their sheet rows matching, so re-running the sheet import
+// brings back neither a deleted one nor the old values of an edited one
+func TestSheetImportAfterChanges(t *testing.T) {
+	f := newFixture(t)
+	for _, income := range []types.Income{
+		{Amount: types.MoneyFromFloat(2000), Description: "Salary", AccountId: f.bank.Id, AccountName: f.bank.Name},
+		{Amount: types.MoneyFromFloat(150), Description: "Refund", AccountId: f.bank.Id, AccountName: f.bank.Name},
+	} {
+		if code := f.do(t, "POST", "/api/income", income, nil); code != http.StatusOK {
+			t.Fatalf("Submit income: expected 200, got %d", code)
+		}
+	}
+	investment := types.Investment{Amount: types.MoneyFromFloat(100), AccountId: f.crypto.Id, AccountName: f.crypto.Name, Type: "deposit"}
+	if code := f.do(t, "POST", "/api/investment", investment, nil); code != http.StatusOK {
+		t.Fatalf("Submit investment: expected 200, got %d", code)
+	}
+
+	if code := f.do(t, "DELETE", "/api/income/1", nil, nil); code != http.StatusOK {
+		t.Fatalf("DELETE income: expected 200, got %d", code)
+	}
+	if code := f.do(t, "PATCH", "/api/income/2", map[string]interface{}{"amount": 175}, nil); code != http.StatusOK {
+		t.Fatalf("PATCH income: expected 200, got %d", code)
+	}
+	if code := f.do(t, "PATCH", "/api/investments/1", map[string]interface{}{"amount": 120}, nil); code != http.StatusOK {
+		t.Fatalf("PATCH investment: expected 200, got %d", code)
+	}
+
+	report, err := importer.ImportSheet(ownerCtx, f.store, f.sheet, false)
+	assertNoError(t, err, "Import sheet")
+	for _, r := range report.Ranges {
+		if r.Imported != 0 || r.Unmapped != 0 {
+			t.Errorf("Re-running the import on %s: %+v", r.Kind, r)
+		}
+	}
+
+	_, total, err := f.store.GetIncomes(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get incomes")
+	if total != 1 {
+		t.Errorf("Expected only the edited income after the import, got %d", total)
+	}
+	_, total, err = f.store.GetInvestments(ownerCtx, 10, 0, nil)
+	assertNoError(t, err, "Get investments")
+	if total != 1 {
+		t.Errorf("Expected only the edited investment after the import, got %d", total)
+	}
+}
+
+// TestExpectedBalanceEndpoint verifies the account_expected_balance formula
+func TestExpectedBalanceEndpoint(t *testing.T) {
+	f := newFixture(t)
+	other, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{Name: "Savings", Type: "Fiat", Balance: types.MoneyFromFloat(500), StartingBalance: types.MoneyFromFloat(500)})
+	assertNoError(t, err, "Insert second account")
+
+	f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 500, "description": "Salary", "account_id": f.bank.Id}, nil)
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 200, "account_id": f.bank.Id, "account_type": "Fiat",
+	}, nil)
+	// Expenses charged to an investment account don't touch fiat balances
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 50, "account_id": f.bank.Id, "account_type": "Crypto",
+	}, nil)
+	f.do(t, "POST", "/api/investment", map[string]interface{}{
+		"amount": 300, "account_id": f.crypto.Id, "type": "deposit", "source_account_id": f.bank.Id,
+	}, nil)
+	f.do(t, "POST", "/api/investment", map[string]interface{}{
+		"amount": 100, "account_id": f.crypto.Id, "type": "withdrawal", "source_account_id": f.bank.Id,
+	}, nil)
+	f.do(t, "POST", "/api/transfer", map[string]interface{}{
+		"source_account_id": f.bank.Id, "source_amount": 150, "dest_account_id": other.Id, "dest_amount": 150,
+	}, nil)
+	f.do(t, "POST", "/api/transfer", map[string]interface{}{
+		"source_account_id": other.Id, "source_amount": 80, "dest_account_id": f.bank.Id, "dest_amount": 80,
+	}, nil)
+
+	var balances []types.AccountExpectedBalance
+	if code := f.do(t, "GET", "/api/accounts/expected-balance", nil, &balances); code != http.StatusOK {
+		t.Fatalf("GET expected balances: expected 200, got %d", code)
+	}
+	var bank *types.AccountExpectedBalance
+	for i := range balances {
+		if balances[i].Id == f.bank.Id {
+			bank = &balances[i]
+		}
+	}
+	if bank == nil {
+		t.Fatal("Bank account missing from expected balances")
+	}
+
+	// 1000 + 500 - 200 - 300 + 100 - 150 + 80
+	assertMoney(t, "1030.00", bank.ExpectedBalance, "Expected balance")
+	assertMoney(t, "1000.00", bank.RealBalance, "Real balance")
+	assertMoney(t, "-30.00", bank.Discrepancy, "Discrepancy is real minus expected")
+	assertMoney(t, "200.00", bank.TotalExpenses, "Only fiat expenses count")
+}
+
+// TestDebtsByDebtorEndpoint verifies debt_by_debtor nets lending against repayments
+func TestDebtsByDebtorEndpoint(t *testing.T) {
+	f := newFixture(t)
+
+	code := f.do(t, "POST", "/api/expense-debt", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 100, "description": "Lent to John",
+		"account_id": f.bank.Id, "account_type": "Fiat",
+		"debts": []map[string]interface{}{{"debtor_id": f.john.Id, "debtor_name": f.john.Name, "amount": 100}},
+	}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Submit expense with debt: expected 200, got %d", code)
+	}
+	code = f.do(t, "POST", "/api/debt/repayment", map[string]interface{}{
+		"debtor_id": f.john.Id, "debtor_name": f.john.Name, "amount": 40, "account_id": f.bank.Id,
+	}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Submit repayment: expected 200, got %d", code)
+	}
+
+	var summary []types.DebtByDebtor
+	if code := f.do(t, "GET", "/api/debts/by-debtor", nil, &summary); code != http.StatusOK {
+		t.Fatalf("GET debts by debtor: expected 200, got %d", code)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("Expected one debtor, got %d", len(summary))
+	}
+	assertMoney(t, "100.00", summary[0].TotalLent, "Total lent")
+	assertMoney(t, "40.00", summary[0].TotalReceived, "Total received")
+	assertMoney(t, "60.00", summary[0].NetOwed, "Net owed")
+	if summary[0].TransactionCount != 2 {
+		t.Errorf("Transaction count: expected 2, got %d", summary[0].TransactionCount)
+	}
+}
+
+// TestInvestmentSummaryEndpoint verifies PnL follows capital as deposits come in
+func TestInvestmentSummaryEndpoint(t *testing.T) {
+	f := newFixture(t)
+
+	f.do(t, "POST", "/api/investment", map[string]interface{}{"amount": 200, "account_id": f.crypto.Id, "type": "deposit"}, nil)
+
+	var summary []types.InvestmentAccountSummary
+	if code := f.do(t, "GET", "/api/investment-accounts/summary", nil, &summary); code != http.StatusOK {
+		t.Fatalf("GET investment summary: expected 200, got %d", code)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("Expected one investment account, got %d", len(summary))
+	}
+	assertMoney(t, "1000.00", summary[0].TotalCapital, "Capital includes the deposit")
+	assertMoney(t, "200.00", summary[0].PnL, "PnL is balance minus capital")
+	assertFloat(t, 20, summary[0].PnLPercent, "PnL percent of capital")
+
+	calls := f.sheet.Calls()
+	last := calls[len(calls)-1]
+	if last.Range != "Fintrack Config!L2" || last.Rows[0][0] != 1000.0 {
+		t.Errorf("Capital cell not refreshed in the account's row: %+v", last)
+	}
+}
+
+// noCapitalConfigStore is a store whose household never set up investment_capital
+type noCapitalConfigStore struct {
+	api.Store
+}
+
+func (s noCapitalConfigStore) GetConfig(ctx context.Context) ([]types.Config, error) {
+	configs, err := s.Store.GetConfig(ctx)
+	kept := []types.Config{}
+	for _, config := range configs {
+		if config.Type != "investment_capital" {
+			kept = append(kept, config)
+		}
+	}
+	return kept, err
+}
+
+// TestInvestmentCapitalCellFallbacks verifies a sheet without an investment_capital
+// range keeps its L{id+2} capital cell, and an account the range doesn't list
+// is skipped rather than failing the write
+func TestInvestmentCapitalCellFallbacks(t *testing.T) {
+	f := newFixture(t)
+	unlisted, err := f.store.InsertInvestmentAccountIntoDatabase(ownerCtx, types.InvestmentAccount{Name: "Bonds", Type: "Bonds", Currency: "USD"})
+	assertNoError(t, err, "Insert investment account")
+	var res struct {
+		SheetQueued bool `json:"sheet_queued"`
+	}
+	// Submitted through the API so its sheet row exists to be cleared
+	f.do(t, "POST", "/api/investment", map[string]interface{}{"amount": 50, "account_id": unlisted.Id, "type": "deposit"}, nil)
+	investments, _, err := f.store.GetInvestments(ownerCtx, 1, 0, &unlisted.Id)
+	assertNoError(t, err, "Get investments")
+	if code := f.do(t, "DELETE", fmt.Sprintf("/api/investments/%d", investments[0].Id), nil, &res); code != http.StatusOK {
+		t.Fatalf("DELETE investment: expected 200, got %d", code)
+	}
+	if !res.SheetQueued {
+		t.Error("Deleting an unlisted account's investment should still queue its sheet writes")
+	}
+	for _, call := range f.sheet.Calls() {
+		if call.Op == "cell" {
+			t.Errorf("An unlisted account has no capital cell to write: %+v", call)
+		}
+	}
+
+	f.router = mux.NewRouter()
+	api.LoadRoutes(f.router, api.NewHandler(noCapitalConfigStore{f.store}, googleSS.NewOutbox(nil, f.sheet), f.auth))
+	f.do(t, "POST", "/api/investment", map[string]interface{}{"amount": 200, "account_id": f.crypto.Id, "type": "deposit"}, nil)
+	calls := f.sheet.Calls()
+	if last := calls[len(calls)-1]; last.Range != fmt.Sprintf("Fintrack Config!L%d", f.crypto.Id+2) || last.Rows[0][0] != 1000.0 {
+		t.Errorf("Capital cell should fall back to L{id+2}: %+v", last)
+	}
+}
+
+// TestBudgetSpentEndpoint verifies GET /api/budget sums this month's expenses
+func TestBudgetSpentEndpoint(t *testing.T) {
+	f := newFixture(t)
+	transport, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Transport"})
+	assertNoError(t, err, "Insert category")
+
+	f.do(t, "POST", "/api/budget", []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300)}, {CategoryId: transport.Id, Amount: types.MoneyFromFloat(100)}}, nil)
+	for _, amount := range []float64{20, 35} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": amount, "account_id": f.bank.Id, "account_type": "Fiat",
+		}, nil)
+	}
+
+	var res struct {
+		Budgets []types.BudgetByCategory `json:"budgets"`
+	}
+	if code := f.do(t, "GET", "/api/budget", nil, &res); code != http.StatusOK {
+		t.Fatalf("GET budget: expected 200, got %d", code)
+	}
+	if len(res.Budgets) != 2 {
+		t.Fatalf("Expected two budgets, got %d", len(res.Budgets))
+	}
+	food, other := res.Budgets[0], res.Budgets[1]
+	if food.CategoryName != "Food" || other.CategoryName != "Transport" {
+		t.Fatalf("Budgets should be ordered by category name: %+v", res.Budgets)
+	}
+	assertMoney(t, "300.00", food.Amount, "Food budget")
+	assertMoney(t, "55.00", food.Spent, "Food spent")
+	assertMoney(t, "0.00", other.Spent, "Transport spent")
+}
+
+// TestHouseholdEndpoint verifies the caller's household can be read and renamed
+func TestHouseholdEndpoint(t *testing.T) {
+	f := newFixture(t)
+	f.store.AddUser(memory.Owner.HouseholdId, "Partner")
+
+	var res api.HouseholdResponse
+	if code := f.do(t, "PATCH", "/api/household", map[string]string{"spreadsheet_id": "sheet-2"}, &res); code != http.StatusOK {
+		t.Fatalf("PATCH household: expected 200, got %d", code)
+	}
+	if res.Household.SpreadsheetId != "sheet-2" || res.Household.Name == "" {
+		t.Errorf("PATCH should keep the name and change the spreadsheet: %+v", res.Household)
+	}
+	if len(res.Users) != 2 {
+		t.Errorf("Expected both members, got %+v", res.Users)
+	}
+
+	if code := f.do(t, "PATCH", "/api/household", map[string]string{"name": ""}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Empty name: expected 422, got %d", code)
+	}
+}
+
+// TestCreateCategory verifies a category added through the API can be budgeted
+// and that a name is required
+func TestCreateCategory(t *testing.T) {
+	f := newFixture(t)
+
+	var category types.Category
+	if code := f.do(t, "POST", "/api/categories", map[string]interface{}{"name": "Rent", "is_essential": true}, &category); code != http.StatusOK {
+		t.Fatalf("POST category: expected 200, got %d", code)
+	}
+	if category.Id == 0 || category.Name != "Rent" || !category.IsEssential {
+		t.Errorf("Unexpected category: %+v", category)
+	}
+	budgets := []types.Budget{{CategoryId: category.Id, Amount: types.MoneyFromFloat(900)}}
+	if code := f.do(t, "POST", "/api/budget", budgets, nil); code != http.StatusOK {
+		t.Errorf("Budget the new category: expected 200, got %d", code)
+	}
+
+	if code := f.do(t, "POST", "/api/categories", map[string]interface{}{"name": ""}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Empty name: expected 422, got %d", code)
+	}
+	if _, err := f.store.GetInvestmentAccountCapital(ownerCtx, 99); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Capital of an unknown account: expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestPrivateAccounts verifies a member doesn't see another's private account or
+// what was recorded on it, and another household sees none of the first one's rows
+func TestPrivateAccounts(t *testing.T) {
+	f := newFixture(t)
+	private, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{Name: "Personal", Type: "Fiat", Currency: "USD", OwnerId: &memory.Owner.UserId})
+	assertNoError(t, err, "Insert private account")
+	code := f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category": f.food.Name, "category_id": f.food.Id, "expense": 12.5, "description": "Lunch",
+		"account_id": private.Id, "account_type": private.Type,
+	}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Submit expense: expected 200, got %d", code)
+	}
+
+	partner := f.store.AddUser(memory.Owner.HouseholdId, "Partner")
+	f.apiKey, _, err = auth.IssueAPIKey(context.Background(), f.store, partner.Id, "partner")
+	assertNoError(t, err, "Issue partner key")
+	var accounts struct {
+		Accounts []types.Account `json:"accounts"`
+	}
+	if code := f.do(t, "GET", "/api/accounts", nil, &accounts); code != http.StatusOK {
+		t.Fatalf("GET accounts: expected 200, got %d", code)
+	}
+	if len(accounts.Accounts) != 1 || accounts.Accounts[0].Id != f.bank.Id {
+		t.Errorf("The partner should only see the shared account: %+v", accounts.Accounts)
+	}
+	if code := f.do(t, "GET", "/api/expenses/1", nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET private expense: expected 404, got %d", code)
+	}
+
+	neighbours := f.store.AddHousehold("Neighbours")
+	neighbour := f.store.AddUser(neighbours.Id, "Neighbour")
+	f.apiKey, _, err = auth.IssueAPIKey(context.Background(), f.store, neighbour.Id, "neighbour")
+	assertNoError(t, err, "Issue neighbour key")
+	accounts.Accounts = nil
+	if code := f.do(t, "GET", "/api/accounts", nil, &accounts); code != http.StatusOK {
+		t.Fatalf("GET accounts: expected 200, got %d", code)
+	}
+	if len(accounts.Accounts) != 0 {
+		t.Errorf("Another household should see no accounts: %+v", accounts.Accounts)
+	}
+	var categories struct {
+		Categories []types.Category `json:"categories"`
+	}
+	if code := f.do(t, "GET", "/api/categories", nil, &categories); code != http.StatusOK {
+		t.Fatalf("GET categories: expected 200, got %d", code)
+	}
+	if len(categories.Categories) != 0 {
+		t.Errorf("Another household should see no categories: %+v", categories.Categories)
+	}
+}
+
+// TestBackdatedTransactions verifies a client date is kept and totals follow it rather than the submit time
+func TestBackdatedTransactions(t *testing.T) {
+	f := newFixture(t)
+
+	if code := f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 500, "account_id": f.bank.Id, "date": "2024-01-15"}, nil); code != http.StatusOK {
+		t.Fatalf("POST backdated income: expected 200, got %d", code)
+	}
+	incomes, _, err := f.store.GetIncomes(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get incomes")
+	if len(incomes) != 1 || incomes[0].Date != "2024-01-15 00:00:00" {
+		t.Fatalf("Income date should be stored normalised: %+v", incomes)
+	}
+	calls := f.sheet.Calls()
+	last := calls[len(calls)-1]
+	if last.Range != "2024!D3" || last.Rows[0][0] != 500.0 {
+		t.Errorf("January's income cell not refreshed: %+v", last)
+	}
+
+	f.do(t, "POST", "/api/budget", []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300)}}, nil)
+	for _, date := range []string{"2024-01-20 12:30:00", ""} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": 40, "account_id": f.bank.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+	var res struct {
+		Budgets []types.BudgetByCategory `json:"budgets"`
+	}
+	f.do(t, "GET", "/api/budget", nil, &res)
+	if len(res.Budgets) != 1 {
+		t.Fatalf("Expected one budget, got %d", len(res.Budgets))
+	}
+	assertMoney(t, "40.00", res.Budgets[0].Spent, "Only this month's expense counts against the budget")
+
+	january, err := f.store.GetExpenseSum(ownerCtx, types.MonthPeriod(2024, 1))
+	assertNoError(t, err, "January expense sum")
+	assertMoney(t, "40.00", january, "Backdated expense counts in its own month")
+
+	if code := f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 5, "account_id": f.bank.Id, "date": "31/01/2024"}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Unparseable date: expected 422, got %d", code)
+	}
+}
+
+// TestHouseholdTimezone verifies a late-evening transaction stays in the month
+// the household saw it in, whatever time it is in UTC
+func TestHouseholdTimezone(t *testing.T) {
+	f := newFixture(t)
+
+	var res api.HouseholdResponse
+	if code := f.do(t, "PATCH", "/api/household", map[string]string{"timezone": "America/Bogota"}, &res); code != http.StatusOK {
+		t.Fatalf("PATCH household timezone: expected 200, got %d", code)
+	}
+	if res.Household.Timezone != "America/Bogota" {
+		t.Errorf("Timezone not saved: %+v", res.Household)
+	}
+
+	// 23:30 on January 31st in Bogotá is already February in UTC
+	if code := f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 80, "account_id": f.bank.Id, "date": "2024-02-01T04:30:00Z"}, nil); code != http.StatusOK {
+		t.Fatalf("POST income: expected 200, got %d", code)
+	}
+	incomes, _, err := f.store.GetIncomes(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get incomes")
+	if len(incomes) != 1 || incomes[0].Date != "2024-01-31 23:30:00" {
+		t.Fatalf("Income should be stored in Bogotá time: %+v", incomes)
+	}
+	calls := f.sheet.Calls()
+	if last := calls[len(calls)-1]; last.Range != "2024!D3" {
+		t.Errorf("January's income cell should be refreshed, got %+v", last)
+	}
+
+	if code := f.do(t, "PATCH", "/api/household", map[string]string{"timezone": "Mars/Olympus"}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Unknown timezone: expected 422, got %d", code)
+	}
+}
+
+// TestPaydayBudgetPeriod verifies budgets, the dashboard and the income summary
+// follow a period running from the 25th to the 24th
+func TestPaydayBudgetPeriod(t *testing.T) {
+	f := newFixture(t)
+
+	period := map[string]interface{}{"budget_period": map[string]interface{}{"kind": "monthly", "start_day": 25}}
+	if code := f.do(t, "PATCH", "/api/household", period, nil); code != http.StatusOK {
+		t.Fatalf("PATCH budget period: expected 200, got %d", code)
+	}
+
+	f.do(t, "POST", "/api/budget", []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"}}, nil)
+	for date, amount := range map[string]float64{"2024-03-24": 10, "2024-03-25": 20, "2024-04-10": 30} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": amount, "account_id": f.bank.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+	for _, date := range []string{"2024-01-26", "2024-02-10"} {
+		f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 1000, "account_id": f.bank.Id, "date": date}, nil)
+	}
+
+	var budgets struct {
+		Period  types.Period             `json:"period"`
+		Budgets []types.BudgetByCategory `json:"budgets"`
+	}
+	if code := f.do(t, "GET", "/api/budget?date=2024-04-01", nil, &budgets); code != http.StatusOK {
+		t.Fatalf("GET budget: expected 200, got %d", code)
+	}
+	if budgets.Period.Start != "2024-03-25 00:00:00" || budgets.Period.End != "2024-04-25 00:00:00" {
+		t.Errorf("Unexpected period: %+v", budgets.Period)
+	}
+	assertMoney(t, "50.00", budgets.Budgets[0].Spent, "Spent from the 25th")
+
+	var dashboard api.DashboardResponse
+	if code := f.do(t, "GET", "/api/dashboard?date=2024-02-01", nil, &dashboard); code != http.StatusOK {
+		t.Fatalf("GET dashboard: expected 200, got %d", code)
+	}
+	if dashboard.CurrentMonth.Year != 2024 || dashboard.CurrentMonth.Month != 1 {
+		t.Errorf("Period should be labelled with its start: %+v", dashboard.CurrentMonth)
+	}
+	assertMoney(t, "2000.00", dashboard.CurrentMonth.Income, "Period income")
+
+	// A period starting in December is still last year's, YTD included
+	if code := f.do(t, "GET", "/api/dashboard?date=2025-01-10", nil, &dashboard); code != http.StatusOK {
+		t.Fatalf("GET dashboard: expected 200, got %d", code)
+	}
+	if dashboard.CurrentMonth.Year != 2024 || dashboard.CurrentMonth.Month != 12 {
+		t.Errorf("Period should start in December 2024: %+v", dashboard.CurrentMonth)
+	}
+	assertMoney(t, "60.00", dashboard.YTD.Expenses, "YTD of the year the period starts in")
+
+	var summary []types.MonthlyIncomeSummary
+	if code := f.do(t, "GET", "/api/income/summary?year=2024", nil, &summary); code != http.StatusOK {
+		t.Fatalf("GET income summary: expected 200, got %d", code)
+	}
+	if len(summary) != 1 || summary[0].Month != 1 || summary[0].Start != "2024-01-25 00:00:00" {
+		t.Fatalf("Both incomes should fall in the period starting January 25th: %+v", summary)
+	}
+	assertMoney(t, "2000.00", summary[0].TotalIncome, "Summary income")
+
+	if code := f.do(t, "GET", "/api/budget?date=someday", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("Invalid date: expected 400, got %d", code)
+	}
+	invalid := map[string]interface{}{"budget_period": map[string]interface{}{"kind": "monthly", "start_day": 31}}
+	if code := f.do(t, "PATCH", "/api/household", invalid, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Start day 31: expected 422, got %d", code)
+	}
+}
+
+// TestBudgetHistory verifies a later month's budget doesn't rewrite earlier
+// months, and budget against spending is reported per month with totals
+func TestBudgetHistory(t *testing.T) {
+	f := newFixture(t)
+
+	for _, budget := range []types.Budget{
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"},
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(400), Month: "2024-03"},
+	} {
+		if code := f.do(t, "POST", "/api/budget", []types.Budget{budget}, nil); code != http.StatusOK {
+			t.Fatalf("POST budget for %s: expected 200, got %d", budget.Month, code)
+		}
+	}
+	calls := f.sheet.Calls()
+	if last := calls[len(calls)-1]; last.Range != "TestSheet!X:Y" || len(last.Rows[0]) != 1 || last.Rows[0][0] != 400.0 {
+		t.Errorf("Budget row should keep the sheet's single amount column: %+v", last)
+	}
+
+	for date, amount := range map[string]float64{"2024-01-10": 100, "2024-02-05": 350, "2024-03-15": 50} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": amount, "account_id": f.bank.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+
+	var res api.BudgetHistoryResponse
+	if code := f.do(t, "GET", "/api/budget/history?from=2024-01&to=2024-03", nil, &res); code != http.StatusOK {
+		t.Fatalf("GET budget history: expected 200, got %d", code)
+	}
+	if len(res.Periods) != 3 {
+		t.Fatalf("Expected three months, got %+v", res.Periods)
+	}
+	expected := []struct{ month, amount, spent, variance string }{
+		{"2024-01", "300.00", "100.00", "200.00"},
+		{"2024-02", "300.00", "350.00", "-50.00"},
+		{"2024-03", "400.00", "50.00", "350.00"},
+	}
+	for i, e := range expected {
+		p := res.Periods[i]
+		if p.Month != e.month {
+			t.Errorf("Period %d: expected %s, got %s", i, e.month, p.Month)
+		}
+		assertMoney(t, e.amount, p.Amount, e.month+" budget")
+		assertMoney(t, e.spent, p.Spent, e.month+" spent")
+		assertMoney(t, e.variance, p.Variance, e.month+" variance")
+	}
+	assertMoney(t, "1000.00", res.Amount, "Total budget")
+	assertMoney(t, "500.00", res.Spent, "Total spent")
+	assertMoney(t, "500.00", res.Variance, "Total variance")
+
+	if code := f.do(t, "GET", "/api/budget/history?from=2024-03&to=2024-01", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("Reversed range: expected 400, got %d", code)
+	}
+	bad := []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(1), Month: "2024-13"}}
+	if code := f.do(t, "POST", "/api/budget", bad, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Invalid month: expected 422, got %d", code)
+	}
+}
+
+// TestBudgetSheetMonths verifies a month with its own budget_<month> range is
+// written there, and every other month to the budget range
+func TestBudgetSheetMonths(t *testing.T) {
+	f := newFixture(t)
+	_, err := f.store.InsertConfigIntoDatabase(ownerCtx, []types.Config{{Type: "budget_2024-02", Sheet: "Feb", A1Range: "!B:B"}})
+	assertNoError(t, err, "Insert config")
+
+	budgets := []types.Budget{
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"},
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(350), Month: "2024-02"},
+	}
+	if code := f.do(t, "POST", "/api/budget", budgets, nil); code != http.StatusOK {
+		t.Fatalf("POST budgets: expected 200, got %d", code)
+	}
+
+	ranges := map[string]float64{}
+	for _, call := range f.sheet.Calls() {
+		if call.Op == "append" && len(call.Rows) == 1 {
+			ranges[call.Range] = call.Rows[0][0].(float64)
+		}
+	}
+	if len(ranges) != 2 || ranges["TestSheet!X:Y"] != 300 || ranges["Feb!B:B"] != 350 {
+		t.Errorf("Expected January in the budget range and February in its own, got %+v", ranges)
+	}
+}
+
+// reversedBudgetsStore is a store listing each period's budgets in reverse,
+// as a database collating names differently might
+type reversedBudgetsStore struct {
+	api.Store
+}
+
+func (s reversedBudgetsStore) GetBudgets(ctx context.Context, periods []types.Period) ([][]types.BudgetByCategory, error) {
+	results, err := s.Store.GetBudgets(ctx, periods)
+	for _, budgets := range results {
+		for i, j := 0, len(budgets)-1; i < j; i, j = i+1, j-1 {
+			budgets[i], budgets[j] = budgets[j], budgets[i]
+		}
+	}
+	return results, err
+}
+
+// TestBudgetOrder verifies a period's categories are listed by name in both
+// budgeting modes, with or without a move between envelopes, whatever the
+// store's order
+func TestBudgetOrder(t *testing.T) {
+	f := newFixture(t)
+	f.router = mux.NewRouter()
+	api.LoadRoutes(f.router, api.NewHandler(reversedBudgetsStore{f.store}, googleSS.NewOutbox(nil, f.sheet), f.auth))
+	bills, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Bills"})
+	assertNoError(t, err, "Insert category")
+	f.do(t, "POST", "/api/budget", []types.Budget{
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"},
+		{CategoryId: bills.Id, Amount: types.MoneyFromFloat(100), Month: "2024-01"},
+	}, nil)
+
+	names := func(date string) []string {
+		t.Helper()
+		var res struct {
+			Budgets []types.BudgetByCategory `json:"budgets"`
+		}
+		if code := f.do(t, "GET", "/api/budget?date="+date, nil, &res); code != http.StatusOK {
+			t.Fatalf("GET budget: expected 200, got %d", code)
+		}
+		var names []string
+		for _, b := range res.Budgets {
+			names = append(names, b.CategoryName)
+		}
+		return names
+	}
+	if got := strings.Join(names("2024-01-15"), ","); got != "Bills,Food" {
+		t.Errorf("Standard mode: expected Bills,Food, got %s", got)
+	}
+
+	f.do(t, "PATCH", "/api/household", map[string]interface{}{"budget_mode": "envelope", "envelope_since": "2024-01"}, nil)
+	if got := strings.Join(names("2024-01-15"), ","); got != "Bills,Food" {
+		t.Errorf("Envelopes without moves: expected Bills,Food, got %s", got)
+	}
+	rent, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Rent"})
+	assertNoError(t, err, "Insert category")
+	f.do(t, "POST", "/api/budget/moves", map[string]interface{}{"from_category_id": f.food.Id, "to_category_id": rent.Id, "amount": 30, "date": "2024-01-20"}, nil)
+	if got := strings.Join(names("2024-01-15"), ","); got != "Bills,Food,Rent" {
+		t.Errorf("Envelopes with a move: expected Bills,Food,Rent, got %s", got)
+	}
+}
+
+// TestEnvelopeBudgets verifies envelopes carry what is left over into the next
+// period, moves shift money between them and ready to assign counts every
+// period since envelopes started
+func TestEnvelopeBudgets(t *testing.T) {
+	f := newFixture(t)
+	fun, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Fun"})
+	assertNoError(t, err, "Insert category")
+
+	mode := map[string]interface{}{"budget_mode": "envelope", "envelope_since": "2024-01"}
+	if code := f.do(t, "PATCH", "/api/household", mode, nil); code != http.StatusOK {
+		t.Fatalf("PATCH budget mode: expected 200, got %d", code)
+	}
+	f.do(t, "POST", "/api/budget", []types.Budget{
+		{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"},
+		{CategoryId: fun.Id, Amount: types.MoneyFromFloat(100), Month: "2024-01"},
+	}, nil)
+	f.do(t, "POST", "/api/income", map[string]interface{}{"amount": 1000, "account_id": f.bank.Id, "date": "2024-01-05"}, nil)
+	for date, amount := range map[string]float64{"2024-01-10": 100, "2024-02-05": 350} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": amount, "account_id": f.bank.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+
+	move := types.BudgetMove{Date: "2024-02-10", FromCategoryId: fun.Id, ToCategoryId: f.food.Id, Amount: types.MoneyFromFloat(30)}
+	if code := f.do(t, "POST", "/api/budget/moves", move, nil); code != http.StatusOK {
+		t.Fatalf("POST budget move: expected 200, got %d", code)
+	}
+	var moves struct {
+		Moves []types.BudgetMove `json:"moves"`
+	}
+	if code := f.do(t, "GET", "/api/budget/moves?date=2024-02-01", nil, &moves); code != http.StatusOK || len(moves.Moves) != 1 {
+		t.Fatalf("GET budget moves: expected one move, got %d %+v", code, moves)
+	}
+
+	var budgets struct {
+		Budgets       []types.BudgetByCategory `json:"budgets"`
+		ReadyToAssign types.Money              `json:"ready_to_assign"`
+	}
+	if code := f.do(t, "GET", "/api/budget?date=2024-02-01", nil, &budgets); code != http.StatusOK {
+		t.Fatalf("GET budget: expected 200, got %d", code)
+	}
+	expected := map[int32]struct{ carryover, moved, available, variance string }{
+		f.food.Id: {"200.00", "30.00", "530.00", "180.00"},
+		fun.Id:    {"100.00", "-30.00", "170.00", "170.00"},
+	}
+	if len(budgets.Budgets) != len(expected) {
+		t.Fatalf("Expected two envelopes, got %+v", budgets.Budgets)
+	}
+	for _, b := range budgets.Budgets {
+		e := expected[b.CategoryId]
+		assertMoney(t, e.carryover, b.Carryover, b.CategoryName+" carryover")
+		assertMoney(t, e.moved, b.Moved, b.CategoryName+" moved")
+		assertMoney(t, e.available, b.Available, b.CategoryName+" available")
+		assertMoney(t, e.variance, b.Variance, b.CategoryName+" variance")
+	}
+	assertMoney(t, "200.00", budgets.ReadyToAssign, "Income less two months of budgets")
+
+	var history api.BudgetHistoryResponse
+	if code := f.do(t, "GET", "/api/budget/history?from=2024-02&to=2024-02", nil, &history); code != http.StatusOK {
+		t.Fatalf("GET budget history: expected 200, got %d", code)
+	}
+	assertMoney(t, "350.00", history.Periods[0].Available.Sub(history.Periods[0].Spent), "History carries over from January")
+	assertMoney(t, "50.00", history.Periods[0].Variance, "Period variance is budget less spent")
+	assertMoney(t, history.Periods[0].Variance.String(), history.Variance, "Period and range variances add up")
+
+	// Gifts has no budget: what is moved into it is its envelope, and carries over
+	gifts, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Gifts"})
+	assertNoError(t, err, "Insert category")
+	move = types.BudgetMove{Date: "2024-03-02", FromCategoryId: fun.Id, ToCategoryId: gifts.Id, Amount: types.MoneyFromFloat(50)}
+	if code := f.do(t, "POST", "/api/budget/moves", move, nil); code != http.StatusOK {
+		t.Fatalf("POST budget move into Gifts: expected 200, got %d", code)
+	}
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": gifts.Id, "expense": 20, "account_id": f.bank.Id, "account_type": "Fiat", "date": "2024-03-05",
+	}, nil)
+	for date, e := range map[string]struct{ carryover, moved, spent, variance string }{
+		"2024-03-01": {"0.00", "50.00", "20.00", "30.00"},
+		"2024-04-01": {"30.00", "0.00", "0.00", "30.00"},
+	} {
+		if code := f.do(t, "GET", "/api/budget?date="+date, nil, &budgets); code != http.StatusOK {
+			t.Fatalf("GET budget %s: expected 200, got %d", date, code)
+		}
+		found := false
+		for _, b := range budgets.Budgets {
+			if b.CategoryId == gifts.Id {
+				found = true
+				assertMoney(t, e.carryover, b.Carryover, "Gifts carryover on "+date)
+				assertMoney(t, e.moved, b.Moved, "Gifts moved on "+date)
+				assertMoney(t, e.spent, b.Spent, "Gifts spent on "+date)
+				assertMoney(t, e.variance, b.Variance, "Gifts variance on "+date)
+			}
+		}
+		if !found || len(budgets.Budgets) != 3 {
+			t.Errorf("Expected Gifts among three envelopes on %s, got %+v", date, budgets.Budgets)
+		}
+	}
+
+	same := types.BudgetMove{FromCategoryId: fun.Id, ToCategoryId: fun.Id, Amount: types.MoneyFromFloat(1)}
+	if code := f.do(t, "POST", "/api/budget/moves", same, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Move into the same envelope: expected 422, got %d", code)
+	}
+	if code := f.do(t, "PATCH", "/api/household", map[string]interface{}{"budget_mode": "jars"}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Unknown budget mode: expected 422, got %d", code)
+	}
+}
+
+// budgetLoads counts the budget and spending loads the handlers make
+type budgetLoads struct {
+	*memory.Store
+	loads int
+}
+
+func (s *budgetLoads) GetBudgets(ctx context.Context, periods []types.Period) ([][]types.BudgetByCategory, error) {
+	s.loads++
+	return s.Store.GetBudgets(ctx, periods)
+}
+
+func (s *budgetLoads) GetSpentByCategory(ctx context.Context, periods []types.Period) ([]map[int32]types.Money, error) {
+	s.loads++
+	return s.Store.GetSpentByCategory(ctx, periods)
+}
+
+// TestEnvelopeSpan verifies envelopes started years back are carried over from
+// a fixed number of loads rather than one per period
+func TestEnvelopeSpan(t *testing.T) {
+	f := newFixture(t)
+	store := &budgetLoads{Store: f.store}
+	f.router = mux.NewRouter()
+	api.LoadRoutes(f.router, api.NewHandler(store, googleSS.NewOutbox(nil, f.sheet), f.auth))
+
+	gifts, err := f.store.InsertCategory(ownerCtx, types.Category{Name: "Gifts"})
+	assertNoError(t, err, "Insert category")
+	mode := map[string]interface{}{"budget_mode": "envelope", "envelope_since": "2015-01"}
+	if code := f.do(t, "PATCH", "/api/household", mode, nil); code != http.StatusOK {
+		t.Fatalf("PATCH budget mode: expected 200, got %d", code)
+	}
+	f.do(t, "POST", "/api/budget", []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2015-01"}}, nil)
+	move := types.BudgetMove{Date: "2015-02-10", FromCategoryId: f.food.Id, ToCategoryId: gifts.Id, Amount: types.MoneyFromFloat(50)}
+	if code := f.do(t, "POST", "/api/budget/moves", move, nil); code != http.StatusOK {
+		t.Fatalf("POST budget move: expected 200, got %d", code)
+	}
+
+	store.loads = 0
+	var budgets struct {
+		Budgets []types.BudgetByCategory `json:"budgets"`
+	}
+	if code := f.do(t, "GET", "/api/budget?date=2024-06-01", nil, &budgets); code != http.StatusOK {
+		t.Fatalf("GET budget: expected 200, got %d", code)
+	}
+	if store.loads > 2 {
+		t.Errorf("Expected the span since 2015 in at most two loads, got %d", store.loads)
+	}
+
+	// 113 months of 300 since January 2015, less the 50 moved to Gifts
+	expected := map[int32]string{f.food.Id: "33850.00", gifts.Id: "50.00"}
+	if len(budgets.Budgets) != len(expected) {
+		t.Fatalf("Expected two envelopes, got %+v", budgets.Budgets)
+	}
+	for _, b := range budgets.Budgets {
+		assertMoney(t, expected[b.CategoryId], b.Carryover, b.CategoryName+" carryover")
+	}
+}
+
+// TestFXConversion verifies amounts recorded in another currency are totalled
+// in the base currency at the rate in effect on their date
+func TestFXConversion(t *testing.T) {
+	f := newFixture(t)
+	pesos, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{
+		Name: "Pesos", Type: "Fiat", Currency: "COP", Balance: types.MoneyFromFloat(1000000),
+	})
+	assertNoError(t, err, "Insert COP account")
+
+	rates := []types.FXRate{
+		{Date: "2024-01-01", Currency: "USD", Quote: "COP", Rate: 4000},
+		{Date: "2024-02-01", Currency: "USD", Quote: "COP", Rate: 5000},
+	}
+	if code := f.do(t, "POST", "/api/fx-rates", rates, nil); code != http.StatusOK {
+		t.Fatalf("POST rates: expected 200, got %d", code)
+	}
+	var listed api.FXRatesResponse
+	if code := f.do(t, "GET", "/api/fx-rates?currency=cop", nil, &listed); code != http.StatusOK || len(listed.Rates) != 2 || listed.BaseCurrency != "USD" {
+		t.Fatalf("GET rates: expected two COP rates in USD, got %d %+v", code, listed)
+	}
+
+	f.do(t, "POST", "/api/budget", []types.Budget{{CategoryId: f.food.Id, Amount: types.MoneyFromFloat(300), Month: "2024-01"}}, nil)
+	for date, amount := range map[string]float64{"2024-01-15": 400000, "2024-02-10": 500000} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": amount, "account_id": pesos.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 20, "account_id": f.bank.Id, "account_type": "Fiat", "date": "2024-01-20",
+	}, nil)
+
+	var dashboard api.DashboardResponse
+	if code := f.do(t, "GET", "/api/dashboard?date=2024-01-20", nil, &dashboard); code != http.StatusOK {
+		t.Fatalf("GET dashboard: expected 200, got %d", code)
+	}
+	if dashboard.Currency != "USD" {
+		t.Errorf("Expected the dashboard in USD, got %q", dashboard.Currency)
+	}
+	assertMoney(t, "120.00", dashboard.CurrentMonth.Expenses, "January expenses at 4000")
+	assertMoney(t, "220.00", dashboard.YTD.Expenses, "YTD expenses at each month's rate")
+	assertMoney(t, "1200.00", dashboard.NetWorth.TotalFiatBalance, "Balances at today's rate")
+
+	var budgets struct {
+		Budgets []types.BudgetByCategory `json:"budgets"`
+	}
+	f.do(t, "GET", "/api/budget?date=2024-02-15", nil, &budgets)
+	assertMoney(t, "100.00", budgets.Budgets[0].Spent, "February spending at 5000")
+
+	bad := []types.FXRate{{Date: "2024-13-01", Currency: "usd", Quote: "COP", Rate: 0}}
+	if code := f.do(t, "POST", "/api/fx-rates", bad, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Invalid rate: expected 422, got %d", code)
+	}
+	if code := f.do(t, "PATCH", "/api/household", map[string]interface{}{"base_currency": "dollars"}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Invalid base currency: expected 422, got %d", code)
+	}
+
+	// Without a EUR rate a total can't count euros, rather than taking them as dollars
+	euros, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{Name: "Euros", Type: "Fiat", Currency: "EUR"})
+	assertNoError(t, err, "Insert EUR account")
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 10, "account_id": euros.Id, "account_type": "Fiat", "date": "2024-01-25",
+	}, nil)
+	res := decodeError(t, f.send(t, "GET", "/api/dashboard?date=2024-01-25", "", nil), http.StatusConflict, api.CodeFXRateMissing)
+	if res.Message != "no exchange rate from EUR to USD" {
+		t.Errorf("Expected the missing rate to be named, got %q", res.Message)
+	}
+	f.do(t, "POST", "/api/fx-rates", []types.FXRate{{Date: "2024-01-01", Currency: "EUR", Quote: "USD", Rate: 1.1}}, nil)
+	f.do(t, "GET", "/api/dashboard?date=2024-01-25", nil, &dashboard)
+	assertMoney(t, "131.00", dashboard.CurrentMonth.Expenses, "January expenses with euros")
+}
+
+// goalsTimeoutStore is a store whose goals query runs out of time
+type goalsTimeoutStore struct {
+	api.Store
+}
+
+func (goalsTimeoutStore) GetYearlyGoals(context.Context, int) (types.YearlyGoals, error) {
+	return types.YearlyGoals{}, fmt.Errorf("error querying goals: %w", context.DeadlineExceeded)
+}
+
+// TestDashboardStoreErrors verifies a store error other than a missing rate
+// fails the dashboard instead of reporting zeros
+func TestDashboardStoreErrors(t *testing.T) {
+	f := newFixture(t)
+	f.router = mux.NewRouter()
+	api.LoadRoutes(f.router, api.NewHandler(goalsTimeoutStore{f.store}, googleSS.NewOutbox(nil, f.sheet), f.auth))
+
+	decodeError(t, f.send(t, "GET", "/api/dashboard", "", nil), http.StatusInternalServerError, api.CodeInternal)
+}
+
+// TestTransferFXCosts verifies a transfer between currencies is costed against
+// the reference rate, also one loaded after it, and reported by account pair and month
+func TestTransferFXCosts(t *testing.T) {
+	f := newFixture(t)
+	pesos, err := f.store.InsertAccountIntoDatabase(ownerCtx, types.Account{Name: "Pesos", Type: "Fiat", Currency: "COP"})
+	assertNoError(t, err, "Insert COP account")
+
+	var transfer types.Transfer
+	code := f.do(t, "POST", "/api/transfer", map[string]interface{}{
+		"source_account_id": f.bank.Id, "source_amount": 100, "dest_account_id": pesos.Id, "dest_amount": 390000, "date": "2024-03-05",
+	}, &transfer)
+	if code != http.StatusOK {
+		t.Fatalf("POST transfer: expected 200, got %d", code)
+	}
+	if transfer.ReferenceRate != 0 {
+		t.Errorf("Expected no reference rate before any rate is known, got %v", transfer.ReferenceRate)
+	}
+	f.do(t, "POST", "/api/fx-rates", []types.FXRate{{Date: "2024-03-01", Currency: "USD", Quote: "COP", Rate: 4000}}, nil)
+	f.do(t, "GET", fmt.Sprintf("/api/transfers/%d", transfer.Id), nil, &transfer)
+	if transfer.ReferenceRate != 4000 {
+		t.Errorf("Expected a reference rate of 4000, got %v", transfer.ReferenceRate)
+	}
+	assertMoney(t, "10000.00", transfer.FXCost, "Cost in pesos")
+	f.do(t, "POST", "/api/transfer", map[string]interface{}{
+		"source_account_id": f.bank.Id, "source_amount": 50, "dest_account_id": pesos.Id, "dest_amount": 200000, "date": "2024-04-10",
+	}, nil)
+
+	var report api.TransferFXCostReport
+	if code := f.do(t, "GET", "/api/transfers/fx-costs?from=2024-03&to=2024-04", nil, &report); code != http.StatusOK {
+		t.Fatalf("GET transfer costs: expected 200, got %d", code)
+	}
+	if len(report.Pairs) != 2 || report.Pairs[0].Month != "2024-03" || report.Pairs[0].DestCurrency != "COP" {
+		t.Fatalf("Expected March and April for Bank to Pesos, got %+v", report.Pairs)
+	}
+	march := report.Pairs[0]
+	assertMoney(t, "400000.00", march.ReferenceAmount, "Pesos at the reference rate")
+	assertMoney(t, "2.50", march.BaseCost, "March cost in dollars")
+	if march.SpreadPercent != 2.5 {
+		t.Errorf("Expected a 2.5%% spread, got %v", march.SpreadPercent)
+	}
+	assertMoney(t, "0.00", report.Pairs[1].Cost, "April at the reference rate")
+	assertMoney(t, "2.50", report.BaseCost, "Total cost")
+
+	if code := f.do(t, "GET", "/api/transfers/fx-costs?from=2024-05&to=2024-04", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("Reversed range: expected 400, got %d", code)
+	}
+}
+
+// TestStatementImport verifies a previewed statement flags what the account
+// already has, and a commit records the rest with one sheet write per kind
+func TestStatementImport(t *testing.T) {
+	f := newFixture(t)
+	f.do(t, "POST", "/api/submit", map[string]interface{}{
+		"category_id": f.food.Id, "expense": 4.5, "account_id": f.bank.Id, "account_type": "Fiat", "date": "2024-03-05 08:15:00",
+	}, nil)
+
+	statement := "Date,Description,Amount\n" +
+		"05/03/2024,Coffee,-4.50\n" +
+		"05/03/2024,Coffee,-4.50\n" +
+		"06/03/2024,Salary,\"2,000.00\"\n" +
+		"07/03/2024,Groceries,-61.20\n"
+	body := map[string]interface{}{"account_id": f.bank.Id, "content": statement, "category_id": f.food.Id}
+	if code := f.do(t, "POST", "/api/import/preview", body, nil); code != http.StatusBadRequest {
+		t.Errorf("CSV without a mapping: expected 400, got %d", code)
+	}
+	mapping := types.ImportMapping{AccountId: f.bank.Id, Date: "Date", DateFormat: "02/01/2006", Amount: "Amount", Description: "Description"}
+	if code := f.do(t, "POST", "/api/import/mappings", mapping, nil); code != http.StatusOK {
+		t.Fatalf("POST mapping: expected 200, got %d", code)
+	}
+
+	var preview api.ImportPreviewResponse
+	if code := f.do(t, "POST", "/api/import/preview", body, &preview); code != http.StatusOK {
+		t.Fatalf("POST preview: expected 200, got %d", code)
+	}
+	if preview.Format != types.ImportCSV || len(preview.Rows) != 4 || preview.Duplicates != 1 {
+		t.Fatalf("Expected 4 CSV rows with 1 duplicate, got %+v", preview)
+	}
+	if !preview.Rows[0].Duplicate || preview.Rows[1].Duplicate {
+		t.Errorf("Only the first coffee should match the recorded one: %+v", preview.Rows[:2])
+	}
+	salary := preview.Rows[2]
+	if salary.Kind != types.ImportIncome || salary.CategoryId != 0 || salary.Line != 4 {
+		t.Errorf("Unexpected salary row: %+v", salary)
+	}
+	assertMoney(t, "2000.00", salary.Amount, "Salary")
+	if preview.Rows[3].Kind != types.ImportExpense || preview.Rows[3].CategoryId != f.food.Id {
+		t.Errorf("Unexpected groceries row: %+v", preview.Rows[3])
+	}
+
+	f.sheet.Reset()
+	var result types.ImportResult
+	if code := f.do(t, "POST", "/api/import/commit", types.ImportCommit{AccountId: f.bank.Id, Rows: preview.Rows}, &result); code != http.StatusOK {
+		t.Fatalf("POST commit: expected 200, got %d", code)
+	}
+	if len(result.Expenses) != 2 || len(result.Incomes) != 1 || result.Skipped != 1 {
+		t.Fatalf("Expected 2 expenses, 1 income and 1 skipped, got %+v", result)
+	}
+	if e := result.Expenses[1]; e.Date != "2024-03-07 00:00:00" || e.Category != "Food" || e.AccountType != "Fiat" {
+		t.Errorf("Unexpected imported expense: %+v", e)
+	}
+	if result.Incomes[0].AccountName != "Bank" {
+		t.Errorf("Unexpected imported income: %+v", result.Incomes[0])
+	}
+	calls := f.sheet.Calls()
+	if len(calls) != 3 || len(calls[0].Rows) != 2 || len(calls[1].Rows) != 1 || calls[2].Range != "2024!D5" {
+		t.Errorf("Expected the expense rows, the income row and March's income cell, got %+v", calls)
+	}
+
+	// The same statement again is all duplicates
+	f.do(t, "POST", "/api/import/preview", body, &preview)
+	if preview.Duplicates != 4 {
+		t.Errorf("Expected every row to be a duplicate after the commit, got %d", preview.Duplicates)
+	}
+
+	// Committing the rows again with the flags cleared records nothing either
+	for i := range preview.Rows {
+		preview.Rows[i].Duplicate = false
+	}
+	if code := f.do(t, "POST", "/api/import/commit", types.ImportCommit{AccountId: f.bank.Id, Rows: preview.Rows}, &result); code != http.StatusOK {
+		t.Fatalf("POST commit again: expected 200, got %d", code)
+	}
+	if len(result.Expenses) != 0 || len(result.Incomes) != 0 || result.Skipped != 4 {
+		t.Errorf("Expected every row to be skipped on a second commit, got %+v", result)
+	}
+
+	rows := []types.ImportRow{{Kind: types.ImportExpense, Date: "2024-03-08", Amount: types.MoneyFromFloat(3)}}
+	if code := f.do(t, "POST", "/api/import/commit", types.ImportCommit{AccountId: f.bank.Id, Rows: rows}, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expense without a category: expected 422, got %d", code)
+	}
+}
+
+// TestExport verifies the dump and the files only hold the requested range,
+// and that unknown formats and bad dates are refused
+func TestExport(t *testing.T) {
+	f := newFixture(t)
+	for _, date := range []string{"2024-03-05 08:15:00", "2024-04-02 12:00:00"} {
+		f.do(t, "POST", "/api/submit", map[string]interface{}{
+			"category_id": f.food.Id, "expense": 4.5, "description": "Coffee", "account_id": f.bank.Id, "account_type": "Fiat", "date": date,
+		}, nil)
+	}
+
+	var dump types.Export
+	if code := f.do(t, "GET", "/api/export?from=2024-03-01&to=2024-03-31", nil, &dump); code != http.StatusOK {
+		t.Fatalf("GET export: expected 200, got %d", code)
+	}
+	if len(dump.Expenses) != 1 || dump.Expenses[0].Date != "2024-03-05 08:15:00" || len(dump.Accounts) != 1 || len(dump.Incomes) != 0 {
+		t.Errorf("Expected March's expense with the accounts, got %+v", dump)
+	}
+
+	req := httptest.NewRequest("GET", "/api/export?format=ledger&from=2024-04-01", nil)
+	req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Disposition") != `attachment; filename="fintrack.ledger"` {
+		t.Fatalf("GET ledger: expected a 200 download, got %d %v", rec.Code, rec.Header())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "2024-04-02 * Coffee\n") || strings.Contains(body, "2024-03-05") {
+		t.Errorf("Expected April's expense only:\n%s", body)
+	}
+
+	for _, query := range []string{"format=xml", "format=json&entity=expenses", "format=csv&entity=budgets", "from=March"} {
+		if code := f.do(t, "GET", "/api/export?"+query, nil, nil); code != http.StatusBadRequest {
+			t.Errorf("GET export?%s: expected 400, got %d", query, code)
+		}
+	}
+}
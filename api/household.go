@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== HOUSEHOLD ==========
+
+// HouseholdResponse is the caller's household and its members
+type HouseholdResponse struct {
+	Household types.Household `json:"household"`
+	Users     []types.User    `json:"users"`
+}
+
+// getHousehold returns the household the caller's key belongs to
+func (h *Handler) getHousehold(w http.ResponseWriter, r *http.Request) error {
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return err
+	}
+	return h.writeHousehold(w, r, household)
+}
+
+// updateHousehold renames the household, points it at another spreadsheet,
+// moves it to another timezone or changes its budget period, budget mode or
+// base currency; omitted fields keep their value
+func (h *Handler) updateHousehold(w http.ResponseWriter, r *http.Request) error {
+	household, err := h.store.GetHousehold(r.Context())
+	if err != nil {
+		return err
+	}
+	if err := decodeJSON(r, &household); err != nil {
+		return err
+	}
+	if err := h.validate(r, household); err != nil {
+		return err
+	}
+
+	// Envelopes carry over from the current budget month unless told otherwise
+	if household.BudgetMode == types.BudgetEnvelope && household.EnvelopeSince == "" {
+		household.EnvelopeSince = household.BudgetPeriod.Containing(types.Now(r.Context())).Month()
+	}
+
+	updated, err := h.store.UpdateHousehold(r.Context(), household)
+	if err != nil {
+		return err
+	}
+	return h.writeHousehold(w, r, updated)
+}
+
+func (h *Handler) writeHousehold(w http.ResponseWriter, r *http.Request, household types.Household) error {
+	users, err := h.store.GetHouseholdUsers(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting household users: %w", err)
+	}
+	return writeJSON(w, HouseholdResponse{Household: household, Users: users})
+}
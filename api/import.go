@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/importer"
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== STATEMENT IMPORTS ==========
+
+// importMethod is the method of the expenses an import records
+const importMethod = "Import"
+
+// ImportPreviewResponse is a statement read into rows, for the client to review
+// and send back to commit
+type ImportPreviewResponse struct {
+	AccountId  int32             `json:"account_id"`
+	Format     string            `json:"format"`
+	Rows       []types.ImportRow `json:"rows"`
+	Duplicates int               `json:"duplicates"`
+}
+
+// previewImport reads a statement into the expenses (money out) and incomes
+// (money in) it would record against an account, flagging those the account
+// already has. Nothing is recorded.
+func (h *Handler) previewImport(w http.ResponseWriter, r *http.Request) error {
+	var preview types.ImportPreview
+	if err := decodeJSON(r, &preview); err != nil {
+		return err
+	}
+	if err := h.validate(r, preview); err != nil {
+		return err
+	}
+
+	format := preview.Format
+	if format == "" {
+		format = importer.Detect(preview.Content)
+	}
+	mapping := preview.Mapping
+	if format == types.ImportCSV && mapping == nil {
+		saved, err := h.store.GetImportMapping(r.Context(), preview.AccountId)
+		if errors.Is(err, types.ErrNotFound) {
+			return badRequest("Account %d has no saved CSV mapping: send one as mapping", preview.AccountId)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting import mapping: %w", err)
+		}
+		mapping = &saved
+	}
+
+	transactions, err := importer.Parse(format, preview.Content, mapping)
+	if err != nil {
+		return badRequest("Invalid %s statement: %v", format, err)
+	}
+	rows := make([]types.ImportRow, len(transactions))
+	for i, t := range transactions {
+		rows[i] = types.ImportRow{Line: t.Line, Kind: types.ImportIncome, Date: t.Date, Amount: t.Amount, Description: t.Description}
+		if t.Amount.Sign() < 0 {
+			rows[i].Kind, rows[i].Amount, rows[i].CategoryId = types.ImportExpense, t.Amount.Neg(), preview.CategoryId
+		}
+	}
+
+	duplicates, err := h.flagDuplicates(r.Context(), preview.AccountId, rows)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, ImportPreviewResponse{AccountId: preview.AccountId, Format: format, Rows: rows, Duplicates: duplicates})
+}
+
+// flagDuplicates marks the rows the account already has a transaction for, of
+// the same kind, day and amount, and returns how many it marked. Each recorded
+// transaction matches a single row, so two identical coffees on a statement
+// are only both flagged when both were recorded.
+func (h *Handler) flagDuplicates(ctx context.Context, accountId int32, rows []types.ImportRow) (int, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	first, last := rows[0].Date, rows[0].Date
+	for _, row := range rows {
+		first, last = min(first, row.Date), max(last, row.Date)
+	}
+	end, err := time.Parse(time.DateOnly, last)
+	if err != nil {
+		return 0, fmt.Errorf("error reading date %q: %w", last, err)
+	}
+	start, err := time.Parse(time.DateOnly, first)
+	if err != nil {
+		return 0, fmt.Errorf("error reading date %q: %w", first, err)
+	}
+
+	expenses, incomes, err := h.store.GetAccountTransactions(ctx, accountId, types.Period{
+		Start: types.FormatDate(start),
+		End:   types.FormatDate(end.AddDate(0, 0, 1)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error getting account transactions: %w", err)
+	}
+
+	recorded := map[string]int{}
+	key := func(kind string, date string, amount types.Money) string {
+		return fmt.Sprintf("%s|%.10s|%d", kind, date, amount.MinorUnits())
+	}
+	for _, e := range expenses {
+		recorded[key(types.ImportExpense, e.Date, e.Expense)]++
+	}
+	for _, i := range incomes {
+		recorded[key(types.ImportIncome, i.Date, i.Amount)]++
+	}
+
+	duplicates := 0
+	for i := range rows {
+		k := key(rows[i].Kind, rows[i].Date, rows[i].Amount)
+		if recorded[k] > 0 {
+			recorded[k]--
+			rows[i].Duplicate = true
+			duplicates++
+		}
+	}
+	return duplicates, nil
+}
+
+// commitImport records the rows of a preview, as the client edited them, in a
+// single transaction: every row or none. Rows flagged duplicate are skipped,
+// and so are rows the account already has a transaction for, whatever the
+// client flagged, so a commit posted twice records nothing the second time.
+// The recorded rows are then appended to the sheet, one write per kind.
+func (h *Handler) commitImport(w http.ResponseWriter, r *http.Request) error {
+	var commit types.ImportCommit
+	if err := decodeJSON(r, &commit); err != nil {
+		return err
+	}
+	if err := h.validate(r, commit); err != nil {
+		return err
+	}
+
+	accounts, err := h.store.GetAccounts(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting accounts: %w", err)
+	}
+	var account types.Account
+	for _, a := range accounts {
+		if a.Id == commit.AccountId {
+			account = a
+		}
+	}
+	if account.Id == 0 {
+		return fmt.Errorf("account %d: %w", commit.AccountId, types.ErrNotFound)
+	}
+	categories, err := h.store.GetCategories(r.Context())
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+	categoryNames := map[int32]string{}
+	for _, c := range categories {
+		categoryNames[c.Id] = c.Name
+	}
+
+	// The server's own check pairs each recorded transaction with one row, as the preview does
+	checked := make([]types.ImportRow, len(commit.Rows))
+	for i, row := range commit.Rows {
+		checked[i] = row
+		checked[i].Duplicate = false
+	}
+	if _, err := h.flagDuplicates(r.Context(), account.Id, checked); err != nil {
+		return err
+	}
+
+	result := types.ImportResult{Expenses: []types.Expense{}, Incomes: []types.Income{}}
+	var expenses []types.Expense
+	var incomes []types.Income
+	for i, row := range commit.Rows {
+		if row.Duplicate || checked[i].Duplicate {
+			result.Skipped++
+			continue
+		}
+		date := transactionDate(r.Context(), row.Date)
+		if row.Kind == types.ImportExpense {
+			expenses = append(expenses, types.Expense{
+				Date: date, Category: categoryNames[row.CategoryId], CategoryId: row.CategoryId,
+				Expense: row.Amount, OriginalAmount: row.Amount, Description: row.Description, Method: importMethod,
+				AccountId: account.Id, AccountType: account.Type,
+			})
+		} else {
+			incomes = append(incomes, types.Income{
+				Date: date, Amount: row.Amount, Description: row.Description,
+				AccountId: account.Id, AccountName: account.Name,
+			})
+		}
+	}
+
+	// Configs are read first, as single submissions do, so a missing one fails before anything is recorded
+	var expenseConfig, incomeConfig types.Config
+	if len(expenses) > 0 {
+		if expenseConfig, err = h.store.GetConfigByType(r.Context(), "expenses"); err != nil {
+			return fmt.Errorf("error getting config: %w", err)
+		}
+	}
+	if len(incomes) > 0 {
+		if incomeConfig, err = h.store.GetConfigByType(r.Context(), "income"); err != nil {
+			return fmt.Errorf("error getting config: %w", err)
+		}
+	}
+
+	if len(expenses) > 0 || len(incomes) > 0 {
+		result.Expenses, result.Incomes, err = h.store.ImportTransactions(r.Context(), expenses, incomes)
+		if err != nil {
+			return fmt.Errorf("error importing transactions: %w", err)
+		}
+	}
+
+	if len(result.Expenses) > 0 {
+		if err := h.sheets.EnqueueExpenseRows(r.Context(), result.Expenses, expenseConfig); err != nil {
+			log.Printf("Error queuing expense rows: %v", err)
+		}
+	}
+	if len(result.Incomes) > 0 {
+		if err := h.sheets.EnqueueIncomes(r.Context(), result.Incomes, incomeConfig); err != nil {
+			log.Printf("Error queuing income rows: %v", err)
+		}
+		refreshed := map[[2]int]bool{}
+		for _, income := range result.Incomes {
+			year, month := transactionMonth(r.Context(), income.Date)
+			if !refreshed[[2]int{year, month}] {
+				refreshed[[2]int{year, month}] = true
+				h.refreshMonthlyIncomeCell(r.Context(), year, month)
+			}
+		}
+	}
+
+	return writeJSON(w, result)
+}
+
+// getImportMappings lists the CSV mappings saved for the accounts
+func (h *Handler) getImportMappings(w http.ResponseWriter, r *http.Request) error {
+	mappings, err := h.store.GetImportMappings(r.Context())
+	if err != nil {
+		return err
+	}
+	if mappings == nil {
+		mappings = []types.ImportMapping{}
+	}
+	return writeJSON(w, importMappingList{Mappings: mappings})
+}
+
+// setImportMapping saves the CSV mapping of an account, used by previews that
+// don't send one
+func (h *Handler) setImportMapping(w http.ResponseWriter, r *http.Request) error {
+	var mapping types.ImportMapping
+	if err := decodeJSON(r, &mapping); err != nil {
+		return err
+	}
+	if err := h.validate(r, mapping); err != nil {
+		return err
+	}
+
+	saved, err := h.store.UpsertImportMapping(r.Context(), mapping)
+	if err != nil {
+		return fmt.Errorf("error saving import mapping: %w", err)
+	}
+	return writeJSON(w, saved)
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/gorilla/mux"
+)
+
+// ========== MIDDLEWARE ==========
+
+const (
+	corsAllowHeaders = "Content-Type, Authorization, X-API-Key"
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+)
+
+// SetAllowedOrigins limits the browser origins allowed to call the API. "*"
+// (the default) allows any origin.
+func (h *Handler) SetAllowedOrigins(origins []string) {
+	h.origins = map[string]bool{}
+	for _, origin := range origins {
+		h.origins[strings.TrimSpace(origin)] = true
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request from origin
+func (h *Handler) allowOrigin(origin string) string {
+	if len(h.origins) == 0 || h.origins["*"] {
+		return "*"
+	}
+	if h.origins[origin] {
+		return origin
+	}
+	return ""
+}
+
+// cors sets the CORS headers of every response
+func (h *Handler) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := h.allowOrigin(r.Header.Get("Origin"))
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+		}
+		if allowed != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight answers CORS preflight requests for every /api route. It is
+// mounted ahead of requireAuth: browsers send preflights without credentials.
+func (h *Handler) preflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+	w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+	w.Header().Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jsonContent marks every response as JSON
+func jsonContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverPanics turns a panicking handler into a 500 instead of a dropped connection
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+				ServerErrorResponse(w, r)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handlerFunc is a handler that returns its failure instead of writing it
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// handle adapts fn to an http.HandlerFunc that writes fn's error as an ErrorResponse
+func handle(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			writeError(w, r, err)
+		}
+	}
+}
+
+// decodeJSON decodes the request body into v. Fields v doesn't have are
+// rejected rather than silently dropped, as is anything after the value.
+func decodeJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &Error{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Message: "Request body is empty", Err: err}
+		}
+		return &Error{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Message: "Invalid JSON: " + err.Error(), Err: err}
+	}
+	if dec.More() {
+		return &Error{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Message: "Invalid JSON: unexpected data after the request body"}
+	}
+	return nil
+}
+
+// writeJSON writes v as a 200 response
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	// Encoded before anything is written, so a value that can't be encoded
+	// (an amount mixing currencies) is still answered with an error
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding response: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+	return nil
+}
+
+// parseIdParam reads the numeric {id} path variable
+func parseIdParam(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		return 0, badRequest("Invalid id parameter")
+	}
+	return int32(id), nil
+}
+
+// queryInt reads an integer query parameter, def when it is absent
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, badRequest("Invalid %s parameter", name)
+	}
+	return parsed, nil
+}
+
+// queryDate reads a date query parameter in the caller's timezone, now when it
+// is absent
+func queryDate(r *http.Request, name string) (time.Time, error) {
+	now := types.Now(r.Context())
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return now, nil
+	}
+	date, err := types.ParseDateIn(value, now.Location())
+	if err != nil {
+		return time.Time{}, badRequest("Invalid %s parameter", name)
+	}
+	return date, nil
+}
+
+// queryMonth reads a budget month query parameter (2006-01), def when it is absent
+func queryMonth(r *http.Request, name string, def string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	if _, _, err := types.ParseMonth(value); err != nil {
+		return "", badRequest("Invalid %s parameter", name)
+	}
+	return value, nil
+}
+
+// validate checks v's rules and the ids it refers to; a failure is a 422
+// listing every invalid field
+func (h *Handler) validate(r *http.Request, v types.Validatable) error {
+	return types.Validate(r.Context(), v, h.store)
+}
+
+// ========== ERRORS ==========
+
+// Error codes of an ErrorResponse. Clients branch on the code; the message is
+// meant for people.
+const (
+	CodeBadRequest        = "bad_request"
+	CodeInvalidJSON       = "invalid_json"
+	CodeValidation        = "validation_failed"
+	CodeUnauthorized      = "unauthorized"
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeInternal          = "internal_error"
+	CodeSheetsUnavailable = "sheets_unavailable"
+	CodeSheetsQuota       = "sheets_quota"
+	CodeSheetsAuth        = "sheets_auth"
+	CodeSheetsBadRange    = "sheets_bad_range"
+	CodeFXRateMissing     = "fx_rate_missing"
+)
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Success bool               `json:"success"` // always false
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Fields  []types.FieldError `json:"fields,omitempty"` // the invalid fields of a validation_failed request
+}
+
+// Error is a failure reported to the client with a status and a code
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []types.FieldError
+	Err     error // the cause, for logs and errors.Is
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// badRequest is a 400 for a request the handler can't act on
+func badRequest(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// rejected is a 400 carrying the message of a store error, for writes the
+// store refused because of what was sent (types.ErrInvalid, like a bad
+// amount). Anything else goes on to errorFor: an unknown account is a 404,
+// a database failure a 500.
+func rejected(err error) error {
+	if !errors.Is(err, types.ErrInvalid) {
+		return err
+	}
+	return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: err.Error(), Err: err}
+}
+
+// errorFor maps err to what the client is told. Requests that fail validation
+// are 422s listing the invalid fields, store lookups that match nothing are
+// 404s, totals missing an exchange rate 409s and sheet errors keep the status
+// of their kind; anything
+// unexpected is a 500 whose details only go to the log.
+func errorFor(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var invalid *types.ValidationError
+	if errors.As(err, &invalid) {
+		return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Message: "Invalid request", Fields: invalid.Fields, Err: err}
+	}
+
+	var noRate *types.FXRateError
+	if errors.As(err, &noRate) {
+		return &Error{Status: http.StatusConflict, Code: CodeFXRateMissing, Message: noRate.Error(), Err: err}
+	}
+
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Not Found", Err: err}
+	case errors.Is(err, googleSS.ErrSheetsDisabled), errors.Is(err, googleSS.ErrUnavailable):
+		return &Error{Status: http.StatusServiceUnavailable, Code: CodeSheetsUnavailable, Message: err.Error(), Err: err}
+	case errors.Is(err, googleSS.ErrQuota):
+		return &Error{Status: http.StatusTooManyRequests, Code: CodeSheetsQuota, Message: err.Error(), Err: err}
+	case errors.Is(err, googleSS.ErrAuth):
+		return &Error{Status: http.StatusBadGateway, Code: CodeSheetsAuth, Message: err.Error(), Err: err}
+	case errors.Is(err, googleSS.ErrNotFound), errors.Is(err, googleSS.ErrRowNotFound):
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: err.Error(), Err: err}
+	case errors.Is(err, googleSS.ErrBadRange):
+		return &Error{Status: http.StatusUnprocessableEntity, Code: CodeSheetsBadRange, Message: err.Error(), Err: err}
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal Server Error", Err: err}
+}
+
+// writeError writes err as an ErrorResponse
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := errorFor(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Printf("Error serving %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	if apiErr.Code == CodeSheetsQuota {
+		w.Header().Set("Retry-After", "60")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(ErrorResponse{Success: false, Code: apiErr.Code, Message: apiErr.Message, Fields: apiErr.Fields})
+}
+
+func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Not Found"})
+}
+
+func ServerErrorResponse(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal Server Error"})
+}
+
+func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, &Error{Status: http.StatusMethodNotAllowed, Code: CodeMethodNotAllowed, Message: "Method Not Allowed"})
+}
@@ -0,0 +1,189 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/api"
+)
+
+// ========== MIDDLEWARE ==========
+
+// send makes an authenticated request with a raw body and returns the recorder
+func (f *fixture) send(t *testing.T, method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeError decodes an ErrorResponse and checks its status and code
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) api.ErrorResponse {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("Expected %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON error, got Content-Type %q", ct)
+	}
+	var res api.ErrorResponse
+	assertNoError(t, json.NewDecoder(rec.Body).Decode(&res), "Decode error response")
+	if res.Success || res.Code != code || res.Message == "" {
+		t.Errorf("Expected code %q with a message, got %+v", code, res)
+	}
+	return res
+}
+
+// TestPreflightSkipsAuth verifies preflights get the CORS headers without credentials
+func TestPreflightSkipsAuth(t *testing.T) {
+	f := newFixture(t)
+
+	req := httptest.NewRequest("OPTIONS", "/api/expenses/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Preflight: expected 204, got %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected any origin to be allowed by default, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), "DELETE") {
+		t.Errorf("Expected DELETE to be allowed, got %q", rec.Header().Get("Access-Control-Allow-Methods"))
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "X-API-Key") {
+		t.Errorf("Expected X-API-Key to be allowed, got %q", rec.Header().Get("Access-Control-Allow-Headers"))
+	}
+}
+
+// TestAllowedOrigins verifies only configured origins are echoed back
+func TestAllowedOrigins(t *testing.T) {
+	f := newFixture(t)
+	f.handler.SetAllowedOrigins([]string{"https://app.example.com", " https://admin.example.com"})
+
+	cases := map[string]string{
+		"https://app.example.com":   "https://app.example.com",
+		"https://admin.example.com": "https://admin.example.com",
+		"https://evil.example.com":  "",
+	}
+	for origin, expected := range cases {
+		rec := f.send(t, "GET", "/api/accounts", "", map[string]string{"Origin": origin})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET accounts from %s: expected 200, got %d", origin, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != expected {
+			t.Errorf("Origin %s: expected Access-Control-Allow-Origin %q, got %q", origin, expected, got)
+		}
+		if rec.Header().Get("Vary") != "Origin" {
+			t.Errorf("Origin %s: expected Vary: Origin", origin)
+		}
+	}
+}
+
+// TestStrictJSON verifies malformed bodies and unknown fields are rejected before anything is stored
+func TestStrictJSON(t *testing.T) {
+	f := newFixture(t)
+
+	cases := map[string]string{
+		"unknown field": `{"expense": 10, "amount": 10}`,
+		"malformed":     `{"expense": `,
+		"empty":         ``,
+		"trailing data": `{"expense": 10} {"expense": 20}`,
+		"wrong type":    `{"expense": "ten"}`,
+	}
+	for name, body := range cases {
+		rec := f.send(t, "POST", "/api/submit", body, nil)
+		res := decodeError(t, rec, http.StatusBadRequest, api.CodeInvalidJSON)
+		if name == "unknown field" && !strings.Contains(res.Message, "amount") {
+			t.Errorf("Expected the unknown field to be named, got %q", res.Message)
+		}
+	}
+
+	_, count, err := f.store.GetExpenses(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get expenses")
+	if count != 0 || len(f.sheet.Calls()) != 0 {
+		t.Errorf("Rejected bodies must not be stored, got %d expenses and %d sheet calls", count, len(f.sheet.Calls()))
+	}
+}
+
+// TestErrorEnvelope verifies failures share one body shape with a code
+func TestErrorEnvelope(t *testing.T) {
+	f := newFixture(t)
+
+	decodeError(t, f.send(t, "GET", "/api/expenses/99", "", nil), http.StatusNotFound, api.CodeNotFound)
+	decodeError(t, f.send(t, "GET", "/api/nope", "", nil), http.StatusNotFound, api.CodeNotFound)
+	decodeError(t, f.send(t, "GET", "/api/expenses?limit=ten", "", nil), http.StatusBadRequest, api.CodeBadRequest)
+	decodeError(t, f.send(t, "POST", "/api/investment", `{"type": "gift"}`, nil), http.StatusUnprocessableEntity, api.CodeValidation)
+
+	req := httptest.NewRequest("GET", "/api/accounts", nil)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	decodeError(t, rec, http.StatusUnauthorized, api.CodeUnauthorized)
+}
+
+// TestValidationFailures verifies invalid bodies are 422s naming every bad field,
+// including ids that don't exist, and nothing is stored
+func TestValidationFailures(t *testing.T) {
+	f := newFixture(t)
+
+	fields := func(res api.ErrorResponse) map[string]string {
+		byField := map[string]string{}
+		for _, field := range res.Fields {
+			byField[field.Field] = field.Message
+		}
+		return byField
+	}
+
+	rec := f.send(t, "POST", "/api/submit", `{"category_id": 99, "expense": -5, "account_id": 42, "account_type": "Fiat"}`, nil)
+	got := fields(decodeError(t, rec, http.StatusUnprocessableEntity, api.CodeValidation))
+	for _, field := range []string{"category_id", "expense", "account_id"} {
+		if got[field] == "" {
+			t.Errorf("Expected %s to be reported, got %v", field, got)
+		}
+	}
+	if !strings.Contains(got["category_id"], "does not exist") {
+		t.Errorf("Unknown category: expected 'does not exist', got %q", got["category_id"])
+	}
+
+	// account 1 is the fiat bank, but as a Broker expense it names an investment account
+	body := fmt.Sprintf(`{"category_id": %d, "expense": 5, "account_id": 2, "account_type": "Broker"}`, f.food.Id)
+	got = fields(decodeError(t, f.send(t, "POST", "/api/submit", body, nil), http.StatusUnprocessableEntity, api.CodeValidation))
+	if len(got) != 1 || got["account_id"] == "" {
+		t.Errorf("Expected only account_id, got %v", got)
+	}
+
+	body = fmt.Sprintf(`{"category_id": %d, "expense": 30, "account_id": %d, "debts": [{"debtor_id": %d, "amount": 10}, {"debtor_id": 77, "amount": 0}]}`,
+		f.food.Id, f.bank.Id, f.john.Id)
+	got = fields(decodeError(t, f.send(t, "POST", "/api/expense-debt", body, nil), http.StatusUnprocessableEntity, api.CodeValidation))
+	if len(got) != 2 || got["debts[1].amount"] == "" || got["debts[1].debtor_id"] == "" {
+		t.Errorf("Expected debts[1].amount and debts[1].debtor_id, got %v", got)
+	}
+
+	got = fields(decodeError(t, f.send(t, "POST", "/api/debt/repayment", `{"amount": 10}`, nil), http.StatusUnprocessableEntity, api.CodeValidation))
+	if got["debtor_id"] == "" || got["account_id"] == "" {
+		t.Errorf("Expected debtor_id and account_id, got %v", got)
+	}
+
+	body = fmt.Sprintf(`{"source_account_id": %d, "source_amount": 10, "dest_account_id": %d, "dest_amount": 10}`, f.bank.Id, f.bank.Id)
+	got = fields(decodeError(t, f.send(t, "POST", "/api/transfer", body, nil), http.StatusUnprocessableEntity, api.CodeValidation))
+	if got["dest_account_id"] == "" {
+		t.Errorf("Expected a transfer to the same account to be refused, got %v", got)
+	}
+
+	_, count, err := f.store.GetExpenses(ownerCtx, 10, 0)
+	assertNoError(t, err, "Get expenses")
+	if count != 0 || len(f.sheet.Calls()) != 0 {
+		t.Errorf("Invalid bodies must not be stored, got %d expenses and %d sheet calls", count, len(f.sheet.Calls()))
+	}
+}
@@ -0,0 +1,545 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"go/token"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/gorilla/mux"
+)
+
+// ========== OPENAPI ==========
+
+// The OpenAPI document is built from the router itself: every registered route
+// and method is looked up in routeDocs, and the request and response values
+// there are turned into schemas by reflecting on their json tags. A route with
+// no entry is left out of the document (and fails TestOpenAPICoversRoutes).
+
+// operation documents one method of one route
+type operation struct {
+	Summary     string
+	Description string
+	Query       []queryParam
+	Request     interface{} // a value of the body type; nil when there is no body
+	Response    interface{} // a value of the 200 body type
+	HTML        bool        // the 200 body is a page rather than Response
+	Files       []string    // other media types the 200 body may be, as a file download
+	Public      bool        // served without credentials
+}
+
+type queryParam struct {
+	Name        string
+	Type        string // "integer" or "string"
+	Description string
+}
+
+var pageParams = []queryParam{
+	{Name: "limit", Type: "integer", Description: "page size"},
+	{Name: "offset", Type: "integer", Description: "rows to skip"},
+}
+
+var yearParam = queryParam{Name: "year", Type: "integer", Description: "defaults to the current year"}
+
+var fxCostParams = []queryParam{
+	{Name: "from", Type: "string", Description: "a month like 2006-01; defaults to January of this year"},
+	{Name: "to", Type: "string", Description: "a month like 2006-01; defaults to this month"},
+}
+
+func monthParam(name string) queryParam {
+	return queryParam{Name: name, Type: "string", Description: "a month like 2006-01; defaults to the current budget month"}
+}
+
+var exportParams = []queryParam{
+	{Name: "format", Type: "string", Description: "csv, json, ledger or beancount; defaults to json"},
+	{Name: "entity", Type: "string", Description: "csv: the one file to export, like expenses; a zip archive of every file when left out"},
+	{Name: "from", Type: "string", Description: "the first day like 2006-01-02; open when left out"},
+	{Name: "to", Type: "string", Description: "the last day like 2006-01-02, included; open when left out"},
+}
+
+var dateParam = queryParam{Name: "date", Type: "string", Description: "any day of the budget period; defaults to today"}
+
+// Bodies of the endpoints that wrap what they answer with in an object
+
+type categoryList struct {
+	Categories []types.Category `json:"categories"`
+}
+
+type budgetList struct {
+	Period        types.Period             `json:"period"`
+	BudgetMode    string                   `json:"budget_mode"`
+	Budgets       []types.BudgetByCategory `json:"budgets"`
+	ReadyToAssign types.Money              `json:"ready_to_assign"`
+}
+
+type budgetMoveList struct {
+	Period types.Period       `json:"period"`
+	Moves  []types.BudgetMove `json:"moves"`
+}
+
+type importMappingList struct {
+	Mappings []types.ImportMapping `json:"mappings"`
+}
+
+type configList struct {
+	Config []types.Config `json:"config"`
+}
+
+type accountList struct {
+	Accounts []types.Account `json:"accounts"`
+}
+
+type investmentAccountList struct {
+	Accounts []types.InvestmentAccount `json:"accounts"`
+}
+
+type debtorList struct {
+	Debtors []types.Debtor `json:"debtors"`
+}
+
+type debtorDebtList struct {
+	Result []types.DebtByDebtor `json:"result"`
+}
+
+type expensePage struct {
+	Expenses []types.Expense `json:"expenses"`
+	Limit    int             `json:"limit"`
+	Offset   int             `json:"offset"`
+	Count    int             `json:"count"`
+}
+
+type incomePage struct {
+	Incomes []types.Income `json:"incomes"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	Count   int            `json:"count"`
+}
+
+type investmentPage struct {
+	Investments []types.Investment `json:"investments"`
+	Total       int                `json:"total"`
+	Limit       int                `json:"limit"`
+	Offset      int                `json:"offset"`
+}
+
+type transferPage struct {
+	Transfers []types.Transfer `json:"transfers"`
+	Count     int              `json:"count"`
+}
+
+type debtPage struct {
+	Debts []types.Debt `json:"debts"`
+	Count int          `json:"count"`
+}
+
+type sheetJobList struct {
+	Jobs  []types.SheetJob `json:"jobs"`
+	Count int              `json:"count"`
+}
+
+type expenseChange struct {
+	Success     bool          `json:"success"`
+	Expense     types.Expense `json:"expense"`
+	SheetQueued bool          `json:"sheet_queued"`
+}
+
+type incomeChange struct {
+	Success     bool         `json:"success"`
+	Income      types.Income `json:"income"`
+	SheetQueued bool         `json:"sheet_queued"`
+}
+
+type investmentChange struct {
+	Success     bool             `json:"success"`
+	Investment  types.Investment `json:"investment"`
+	SheetQueued bool             `json:"sheet_queued"`
+}
+
+// transferChange has sheet_queued like the other changes; transfers aren't
+// written to the sheet, so it is always false
+type transferChange struct {
+	Success     bool           `json:"success"`
+	Transfer    types.Transfer `json:"transfer"`
+	SheetQueued bool           `json:"sheet_queued"`
+}
+
+type expenseDebtResult struct {
+	Success bool          `json:"success"`
+	Expense types.Expense `json:"expense"`
+	Debts   []types.Debt  `json:"debts"`
+}
+
+type repaymentResult struct {
+	Income types.Income `json:"income"`
+	Debt   types.Debt   `json:"debt"`
+}
+
+type sheetStatus struct {
+	Enabled bool `json:"enabled"`
+	DevMode bool `json:"dev_mode"`
+}
+
+// routeDocs documents LoadRoutes, keyed by method and path template
+var routeDocs = map[string]operation{
+	"POST /api/login":             {Summary: "Exchange an API key for a session token", Request: LoginRequest{}, Response: LoginResponse{}, Public: true},
+	"GET /api/openapi.json":       {Summary: "This document", Response: map[string]interface{}{}, Public: true},
+	"GET /api/docs":               {Summary: "API reference page", HTML: true, Public: true},
+	"GET /api/":                   {Summary: "Health check", Response: types.Response{}},
+	"GET /api/categories":         {Summary: "List categories", Response: categoryList{}},
+	"POST /api/categories":        {Summary: "Create a category", Request: types.Category{}, Response: types.Category{}},
+	"GET /api/config":             {Summary: "List sheet ranges", Response: configList{}},
+	"POST /api/config":            {Summary: "Set sheet ranges", Request: types.Configs{}, Response: types.Response{}},
+	"GET /api/budget":             {Summary: "Budgets with a budget period's spending", Query: []queryParam{dateParam}, Response: budgetList{}},
+	"POST /api/budget":            {Summary: "Set budgets from a month on", Request: types.Budgets{}, Response: types.Response{}},
+	"GET /api/budget/history":     {Summary: "Budget against spending per budget period", Query: []queryParam{monthParam("from"), monthParam("to")}, Response: BudgetHistoryResponse{}},
+	"GET /api/budget/moves":       {Summary: "Moves between envelopes in a budget period", Query: []queryParam{dateParam}, Response: budgetMoveList{}},
+	"POST /api/budget/moves":      {Summary: "Move money between envelopes", Request: types.BudgetMove{}, Response: types.BudgetMove{}},
+	"GET /api/transfers/fx-costs": {Summary: "Cost of currency conversions against the reference rates, by account pair and month", Query: fxCostParams, Response: TransferFXCostReport{}},
+	"GET /api/fx-rates":           {Summary: "Exchange rates", Query: []queryParam{{Name: "currency", Type: "string", Description: "only the rates from or into this currency"}}, Response: FXRatesResponse{}},
+	"POST /api/fx-rates":          {Summary: "Enter exchange rates", Request: types.FXRates{}, Response: FXRatesResponse{}},
+	"POST /api/import/preview":    {Summary: "Read a CSV, OFX/QFX or QIF statement into expenses and incomes, flagging duplicates", Request: types.ImportPreview{}, Response: ImportPreviewResponse{}},
+	"POST /api/import/commit":     {Summary: "Record the rows of a statement preview in one transaction", Request: types.ImportCommit{}, Response: types.ImportResult{}},
+	"GET /api/import/mappings":    {Summary: "Saved CSV column mappings", Response: importMappingList{}},
+	"POST /api/import/mappings":   {Summary: "Save the CSV column mapping of an account", Request: types.ImportMapping{}, Response: types.ImportMapping{}},
+	"GET /api/dashboard":          {Summary: "Current budget period, year to date, goals and net worth", Query: []queryParam{dateParam}, Response: DashboardResponse{}},
+	"GET /api/household":          {Summary: "The caller's household and its members", Response: HouseholdResponse{}},
+	"PATCH /api/household":        {Summary: "Rename the household or change its spreadsheet, timezone, budget period, budget mode or base currency", Request: types.Household{}, Response: HouseholdResponse{}},
+	"POST /api/accounting":        {Summary: "Reconcile real balances and snapshot net worth", Request: types.RealBalanceByAccounts{}, Response: types.RealBalanceByAccounts{}},
+	"GET /api/goals":              {Summary: "Yearly goals", Query: []queryParam{yearParam}, Response: types.YearlyGoals{}},
+	"POST /api/goals":             {Summary: "Set yearly goals", Request: types.YearlyGoals{}, Response: types.YearlyGoals{}},
+	"GET /api/income/summary":     {Summary: "Income per budget period", Query: []queryParam{yearParam}, Response: []types.MonthlyIncomeSummary{}},
+	"GET /api/net-worth/history":  {Summary: "Monthly net worth snapshots", Response: []types.NetWorthSnapshot{}},
+
+	"POST /api/submit":          {Summary: "Record an expense", Request: types.Expense{}, Response: types.Response{}},
+	"GET /api/expenses":         {Summary: "List expenses", Query: pageParams, Response: expensePage{}},
+	"GET /api/expenses/recent":  {Summary: "Latest expenses", Query: pageParams[:1], Response: []types.Expense{}},
+	"GET /api/expenses/{id}":    {Summary: "Get an expense", Response: types.Expense{}},
+	"PUT /api/expenses/{id}":    {Summary: "Replace an expense", Request: types.Expense{}, Response: expenseChange{}},
+	"PATCH /api/expenses/{id}":  {Summary: "Update an expense; omitted fields keep their value", Request: types.Expense{}, Response: expenseChange{}},
+	"DELETE /api/expenses/{id}": {Summary: "Delete an expense and its linked debts", Response: expenseChange{}},
+	"POST /api/expense-debt": {
+		Summary: "Record an expense lent to one or more debtors",
+		Description: "Send the debts in `debts`, or a single one in the top-level `debtor_id`, `debtor_name`, " +
+			"`debt_amount` (defaults to `expense`) and `currency` fields.",
+		Request:  ExpenseDebtRequest{},
+		Response: expenseDebtResult{},
+	},
+
+	"POST /api/income":        {Summary: "Record an income", Request: types.Income{}, Response: types.Response{}},
+	"GET /api/income":         {Summary: "List incomes", Query: pageParams, Response: incomePage{}},
+	"GET /api/income/{id}":    {Summary: "Get an income", Response: types.Income{}},
+	"PUT /api/income/{id}":    {Summary: "Replace an income", Request: types.Income{}, Response: incomeChange{}},
+	"PATCH /api/income/{id}":  {Summary: "Update an income; omitted fields keep their value", Request: types.Income{}, Response: incomeChange{}},
+	"DELETE /api/income/{id}": {Summary: "Delete an income and its linked repayment", Response: incomeChange{}},
+
+	"POST /api/investment": {Summary: "Record an investment deposit or withdrawal", Request: types.Investment{}, Response: types.Response{}},
+	"GET /api/investments": {
+		Summary:  "List investments",
+		Query:    append(pageParams[:2:2], queryParam{Name: "account_id", Type: "integer", Description: "only this investment account"}),
+		Response: investmentPage{},
+	},
+	"GET /api/investments/{id}":    {Summary: "Get an investment", Response: types.Investment{}},
+	"PUT /api/investments/{id}":    {Summary: "Replace an investment", Request: types.Investment{}, Response: investmentChange{}},
+	"PATCH /api/investments/{id}":  {Summary: "Update an investment; omitted fields keep their value", Request: types.Investment{}, Response: investmentChange{}},
+	"DELETE /api/investments/{id}": {Summary: "Delete an investment and reverse its capital change", Response: investmentChange{}},
+
+	"GET /api/accounts":                             {Summary: "List accounts", Response: accountList{}},
+	"POST /api/accounts":                            {Summary: "Create an account", Request: types.Account{}, Response: types.Response{}},
+	"GET /api/accounts/expected-balance":            {Summary: "Expected balance of each account from its transactions", Response: []types.AccountExpectedBalance{}},
+	"GET /api/investment-accounts":                  {Summary: "List investment accounts", Response: investmentAccountList{}},
+	"POST /api/investment-accounts":                 {Summary: "Create an investment account", Request: types.InvestmentAccount{}, Response: types.Response{}},
+	"GET /api/investment-accounts/summary":          {Summary: "Balance, capital and PnL of each investment account", Response: []types.InvestmentAccountSummary{}},
+	"GET /api/investment-accounts/expected-capital": {Summary: "Expected capital of each investment account", Response: []types.InvestmentAccountExpectedCapital{}},
+
+	"POST /api/debt": {Summary: "Record a debt", Request: types.Debt{}, Response: types.Response{}},
+	"GET /api/debts": {
+		Summary:  "List debts",
+		Query:    append(pageParams[:2:2], queryParam{Name: "debtor_id", Type: "integer", Description: "only this debtor"}),
+		Response: debtPage{},
+	},
+	"GET /api/debts/by-debtor": {Summary: "Net debt per debtor", Response: []types.DebtByDebtor{}},
+	"POST /api/debt/repayment": {Summary: "Record a debtor paying back", Request: RepaymentRequest{}, Response: repaymentResult{}},
+	"GET /api/debtors":         {Summary: "List debtors", Response: debtorList{}},
+	"POST /api/debtors":        {Summary: "Create a debtor", Request: types.Debtor{}, Response: types.Response{}},
+	"GET /api/debtors/debt":    {Summary: "Net debt per debtor", Response: debtorDebtList{}},
+
+	"POST /api/transfer":         {Summary: "Record a transfer between accounts", Request: types.Transfer{}, Response: types.Transfer{}},
+	"GET /api/transfers":         {Summary: "List transfers", Query: pageParams, Response: transferPage{}},
+	"GET /api/transfers/{id}":    {Summary: "Get a transfer", Response: types.Transfer{}},
+	"PUT /api/transfers/{id}":    {Summary: "Replace a transfer", Request: types.Transfer{}, Response: transferChange{}},
+	"PATCH /api/transfers/{id}":  {Summary: "Update a transfer; omitted fields keep their value", Request: types.Transfer{}, Response: transferChange{}},
+	"DELETE /api/transfers/{id}": {Summary: "Delete a transfer", Response: transferChange{}},
+
+	"GET /api/admin/sheets": {Summary: "Check the spreadsheet is reachable", Response: sheetStatus{}},
+	"GET /api/admin/sheet-jobs": {
+		Summary: "Sheet writes that have not reached the sheet yet",
+		Query: []queryParam{
+			{Name: "status", Type: "string", Description: "comma separated: pending, processing, failed"},
+			{Name: "limit", Type: "integer", Description: "page size"},
+		},
+		Response: sheetJobList{},
+	},
+	"POST /api/admin/sheet-jobs/{id}/retry": {Summary: "Queue a failed sheet write again", Response: types.SheetJob{}},
+	"DELETE /api/admin/sheet-jobs/{id}":     {Summary: "Drop a failed sheet write, letting the later ones run", Response: types.SheetJob{}},
+
+	"GET /api/export": {
+		Summary: "Download the household's data as CSV, a JSON dump or a ledger or beancount journal",
+		Description: "The JSON dump is the body below; the other formats are files. A journal posts expenses, incomes, transfers, " +
+			"investments and debts against the accounts as balanced transactions, priced (@@) when they convert between currencies.",
+		Query:    exportParams,
+		Response: types.Export{},
+		Files:    []string{"text/csv", "application/zip", "text/plain"},
+	},
+}
+
+// pathVar matches a mux path variable and its pattern, "{id:[0-9]+}"
+var pathVar = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// Route is a method and path the router serves, the path in OpenAPI form
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Routes lists every method and path the router serves, with paths in OpenAPI
+// form ("/api/expenses/{id}"). Preflights are left out.
+func Routes(router *mux.Router) []Route {
+	var routes []Route
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // a subrouter prefix
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				routes = append(routes, Route{Method: method, Path: pathVar.ReplaceAllString(template, "{$1}")})
+			}
+		}
+		return nil
+	})
+	return routes
+}
+
+// OpenAPI builds the OpenAPI 3 document of the routes registered on router
+func OpenAPI(router *mux.Router) map[string]interface{} {
+	schemas := schemaSet{}
+	paths := map[string]interface{}{}
+
+	for _, route := range Routes(router) {
+		doc, ok := routeDocs[route.Method+" "+route.Path]
+		if !ok {
+			log.Printf("No OpenAPI documentation for %s %s", route.Method, route.Path)
+			continue
+		}
+		item, _ := paths[route.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = doc.spec(route.Path, schemas)
+	}
+
+	schemas.add(reflect.TypeOf(ErrorResponse{}))
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Fintrack API",
+			"version":     "1.0.0",
+			"description": "Amounts are exact decimals with two places. Errors share the ErrorResponse body.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "An API key or a session token from /api/login"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		},
+	}
+}
+
+// spec is the OpenAPI operation object of op served at path
+func (op operation) spec(path string, schemas schemaSet) map[string]interface{} {
+	var params []interface{}
+	for _, match := range pathVar.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name": match[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "integer"},
+		})
+	}
+	for _, q := range op.Query {
+		params = append(params, map[string]interface{}{
+			"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]interface{}{"type": q.Type},
+		})
+	}
+
+	errorBody := map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}
+	ok := map[string]interface{}{"description": "OK", "content": map[string]interface{}{"text/html": map[string]interface{}{}}}
+	if !op.HTML {
+		ok["content"] = jsonContentOf(schemas.of(reflect.TypeOf(op.Response)))
+	}
+	for _, mediaType := range op.Files {
+		ok["content"].(map[string]interface{})[mediaType] = map[string]interface{}{
+			"schema": map[string]interface{}{"type": "string", "format": "binary"},
+		}
+	}
+	responses := map[string]interface{}{
+		"200":     ok,
+		"default": map[string]interface{}{"description": "Error", "content": jsonContentOf(errorBody)},
+	}
+
+	spec := map[string]interface{}{
+		"summary":   op.Summary,
+		"responses": responses,
+	}
+	if op.Description != "" {
+		spec["description"] = op.Description
+	}
+	if len(params) > 0 {
+		spec["parameters"] = params
+	}
+	if op.Request != nil {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContentOf(schemas.of(reflect.TypeOf(op.Request))),
+		}
+		responses["422"] = map[string]interface{}{"description": "Invalid fields, listed in `fields`", "content": jsonContentOf(errorBody)}
+	}
+	if op.Public {
+		spec["security"] = []interface{}{}
+	}
+	return spec
+}
+
+func jsonContentOf(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// ========== SCHEMAS ==========
+
+var (
+	moneyType = reflect.TypeOf(types.Money{})
+	timeType  = reflect.TypeOf(time.Time{})
+	rawType   = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaSet holds the component schemas, one per exported struct type
+type schemaSet map[string]interface{}
+
+// of returns the schema of t, a $ref for exported structs
+func (s schemaSet) of(t reflect.Type) map[string]interface{} {
+	switch t {
+	case moneyType:
+		return map[string]interface{}{"type": "number", "multipleOf": 0.01}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.of(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if !token.IsExported(t.Name()) {
+			return s.object(t)
+		}
+		s.add(t)
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+// add registers the component schema of the struct type t
+func (s schemaSet) add(t reflect.Type) {
+	if _, ok := s[t.Name()]; ok {
+		return
+	}
+	s[t.Name()] = nil // placeholder, so recursive types terminate
+	s[t.Name()] = s.object(t)
+}
+
+// object is the inline schema of the struct type t, from its json tags. The
+// required fields are those its validation rules require.
+func (s schemaSet) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// Embedded fields are promoted, as encoding/json does
+			for promoted, schema := range s.object(field.Type)["properties"].(map[string]interface{}) {
+				properties[promoted] = schema
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.of(field.Type)
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if v, ok := reflect.Zero(t).Interface().(types.Validatable); ok {
+		if required := types.RequiredFields(v); len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	return schema
+}
+
+// ========== DOCS ==========
+
+//go:embed docs.html
+var docsPage []byte
+
+// openAPI serves the OpenAPI document of the routes registered on router
+func (h *Handler) openAPI(router *mux.Router) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return writeJSON(w, OpenAPI(router))
+	}
+}
+
+// docs serves a Redoc page rendering /api/openapi.json
+func (h *Handler) docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
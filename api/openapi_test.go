@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/api"
+)
+
+// ========== OPENAPI ==========
+
+type openAPIDoc struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+			Required   []string               `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func fetchOpenAPI(t *testing.T, f *fixture) openAPIDoc {
+	t.Helper()
+	// No credentials: the document is public
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET openapi.json: expected 200, got %d", rec.Code)
+	}
+	var doc openAPIDoc
+	assertNoError(t, json.NewDecoder(rec.Body).Decode(&doc), "Decode OpenAPI document")
+	return doc
+}
+
+// TestOpenAPICoversRoutes fails when a route is registered without documentation
+func TestOpenAPICoversRoutes(t *testing.T) {
+	f := newFixture(t)
+	doc := fetchOpenAPI(t, f)
+
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	for _, route := range api.Routes(f.router) {
+		if _, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s has no schema: add it to routeDocs in api/openapi.go", route.Method, route.Path)
+		}
+	}
+}
+
+// TestOpenAPISchemas verifies schemas follow the json tags and validation rules
+func TestOpenAPISchemas(t *testing.T) {
+	f := newFixture(t)
+	doc := fetchOpenAPI(t, f)
+
+	request := doc.Components.Schemas["ExpenseDebtRequest"]
+	for _, field := range []string{"debts", "debtor_id", "debt_amount", "expense"} {
+		if _, ok := request.Properties[field]; !ok {
+			t.Errorf("ExpenseDebtRequest schema lacks %s: %v", field, request.Properties)
+		}
+	}
+	if strings.Join(request.Required, ",") != "category_id,account_id" {
+		t.Errorf("ExpenseDebtRequest: expected category_id and account_id to be required, got %v", request.Required)
+	}
+
+	var op struct {
+		Parameters []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	}
+	assertNoError(t, json.Unmarshal(doc.Paths["/api/expenses/{id}"]["patch"], &op), "Decode operation")
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+		t.Errorf("Expected the id path parameter, got %+v", op.Parameters)
+	}
+
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/docs", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("GET /api/docs: expected an HTML page, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if strings.Contains(rec.Body.String(), "latest") {
+		t.Error("GET /api/docs: expected Redoc pinned to a version")
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// Store is everything the handlers need from storage. Every call acts for the
+// types.Scope in its context (set by the auth middleware) and only sees that
+// household's data. Lookups, updates and deletes that match no visible row
+// return an error wrapping types.ErrNotFound. Implemented by postgres.Store.
+type Store interface {
+	types.RefChecker
+	HouseholdStore
+	ConfigStore
+	ExpenseStore
+	BudgetStore
+	IncomeStore
+	DebtStore
+	AccountStore
+	InvestmentStore
+	TransferStore
+	FXStore
+	ImportStore
+	ExportStore
+	GoalStore
+	SnapshotStore
+}
+
+type HouseholdStore interface {
+	GetHousehold(ctx context.Context) (types.Household, error)
+	UpdateHousehold(ctx context.Context, household types.Household) (types.Household, error)
+	GetHouseholdUsers(ctx context.Context) ([]types.User, error)
+}
+
+type ConfigStore interface {
+	GetConfigByType(ctx context.Context, configType string) (types.Config, error)
+	GetConfig(ctx context.Context) ([]types.Config, error)
+	InsertConfigIntoDatabase(ctx context.Context, configs []types.Config) ([]types.Config, error)
+	GetCategories(ctx context.Context) ([]types.Category, error)
+	InsertCategory(ctx context.Context, category types.Category) (types.Category, error)
+}
+
+type ExpenseStore interface {
+	InsertExpense(ctx context.Context, expense types.Expense) (types.Expense, error)
+	GetExpenses(ctx context.Context, limit int, offset int) ([]types.Expense, int, error)
+	GetRecentExpenses(ctx context.Context, limit int) ([]types.Expense, error)
+	GetExpenseById(ctx context.Context, id int32) (types.Expense, error)
+	UpdateExpense(ctx context.Context, expense types.Expense) (types.Expense, error)
+	DeleteExpense(ctx context.Context, id int32) (types.Expense, error)
+	GetExpenseSum(ctx context.Context, period types.Period) (types.Money, error)
+}
+
+type BudgetStore interface {
+	GetBudgets(ctx context.Context, periods []types.Period) ([][]types.BudgetByCategory, error)
+	GetSpentByCategory(ctx context.Context, periods []types.Period) ([]map[int32]types.Money, error)
+	InsertBudgetsIntoDatabase(ctx context.Context, budgets []types.Budget) ([]types.Budget, error)
+	InsertBudgetMove(ctx context.Context, move types.BudgetMove) (types.BudgetMove, error)
+	GetBudgetMoves(ctx context.Context, period types.Period) ([]types.BudgetMove, error)
+}
+
+type IncomeStore interface {
+	InsertIncome(ctx context.Context, income types.Income) (types.Income, error)
+	GetIncomes(ctx context.Context, limit int, offset int) ([]types.Income, int, error)
+	GetIncomeById(ctx context.Context, id int32) (types.Income, error)
+	UpdateIncome(ctx context.Context, income types.Income) (types.Income, error)
+	DeleteIncome(ctx context.Context, id int32) (types.Income, error)
+	GetIncomeSum(ctx context.Context, period types.Period) (types.Money, error)
+}
+
+type DebtStore interface {
+	InsertDebt(ctx context.Context, debt types.Debt) (types.Debt, error)
+	InsertExpenseWithDebts(ctx context.Context, expense types.Expense, debts []types.Debt) (types.Expense, []types.Debt, error)
+	RecordDebtRepayment(ctx context.Context, income types.Income, debt types.Debt) (types.Income, types.Debt, error)
+	GetDebts(ctx context.Context, limit int, offset int, debtorId *int32) ([]types.Debt, int, error)
+	GetDebtors(ctx context.Context) ([]types.Debtor, error)
+	GetDebtorsWithDebts(ctx context.Context) ([]types.DebtByDebtor, error)
+	InsertDebtorIntoDatabase(ctx context.Context, debtor types.Debtor) (types.Debtor, error)
+}
+
+type AccountStore interface {
+	GetAccounts(ctx context.Context) ([]types.Account, error)
+	InsertAccountIntoDatabase(ctx context.Context, account types.Account) (types.Account, error)
+	UpdateAccountBalances(ctx context.Context, accounts []types.Account) ([]types.Account, error)
+	GetAccountExpectedBalances(ctx context.Context) ([]types.AccountExpectedBalance, error)
+}
+
+type InvestmentStore interface {
+	InsertInvestment(ctx context.Context, investment types.Investment) (types.Investment, error)
+	GetInvestments(ctx context.Context, limit int, offset int, accountId *int32) ([]types.Investment, int, error)
+	GetInvestmentById(ctx context.Context, id int32) (types.Investment, error)
+	UpdateInvestment(ctx context.Context, investment types.Investment) (types.Investment, error)
+	DeleteInvestment(ctx context.Context, id int32) (types.Investment, error)
+	GetInvestmentSum(ctx context.Context, period types.Period) (types.Money, error)
+	GetInvestmentAccounts(ctx context.Context) ([]types.InvestmentAccount, error)
+	InsertInvestmentAccountIntoDatabase(ctx context.Context, account types.InvestmentAccount) (types.InvestmentAccount, error)
+	UpdateInvestmentAccountBalances(ctx context.Context, accounts []types.InvestmentAccount) ([]types.InvestmentAccount, error)
+	GetInvestmentAccountCapital(ctx context.Context, accountId int32) (types.Money, error)
+	GetInvestmentAccountSummary(ctx context.Context) ([]types.InvestmentAccountSummary, error)
+	GetInvestmentAccountExpectedCapital(ctx context.Context) ([]types.InvestmentAccountExpectedCapital, error)
+}
+
+type TransferStore interface {
+	InsertTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error)
+	GetTransfers(ctx context.Context, limit int, offset int) ([]types.Transfer, int, error)
+	GetTransferById(ctx context.Context, id int32) (types.Transfer, error)
+	UpdateTransfer(ctx context.Context, transfer types.Transfer) (types.Transfer, error)
+	DeleteTransfer(ctx context.Context, id int32) (types.Transfer, error)
+	GetTransferFXCosts(ctx context.Context, period types.Period) ([]types.TransferFXCost, error)
+}
+
+type FXStore interface {
+	UpsertFXRates(ctx context.Context, rates []types.FXRate) ([]types.FXRate, error)
+	GetFXRates(ctx context.Context, currency string) ([]types.FXRate, error)
+}
+
+type ImportStore interface {
+	GetImportMappings(ctx context.Context) ([]types.ImportMapping, error)
+	GetImportMapping(ctx context.Context, accountId int32) (types.ImportMapping, error)
+	UpsertImportMapping(ctx context.Context, mapping types.ImportMapping) (types.ImportMapping, error)
+	GetAccountTransactions(ctx context.Context, accountId int32, period types.Period) ([]types.Expense, []types.Income, error)
+	ImportTransactions(ctx context.Context, expenses []types.Expense, incomes []types.Income) ([]types.Expense, []types.Income, error)
+}
+
+type ExportStore interface {
+	GetTransactions(ctx context.Context, period types.Period) (types.Transactions, error)
+}
+
+type GoalStore interface {
+	GetYearlyGoals(ctx context.Context, year int) (types.YearlyGoals, error)
+	UpsertYearlyGoals(ctx context.Context, goals types.YearlyGoals) (types.YearlyGoals, error)
+}
+
+type SnapshotStore interface {
+	CalculateNetWorthSnapshot(ctx context.Context, year int, month int) (types.NetWorthSnapshot, error)
+	UpsertNetWorthSnapshot(ctx context.Context, snapshot types.NetWorthSnapshot) (types.NetWorthSnapshot, error)
+	GetNetWorthHistory(ctx context.Context) ([]types.NetWorthSnapshot, error)
+	GetYTDTotals(ctx context.Context, year int) (income types.Money, expenses types.Money, investments types.Money, err error)
+}
@@ -0,0 +1,164 @@
+// Package auth authenticates API callers with hashed API keys or HS256 session
+// tokens obtained by logging in with a key.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ErrUnauthenticated is returned when a request carries no valid credential
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// DefaultSessionTTL is how long a session token from Login stays valid
+const DefaultSessionTTL = 12 * time.Hour
+
+// Principal is the authenticated caller of a request: the user a key was
+// issued to, acting in their household
+type Principal struct {
+	KeyId       int64
+	KeyName     string
+	Method      string // "api_key" or "session"
+	UserId      int64
+	HouseholdId int64
+}
+
+// Scope is the data the principal may see
+func (p Principal) Scope() types.Scope {
+	return types.Scope{HouseholdId: p.HouseholdId, UserId: p.UserId}
+}
+
+// Authenticator checks credentials against stored keys and signs sessions
+type Authenticator struct {
+	keys       KeyStore
+	secret     []byte
+	sessionTTL time.Duration
+	now        func() time.Time
+}
+
+// NewAuthenticator signs sessions with secret, which must be at least
+// MinSecretLength bytes. A zero sessionTTL means DefaultSessionTTL.
+func NewAuthenticator(keys KeyStore, secret []byte, sessionTTL time.Duration) (*Authenticator, error) {
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("session secret must be at least %d bytes, got %d", MinSecretLength, len(secret))
+	}
+	if sessionTTL <= 0 {
+		sessionTTL = DefaultSessionTTL
+	}
+	return &Authenticator{keys: keys, secret: secret, sessionTTL: sessionTTL, now: time.Now}, nil
+}
+
+// Authenticate reads "Authorization: Bearer <api key or session token>" or
+// "X-API-Key: <api key>". Failures wrap ErrUnauthenticated unless the key
+// store itself failed.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	credential := r.Header.Get("X-API-Key")
+	if credential == "" {
+		scheme, value, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return Principal{}, fmt.Errorf("%w: missing credentials", ErrUnauthenticated)
+		}
+		credential = strings.TrimSpace(value)
+	}
+
+	if looksLikeAPIKey(credential) {
+		key, err := a.verifyAPIKey(r.Context(), credential)
+		if err != nil {
+			return Principal{}, err
+		}
+		return principal(key, "api_key"), nil
+	}
+
+	claims, err := ParseToken(a.secret, credential, a.now())
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	// Revoking a key ends the sessions opened with it
+	key, err := a.activeKey(a.keys.GetAPIKey(r.Context(), claims.KeyId))
+	if err != nil {
+		return Principal{}, err
+	}
+	return principal(key, "session"), nil
+}
+
+func principal(key types.APIKey, method string) Principal {
+	return Principal{
+		KeyId:       key.Id,
+		KeyName:     key.Name,
+		Method:      method,
+		UserId:      key.UserId,
+		HouseholdId: key.HouseholdId,
+	}
+}
+
+// Login exchanges an API key for a session token and its expiry
+func (a *Authenticator) Login(ctx context.Context, apiKey string) (string, time.Time, error) {
+	key, err := a.verifyAPIKey(ctx, apiKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := a.now()
+	expiresAt := now.Add(a.sessionTTL)
+	token, err := SignToken(a.secret, Claims{
+		Subject:   key.Name,
+		KeyId:     key.Id,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func (a *Authenticator) verifyAPIKey(ctx context.Context, apiKey string) (types.APIKey, error) {
+	prefix, ok := keyPrefix(apiKey)
+	if !ok {
+		return types.APIKey{}, fmt.Errorf("%w: malformed API key", ErrUnauthenticated)
+	}
+	key, err := a.activeKey(a.keys.GetAPIKeyByPrefix(ctx, prefix))
+	if err != nil {
+		return types.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(apiKey)), []byte(key.Hash)) != 1 {
+		return types.APIKey{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+	return key, nil
+}
+
+// activeKey turns a missing or revoked key into ErrUnauthenticated
+func (a *Authenticator) activeKey(key types.APIKey, err error) (types.APIKey, error) {
+	if errors.Is(err, types.ErrNotFound) {
+		return types.APIKey{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+	if err != nil {
+		return types.APIKey{}, err
+	}
+	if key.RevokedAt != nil {
+		return types.APIKey{}, fmt.Errorf("%w: API key %d is revoked", ErrUnauthenticated, key.Id)
+	}
+	return key, nil
+}
+
+// ========== REQUEST CONTEXT ==========
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal the auth middleware stored in ctx
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
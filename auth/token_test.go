@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var secret = []byte("token-test-secret-at-least-32-bytes")
+
+// TestParseToken verifies round trips and rejects expired, tampered and unsigned tokens
+func TestParseToken(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{Subject: "web", KeyId: 7, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+	token, err := SignToken(secret, claims)
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+
+	parsed, err := ParseToken(secret, token, now)
+	if err != nil || parsed != claims {
+		t.Fatalf("ParseToken: got %+v, %v", parsed, err)
+	}
+
+	parts := strings.Split(token, ".")
+	forged, _ := SignToken(secret, Claims{KeyId: 8, ExpiresAt: claims.ExpiresAt})
+	unsigned := encoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+
+	cases := map[string]struct {
+		token string
+		now   time.Time
+	}{
+		"expired":         {token, now.Add(time.Hour)},
+		"swapped payload": {parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2], now},
+		"alg none":        {unsigned, now},
+		"malformed":       {"abc", now},
+	}
+	for name, c := range cases {
+		if _, err := ParseToken(secret, c.token, c.now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package exporter
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== CSV ==========
+
+// Entities are the CSV files of an export, in the order an archive lists them
+var Entities = []string{
+	"expenses", "incomes", "transfers", "investments", "debts",
+	"accounts", "investment_accounts", "categories", "debtors", "fx_rates",
+}
+
+// table is the CSV file of one entity: its header and a row per record
+type table struct {
+	header []string
+	rows   func(export types.Export) [][]string
+}
+
+var tables = map[string]table{
+	"expenses": {
+		header: []string{"id", "date", "category_id", "category", "amount", "original_amount", "description", "method", "account_id", "account_type"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, e := range export.Expenses {
+				rows = append(rows, []string{id(e.Id), e.Date, id(e.CategoryId), e.Category, e.Expense.String(), e.OriginalAmount.String(),
+					e.Description, e.Method, id(e.AccountId), e.AccountType})
+			}
+			return rows
+		},
+	},
+	"incomes": {
+		header: []string{"id", "date", "amount", "description", "account_id", "account_name"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, i := range export.Incomes {
+				rows = append(rows, []string{id(i.Id), i.Date, i.Amount.String(), i.Description, id(i.AccountId), i.AccountName})
+			}
+			return rows
+		},
+	},
+	"transfers": {
+		header: []string{"id", "date", "description", "source_account_id", "source_account_name", "source_amount",
+			"dest_account_id", "dest_account_name", "dest_amount", "exchange_rate", "reference_rate", "fx_cost"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, t := range export.Transfers {
+				rows = append(rows, []string{id(t.Id), t.Date, t.Description, id(t.SourceAccountId), t.SourceAccountName, t.SourceAmount.String(),
+					id(t.DestAccountId), t.DestAccountName, t.DestAmount.String(), rate(t.ExchangeRate), rate(t.ReferenceRate), t.FXCost.String()})
+			}
+			return rows
+		},
+	},
+	"investments": {
+		header: []string{"id", "date", "type", "amount", "description", "account_id", "account_name", "source_account_id"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, i := range export.Investments {
+				rows = append(rows, []string{id(i.Id), i.Date, i.Type, i.Amount.String(), i.Description, id(i.AccountId), i.AccountName, optionalId(i.SourceAccountId)})
+			}
+			return rows
+		},
+	},
+	"debts": {
+		header: []string{"id", "date", "debtor_id", "debtor_name", "outbound", "amount", "original_amount", "currency", "description",
+			"account_id", "expense_id", "income_id"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, d := range export.Debts {
+				rows = append(rows, []string{id(d.Id), d.Date, id(d.DebtorId), d.DebtorName, strconv.FormatBool(d.Outbound), d.Amount.String(),
+					d.OriginalAmount.String(), d.Currency, d.Description, optionalId(d.AccountId), optionalId(d.ExpenseId), optionalId(d.IncomeId)})
+			}
+			return rows
+		},
+	},
+	"accounts": {
+		header: []string{"id", "name", "type", "currency", "balance", "starting_balance", "starting_date", "description"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, a := range export.Accounts {
+				rows = append(rows, []string{id(a.Id), a.Name, a.Type, a.Currency, a.Balance.String(), a.StartingBalance.String(),
+					day(a.StartingDate), a.Description})
+			}
+			return rows
+		},
+	},
+	"investment_accounts": {
+		header: []string{"id", "name", "type", "currency", "balance", "capital", "starting_capital", "starting_date", "description"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, a := range export.InvestmentAccounts {
+				rows = append(rows, []string{id(a.Id), a.Name, a.Type, a.Currency, a.Balance.String(), a.Capital.String(),
+					a.StartingCapital.String(), day(a.StartingDate), a.Description})
+			}
+			return rows
+		},
+	},
+	"categories": {
+		header: []string{"id", "name", "description", "is_essential"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, c := range export.Categories {
+				rows = append(rows, []string{id(c.Id), c.Name, c.Description, strconv.FormatBool(c.IsEssential)})
+			}
+			return rows
+		},
+	},
+	"debtors": {
+		header: []string{"id", "name", "first_name", "last_name", "description"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, d := range export.Debtors {
+				rows = append(rows, []string{id(d.Id), d.Name, d.FirstName, d.LastName, d.Description})
+			}
+			return rows
+		},
+	},
+	"fx_rates": {
+		header: []string{"date", "currency", "quote", "rate", "source"},
+		rows: func(export types.Export) [][]string {
+			var rows [][]string
+			for _, r := range export.FXRates {
+				rows = append(rows, []string{r.Date, r.Currency, r.Quote, rate(r.Rate), r.Source})
+			}
+			return rows
+		},
+	},
+}
+
+// WriteCSV writes the CSV file of one entity of export, with a header row
+func WriteCSV(w io.Writer, entity string, export types.Export) error {
+	t, ok := tables[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %q: expected one of %v", entity, Entities)
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(t.header); err != nil {
+		return err
+	}
+	if err := out.WriteAll(t.rows(export)); err != nil {
+		return fmt.Errorf("error writing %s: %w", entity, err)
+	}
+	return nil
+}
+
+// WriteArchive writes a zip archive of the CSV file of every entity
+func WriteArchive(w io.Writer, export types.Export) error {
+	archive := zip.NewWriter(w)
+	for _, entity := range Entities {
+		file, err := archive.Create(entity + ".csv")
+		if err != nil {
+			return fmt.Errorf("error adding %s: %w", entity, err)
+		}
+		if err := WriteCSV(file, entity, export); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func id(id int32) string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// optionalId is empty for no id
+func optionalId(ref *int32) string {
+	if ref == nil {
+		return ""
+	}
+	return id(*ref)
+}
+
+// rate is empty for no rate
+func rate(r float64) string {
+	if r == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(r, 'f', -1, 64)
+}
+
+// day is empty for no date
+func day(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,151 @@
+// Package exporter writes a household's data out: a CSV file per entity, a
+// JSON dump, or a ledger or beancount journal of double-entry transactions.
+// The export endpoint and `fintrack export` both go through it.
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// Formats are the export formats
+var Formats = []string{types.ExportCSV, types.ExportJSON, types.ExportLedger, types.ExportBeancount}
+
+// Store is what an export reads. Implemented by the stores.
+type Store interface {
+	GetHousehold(ctx context.Context) (types.Household, error)
+	GetCategories(ctx context.Context) ([]types.Category, error)
+	GetAccounts(ctx context.Context) ([]types.Account, error)
+	GetInvestmentAccounts(ctx context.Context) ([]types.InvestmentAccount, error)
+	GetDebtors(ctx context.Context) ([]types.Debtor, error)
+	GetFXRates(ctx context.Context, currency string) ([]types.FXRate, error)
+	GetTransactions(ctx context.Context, period types.Period) (types.Transactions, error)
+}
+
+// Range is the period from one day to another, both included and written
+// 2006-01-02. Either may be empty to leave that end open.
+func Range(from string, to string) (types.Period, error) {
+	var period types.Period
+	if from != "" {
+		start, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return period, fmt.Errorf("invalid from date %q", from)
+		}
+		period.Start = types.FormatDate(start)
+	}
+	if to != "" {
+		end, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return period, fmt.Errorf("invalid to date %q", to)
+		}
+		period.End = types.FormatDate(end.AddDate(0, 0, 1))
+	}
+	if period.Start != "" && period.End != "" && period.End <= period.Start {
+		return period, fmt.Errorf("from must not be after to")
+	}
+	return period, nil
+}
+
+// Load reads the transactions of period, with the accounts, categories,
+// debtors and rates they refer to
+func Load(ctx context.Context, store Store, period types.Period) (types.Export, error) {
+	export := types.Export{Period: period}
+	var err error
+	if export.Household, err = store.GetHousehold(ctx); err != nil {
+		return export, fmt.Errorf("error getting household: %w", err)
+	}
+	if export.Categories, err = store.GetCategories(ctx); err != nil {
+		return export, fmt.Errorf("error getting categories: %w", err)
+	}
+	if export.Accounts, err = store.GetAccounts(ctx); err != nil {
+		return export, fmt.Errorf("error getting accounts: %w", err)
+	}
+	if export.InvestmentAccounts, err = store.GetInvestmentAccounts(ctx); err != nil {
+		return export, fmt.Errorf("error getting investment accounts: %w", err)
+	}
+	if export.Debtors, err = store.GetDebtors(ctx); err != nil {
+		return export, fmt.Errorf("error getting debtors: %w", err)
+	}
+	if export.FXRates, err = store.GetFXRates(ctx, ""); err != nil {
+		return export, fmt.Errorf("error getting exchange rates: %w", err)
+	}
+	if export.Transactions, err = store.GetTransactions(ctx, period); err != nil {
+		return export, fmt.Errorf("error getting transactions: %w", err)
+	}
+
+	// Empty lists are written [] rather than null
+	export.Categories = nonNil(export.Categories)
+	export.Accounts = nonNil(export.Accounts)
+	export.InvestmentAccounts = nonNil(export.InvestmentAccounts)
+	export.Debtors = nonNil(export.Debtors)
+	export.FXRates = nonNil(export.FXRates)
+	export.Expenses = nonNil(export.Expenses)
+	export.Incomes = nonNil(export.Incomes)
+	export.Transfers = nonNil(export.Transfers)
+	export.Investments = nonNil(export.Investments)
+	export.Debts = nonNil(export.Debts)
+	return export, nil
+}
+
+func nonNil[S ~[]T, T any](s S) S {
+	if s == nil {
+		return S{}
+	}
+	return s
+}
+
+// File is the content type and file name of an export in format. A CSV
+// export of a single entity is that entity's file; of every entity, a zip
+// archive of them.
+func File(format string, entity string) (contentType string, name string) {
+	switch format {
+	case types.ExportCSV:
+		if entity != "" {
+			return "text/csv; charset=utf-8", "fintrack-" + entity + ".csv"
+		}
+		return "application/zip", "fintrack-csv.zip"
+	case types.ExportJSON:
+		return "application/json", "fintrack.json"
+	}
+	return "text/plain; charset=utf-8", "fintrack." + format
+}
+
+// Write writes export in format. For CSV, entity picks the one file to
+// write; empty writes them all, zipped.
+func Write(w io.Writer, format string, entity string, export types.Export) error {
+	if err := Valid(format, entity); err != nil {
+		return err
+	}
+	switch format {
+	case types.ExportCSV:
+		if entity == "" {
+			return WriteArchive(w, export)
+		}
+		return WriteCSV(w, entity, export)
+	case types.ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	}
+	return WriteJournal(w, format, export)
+}
+
+// Valid checks format is known, and entity too when one is picked
+func Valid(format string, entity string) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("unknown export format %q: expected one of %v", format, Formats)
+	}
+	if entity != "" && format != types.ExportCSV {
+		return fmt.Errorf("an entity can only be picked for a CSV export")
+	}
+	if entity != "" && !slices.Contains(Entities, entity) {
+		return fmt.Errorf("unknown entity %q: expected one of %v", entity, Entities)
+	}
+	return nil
+}
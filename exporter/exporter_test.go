@@ -0,0 +1,217 @@
+package exporter_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/memory"
+	"github.com/carlosdimatteo/fintrack-backend-go/exporter"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// load records a month of transactions in two currencies, and one before it,
+// and exports March
+func load(t *testing.T) types.Export {
+	t.Helper()
+	ctx := types.WithScope(context.Background(), memory.Owner)
+	store := memory.New()
+	food, _ := store.InsertCategory(ctx, types.Category{Name: "Food"})
+	bank, _ := store.InsertAccountIntoDatabase(ctx, types.Account{Name: "Bank", Type: "Fiat", Currency: "USD"})
+	savings, _ := store.InsertAccountIntoDatabase(ctx, types.Account{Name: "cuenta ahorros", Type: "Fiat", Currency: "COP"})
+	stocks, _ := store.InsertInvestmentAccountIntoDatabase(ctx, types.InvestmentAccount{Name: "Stocks", Type: "Broker", Currency: "USD"})
+	john, _ := store.InsertDebtorIntoDatabase(ctx, types.Debtor{Name: "John"})
+	if _, err := store.UpsertFXRates(ctx, []types.FXRate{{Date: "2024-03-01", Currency: "USD", Quote: "COP", Rate: 4000}}); err != nil {
+		t.Fatalf("Insert rates: %v", err)
+	}
+
+	must := func(_ interface{}, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	must(store.InsertIncome(ctx, types.Income{Date: "2024-02-28 09:00:00", Amount: types.MoneyFromFloat(1000), Description: "February", AccountId: bank.Id}))
+	must(store.InsertIncome(ctx, types.Income{Date: "2024-03-01 09:00:00", Amount: types.MoneyFromFloat(2000), Description: "Salary", AccountId: bank.Id, AccountName: "Bank"}))
+	must(store.InsertExpense(ctx, types.Expense{Date: "2024-03-05 12:00:00", Category: "Food", CategoryId: food.Id,
+		Expense: types.MoneyFromFloat(12.5), Description: "Lunch", AccountId: bank.Id, AccountType: "Fiat"}))
+	must(store.InsertTransfer(ctx, types.Transfer{Date: "2024-03-10 10:00:00", Description: "Savings",
+		SourceAccountId: bank.Id, SourceAmount: types.MoneyFromFloat(100), DestAccountId: savings.Id, DestAmount: types.MoneyFromFloat(390000)}))
+	must(store.InsertInvestment(ctx, types.Investment{Date: "2024-03-15 10:00:00", Description: "Monthly", Amount: types.MoneyFromFloat(50),
+		AccountId: stocks.Id, AccountName: "Stocks", Type: "deposit", SourceAccountId: &savings.Id}))
+	_, _, err := store.InsertExpenseWithDebts(ctx,
+		types.Expense{Date: "2024-03-20 21:00:00", Category: "Food", CategoryId: food.Id, Expense: types.MoneyFromFloat(60),
+			Description: "Dinner", AccountId: bank.Id, AccountType: "Fiat"},
+		[]types.Debt{{Date: "2024-03-20 21:00:00", Description: "Dinner", Amount: types.MoneyFromFloat(30), DebtorId: john.Id,
+			DebtorName: "John", Currency: "USD", Outbound: true, AccountId: &bank.Id}},
+	)
+	if err != nil {
+		t.Fatalf("Record expense with debts: %v", err)
+	}
+
+	period, err := exporter.Range("2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	export, err := exporter.Load(ctx, store, period)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return export
+}
+
+// TestLedgerJournal verifies every kind of transaction becomes a balanced
+// entry, converting between currencies with a total price
+func TestLedgerJournal(t *testing.T) {
+	var out bytes.Buffer
+	if err := exporter.Write(&out, types.ExportLedger, "", load(t)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	expected := `; Fintrack export of Household from 2024-03-01 to 2024-03-31
+
+account Assets:Bank
+account Assets:Cuenta-Ahorros
+account Assets:Debtors:John
+account Assets:Investments:Stocks
+account Expenses:Food
+account Income:Uncategorized
+
+2024-03-01 * Salary
+    ; fintrack: income 2
+    Assets:Bank                                     2000.00 USD
+    Income:Uncategorized                           -2000.00 USD
+
+2024-03-05 * Lunch
+    ; fintrack: expense 1
+    Expenses:Food                                     12.50 USD
+    Assets:Bank                                      -12.50 USD
+
+2024-03-10 * Savings
+    ; fintrack: transfer 1
+    ; exchange_rate: 3900
+    ; reference_rate: 4000
+    ; fx_cost: 10000.00 COP
+    Assets:Cuenta-Ahorros                         390000.00 COP @@ 100.00 USD
+    Assets:Bank                                     -100.00 USD
+
+2024-03-15 * Monthly
+    ; fintrack: investment 1
+    ; rate: 4000
+    Assets:Investments:Stocks                         50.00 USD @@ 200000.00 COP
+    Assets:Cuenta-Ahorros                        -200000.00 COP
+
+2024-03-20 * Dinner
+    ; fintrack: expense 2
+    Expenses:Food                                     60.00 USD
+    Assets:Bank                                      -60.00 USD
+
+2024-03-20 * Dinner
+    ; fintrack: debt 1
+    Assets:Debtors:John                               30.00 USD
+    Expenses:Food                                    -30.00 USD
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected journal:\n%s", out.String())
+	}
+}
+
+// TestBeancountJournal verifies accounts are opened on first use and
+// narrations and metadata are quoted
+func TestBeancountJournal(t *testing.T) {
+	var out bytes.Buffer
+	if err := exporter.Write(&out, types.ExportBeancount, "", load(t)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, line := range []string{
+		`option "operating_currency" "USD"`,
+		"2024-03-01 open Assets:Bank\n",
+		"2024-03-10 open Assets:Cuenta-Ahorros\n",
+		`2024-03-05 * "Lunch"`,
+		`  fintrack: "expense 1"`,
+		"  exchange_rate: 3900\n",
+		"  Assets:Cuenta-Ahorros                         390000.00 COP @@ 100.00 USD\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+// TestCSVAndJSON verifies the CSV files and the dump only hold the range
+func TestCSVAndJSON(t *testing.T) {
+	export := load(t)
+
+	var out bytes.Buffer
+	if err := exporter.Write(&out, types.ExportCSV, "incomes", export); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	expected := "id,date,amount,description,account_id,account_name\n2,2024-03-01 09:00:00,2000.00,Salary,1,Bank\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := exporter.Write(&out, types.ExportCSV, "", export); err != nil {
+		t.Fatalf("Write archive: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("Read archive: %v", err)
+	}
+	if len(archive.File) != len(exporter.Entities) || archive.File[0].Name != "expenses.csv" {
+		t.Errorf("Expected a file per entity, got %d", len(archive.File))
+	}
+
+	out.Reset()
+	if err := exporter.Write(&out, types.ExportJSON, "", export); err != nil {
+		t.Fatalf("Write JSON: %v", err)
+	}
+	var dump types.Export
+	if err := json.Unmarshal(out.Bytes(), &dump); err != nil {
+		t.Fatalf("Read JSON: %v", err)
+	}
+	if len(dump.Incomes) != 1 || len(dump.Expenses) != 2 || len(dump.Transfers) != 1 || len(dump.Debts) != 1 || len(dump.Accounts) != 2 {
+		t.Errorf("Unexpected dump: %+v", dump)
+	}
+
+	if err := exporter.Write(&out, types.ExportLedger, "expenses", export); err == nil {
+		t.Error("Expected an entity to be refused outside CSV")
+	}
+	if _, err := exporter.Range("2024-03-31", "2024-03-01"); err == nil {
+		t.Error("Expected from after to to be refused")
+	}
+}
+
+// TestLoadHouseholdWide verifies the CLI's household-wide scope exports the
+// accounts private to a member, with their transactions
+func TestLoadHouseholdWide(t *testing.T) {
+	ctx := types.WithScope(context.Background(), memory.Owner)
+	store := memory.New()
+	owner := memory.Owner.UserId
+	savings, err := store.InsertAccountIntoDatabase(ctx, types.Account{Name: "Savings", Type: "Fiat", Currency: "USD", OwnerId: &owner})
+	if err != nil {
+		t.Fatalf("Insert account: %v", err)
+	}
+	if _, err := store.InsertIncome(ctx, types.Income{Date: "2024-03-01 09:00:00", Amount: types.MoneyFromFloat(12), Description: "Interest", AccountId: savings.Id}); err != nil {
+		t.Fatalf("Insert income: %v", err)
+	}
+
+	period, _ := exporter.Range("", "")
+	export, err := exporter.Load(types.WithScope(context.Background(), types.Scope{HouseholdId: memory.Owner.HouseholdId}), store, period)
+	if err != nil {
+		t.Fatalf("Load without a user: %v", err)
+	}
+	if len(export.Accounts) != 0 || len(export.Incomes) != 0 {
+		t.Errorf("Expected no private rows without a user, got %+v", export)
+	}
+	export, err = exporter.Load(types.WithScope(context.Background(), types.HouseholdScope(memory.Owner.HouseholdId)), store, period)
+	if err != nil {
+		t.Fatalf("Load household-wide: %v", err)
+	}
+	if len(export.Accounts) != 1 || len(export.Incomes) != 1 {
+		t.Errorf("Expected the private account and its income household-wide, got %+v", export)
+	}
+}
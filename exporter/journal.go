@@ -0,0 +1,423 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== JOURNALS ==========
+
+// A journal is the export as double-entry transactions, in ledger or
+// beancount syntax. Accounts are Assets:<name>, investment accounts
+// Assets:Investments:<name>, debtors Assets:Debtors:<name> (below zero, what
+// the household owes them) and categories Expenses:<name>. Incomes have no
+// category and come from Income:Uncategorized; money from outside every
+// account, like a deposit without a source account, from Equity:External.
+// Each transaction balances in every currency, or through the total price
+// (@@) of its posting when it converts between two.
+
+// Accounts that stand for no record
+const (
+	incomeAccount        = "Income:Uncategorized"
+	uncategorizedAccount = "Expenses:Uncategorized"
+	externalAccount      = "Equity:External"
+	feeAccount           = "Expenses:Transfer-Fees"
+)
+
+// amount is a quantity of a currency
+type amount struct {
+	value    types.Money
+	currency string
+}
+
+func (a amount) neg() amount {
+	return amount{a.value.Neg(), a.currency}
+}
+
+type posting struct {
+	account string
+	amount  amount
+	price   *amount // the total price in another currency, always positive
+}
+
+// entry is one transaction of the journal
+type entry struct {
+	date      string // 2006-01-02
+	narration string
+	meta      [][2]string
+	postings  []posting
+}
+
+// ledgerAccount is a journal account with the currency its records are in
+type ledgerAccount struct {
+	name     string
+	currency string
+}
+
+// journal turns the records of an export into entries
+type journal struct {
+	export      types.Export
+	base        string
+	accounts    map[int32]ledgerAccount
+	investments map[int32]ledgerAccount
+	debtors     map[int32]string
+	categories  map[int32]string
+	expenses    map[int32]types.Expense
+	entries     []entry
+}
+
+func newJournal(export types.Export) *journal {
+	j := &journal{
+		export:      export,
+		base:        export.Household.BaseCurrency,
+		accounts:    map[int32]ledgerAccount{},
+		investments: map[int32]ledgerAccount{},
+		debtors:     map[int32]string{},
+		categories:  map[int32]string{},
+		expenses:    map[int32]types.Expense{},
+	}
+	if j.base == "" {
+		j.base = "USD"
+	}
+
+	// Two records sanitized to the same name are told apart by their ids
+	taken := map[string]bool{}
+	name := func(parent string, id int32, name string) string {
+		n := parent + ":" + component(name)
+		if taken[n] {
+			n = fmt.Sprintf("%s-%d", n, id)
+		}
+		taken[n] = true
+		return n
+	}
+	for _, a := range export.Accounts {
+		j.accounts[a.Id] = ledgerAccount{name("Assets", a.Id, a.Name), j.currency(a.Currency)}
+	}
+	for _, a := range export.InvestmentAccounts {
+		j.investments[a.Id] = ledgerAccount{name("Assets:Investments", a.Id, a.Name), j.currency(a.Currency)}
+	}
+	for _, d := range export.Debtors {
+		j.debtors[d.Id] = name("Assets:Debtors", d.Id, d.Name)
+	}
+	for _, c := range export.Categories {
+		j.categories[c.Id] = name("Expenses", c.Id, c.Name)
+	}
+	for _, e := range export.Expenses {
+		j.expenses[e.Id] = e
+	}
+	return j
+}
+
+// currency is c, or the base currency when it is empty
+func (j *journal) currency(c string) string {
+	if c == "" {
+		return j.base
+	}
+	return c
+}
+
+// fiat is a fiat account; one the export doesn't list (another member's
+// private account) is named by its id
+func (j *journal) fiat(id int32) ledgerAccount {
+	if a, ok := j.accounts[id]; ok {
+		return a
+	}
+	return ledgerAccount{fmt.Sprintf("Assets:Account-%d", id), j.base}
+}
+
+func (j *journal) investmentAccount(id int32) ledgerAccount {
+	if a, ok := j.investments[id]; ok {
+		return a
+	}
+	return ledgerAccount{fmt.Sprintf("Assets:Investments:Account-%d", id), j.base}
+}
+
+// expenseSource is the account an expense was paid from; account_type tells
+// the two tables apart
+func (j *journal) expenseSource(e types.Expense) ledgerAccount {
+	switch e.AccountType {
+	case "Investment", "Crypto", "Broker":
+		return j.investmentAccount(e.AccountId)
+	}
+	return j.fiat(e.AccountId)
+}
+
+func (j *journal) category(e types.Expense) string {
+	if c, ok := j.categories[e.CategoryId]; ok {
+		return c
+	}
+	if e.Category != "" {
+		return "Expenses:" + component(e.Category)
+	}
+	return uncategorizedAccount
+}
+
+func (j *journal) debtor(d types.Debt) string {
+	if name, ok := j.debtors[d.DebtorId]; ok {
+		return name
+	}
+	return "Assets:Debtors:" + component(d.DebtorName)
+}
+
+func (j *journal) add(date string, narration string, meta [][2]string, postings ...posting) {
+	j.entries = append(j.entries, entry{date: dayOf(date), narration: narration, meta: meta, postings: postings})
+}
+
+func (j *journal) expense(e types.Expense) {
+	from := j.expenseSource(e)
+	spent := amount{e.Expense, from.currency}
+	j.add(e.Date, or(e.Description, or(e.Category, "Expense")), meta("expense", e.Id),
+		posting{account: j.category(e), amount: spent},
+		posting{account: from.name, amount: spent.neg()},
+	)
+}
+
+func (j *journal) income(i types.Income) {
+	to := j.fiat(i.AccountId)
+	received := amount{i.Amount, to.currency}
+	j.add(i.Date, or(i.Description, "Income"), meta("income", i.Id),
+		posting{account: to.name, amount: received},
+		posting{account: incomeAccount, amount: received.neg()},
+	)
+}
+
+// transfer posts both legs. Between currencies the destination leg is priced
+// at what left the source; within one, any difference is a fee.
+func (j *journal) transfer(t types.Transfer) {
+	from, to := j.fiat(t.SourceAccountId), j.fiat(t.DestAccountId)
+	sent, received := amount{t.SourceAmount, from.currency}, amount{t.DestAmount, to.currency}
+	m := meta("transfer", t.Id)
+	narration := or(t.Description, "Transfer")
+
+	if from.currency == to.currency {
+		postings := []posting{{account: to.name, amount: received}, {account: from.name, amount: sent.neg()}}
+		if fee := t.SourceAmount.Sub(t.DestAmount); fee.Sign() != 0 {
+			postings = append(postings, posting{account: feeAccount, amount: amount{fee, from.currency}})
+		}
+		j.add(t.Date, narration, m, postings...)
+		return
+	}
+
+	if t.ExchangeRate != 0 {
+		m = append(m, [2]string{"exchange_rate", decimal(t.ExchangeRate)})
+	}
+	if t.ReferenceRate != 0 {
+		m = append(m, [2]string{"reference_rate", decimal(t.ReferenceRate)}, [2]string{"fx_cost", t.FXCost.String() + " " + to.currency})
+	}
+	j.add(t.Date, narration, m,
+		posting{account: to.name, amount: received, price: &sent},
+		posting{account: from.name, amount: sent.neg()},
+	)
+}
+
+// investment posts a deposit into (or withdrawal from) an investment account
+// against its source account, converted at the rate of its date when the
+// two currencies differ. Without a source it is money from outside; without
+// a rate the source leg stays in the investment's currency.
+func (j *journal) investment(i types.Investment) {
+	account := j.investmentAccount(i.AccountId)
+	moved := amount{i.Amount, account.currency}
+	if i.Type == "withdrawal" {
+		moved = moved.neg()
+	}
+	m := meta("investment", i.Id)
+	narration := or(i.Description, "Investment "+i.Type)
+
+	if i.SourceAccountId == nil {
+		j.add(i.Date, narration, m, posting{account: account.name, amount: moved}, posting{account: externalAccount, amount: moved.neg()})
+		return
+	}
+	source := j.fiat(*i.SourceAccountId)
+	rate, ok := j.export.FXRates.Lookup(account.currency, source.currency, i.Date)
+	if !ok {
+		j.add(i.Date, narration, m, posting{account: account.name, amount: moved}, posting{account: source.name, amount: moved.neg()})
+		return
+	}
+	price := amount{i.Amount.Times(rate), source.currency}
+	paid := price
+	if i.Type == "withdrawal" {
+		paid = paid.neg()
+	}
+	m = append(m, [2]string{"rate", decimal(rate)})
+	j.add(i.Date, narration, m,
+		posting{account: account.name, amount: moved, price: &price},
+		posting{account: source.name, amount: paid.neg()},
+	)
+}
+
+// debt moves money to (lent) or from (borrowed, repaid) the debtor. A debt
+// split off an expense comes out of the expense's category, a repayment out of
+// the income it was received as, anything else out of its account.
+func (j *journal) debt(d types.Debt) {
+	owed := amount{d.Amount, j.currency(d.Currency)}
+	if !d.Outbound {
+		owed = owed.neg()
+	}
+
+	against := externalAccount
+	switch {
+	case d.ExpenseId != nil:
+		against = uncategorizedAccount
+		if e, ok := j.expenses[*d.ExpenseId]; ok {
+			against = j.category(e)
+		}
+	case d.IncomeId != nil:
+		against = incomeAccount
+	case d.AccountId != nil:
+		against = j.fiat(*d.AccountId).name
+	}
+	j.add(d.Date, or(d.Description, "Debt: "+d.DebtorName), meta("debt", d.Id),
+		posting{account: j.debtor(d), amount: owed},
+		posting{account: against, amount: owed.neg()},
+	)
+}
+
+// WriteJournal writes export as a ledger or beancount journal, oldest
+// transaction first
+func WriteJournal(w io.Writer, format string, export types.Export) error {
+	j := newJournal(export)
+	for _, e := range export.Expenses {
+		j.expense(e)
+	}
+	for _, i := range export.Incomes {
+		j.income(i)
+	}
+	for _, t := range export.Transfers {
+		j.transfer(t)
+	}
+	for _, i := range export.Investments {
+		j.investment(i)
+	}
+	for _, d := range export.Debts {
+		j.debt(d)
+	}
+	sort.SliceStable(j.entries, func(a, b int) bool { return j.entries[a].date < j.entries[b].date })
+
+	// Every account is opened on the day it is first used
+	opened := map[string]string{}
+	var names []string
+	for _, e := range j.entries {
+		for _, p := range e.postings {
+			if _, ok := opened[p.account]; !ok {
+				opened[p.account] = e.date
+				names = append(names, p.account)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	out := bufio.NewWriter(w)
+	beancount := format == types.ExportBeancount
+	fmt.Fprintf(out, "; %s\n", title(export))
+	if beancount {
+		fmt.Fprintf(out, "option \"title\" %s\n", quote(export.Household.Name))
+		fmt.Fprintf(out, "option \"operating_currency\" %s\n", quote(j.base))
+	}
+	fmt.Fprintln(out)
+	for _, name := range names {
+		if beancount {
+			fmt.Fprintf(out, "%s open %s\n", opened[name], name)
+		} else {
+			fmt.Fprintf(out, "account %s\n", name)
+		}
+	}
+
+	for _, e := range j.entries {
+		fmt.Fprintln(out)
+		indent := "    "
+		if beancount {
+			indent = "  "
+			fmt.Fprintf(out, "%s * %s\n", e.date, quote(e.narration))
+		} else {
+			fmt.Fprintf(out, "%s * %s\n", e.date, e.narration)
+		}
+		for _, m := range e.meta {
+			if beancount {
+				fmt.Fprintf(out, "%s%s: %s\n", indent, m[0], metaValue(m[1]))
+			} else {
+				fmt.Fprintf(out, "%s; %s: %s\n", indent, m[0], m[1])
+			}
+		}
+		for _, p := range e.postings {
+			line := fmt.Sprintf("%s%-40s %14s %s", indent, p.account, p.amount.value, p.amount.currency)
+			if p.price != nil {
+				line += fmt.Sprintf(" @@ %s %s", p.price.value, p.price.currency)
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+	return out.Flush()
+}
+
+// title describes the export and its range
+func title(export types.Export) string {
+	t := "Fintrack export of " + export.Household.Name
+	if export.Period.Start != "" {
+		t += " from " + dayOf(export.Period.Start)
+	}
+	if end, err := time.Parse(types.DateLayout, export.Period.End); err == nil {
+		t += " to " + end.AddDate(0, 0, -1).Format(time.DateOnly)
+	}
+	return t
+}
+
+// meta is the metadata tying an entry to the record it was made from
+func meta(kind string, id int32) [][2]string {
+	return [][2]string{{"fintrack", fmt.Sprintf("%s %d", kind, id)}}
+}
+
+// component is name as an account name component: words capitalized and
+// joined by dashes, which both ledger and beancount read
+func component(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for i, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	if len(words) == 0 {
+		return "Unnamed"
+	}
+	return strings.Join(words, "-")
+}
+
+// or is text on a single line, or fallback when it is blank
+func or(text string, fallback string) string {
+	if text = strings.Join(strings.Fields(text), " "); text != "" {
+		return text
+	}
+	return fallback
+}
+
+// quote is s as a beancount string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// metaValue is a beancount metadata value: numbers bare, anything else quoted
+func metaValue(v string) string {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return quote(v)
+}
+
+func decimal(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// dayOf is the day of a stored-form date
+func dayOf(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
@@ -1,16 +1,47 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	_ "time/tzdata" // household timezones resolve without the host's zoneinfo
 
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
 	"github.com/carlosdimatteo/fintrack-backend-go/api"
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	"github.com/carlosdimatteo/fintrack-backend-go/exporter"
+	"github.com/carlosdimatteo/fintrack-backend-go/importer"
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
 
+const usage = `usage:
+  fintrack                                          serve the API
+  fintrack migrate up                               apply pending migrations
+  fintrack migrate down                             revert the latest applied migration
+  fintrack migrate status                           list migrations and whether they are applied
+  fintrack households create <name> [spreadsheet]   add a household, optionally with its own spreadsheet id
+  fintrack households list                          list households
+  fintrack users create <household id> <name>       add a user to a household
+  fintrack users list                               list users
+  fintrack keys create <user id> <name>             issue an API key for a user (printed once)
+  fintrack keys list                                list API keys
+  fintrack keys revoke <id>                         revoke an API key and the sessions opened with it
+  fintrack fx import <household id> <file.csv>      load exchange rates (date,currency,quote,rate rows)
+  fintrack fx list <household id> [currency]        list a household's exchange rates
+  fintrack sheet import <household id> [--dry-run]  record the history in the household's sheet ranges not yet in the database
+  fintrack export <household id> <format> [--from 2006-01-02] [--to 2006-01-02] [--entity name]
+                                                    write a household's data to stdout as csv (one entity, or a zip of all), json, ledger or beancount`
+
 func main() {
 
 	err := godotenv.Load(".env")
@@ -18,8 +49,87 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error loading .env file")
 	}
+
+	ctx := context.Background()
+	store, err := postgres.New(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer store.Close()
+
+	// DB_QUERY_TIMEOUT (e.g. "5s") bounds every query; "0" leaves only the request's deadline
+	if timeout := os.Getenv("DB_QUERY_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			log.Fatalf("Invalid DB_QUERY_TIMEOUT %q: %v", timeout, err)
+		}
+		store.SetQueryTimeout(d)
+	}
+
+	if len(os.Args) > 1 {
+		var err error
+		switch {
+		case os.Args[1] == "migrate" && len(os.Args) == 3:
+			err = migrate(ctx, store, os.Args[2])
+		case os.Args[1] == "households" && len(os.Args) >= 3:
+			err = households(ctx, store, os.Args[2], os.Args[3:])
+		case os.Args[1] == "users" && len(os.Args) >= 3:
+			err = users(ctx, store, os.Args[2], os.Args[3:])
+		case os.Args[1] == "keys" && len(os.Args) >= 3:
+			err = keys(ctx, store, os.Args[2], os.Args[3:])
+		case os.Args[1] == "fx" && len(os.Args) >= 3:
+			err = fx(ctx, store, os.Args[2], os.Args[3:])
+		case os.Args[1] == "sheet" && len(os.Args) >= 3:
+			err = sheet(ctx, store, os.Args[2], os.Args[3:])
+		case os.Args[1] == "export" && len(os.Args) >= 4:
+			err = export(ctx, store, os.Args[2], os.Args[3], os.Args[4:])
+		default:
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Refuse to serve queries written for a newer schema
+	if err := store.CheckSchema(ctx); err != nil {
+		log.Fatalf("%v (run `fintrack migrate up`)", err)
+	}
+
+	// AUTH_JWT_SECRET signs session tokens; AUTH_SESSION_TTL (e.g. "12h") is optional
+	var sessionTTL time.Duration
+	if ttl := os.Getenv("AUTH_SESSION_TTL"); ttl != "" {
+		sessionTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid AUTH_SESSION_TTL %q: %v", ttl, err)
+		}
+	}
+	authenticator, err := auth.NewAuthenticator(store, []byte(os.Getenv("AUTH_JWT_SECRET")), sessionTTL)
+	if err != nil {
+		log.Fatalf("Invalid AUTH_JWT_SECRET: %v", err)
+	}
+
+	// Without credentials the server still serves; sheet writes stay queued
+	sink, err := googleSS.NewSheetsSink(ctx)
+	if err != nil {
+		log.Printf("Running with sheets disabled: %v", err)
+		sink = googleSS.DisabledSink{Reason: err}
+	}
+
+	// Sheet writes go through the outbox; pending jobs from a previous run resume here
+	outbox := googleSS.NewOutbox(store, sink)
+	outbox.Start(ctx)
+
+	handler := api.NewHandler(store, outbox, authenticator)
+	// CORS_ALLOWED_ORIGINS is a comma separated list; unset allows any origin
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		handler.SetAllowedOrigins(strings.Split(origins, ","))
+	}
+
 	muxRouter := mux.NewRouter()
-	api.LoadRoutes(muxRouter)
+	api.LoadRoutes(muxRouter, handler)
 	fmt.Println("API routes loaded")
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -31,3 +141,289 @@ func main() {
 	}
 
 }
+
+// migrate runs `fintrack migrate <command>`
+func migrate(ctx context.Context, store *postgres.Store, command string) error {
+	switch command {
+	case "up":
+		applied, err := store.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		m, reverted, err := store.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		if !reverted {
+			fmt.Println("no migrations to revert")
+			return nil
+		}
+		fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+	case "status":
+		statuses, err := store.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, usage)
+	}
+	return nil
+}
+
+// households runs `fintrack households <command>`
+func households(ctx context.Context, store *postgres.Store, command string, args []string) error {
+	switch {
+	case command == "create" && (len(args) == 1 || len(args) == 2):
+		spreadsheetId := ""
+		if len(args) == 2 {
+			spreadsheetId = args[1]
+		}
+		household, err := store.CreateHousehold(ctx, args[0], spreadsheetId)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created household %d (%s)\n", household.Id, household.Name)
+	case command == "list" && len(args) == 0:
+		list, err := store.ListHouseholds(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSPREADSHEET\tTIMEZONE\tCREATED AT")
+		for _, h := range list {
+			spreadsheet := h.SpreadsheetId
+			if spreadsheet == "" {
+				spreadsheet = "(SPREADSHEET_ID)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", h.Id, h.Name, spreadsheet, h.Timezone, h.CreatedAt.Format(time.DateTime))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown households command\n%s", usage)
+	}
+	return nil
+}
+
+// users runs `fintrack users <command>`
+func users(ctx context.Context, store *postgres.Store, command string, args []string) error {
+	switch {
+	case command == "create" && len(args) == 2:
+		householdId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid household id %q", args[0])
+		}
+		user, err := store.CreateUser(ctx, householdId, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %d (%s) in household %d\n", user.Id, user.Name, user.HouseholdId)
+	case command == "list" && len(args) == 0:
+		list, err := store.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tHOUSEHOLD\tNAME\tCREATED AT")
+		for _, u := range list {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", u.Id, u.HouseholdId, u.Name, u.CreatedAt.Format(time.DateTime))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown users command\n%s", usage)
+	}
+	return nil
+}
+
+// keys runs `fintrack keys <command>`
+func keys(ctx context.Context, store *postgres.Store, command string, args []string) error {
+	switch {
+	case command == "create" && len(args) == 2:
+		userId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q", args[0])
+		}
+		key, stored, err := auth.IssueAPIKey(ctx, store, userId, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created key %d (%s) for user %d\n%s\n", stored.Id, stored.Name, stored.UserId, key)
+		fmt.Println("store it now: only its hash is kept")
+	case command == "list" && len(args) == 0:
+		list, err := store.ListAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tNAME\tPREFIX\tCREATED AT\tREVOKED AT")
+		for _, k := range list {
+			revokedAt := "-"
+			if k.RevokedAt != nil {
+				revokedAt = k.RevokedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%d\t%d\t%s\tftk_%s_...\t%s\t%s\n", k.Id, k.UserId, k.Name, k.Prefix, k.CreatedAt.Format(time.DateTime), revokedAt)
+		}
+		return w.Flush()
+	case command == "revoke" && len(args) == 1:
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key id %q", args[0])
+		}
+		revoked, err := store.RevokeAPIKey(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked key %d (%s)\n", revoked.Id, revoked.Name)
+	default:
+		return fmt.Errorf("unknown keys command\n%s", usage)
+	}
+	return nil
+}
+
+// fx runs `fintrack fx <command>` for one household
+func fx(ctx context.Context, store *postgres.Store, command string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("unknown fx command\n%s", usage)
+	}
+	householdId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid household id %q", args[0])
+	}
+	ctx = types.WithScope(ctx, types.HouseholdScope(householdId))
+
+	switch {
+	case command == "import" && len(args) == 2:
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		rates, err := types.ParseFXRatesCSV(file)
+		if err != nil {
+			return err
+		}
+		if err := types.Validate(ctx, types.FXRates(rates), nil); err != nil {
+			return err
+		}
+		stored, err := store.UpsertFXRates(ctx, rates)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("loaded %d rates into household %d\n", len(stored), householdId)
+	case command == "list" && len(args) <= 2:
+		currency := ""
+		if len(args) == 2 {
+			currency = strings.ToUpper(args[1])
+		}
+		list, err := store.GetFXRates(ctx, currency)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tCURRENCY\tQUOTE\tRATE\tSOURCE")
+		for _, r := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%g\t%s\n", r.Date, r.Currency, r.Quote, r.Rate, r.Source)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown fx command\n%s", usage)
+	}
+	return nil
+}
+
+// sheet runs `fintrack sheet <command>` for one household
+func sheet(ctx context.Context, store *postgres.Store, command string, args []string) error {
+	if command != "import" || len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "--dry-run") {
+		return fmt.Errorf("unknown sheet command\n%s", usage)
+	}
+	householdId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid household id %q", args[0])
+	}
+	ctx = types.WithScope(ctx, types.HouseholdScope(householdId))
+	dryRun := len(args) == 2
+
+	// Years of history are recorded in one transaction, which can outlast the
+	// deadline meant for a request; DB_QUERY_TIMEOUT still wins when set
+	if os.Getenv("DB_QUERY_TIMEOUT") == "" {
+		store.SetQueryTimeout(5 * time.Minute)
+	}
+
+	household, err := store.GetHousehold(ctx)
+	if err != nil {
+		return err
+	}
+	sink, err := googleSS.NewSheetsSink(ctx)
+	if err != nil {
+		return err
+	}
+	if s, ok := sink.(googleSS.SpreadsheetSink); ok && household.SpreadsheetId != "" {
+		sink = s.ForSpreadsheet(household.SpreadsheetId)
+	}
+
+	report, err := importer.ImportSheet(ctx, store, sink, dryRun)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tRANGE\tROWS\tIMPORTED\tEXISTING\tUNMAPPED")
+	for _, r := range report.Ranges {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", r.Kind, r.Range, r.Rows, r.Imported, r.Existing, r.Unmapped)
+	}
+	if len(report.Unmapped) > 0 {
+		fmt.Fprintln(w, "\nKIND\tROW\tREASON\tVALUES")
+		for _, u := range report.Unmapped {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%v\n", u.Kind, u.Row, u.Reason, u.Values)
+		}
+	}
+	if dryRun {
+		fmt.Fprintln(w, "\ndry run: nothing was recorded")
+	}
+	return w.Flush()
+}
+
+// export runs `fintrack export`, writing one household's data to stdout
+func export(ctx context.Context, store *postgres.Store, household string, format string, args []string) error {
+	householdId, err := strconv.ParseInt(household, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid household id %q", household)
+	}
+	ctx = types.WithScope(ctx, types.HouseholdScope(householdId))
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	from := flags.String("from", "", "the first day, 2006-01-02")
+	to := flags.String("to", "", "the last day, 2006-01-02, included")
+	entity := flags.String("entity", "", "csv: the one entity to write, like expenses")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%s", err, usage)
+	}
+	if err := exporter.Valid(format, *entity); err != nil {
+		return err
+	}
+	period, err := exporter.Range(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	data, err := exporter.Load(ctx, store, period)
+	if err != nil {
+		return err
+	}
+	return exporter.Write(os.Stdout, format, *entity, data)
+}
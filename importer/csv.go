@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// parseCSV reads a statement whose first row is a header naming the columns
+// of mapping
+func parseCSV(content string, mapping types.ImportMapping) ([]Transaction, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	if mapping.Delimiter != "" {
+		reader.Comma = []rune(mapping.Delimiter)[0]
+	}
+	layout := mapping.DateFormat
+	if layout == "" {
+		layout = time.DateOnly
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("column %q is not in the header", name)
+		}
+		return i, nil
+	}
+
+	var date, amount, debit, credit, description int
+	for _, c := range []struct {
+		index *int
+		name  string
+	}{
+		{&date, mapping.Date}, {&amount, mapping.Amount}, {&debit, mapping.Debit},
+		{&credit, mapping.Credit}, {&description, mapping.Description},
+	} {
+		if *c.index, err = column(c.name); err != nil {
+			return nil, err
+		}
+	}
+	cell := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var transactions []Transaction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return transactions, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the statement: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		t := Transaction{Line: line, Description: cell(record, description)}
+		if t.Date, err = parseDay(layout, cell(record, date)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if amount >= 0 {
+			if t.Amount, err = parseAmount(cell(record, amount), mapping.DecimalComma); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if mapping.Negate {
+				t.Amount = t.Amount.Neg()
+			}
+		} else {
+			// Some banks write debits below zero, others don't
+			out, err := parseAmount(cell(record, debit), mapping.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			in, err := parseAmount(cell(record, credit), mapping.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			t.Amount = abs(in).Sub(abs(out))
+		}
+		transactions = append(transactions, t)
+	}
+}
+
+func abs(m types.Money) types.Money {
+	if m.Sign() < 0 {
+		return m.Neg()
+	}
+	return m
+}
@@ -0,0 +1,125 @@
+// Package importer reads bank statements (CSV, OFX/QFX and QIF) into the
+// transactions they list. It knows nothing of accounts or categories: the
+// import endpoints turn its transactions into expenses and incomes.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ErrNoMapping is returned for a CSV statement read without a column mapping
+var ErrNoMapping = errors.New("a CSV statement needs a column mapping")
+
+// Transaction is one transaction of a statement
+type Transaction struct {
+	Line        int         // where it starts in the statement, counting from 1
+	Date        string      // 2006-01-02
+	Amount      types.Money // below zero is money out of the account
+	Description string
+}
+
+// Detect guesses the format of a statement from its first lines: OFX and QFX
+// start with an OFX header or tag, QIF with a !Type line, anything else is CSV
+func Detect(content string) string {
+	head := strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(content, "\ufeff")))
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	switch {
+	case strings.HasPrefix(head, "OFXHEADER") || strings.Contains(head, "<OFX>"):
+		return types.ImportOFX
+	case strings.HasPrefix(head, "!TYPE:") || strings.HasPrefix(head, "!ACCOUNT") || strings.HasPrefix(head, "!OPTION"):
+		return types.ImportQIF
+	}
+	return types.ImportCSV
+}
+
+// Parse reads a statement in format, detected when empty. A CSV statement is
+// read with mapping; the other formats don't need one. Transactions of no
+// amount are left out.
+func Parse(format string, content string, mapping *types.ImportMapping) ([]Transaction, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	if format == "" {
+		format = Detect(content)
+	}
+
+	var transactions []Transaction
+	var err error
+	switch format {
+	case types.ImportCSV:
+		if mapping == nil {
+			return nil, ErrNoMapping
+		}
+		transactions, err = parseCSV(content, *mapping)
+	case types.ImportOFX, types.ImportQFX:
+		transactions, err = parseOFX(content)
+	case types.ImportQIF:
+		transactions, err = parseQIF(content)
+	default:
+		return nil, fmt.Errorf("unknown statement format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := transactions[:0]
+	for _, t := range transactions {
+		if t.Amount.Sign() != 0 {
+			results = append(results, t)
+		}
+	}
+	return results, nil
+}
+
+// parseAmount reads an amount as banks write them: with a currency sign,
+// thousands separators, a trailing minus or in parentheses when negative.
+// Blank is zero.
+func parseAmount(value string, decimalComma bool) (types.Money, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, s[1:len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative, s = true, s[:len(s)-1]
+	}
+
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c == '-', c == '+':
+			b.WriteRune(c)
+		case c == '.' && !decimalComma, c == ',' && decimalComma:
+			b.WriteRune('.')
+		}
+	}
+	if b.Len() == 0 {
+		if strings.TrimSpace(value) == "" {
+			return types.Money{}, nil
+		}
+		return types.Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	amount, err := types.ParseMoney(b.String())
+	if err != nil {
+		return types.Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
+
+// parseDay reads a date in layout and returns it as 2006-01-02
+func parseDay(layout string, value string) (string, error) {
+	t, err := time.Parse(layout, strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q, expected one like %s", value, layout)
+	}
+	return t.Format(time.DateOnly), nil
+}
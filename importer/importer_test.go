@@ -0,0 +1,142 @@
+package importer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/importer"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// expect compares transactions to date|amount|description lines
+func expect(t *testing.T, got []importer.Transaction, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d transactions, got %d: %+v", len(want), len(got), got)
+	}
+	for i, tx := range got {
+		if line := tx.Date + "|" + tx.Amount.String() + "|" + tx.Description; line != want[i] {
+			t.Errorf("Transaction %d: expected %s, got %s", i, want[i], line)
+		}
+	}
+}
+
+// TestParseCSV verifies columns are found by header whatever their order, and
+// amounts are read signed, negated or from debit and credit columns
+func TestParseCSV(t *testing.T) {
+	statement := "Description;Booked;Amount\n" +
+		"\"Coffee; large\";05/03/2024;-4,50\n" +
+		"Salary;06/03/2024;\"1.500,00\"\n" +
+		"Fee reversal;07/03/2024;0,00\n"
+	transactions, err := importer.Parse(types.ImportCSV, statement, &types.ImportMapping{
+		Date: "booked", DateFormat: "02/01/2006", Amount: "Amount", Description: "Description",
+		Delimiter: ";", DecimalComma: true,
+	})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	expect(t, transactions, "2024-03-05|-4.50|Coffee; large", "2024-03-06|1500.00|Salary")
+	if transactions[1].Line != 3 {
+		t.Errorf("Expected the salary on line 3, got %d", transactions[1].Line)
+	}
+
+	card := "Date,Details,Amount\n2024-03-05,Groceries,$82.10\n2024-03-06,Payment,(200.00)\n"
+	transactions, err = importer.Parse("", card, &types.ImportMapping{Date: "Date", Amount: "Amount", Description: "Details", Negate: true})
+	if err != nil {
+		t.Fatalf("Parse card: %v", err)
+	}
+	expect(t, transactions, "2024-03-05|-82.10|Groceries", "2024-03-06|200.00|Payment")
+
+	split := "Date,Memo,Debit,Credit\n2024-03-05,Rent,-900.00,\n2024-03-06,Refund,,15.25\n"
+	transactions, err = importer.Parse(types.ImportCSV, split, &types.ImportMapping{Date: "Date", Debit: "Debit", Credit: "Credit", Description: "Memo"})
+	if err != nil {
+		t.Fatalf("Parse debit/credit: %v", err)
+	}
+	expect(t, transactions, "2024-03-05|-900.00|Rent", "2024-03-06|15.25|Refund")
+
+	_, err = importer.Parse(types.ImportCSV, split, &types.ImportMapping{Date: "Posted", Amount: "Debit", Description: "Memo"})
+	if err == nil || !strings.Contains(err.Error(), `"Posted"`) {
+		t.Errorf("Expected a missing column error, got %v", err)
+	}
+	_, err = importer.Parse(types.ImportCSV, "Date,Memo,Amount\n2024-03-05,Rent,-900\n05/03/2024,Rent,-900\n", &types.ImportMapping{Date: "Date", Amount: "Amount", Description: "Memo"})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected a bad date on line 3, got %v", err)
+	}
+	if _, err := importer.Parse(types.ImportCSV, split, nil); !errors.Is(err, importer.ErrNoMapping) {
+		t.Errorf("Expected ErrNoMapping, got %v", err)
+	}
+}
+
+// TestParseOFX verifies both the SGML of OFX 1, without closing tags, and the
+// XML of OFX 2 are read
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240305120000.000[-5:EST]
+<TRNAMT>-12.34
+<FITID>1
+<NAME>Corner Shop &amp; Deli
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240306
+<TRNAMT>250.00
+<FITID>2
+<MEMO>Interest
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+	if format := importer.Detect(sgml); format != types.ImportOFX {
+		t.Errorf("Expected ofx to be detected, got %s", format)
+	}
+	transactions, err := importer.Parse("", sgml, nil)
+	if err != nil {
+		t.Fatalf("Parse SGML: %v", err)
+	}
+	expect(t, transactions, "2024-03-05|-12.34|Corner Shop & Deli", "2024-03-06|250.00|Interest")
+	if transactions[0].Line != 5 {
+		t.Errorf("Expected the first transaction on line 5, got %d", transactions[0].Line)
+	}
+
+	xml := `<?xml version="1.0"?><?OFX OFXHEADER="200"?>
+<OFX><BANKTRANLIST><STMTTRN><DTPOSTED>20240307</DTPOSTED><TRNAMT>-5.00</TRNAMT><NAME>Bus</NAME></STMTTRN></BANKTRANLIST></OFX>`
+	transactions, err = importer.Parse(types.ImportQFX, xml, nil)
+	if err != nil {
+		t.Fatalf("Parse XML: %v", err)
+	}
+	expect(t, transactions, "2024-03-07|-5.00|Bus")
+
+	_, err = importer.Parse(types.ImportOFX, "<OFX><STMTTRN><TRNAMT>-1.00</STMTTRN></OFX>", nil)
+	if err == nil || !strings.Contains(err.Error(), "DTPOSTED") {
+		t.Errorf("Expected a missing date error, got %v", err)
+	}
+}
+
+// TestParseQIF verifies bank sections are read with Quicken's dates, and
+// account lists and investment sections are skipped
+func TestParseQIF(t *testing.T) {
+	statement := "!Account\nNChecking\nTBank\n^\n" +
+		"!Type:Bank\r\n" +
+		"D3/ 5'24\r\nT-1,234.50\r\nPLandlord\r\nMMarch rent\r\n^\r\n" +
+		"D03/06/2024\r\nU80.00\r\nMCashback\r\n^\r\n" +
+		"!Type:Invst\nD3/7'24\nNBuy\nT-100.00\n^\n"
+	if format := importer.Detect(statement); format != types.ImportQIF {
+		t.Errorf("Expected qif to be detected, got %s", format)
+	}
+	transactions, err := importer.Parse("", statement, nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	expect(t, transactions, "2024-03-05|-1234.50|Landlord", "2024-03-06|80.00|Cashback")
+	if transactions[1].Line != 11 {
+		t.Errorf("Expected the second transaction on line 11, got %d", transactions[1].Line)
+	}
+
+	_, err = importer.Parse(types.ImportQIF, "!Type:CCard\nD31/12/2024\nT-1\n^\n", nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected a bad date on line 2, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// parseOFX reads the STMTTRN transactions of an OFX or QFX statement, either
+// the SGML of OFX 1 (where closing tags are optional) or the XML of OFX 2. The
+// description is the transaction's NAME, or its MEMO when it has none.
+func parseOFX(content string) ([]Transaction, error) {
+	var transactions []Transaction
+	var current *Transaction
+	var memo string
+	var err error
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		if current.Date == "" {
+			return fmt.Errorf("line %d: transaction has no DTPOSTED", current.Line)
+		}
+		if current.Description == "" {
+			current.Description = memo
+		}
+		transactions = append(transactions, *current)
+		current = nil
+		return nil
+	}
+
+	line := 1
+	for _, token := range strings.Split(content, "<") {
+		tag, value, found := strings.Cut(token, ">")
+		if found {
+			tag = strings.ToUpper(strings.TrimSpace(tag))
+			value = html.UnescapeString(strings.TrimSpace(value))
+
+			switch tag {
+			case "STMTTRN":
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				current, memo = &Transaction{Line: line}, ""
+			case "/STMTTRN", "/BANKTRANLIST":
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+
+			if current != nil {
+				switch tag {
+				case "DTPOSTED":
+					// 20240305, 20240305120000 or 20240305120000.000[-5:EST]
+					if len(value) < 8 {
+						return nil, fmt.Errorf("line %d: invalid DTPOSTED %q", line, value)
+					}
+					if current.Date, err = parseDay("20060102", value[:8]); err != nil {
+						return nil, fmt.Errorf("line %d: %w", line, err)
+					}
+				case "TRNAMT":
+					if current.Amount, err = parseAmount(value, strings.Contains(value, ",") && !strings.Contains(value, ".")); err != nil {
+						return nil, fmt.Errorf("line %d: %w", line, err)
+					}
+				case "NAME":
+					current.Description = value
+				case "MEMO":
+					memo = value
+				}
+			}
+		}
+		line += strings.Count(token, "\n")
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
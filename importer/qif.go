@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// qifLayouts are the dates Quicken writes, month first, once spaces are
+// dropped and the apostrophe of a two-digit year made a slash
+var qifLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02"}
+
+// parseQIF reads the transactions of the bank, cash and card sections of a QIF
+// file. Investment and account list sections are skipped, as are splits. The
+// description is the payee, or the memo when there is none.
+func parseQIF(content string) ([]Transaction, error) {
+	var transactions []Transaction
+	var current *Transaction
+	var memo string
+	skipping := false
+
+	for i, raw := range strings.Split(content, "\n") {
+		line := i + 1
+		text := strings.TrimRight(raw, "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			header := strings.ToUpper(strings.TrimSpace(text))
+			switch {
+			case strings.HasPrefix(header, "!TYPE:"):
+				kind := strings.TrimSpace(header[len("!TYPE:"):])
+				skipping = kind != "BANK" && kind != "CASH" && kind != "CCARD" && kind != "OTH A" && kind != "OTH L"
+			case strings.HasPrefix(header, "!ACCOUNT"):
+				skipping = true
+			}
+			continue
+		}
+		if skipping {
+			continue
+		}
+
+		code, value := text[0], strings.TrimSpace(text[1:])
+		if code == '^' {
+			if current != nil {
+				if current.Date == "" {
+					return nil, fmt.Errorf("line %d: transaction has no date", current.Line)
+				}
+				if current.Description == "" {
+					current.Description = memo
+				}
+				transactions = append(transactions, *current)
+			}
+			current = nil
+			continue
+		}
+		if current == nil {
+			current, memo = &Transaction{Line: line}, ""
+		}
+
+		var err error
+		switch code {
+		case 'D':
+			if current.Date, err = parseQIFDate(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case 'T', 'U':
+			if current.Amount, err = parseAmount(value, false); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case 'P':
+			current.Description = value
+		case 'M':
+			memo = value
+		}
+	}
+
+	// A last record without its ^ is still taken
+	if current != nil && current.Date != "" {
+		if current.Description == "" {
+			current.Description = memo
+		}
+		transactions = append(transactions, *current)
+	}
+	return transactions, nil
+}
+
+// parseQIFDate reads dates like 3/5/2024, 03/05/24 and 3/ 5'24
+func parseQIFDate(value string) (string, error) {
+	normalized := strings.ReplaceAll(strings.ReplaceAll(value, " ", ""), "'", "/")
+	for _, layout := range qifLayouts {
+		if day, err := parseDay(layout, normalized); err == nil {
+			return day, nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,536 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	types "github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== SHEET HISTORY ==========
+
+// The sheet predates the database: its ranges hold every transaction the app
+// wrote before the move to postgres, in the row layouts of the googleSS
+// package. ImportSheet reads them back and records the rows the database
+// doesn't already have, so running it again only picks up what is new.
+
+// Kinds of sheet range, named after the config rows that point at them
+const (
+	SheetExpenses    = "expenses"
+	SheetIncome      = "income"
+	SheetInvestments = "investments"
+	SheetDebt        = "debt"
+)
+
+// sheetKinds are the ranges ImportSheet reads, in order
+var sheetKinds = []string{SheetExpenses, SheetIncome, SheetInvestments, SheetDebt}
+
+// historyPage is how many recorded transactions are read at a time
+const historyPage = 1000
+
+// RangeReader reads a range of the spreadsheet, returning the resolved range
+// ("'2024 Fintrack'!A1:I812") and its unformatted values. Implemented by the
+// googleSS sinks.
+type RangeReader interface {
+	ReadRange(sheetRange string) (string, [][]interface{}, error)
+}
+
+// HistoryStore is what ImportSheet resolves names with, compares against and
+// records into. Implemented by the stores.
+type HistoryStore interface {
+	GetHousehold(ctx context.Context) (types.Household, error)
+	GetConfig(ctx context.Context) ([]types.Config, error)
+	GetCategories(ctx context.Context) ([]types.Category, error)
+	GetAccounts(ctx context.Context) ([]types.Account, error)
+	GetInvestmentAccounts(ctx context.Context) ([]types.InvestmentAccount, error)
+	GetDebtors(ctx context.Context) ([]types.Debtor, error)
+	GetExpenses(ctx context.Context, limit int, offset int) ([]types.Expense, int, error)
+	GetIncomes(ctx context.Context, limit int, offset int) ([]types.Income, int, error)
+	GetInvestments(ctx context.Context, limit int, offset int, accountId *int32) ([]types.Investment, int, error)
+	GetDebts(ctx context.Context, limit int, offset int, debtorId *int32) ([]types.Debt, int, error)
+	ImportHistory(ctx context.Context, history types.SheetHistory) (types.SheetHistory, error)
+}
+
+// SheetReport is what ImportSheet recorded, or would record on a dry run
+type SheetReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Ranges   []RangeReport `json:"ranges"`
+	Unmapped []UnmappedRow `json:"unmapped"`
+}
+
+// RangeReport counts the rows of one configured range
+type RangeReport struct {
+	Kind     string `json:"kind"`
+	Range    string `json:"range"`
+	Rows     int    `json:"rows"`     // leaving out a header and blank rows
+	Imported int    `json:"imported"` // recorded, or to be on a dry run
+	Existing int    `json:"existing"` // already in the database
+	Unmapped int    `json:"unmapped"`
+}
+
+// UnmappedRow is a row that couldn't be read back into a transaction
+type UnmappedRow struct {
+	Kind   string        `json:"kind"`
+	Row    int           `json:"row"` // sheet row number
+	Reason string        `json:"reason"`
+	Values []interface{} `json:"values"`
+}
+
+// ImportSheet reads the expense, income, investment and debt ranges of the
+// config table from sheet and records every row the database doesn't already
+// have; on a dry run it only reports. A row is already there when a recorded
+// transaction of its kind has the same day, amount and category, account or
+// debtor; each recorded one matches a single row. Rows are recorded as
+// history, in one transaction: balances and capital are left as they are.
+func ImportSheet(ctx context.Context, store HistoryStore, sheet RangeReader, dryRun bool) (SheetReport, error) {
+	report := SheetReport{DryRun: dryRun, Ranges: []RangeReport{}, Unmapped: []UnmappedRow{}}
+
+	household, err := store.GetHousehold(ctx)
+	if err != nil {
+		return report, fmt.Errorf("error getting household: %w", err)
+	}
+	loc, err := types.LoadTimezone(household.Timezone)
+	if err != nil {
+		return report, err
+	}
+	configs, err := store.GetConfig(ctx)
+	if err != nil {
+		return report, fmt.Errorf("error getting config: %w", err)
+	}
+	n, err := loadNames(ctx, store)
+	if err != nil {
+		return report, err
+	}
+	recorded, err := recordedKeys(ctx, store)
+	if err != nil {
+		return report, err
+	}
+
+	var history types.SheetHistory
+	for _, kind := range sheetKinds {
+		var config *types.Config
+		for i := range configs {
+			if configs[i].Type == kind {
+				config = &configs[i]
+			}
+		}
+		if config == nil {
+			continue
+		}
+
+		sheetRange := fmt.Sprint(config.Sheet, config.A1Range)
+		resolved, values, err := sheet.ReadRange(sheetRange)
+		if err != nil {
+			return report, fmt.Errorf("error reading %s: %w", sheetRange, err)
+		}
+		counts := RangeReport{Kind: kind, Range: sheetRange}
+		first := firstRow(resolved)
+
+		for i, values := range values {
+			row := sheetRow(values)
+			if row.blank() {
+				continue
+			}
+
+			var key string
+			var add func()
+			switch kind {
+			case SheetExpenses:
+				var e types.Expense
+				if e, err = n.expense(row, loc); err == nil {
+					key = expenseKey(e)
+					add = func() { history.Expenses = append(history.Expenses, e) }
+				}
+			case SheetIncome:
+				var in types.Income
+				if in, err = n.income(row, loc); err == nil {
+					key = incomeKey(in)
+					add = func() { history.Incomes = append(history.Incomes, in) }
+				}
+			case SheetInvestments:
+				var inv types.Investment
+				if inv, err = n.investment(row, loc); err == nil {
+					key = investmentKey(inv)
+					add = func() { history.Investments = append(history.Investments, inv) }
+				}
+			case SheetDebt:
+				var d types.Debt
+				if d, err = n.debt(row, loc); err == nil {
+					key = debtKey(d)
+					add = func() { history.Debts = append(history.Debts, d) }
+				}
+			}
+
+			if err != nil {
+				// A header is a first row without a date
+				if _, dateErr := row.date(0, loc); i == 0 && dateErr != nil {
+					continue
+				}
+				counts.Rows++
+				counts.Unmapped++
+				report.Unmapped = append(report.Unmapped, UnmappedRow{Kind: kind, Row: first + i, Reason: err.Error(), Values: values})
+				continue
+			}
+			counts.Rows++
+			if recorded[key] > 0 {
+				recorded[key]--
+				counts.Existing++
+				continue
+			}
+			counts.Imported++
+			add()
+		}
+		report.Ranges = append(report.Ranges, counts)
+	}
+
+	empty := len(history.Expenses) == 0 && len(history.Incomes) == 0 && len(history.Investments) == 0 && len(history.Debts) == 0
+	if dryRun || empty {
+		return report, nil
+	}
+	if _, err := store.ImportHistory(ctx, history); err != nil {
+		return report, fmt.Errorf("error recording history: %w", err)
+	}
+	return report, nil
+}
+
+// The keys rows are matched with recorded transactions by: the day, the
+// amount and what the transaction is against
+func expenseKey(e types.Expense) string {
+	return fmt.Sprintf("expense|%.10s|%d|%d|%d", e.Date, e.Expense.MinorUnits(), e.CategoryId, e.AccountId)
+}
+
+func incomeKey(in types.Income) string {
+	return fmt.Sprintf("income|%.10s|%d|%d", in.Date, in.Amount.MinorUnits(), in.AccountId)
+}
+
+func investmentKey(inv types.Investment) string {
+	return fmt.Sprintf("investment|%.10s|%d|%d|%s", inv.Date, inv.Amount.MinorUnits(), inv.AccountId, inv.Type)
+}
+
+func debtKey(d types.Debt) string {
+	return fmt.Sprintf("debt|%.10s|%d|%d|%t", d.Date, d.Amount.MinorUnits(), d.DebtorId, d.Outbound)
+}
+
+// recordedKeys counts the transactions already recorded by their keys
+func recordedKeys(ctx context.Context, store HistoryStore) (map[string]int, error) {
+	keys := map[string]int{}
+
+	expenses, err := all(func(limit, offset int) ([]types.Expense, int, error) { return store.GetExpenses(ctx, limit, offset) })
+	if err != nil {
+		return nil, fmt.Errorf("error getting expenses: %w", err)
+	}
+	for _, e := range expenses {
+		keys[expenseKey(e)]++
+	}
+	incomes, err := all(func(limit, offset int) ([]types.Income, int, error) { return store.GetIncomes(ctx, limit, offset) })
+	if err != nil {
+		return nil, fmt.Errorf("error getting incomes: %w", err)
+	}
+	for _, in := range incomes {
+		keys[incomeKey(in)]++
+	}
+	investments, err := all(func(limit, offset int) ([]types.Investment, int, error) {
+		return store.GetInvestments(ctx, limit, offset, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting investments: %w", err)
+	}
+	for _, inv := range investments {
+		keys[investmentKey(inv)]++
+	}
+	debts, err := all(func(limit, offset int) ([]types.Debt, int, error) { return store.GetDebts(ctx, limit, offset, nil) })
+	if err != nil {
+		return nil, fmt.Errorf("error getting debts: %w", err)
+	}
+	for _, d := range debts {
+		keys[debtKey(d)]++
+	}
+	return keys, nil
+}
+
+// all reads every page of a paginated list
+func all[T any](list func(limit int, offset int) ([]T, int, error)) ([]T, error) {
+	var rows []T
+	for {
+		page, total, err := list(historyPage, len(rows))
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, page...)
+		if len(page) == 0 || len(rows) >= total {
+			return rows, nil
+		}
+	}
+}
+
+// firstRow is the row number a resolved range like "Sheet!A2:I40" starts on
+func firstRow(resolved string) int {
+	ref := resolved[strings.LastIndex(resolved, "!")+1:]
+	if i := strings.Index(ref, ":"); i >= 0 {
+		ref = ref[:i]
+	}
+	row, err := strconv.Atoi(strings.TrimLeft(ref, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz$"))
+	if err != nil || row < 1 {
+		return 1
+	}
+	return row
+}
+
+// ========== ROWS ==========
+
+// sheetRow is the unformatted values of a sheet row; trailing empty cells are
+// left out by the Sheets API
+type sheetRow []interface{}
+
+func (r sheetRow) blank() bool {
+	for _, v := range r {
+		if strings.TrimSpace(fmt.Sprint(v)) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r sheetRow) text(i int) string {
+	if i >= len(r) || r[i] == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(r[i]))
+}
+
+// id is a cell holding an id, 0 when it doesn't
+func (r sheetRow) id(i int) int32 {
+	if i < len(r) {
+		if v, ok := r[i].(float64); ok {
+			return int32(v)
+		}
+	}
+	id, _ := strconv.ParseInt(r.text(i), 10, 32)
+	return int32(id)
+}
+
+// amount is a cell holding a positive amount
+func (r sheetRow) amount(i int, name string) (types.Money, error) {
+	var amount types.Money
+	if i < len(r) {
+		if v, ok := r[i].(float64); ok {
+			amount = types.MoneyFromFloat(v)
+		} else {
+			var err error
+			if amount, err = parseAmount(r.text(i), false); err != nil {
+				return types.Money{}, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if amount.Sign() <= 0 {
+		return types.Money{}, fmt.Errorf("%s must be greater than 0, got %q", name, r.text(i))
+	}
+	return amount, nil
+}
+
+// sheetEpoch is day 0 of the serial numbers Sheets stores dates as
+var sheetEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// sheetDateLayouts are the ways dates were written to the sheet over the years
+var sheetDateLayouts = []string{"1/2/2006 15:04:05", "1/2/2006", "2006-01-02 15:04:05Z07", "2006-01-02 15:04:05.999999Z07"}
+
+// date reads a cell as a transaction date in its stored form: a date serial
+// number is a wall-clock time of the household, as is a written date without
+// an offset
+func (r sheetRow) date(i int, loc *time.Location) (string, error) {
+	if i < len(r) {
+		if serial, ok := r[i].(float64); ok {
+			t := sheetEpoch.Add(time.Duration(math.Round(serial*86400)) * time.Second)
+			return types.FormatDate(t), nil
+		}
+	}
+	value := r.text(i)
+	if t, err := types.ParseDateIn(value, loc); err == nil {
+		return types.FormatDate(t), nil
+	}
+	for _, layout := range sheetDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return types.FormatDate(t.In(loc)), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", value)
+}
+
+// ========== NAMES ==========
+
+// names resolves the ids and names written in the sheet to the rows they refer
+// to: by id when it is known, else by name, ignoring case
+type names struct {
+	categories         index[types.Category]
+	accounts           index[types.Account]
+	investmentAccounts index[types.InvestmentAccount]
+	debtors            index[types.Debtor]
+}
+
+type index[T any] struct {
+	byId   map[int32]T
+	byName map[string]T
+}
+
+func newIndex[T any](rows []T, id func(T) int32, name func(T) string) index[T] {
+	x := index[T]{byId: map[int32]T{}, byName: map[string]T{}}
+	for _, row := range rows {
+		x.byId[id(row)] = row
+		x.byName[strings.ToLower(strings.TrimSpace(name(row)))] = row
+	}
+	return x
+}
+
+func (x index[T]) find(id int32, name string) (T, bool) {
+	if row, ok := x.byId[id]; ok && id != 0 {
+		return row, true
+	}
+	row, ok := x.byName[strings.ToLower(strings.TrimSpace(name))]
+	return row, ok && name != ""
+}
+
+func loadNames(ctx context.Context, store HistoryStore) (*names, error) {
+	categories, err := store.GetCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting categories: %w", err)
+	}
+	accounts, err := store.GetAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting accounts: %w", err)
+	}
+	investmentAccounts, err := store.GetInvestmentAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting investment accounts: %w", err)
+	}
+	debtors, err := store.GetDebtors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting debtors: %w", err)
+	}
+	return &names{
+		categories: newIndex(categories, func(c types.Category) int32 { return c.Id }, func(c types.Category) string { return c.Name }),
+		accounts:   newIndex(accounts, func(a types.Account) int32 { return a.Id }, func(a types.Account) string { return a.Name }),
+		investmentAccounts: newIndex(investmentAccounts,
+			func(a types.InvestmentAccount) int32 { return a.Id }, func(a types.InvestmentAccount) string { return a.Name }),
+		debtors: newIndex(debtors, func(d types.Debtor) int32 { return d.Id }, func(d types.Debtor) string { return d.Name }),
+	}, nil
+}
+
+// expense reads a row of date, category, expense, description, method,
+// original amount, category id, account id and account type. Rows written
+// before the id columns existed are resolved by category name, and by method
+// as an account name.
+func (n *names) expense(row sheetRow, loc *time.Location) (types.Expense, error) {
+	date, err := row.date(0, loc)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	amount, err := row.amount(2, "expense")
+	if err != nil {
+		return types.Expense{}, err
+	}
+	category, ok := n.categories.find(row.id(6), row.text(1))
+	if !ok {
+		return types.Expense{}, fmt.Errorf("unknown category %q", row.text(1))
+	}
+	e := types.Expense{
+		Date: date, Category: category.Name, CategoryId: category.Id, Expense: amount, OriginalAmount: amount,
+		Description: row.text(3), Method: row.text(4), AccountType: row.text(8),
+	}
+	if original, err := row.amount(5, "original amount"); err == nil {
+		e.OriginalAmount = original
+	}
+
+	accountId := row.id(7)
+	if e.AccountType != "" && types.ExpenseAccountKind(e.AccountType) == types.RefInvestmentAccount {
+		if a, ok := n.investmentAccounts.find(accountId, ""); ok {
+			e.AccountId = a.Id
+		}
+	} else if a, ok := n.accounts.find(accountId, e.Method); ok {
+		e.AccountId = a.Id
+		if e.AccountType == "" {
+			e.AccountType = a.Type
+		}
+	} else if a, ok := n.investmentAccounts.find(0, e.Method); ok && e.AccountType == "" {
+		e.AccountId, e.AccountType = a.Id, a.Type
+	}
+	if e.AccountId == 0 {
+		return types.Expense{}, fmt.Errorf("unknown account %q (method %q)", row.text(7), e.Method)
+	}
+	return e, nil
+}
+
+// income reads a row of date, account id, account name, description and amount
+func (n *names) income(row sheetRow, loc *time.Location) (types.Income, error) {
+	date, err := row.date(0, loc)
+	if err != nil {
+		return types.Income{}, err
+	}
+	amount, err := row.amount(4, "amount")
+	if err != nil {
+		return types.Income{}, err
+	}
+	account, ok := n.accounts.find(row.id(1), row.text(2))
+	if !ok {
+		return types.Income{}, fmt.Errorf("unknown account %q", row.text(2))
+	}
+	return types.Income{
+		Date: date, Amount: amount, Description: row.text(3), AccountId: account.Id, AccountName: account.Name,
+	}, nil
+}
+
+// investment reads a row of date, account id, account name, description,
+// amount and type
+func (n *names) investment(row sheetRow, loc *time.Location) (types.Investment, error) {
+	date, err := row.date(0, loc)
+	if err != nil {
+		return types.Investment{}, err
+	}
+	amount, err := row.amount(4, "amount")
+	if err != nil {
+		return types.Investment{}, err
+	}
+	account, ok := n.investmentAccounts.find(row.id(1), row.text(2))
+	if !ok {
+		return types.Investment{}, fmt.Errorf("unknown investment account %q", row.text(2))
+	}
+	kind := strings.ToLower(row.text(5))
+	if kind != "deposit" && kind != "withdrawal" {
+		return types.Investment{}, fmt.Errorf("type must be deposit or withdrawal, got %q", row.text(5))
+	}
+	return types.Investment{
+		Date: date, Amount: amount, Description: row.text(3), AccountId: account.Id, AccountName: account.Name, Type: kind,
+	}, nil
+}
+
+// debt reads a row of date, debtor id, debtor name, description, amount,
+// Borrowed or Lent, and whether it was lent
+func (n *names) debt(row sheetRow, loc *time.Location) (types.Debt, error) {
+	date, err := row.date(0, loc)
+	if err != nil {
+		return types.Debt{}, err
+	}
+	amount, err := row.amount(4, "amount")
+	if err != nil {
+		return types.Debt{}, err
+	}
+	debtor, ok := n.debtors.find(row.id(1), row.text(2))
+	if !ok {
+		return types.Debt{}, fmt.Errorf("unknown debtor %q", row.text(2))
+	}
+	outbound := strings.EqualFold(row.text(5), "Lent")
+	if len(row) > 6 {
+		if v, ok := row[6].(bool); ok {
+			outbound = v
+		} else if v, err := strconv.ParseBool(row.text(6)); err == nil {
+			outbound = v
+		}
+	}
+	return types.Debt{
+		Date: date, Amount: amount, OriginalAmount: amount, Description: row.text(3),
+		DebtorId: debtor.Id, DebtorName: debtor.Name, Outbound: outbound,
+	}, nil
+}
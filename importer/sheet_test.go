@@ -0,0 +1,164 @@
+package importer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/memory"
+	"github.com/carlosdimatteo/fintrack-backend-go/importer"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// TestImportSheet verifies sheet rows are resolved by id or name, rows the
+// database has are skipped, unmapped ones reported, and a second run imports nothing
+func TestImportSheet(t *testing.T) {
+	ctx := types.WithScope(context.Background(), memory.Owner)
+	store := memory.New()
+	food, err := store.InsertCategory(ctx, types.Category{Name: "Food"})
+	if err != nil {
+		t.Fatalf("Insert category: %v", err)
+	}
+	bank, err := store.InsertAccountIntoDatabase(ctx, types.Account{Name: "Bank", Type: "Fiat", Currency: "USD"})
+	if err != nil {
+		t.Fatalf("Insert account: %v", err)
+	}
+	stocks, err := store.InsertInvestmentAccountIntoDatabase(ctx, types.InvestmentAccount{Name: "Stocks", Type: "Broker", Currency: "USD", Capital: types.MoneyFromFloat(800)})
+	if err != nil {
+		t.Fatalf("Insert investment account: %v", err)
+	}
+	john, err := store.InsertDebtorIntoDatabase(ctx, types.Debtor{Name: "John"})
+	if err != nil {
+		t.Fatalf("Insert debtor: %v", err)
+	}
+	if _, err := store.InsertConfigIntoDatabase(ctx, []types.Config{
+		{Type: "expenses", Sheet: "History", A1Range: "!A:I"},
+		{Type: "income", Sheet: "History", A1Range: "!K:O"},
+		{Type: "investments", Sheet: "History", A1Range: "!Q:V"},
+		{Type: "debt", Sheet: "History", A1Range: "!X:AD"},
+	}); err != nil {
+		t.Fatalf("Insert config: %v", err)
+	}
+
+	// Dates come back from Sheets as serial numbers: days since 1899-12-30
+	serial := time.Date(2023, time.January, 10, 12, 0, 0, 0, time.UTC).Sub(time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	sheet := googleSS.NewRecordingSink()
+	sheet.AppendRows("History!A:I", [][]interface{}{
+		{"Date", "Category", "Expense", "Description", "Method", "Original", "Category id", "Account id", "Account type"},
+		{"2024-03-05 12:00:00", "Food", 12.5, "Lunch", "Debit", 12.5, food.Id, bank.Id, "Fiat"},
+		{serial, "food", 30.0, "Groceries", "Bank"},
+		{},
+		{"2023-01-11", "Travel", 100.0, "Flight", "Bank"},
+	})
+	sheet.AppendRows("History!K:O", [][]interface{}{
+		{"2023-02-01 09:00:00", bank.Id, "Bank", "Salary", 2000.0},
+		{"2023-02-01", "", "bank", "Bonus", "1,000.00"},
+	})
+	sheet.AppendRows("History!Q:V", [][]interface{}{
+		{"2023-03-01", stocks.Id, "Stocks", "Monthly", 500.0, "deposit"},
+		{"2023-03-02", stocks.Id, "Stocks", "Monthly", 500.0, "buy"},
+	})
+	sheet.AppendRows("History!X:AD", [][]interface{}{
+		{"2023-04-01", john.Id, "John", "Dinner", 40.0, "Lent", true},
+	})
+
+	// Lunch was recorded in both since the move to postgres
+	if _, err := store.InsertExpense(ctx, types.Expense{
+		Date: "2024-03-05 12:00:00", Category: "Food", CategoryId: food.Id, Expense: types.MoneyFromFloat(12.5), AccountId: bank.Id, AccountType: "Fiat",
+	}); err != nil {
+		t.Fatalf("Insert expense: %v", err)
+	}
+
+	report, err := importer.ImportSheet(ctx, store, sheet, true)
+	if err != nil {
+		t.Fatalf("Dry run: %v", err)
+	}
+	if _, count, _ := store.GetExpenses(ctx, 10, 0); count != 1 {
+		t.Errorf("A dry run should record nothing, got %d expenses", count)
+	}
+	expected := []importer.RangeReport{
+		{Kind: "expenses", Range: "History!A:I", Rows: 3, Imported: 1, Existing: 1, Unmapped: 1},
+		{Kind: "income", Range: "History!K:O", Rows: 2, Imported: 2},
+		{Kind: "investments", Range: "History!Q:V", Rows: 2, Imported: 1, Unmapped: 1},
+		{Kind: "debt", Range: "History!X:AD", Rows: 1, Imported: 1},
+	}
+	if len(report.Ranges) != len(expected) {
+		t.Fatalf("Expected %d ranges, got %+v", len(expected), report.Ranges)
+	}
+	for i, r := range report.Ranges {
+		if r != expected[i] {
+			t.Errorf("Range %d: expected %+v, got %+v", i, expected[i], r)
+		}
+	}
+	if len(report.Unmapped) != 2 || report.Unmapped[0].Row != 5 || report.Unmapped[0].Reason != `unknown category "Travel"` || report.Unmapped[1].Row != 2 {
+		t.Errorf("Unexpected unmapped rows: %+v", report.Unmapped)
+	}
+
+	if _, err := importer.ImportSheet(ctx, store, sheet, false); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	expenses, _, _ := store.GetExpenses(ctx, 10, 0)
+	if len(expenses) != 2 || expenses[0].Date != "2023-01-10 12:00:00" || expenses[0].AccountId != bank.Id || expenses[0].CategoryId != food.Id {
+		t.Errorf("Groceries should be resolved by name and method: %+v", expenses)
+	}
+	incomes, _, _ := store.GetIncomes(ctx, 10, 0)
+	if len(incomes) != 2 || incomes[0].AccountName != "Bank" || incomes[0].Amount != types.MoneyFromFloat(1000) {
+		t.Errorf("Unexpected incomes: %+v", incomes)
+	}
+	debts, _, _ := store.GetDebts(ctx, 10, 0, nil)
+	if len(debts) != 1 || !debts[0].Outbound || debts[0].DebtorName != "John" {
+		t.Errorf("Unexpected debts: %+v", debts)
+	}
+	accounts, _ := store.GetInvestmentAccounts(ctx)
+	if accounts[0].Capital != types.MoneyFromFloat(800) {
+		t.Errorf("History should leave capital alone, got %s", accounts[0].Capital)
+	}
+
+	report, err = importer.ImportSheet(ctx, store, sheet, false)
+	if err != nil {
+		t.Fatalf("Second import: %v", err)
+	}
+	for _, r := range report.Ranges {
+		if r.Imported != 0 {
+			t.Errorf("A second run should import nothing, got %+v", r)
+		}
+	}
+}
+
+// TestImportSheetPrivateAccounts verifies the CLI's household-wide scope
+// resolves rows of an account private to one member
+func TestImportSheetPrivateAccounts(t *testing.T) {
+	ctx := types.WithScope(context.Background(), memory.Owner)
+	store := memory.New()
+	owner := memory.Owner.UserId
+	savings, err := store.InsertAccountIntoDatabase(ctx, types.Account{Name: "Savings", Type: "Fiat", Currency: "USD", OwnerId: &owner})
+	if err != nil {
+		t.Fatalf("Insert account: %v", err)
+	}
+	if _, err := store.InsertConfigIntoDatabase(ctx, []types.Config{{Type: "income", Sheet: "History", A1Range: "!K:O"}}); err != nil {
+		t.Fatalf("Insert config: %v", err)
+	}
+	sheet := googleSS.NewRecordingSink()
+	sheet.AppendRows("History!K:O", [][]interface{}{{"2023-02-01", savings.Id, "Savings", "Interest", 12.0}})
+
+	// Without a user, the private account is unknown
+	report, err := importer.ImportSheet(types.WithScope(context.Background(), types.Scope{HouseholdId: memory.Owner.HouseholdId}), store, sheet, true)
+	if err != nil {
+		t.Fatalf("Dry run: %v", err)
+	}
+	if r := report.Ranges[0]; r.Imported != 0 || r.Unmapped != 1 {
+		t.Errorf("Expected the row unmapped without a user, got %+v", r)
+	}
+
+	report, err = importer.ImportSheet(types.WithScope(context.Background(), types.HouseholdScope(memory.Owner.HouseholdId)), store, sheet, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if r := report.Ranges[0]; r.Imported != 1 || r.Unmapped != 0 {
+		t.Errorf("Expected the row imported household-wide, got %+v", r)
+	}
+	if _, count, _ := store.GetIncomes(ctx, 10, 0); count != 1 {
+		t.Errorf("Expected the income recorded, got %d", count)
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== AUTH ==========
+
+// TestAPIKeyStore verifies keys are stored hashed and stay revoked
+func TestAPIKeyStore(t *testing.T) {
+	ctx := context.Background()
+
+	key, stored, err := auth.IssueAPIKey(ctx, testStore, TestUserID, "store test")
+	AssertNoError(t, err, "Issue API key")
+	AssertEqual(t, auth.HashAPIKey(key), stored.Hash, "Stored hash")
+
+	found, err := testStore.GetAPIKeyByPrefix(ctx, stored.Prefix)
+	AssertNoError(t, err, "Get key by prefix")
+	AssertEqual(t, stored.Id, found.Id, "Key found by prefix")
+
+	revoked, err := testStore.RevokeAPIKey(ctx, stored.Id)
+	AssertNoError(t, err, "Revoke key")
+	if revoked.RevokedAt == nil {
+		t.Fatal("Expected revoked_at to be set")
+	}
+	again, err := testStore.RevokeAPIKey(ctx, stored.Id)
+	AssertNoError(t, err, "Revoke key twice")
+	AssertEqual(t, revoked.RevokedAt.Unix(), again.RevokedAt.Unix(), "First revocation time is kept")
+
+	_, err = testStore.GetAPIKey(ctx, -1)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing key, got %v", err)
+	}
+}
+
+// TestUnauthenticatedRequestsRejected verifies the API refuses calls without a credential
+func TestUnauthenticatedRequestsRejected(t *testing.T) {
+	router := NewTestRouter()
+
+	for _, path := range []string{"/api/accounts", "/api/expenses", "/api/budget"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		AssertEqual(t, http.StatusUnauthorized, rec.Code, "GET "+path+" without credentials")
+	}
+
+	req := httptest.NewRequest("GET", "/api/accounts", nil)
+	AuthorizeRequest(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	AssertEqual(t, http.StatusOK, rec.Code, "GET /api/accounts with the test key")
+}
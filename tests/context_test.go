@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+)
+
+// ========== CONTEXT ==========
+
+// TestCancelledContextAbortsQuery verifies a cancelled request does not reach the database
+func TestCancelledContextAbortsQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(testCtx)
+	cancel()
+
+	_, err := testStore.GetAccounts(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	_, err = testStore.CalculateNetWorthSnapshot(ctx, 2026, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from a multi-query call, got %v", err)
+	}
+}
+
+// TestQueryTimeout verifies the store's deadline applies when the caller sets none
+func TestQueryTimeout(t *testing.T) {
+	store := postgres.NewWithPool(testPool)
+	store.SetQueryTimeout(time.Nanosecond)
+
+	_, err := store.GetAccounts(testCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	store.SetQueryTimeout(0)
+	_, err = store.GetAccounts(testCtx)
+	AssertNoError(t, err, "Query without a store deadline")
+}
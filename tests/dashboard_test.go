@@ -5,7 +5,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
 	"github.com/carlosdimatteo/fintrack-backend-go/types"
 )
 
@@ -29,22 +28,22 @@ func TestGetMonthlyExpenseSum(t *testing.T) {
 			Date:           now.Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        amount,
+			Expense:        types.MoneyFromFloat(amount),
 			Description:    "Monthly expense",
 			Method:         "Debit",
-			OriginalAmount: amount,
+			OriginalAmount: types.MoneyFromFloat(amount),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense")
 		expectedSum += amount
 	}
 
 	// Get monthly sum
-	sum, err := postgres.GetMonthlyExpenseSum(now.Year(), int(now.Month()))
+	sum, err := testStore.GetExpenseSum(testCtx, types.MonthPeriod(now.Year(), int(now.Month())))
 	AssertNoError(t, err, "Get monthly expense sum")
-	AssertFloatEqual(t, expectedSum, sum, 0.01, "Monthly expense sum")
+	AssertFloatEqual(t, expectedSum, sum.Float64(), 0.01, "Monthly expense sum")
 }
 
 // TestGetMonthlyInvestmentSum verifies investment deposit sum
@@ -64,13 +63,13 @@ func TestGetMonthlyInvestmentSum(t *testing.T) {
 		investment := types.Investment{
 			Date:            now.Format(time.DateTime),
 			Description:     "Monthly deposit",
-			Amount:          amount,
+			Amount:          types.MoneyFromFloat(amount),
 			AccountId:       testInvAccount.ID,
 			AccountName:     testInvAccount.Name,
 			Type:            "deposit",
 			SourceAccountId: &testFiatAccount.ID,
 		}
-		_, err := postgres.InsertInvestment(investment)
+		_, err := testStore.InsertInvestment(testCtx, investment)
 		AssertNoError(t, err, "Insert investment")
 		expectedSum += amount
 	}
@@ -79,19 +78,19 @@ func TestGetMonthlyInvestmentSum(t *testing.T) {
 	withdrawal := types.Investment{
 		Date:            now.Format(time.DateTime),
 		Description:     "Withdrawal",
-		Amount:          100.00,
+		Amount:          types.MoneyFromFloat(100.00),
 		AccountId:       testInvAccount.ID,
 		AccountName:     testInvAccount.Name,
 		Type:            "withdrawal",
 		SourceAccountId: &testFiatAccount.ID,
 	}
-	_, err := postgres.InsertInvestment(withdrawal)
+	_, err := testStore.InsertInvestment(testCtx, withdrawal)
 	AssertNoError(t, err, "Insert withdrawal")
 
 	// Get monthly investment sum (deposits only)
-	sum, err := postgres.GetMonthlyInvestmentSum(now.Year(), int(now.Month()))
+	sum, err := testStore.GetInvestmentSum(testCtx, types.MonthPeriod(now.Year(), int(now.Month())))
 	AssertNoError(t, err, "Get monthly investment sum")
-	AssertFloatEqual(t, expectedSum, sum, 0.01, "Monthly investment sum (deposits only)")
+	AssertFloatEqual(t, expectedSum, sum.Float64(), 0.01, "Monthly investment sum (deposits only)")
 }
 
 // ========== YTD TOTALS ==========
@@ -112,12 +111,12 @@ func TestGetYTDTotals(t *testing.T) {
 	for _, amount := range incomeAmounts {
 		income := types.Income{
 			Date:        now.Format(time.DateTime),
-			Amount:      amount,
+			Amount:      types.MoneyFromFloat(amount),
 			Description: "YTD income",
 			AccountId:   testAccount.ID,
 			AccountName: testAccount.Name,
 		}
-		_, err := postgres.InsertIncome(income)
+		_, err := testStore.InsertIncome(testCtx, income)
 		AssertNoError(t, err, "Insert income")
 		totalIncome += amount
 	}
@@ -130,14 +129,14 @@ func TestGetYTDTotals(t *testing.T) {
 			Date:           now.Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        amount,
+			Expense:        types.MoneyFromFloat(amount),
 			Description:    "YTD expense",
 			Method:         "Debit",
-			OriginalAmount: amount,
+			OriginalAmount: types.MoneyFromFloat(amount),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense")
 		totalExpenses += amount
 	}
@@ -149,23 +148,24 @@ func TestGetYTDTotals(t *testing.T) {
 		investment := types.Investment{
 			Date:            now.Format(time.DateTime),
 			Description:     "YTD investment",
-			Amount:          amount,
+			Amount:          types.MoneyFromFloat(amount),
 			AccountId:       testInvAccount.ID,
 			AccountName:     testInvAccount.Name,
 			Type:            "deposit",
 			SourceAccountId: &testAccount.ID,
 		}
-		_, err := postgres.InsertInvestment(investment)
+		_, err := testStore.InsertInvestment(testCtx, investment)
 		AssertNoError(t, err, "Insert investment")
 		totalInvestments += amount
 	}
 
 	// Get YTD totals
-	ytdIncome, ytdExpenses, ytdInvestments := postgres.GetYTDTotals(now.Year())
+	ytdIncome, ytdExpenses, ytdInvestments, err := testStore.GetYTDTotals(testCtx, now.Year())
+	AssertNoError(t, err, "Get YTD totals")
 
-	AssertFloatEqual(t, totalIncome, ytdIncome, 0.01, "YTD income")
-	AssertFloatEqual(t, totalExpenses, ytdExpenses, 0.01, "YTD expenses")
-	AssertFloatEqual(t, totalInvestments, ytdInvestments, 0.01, "YTD investments")
+	AssertFloatEqual(t, totalIncome, ytdIncome.Float64(), 0.01, "YTD income")
+	AssertFloatEqual(t, totalExpenses, ytdExpenses.Float64(), 0.01, "YTD expenses")
+	AssertFloatEqual(t, totalInvestments, ytdInvestments.Float64(), 0.01, "YTD investments")
 }
 
 // ========== INVESTMENT ACCOUNT SUMMARY ==========
@@ -176,7 +176,7 @@ func TestInvestmentAccountSummary(t *testing.T) {
 	SeedTestData(t)
 
 	// Get summary
-	summary, err := postgres.GetInvestmentAccountSummary()
+	summary, err := testStore.GetInvestmentAccountSummary(testCtx)
 	AssertNoError(t, err, "Get investment account summary")
 
 	if len(summary) < 2 {
@@ -200,7 +200,7 @@ func TestInvestmentAccountSummary(t *testing.T) {
 
 	// PnL = real_balance - capital
 	expectedPnL := testInvAccount.Balance - testInvAccount.Capital
-	AssertFloatEqual(t, expectedPnL, cryptoSummary.PnL, 0.01, "PnL calculation")
+	AssertFloatEqual(t, expectedPnL, cryptoSummary.PnL.Float64(), 0.01, "PnL calculation")
 
 	// PnL percent = (PnL / capital) * 100
 	if testInvAccount.Capital > 0 {
@@ -219,26 +219,30 @@ func TestCalculateNetWorthSnapshot(t *testing.T) {
 	now := time.Now()
 
 	// Calculate snapshot
-	snapshot, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate net worth snapshot")
 
 	// Verify year/month
 	AssertEqual(t, now.Year(), snapshot.Year, "Snapshot year")
 	AssertEqual(t, int(now.Month()), snapshot.Month, "Snapshot month")
 
-	// Total fiat should be sum of all test account balances
+	// Total fiat should be sum of all test account balances, in dollars
 	expectedFiat := 0.0
 	for _, acc := range TestAccounts {
-		expectedFiat += acc.Balance
+		if acc.Currency == "COP" {
+			expectedFiat += acc.Balance / TestRateUSDCOP
+		} else {
+			expectedFiat += acc.Balance
+		}
 	}
 
 	// Note: There might be other accounts in DB, so we check >= expected
-	if snapshot.TotalFiatBalance < expectedFiat {
-		t.Errorf("Total fiat balance %.2f should be >= %.2f", snapshot.TotalFiatBalance, expectedFiat)
+	if snapshot.TotalFiatBalance.Float64() < expectedFiat {
+		t.Errorf("Total fiat balance %.2f should be >= %.2f", snapshot.TotalFiatBalance.Float64(), expectedFiat)
 	}
 
 	// Total real net worth = fiat + investments
-	if snapshot.TotalRealNetWorth <= 0 {
+	if snapshot.TotalRealNetWorth.Sign() <= 0 {
 		t.Error("Total real net worth should be positive")
 	}
 
@@ -259,7 +263,7 @@ func TestNetWorthSnapshotExpectedVsReal(t *testing.T) {
 	now := time.Now()
 
 	// Initially, expected = real (no transactions)
-	snapshot1, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot1, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate initial snapshot")
 
 	// Add expense (creates discrepancy: expected decreases, real stays same)
@@ -267,32 +271,32 @@ func TestNetWorthSnapshotExpectedVsReal(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        500.00,
+		Expense:        types.MoneyFromFloat(500.00),
 		Description:    "Create discrepancy",
 		Method:         "Debit",
-		OriginalAmount: 500.00,
+		OriginalAmount: types.MoneyFromFloat(500.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
-	_, err = postgres.InsertExpense(expense)
+	_, err = testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Calculate again
-	snapshot2, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot2, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate snapshot after expense")
 
 	// Expected fiat should be less than before
-	if snapshot2.ExpectedFiatBalance >= snapshot1.ExpectedFiatBalance {
+	if snapshot2.ExpectedFiatBalance.Cmp(snapshot1.ExpectedFiatBalance) >= 0 {
 		t.Error("Expected fiat should decrease after expense")
 	}
 
 	// Real fiat should be unchanged
-	AssertFloatEqual(t, snapshot1.TotalFiatBalance, snapshot2.TotalFiatBalance, 0.01,
+	AssertFloatEqual(t, snapshot1.TotalFiatBalance.Float64(), snapshot2.TotalFiatBalance.Float64(), 0.01,
 		"Real fiat unchanged by expense")
 
 	// Fiat discrepancy should be positive (real > expected)
-	if snapshot2.FiatDiscrepancy <= 0 {
-		t.Errorf("Fiat discrepancy should be positive, got %.2f", snapshot2.FiatDiscrepancy)
+	if snapshot2.FiatDiscrepancy.Sign() <= 0 {
+		t.Errorf("Fiat discrepancy should be positive, got %s", snapshot2.FiatDiscrepancy)
 	}
 }
 
@@ -304,10 +308,10 @@ func TestUpsertNetWorthSnapshot(t *testing.T) {
 	now := time.Now()
 
 	// Calculate and save snapshot
-	snapshot1, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot1, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate snapshot")
 
-	saved1, err := postgres.UpsertNetWorthSnapshot(snapshot1)
+	saved1, err := testStore.UpsertNetWorthSnapshot(testCtx, snapshot1)
 	AssertNoError(t, err, "Upsert snapshot 1")
 
 	if saved1.Id == 0 {
@@ -315,10 +319,10 @@ func TestUpsertNetWorthSnapshot(t *testing.T) {
 	}
 
 	// Upsert again for same year/month - should update, not create new
-	snapshot2, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot2, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate snapshot 2")
 
-	saved2, err := postgres.UpsertNetWorthSnapshot(snapshot2)
+	saved2, err := testStore.UpsertNetWorthSnapshot(testCtx, snapshot2)
 	AssertNoError(t, err, "Upsert snapshot 2")
 
 	// Should have same ID (updated, not inserted)
@@ -342,14 +346,14 @@ func TestGetNetWorthHistory(t *testing.T) {
 	now := time.Now()
 
 	// Create and save a snapshot
-	snapshot, err := postgres.CalculateNetWorthSnapshot(now.Year(), int(now.Month()))
+	snapshot, err := testStore.CalculateNetWorthSnapshot(testCtx, now.Year(), int(now.Month()))
 	AssertNoError(t, err, "Calculate snapshot")
 
-	_, err = postgres.UpsertNetWorthSnapshot(snapshot)
+	_, err = testStore.UpsertNetWorthSnapshot(testCtx, snapshot)
 	AssertNoError(t, err, "Upsert snapshot")
 
 	// Get history
-	history, err := postgres.GetNetWorthHistory()
+	history, err := testStore.GetNetWorthHistory(testCtx)
 	AssertNoError(t, err, "Get net worth history")
 
 	if len(history) == 0 {
@@ -362,7 +366,7 @@ func TestGetNetWorthHistory(t *testing.T) {
 		if h.Year == now.Year() && h.Month == int(now.Month()) {
 			found = true
 			// Verify all fields are populated
-			if h.TotalRealNetWorth <= 0 {
+			if h.TotalRealNetWorth.Sign() <= 0 {
 				t.Error("Snapshot in history should have positive net worth")
 			}
 		}
@@ -382,7 +386,7 @@ func TestYearlyGoals(t *testing.T) {
 	year := time.Now().Year()
 
 	// Get goals (might be empty)
-	goals1, err := postgres.GetYearlyGoals(year)
+	goals1, err := testStore.GetYearlyGoals(testCtx, year)
 	AssertNoError(t, err, "Get initial goals")
 	// Empty goals should have the year set
 	AssertEqual(t, year, goals1.Year, "Goals year")
@@ -390,30 +394,30 @@ func TestYearlyGoals(t *testing.T) {
 	// Upsert new goals
 	newGoals := types.YearlyGoals{
 		Year:            year,
-		SavingsGoal:     15000.00,
-		InvestmentGoal:  8000.00,
-		IdealInvestment: 12000.00,
+		SavingsGoal:     types.MoneyFromFloat(15000.00),
+		InvestmentGoal:  types.MoneyFromFloat(8000.00),
+		IdealInvestment: types.MoneyFromFloat(12000.00),
 	}
 
-	saved, err := postgres.UpsertYearlyGoals(newGoals)
+	saved, err := testStore.UpsertYearlyGoals(testCtx, newGoals)
 	AssertNoError(t, err, "Upsert goals")
 
-	AssertFloatEqual(t, 15000.00, saved.SavingsGoal, 0.01, "Savings goal")
-	AssertFloatEqual(t, 8000.00, saved.InvestmentGoal, 0.01, "Investment goal")
-	AssertFloatEqual(t, 12000.00, saved.IdealInvestment, 0.01, "Ideal investment")
+	AssertFloatEqual(t, 15000.00, saved.SavingsGoal.Float64(), 0.01, "Savings goal")
+	AssertFloatEqual(t, 8000.00, saved.InvestmentGoal.Float64(), 0.01, "Investment goal")
+	AssertFloatEqual(t, 12000.00, saved.IdealInvestment.Float64(), 0.01, "Ideal investment")
 
 	// Update goals
 	updatedGoals := types.YearlyGoals{
 		Year:            year,
-		SavingsGoal:     20000.00,
-		InvestmentGoal:  10000.00,
-		IdealInvestment: 15000.00,
+		SavingsGoal:     types.MoneyFromFloat(20000.00),
+		InvestmentGoal:  types.MoneyFromFloat(10000.00),
+		IdealInvestment: types.MoneyFromFloat(15000.00),
 	}
 
-	saved2, err := postgres.UpsertYearlyGoals(updatedGoals)
+	saved2, err := testStore.UpsertYearlyGoals(testCtx, updatedGoals)
 	AssertNoError(t, err, "Update goals")
 
-	AssertFloatEqual(t, 20000.00, saved2.SavingsGoal, 0.01, "Updated savings goal")
+	AssertFloatEqual(t, 20000.00, saved2.SavingsGoal.Float64(), 0.01, "Updated savings goal")
 
 	// Verify only 1 record for this year
 	var count int
@@ -432,7 +436,7 @@ func TestAccountExpectedBalanceView(t *testing.T) {
 	SeedTestData(t)
 
 	// Get expected balances
-	balances, err := postgres.GetAccountExpectedBalances()
+	balances, err := testStore.GetAccountExpectedBalances(testCtx)
 	AssertNoError(t, err, "Get expected balances")
 
 	if len(balances) == 0 {
@@ -455,11 +459,11 @@ func TestAccountExpectedBalanceView(t *testing.T) {
 	testAccount := GetTestAccount(TestAccountBankID)
 
 	// With no transactions, expected = starting = real
-	AssertFloatEqual(t, testAccount.StartingBalance, testBalance.ExpectedBalance, 0.01,
+	AssertFloatEqual(t, testAccount.StartingBalance, testBalance.ExpectedBalance.Float64(), 0.01,
 		"Expected equals starting with no transactions")
-	AssertFloatEqual(t, testAccount.Balance, testBalance.RealBalance, 0.01,
+	AssertFloatEqual(t, testAccount.Balance, testBalance.RealBalance.Float64(), 0.01,
 		"Real balance matches account balance")
-	AssertFloatEqual(t, 0, testBalance.Discrepancy, 0.01,
+	AssertFloatEqual(t, 0, testBalance.Discrepancy.Float64(), 0.01,
 		"No discrepancy with no transactions")
 }
 
@@ -480,12 +484,12 @@ func TestExpectedBalanceFullFormula(t *testing.T) {
 	// Add income: +500
 	income := types.Income{
 		Date:        now.Format(time.DateTime),
-		Amount:      500.00,
+		Amount:      types.MoneyFromFloat(500.00),
 		Description: "Test income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Add expense: -200
@@ -493,40 +497,40 @@ func TestExpectedBalanceFullFormula(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        200.00,
+		Expense:        types.MoneyFromFloat(200.00),
 		Description:    "Test expense",
 		Method:         "Debit",
-		OriginalAmount: 200.00,
+		OriginalAmount: types.MoneyFromFloat(200.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
-	_, err = postgres.InsertExpense(expense)
+	_, err = testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Add investment deposit (from this account): -300
 	investment := types.Investment{
 		Date:            now.Format(time.DateTime),
 		Description:     "Test deposit",
-		Amount:          300.00,
+		Amount:          types.MoneyFromFloat(300.00),
 		AccountId:       testInvAccount.ID,
 		AccountName:     testInvAccount.Name,
 		Type:            "deposit",
 		SourceAccountId: &testAccount.ID,
 	}
-	_, err = postgres.InsertInvestment(investment)
+	_, err = testStore.InsertInvestment(testCtx, investment)
 	AssertNoError(t, err, "Insert investment deposit")
 
 	// Add investment withdrawal (to this account): +100
 	withdrawal := types.Investment{
 		Date:            now.Format(time.DateTime),
 		Description:     "Test withdrawal",
-		Amount:          100.00,
+		Amount:          types.MoneyFromFloat(100.00),
 		AccountId:       testInvAccount.ID,
 		AccountName:     testInvAccount.Name,
 		Type:            "withdrawal",
 		SourceAccountId: &testAccount.ID,
 	}
-	_, err = postgres.InsertInvestment(withdrawal)
+	_, err = testStore.InsertInvestment(testCtx, withdrawal)
 	AssertNoError(t, err, "Insert investment withdrawal")
 
 	// Add transfer out: -150
@@ -534,11 +538,11 @@ func TestExpectedBalanceFullFormula(t *testing.T) {
 		Date:            now.Format(time.DateTime),
 		Description:     "Transfer out",
 		SourceAccountId: testAccount.ID,
-		SourceAmount:    150.00,
+		SourceAmount:    types.MoneyFromFloat(150.00),
 		DestAccountId:   otherAccount.ID,
-		DestAmount:      150.00,
+		DestAmount:      types.MoneyFromFloat(150.00),
 	}
-	_, err = postgres.InsertTransfer(transferOut)
+	_, err = testStore.InsertTransfer(testCtx, transferOut)
 	AssertNoError(t, err, "Insert transfer out")
 
 	// Add transfer in: +80
@@ -546,11 +550,11 @@ func TestExpectedBalanceFullFormula(t *testing.T) {
 		Date:            now.Format(time.DateTime),
 		Description:     "Transfer in",
 		SourceAccountId: otherAccount.ID,
-		SourceAmount:    80.00,
+		SourceAmount:    types.MoneyFromFloat(80.00),
 		DestAccountId:   testAccount.ID,
-		DestAmount:      80.00,
+		DestAmount:      types.MoneyFromFloat(80.00),
 	}
-	_, err = postgres.InsertTransfer(transferIn)
+	_, err = testStore.InsertTransfer(testCtx, transferIn)
 	AssertNoError(t, err, "Insert transfer in")
 
 	// Expected = starting + income - expense - inv_deposit + inv_withdrawal - transfer_out + transfer_in
@@ -5,7 +5,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
 	"github.com/carlosdimatteo/fintrack-backend-go/types"
 )
 
@@ -25,39 +24,39 @@ func TestExpenseWithDebtCreation(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Lent money to John",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
 	debt := types.Debt{
 		Description:    "Lent money to John",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
 
-	expenseResult, debtResult, err := postgres.InsertExpenseWithDebt(expense, debt)
+	expenseResult, debtResult, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertNoError(t, err, "Insert expense with debt")
 
 	// Verify expense created
 	if expenseResult.Id == 0 {
 		t.Error("Expense should have an ID")
 	}
-	AssertFloatEqual(t, 100.00, expenseResult.Expense, 0.01, "Expense amount")
+	AssertFloatEqual(t, 100.00, expenseResult.Expense.Float64(), 0.01, "Expense amount")
 
 	// Verify debt created
 	if debtResult.Id == 0 {
 		t.Error("Debt should have an ID")
 	}
-	AssertFloatEqual(t, 100.00, debtResult.Amount, 0.01, "Debt amount")
+	AssertFloatEqual(t, 100.00, debtResult.Amount.Float64(), 0.01, "Debt amount")
 	AssertEqual(t, true, debtResult.Outbound, "Debt should be outbound")
 
 	// Verify debt is linked to expense
@@ -83,26 +82,26 @@ func TestExpenseWithDebtAffectsExpectedBalance(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        150.00,
+		Expense:        types.MoneyFromFloat(150.00),
 		Description:    "Lent money",
 		Method:         "Debit",
-		OriginalAmount: 150.00,
+		OriginalAmount: types.MoneyFromFloat(150.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
 	debt := types.Debt{
 		Description:    "Lent money",
-		Amount:         150.00,
+		Amount:         types.MoneyFromFloat(150.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 150.00,
+		OriginalAmount: types.MoneyFromFloat(150.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
 
-	_, _, err := postgres.InsertExpenseWithDebt(expense, debt)
+	_, _, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertNoError(t, err, "Insert expense with debt")
 
 	// Check expected balance decreased by expense amount
@@ -126,30 +125,30 @@ func TestExpenseWithDebtPartialAmount(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Dinner split",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
 	debt := types.Debt{
 		Description:    "John's share of dinner",
-		Amount:         60.00, // Partial amount
+		Amount:         types.MoneyFromFloat(60.00), // Partial amount
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 60.00,
+		OriginalAmount: types.MoneyFromFloat(60.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
 
-	expenseResult, debtResult, err := postgres.InsertExpenseWithDebt(expense, debt)
+	expenseResult, debtResult, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertNoError(t, err, "Insert expense with partial debt")
 
-	AssertFloatEqual(t, 100.00, expenseResult.Expense, 0.01, "Expense should be full amount")
-	AssertFloatEqual(t, 60.00, debtResult.Amount, 0.01, "Debt should be partial amount")
+	AssertFloatEqual(t, 100.00, expenseResult.Expense.Float64(), 0.01, "Expense should be full amount")
+	AssertFloatEqual(t, 60.00, debtResult.Amount.Float64(), 0.01, "Debt should be partial amount")
 }
 
 // TestExpenseWithMultipleDebts verifies creating expense with multiple debts (split)
@@ -170,10 +169,10 @@ func TestExpenseWithMultipleDebts(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Group dinner split",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
@@ -181,36 +180,36 @@ func TestExpenseWithMultipleDebts(t *testing.T) {
 	debts := []types.Debt{
 		{
 			Description:    "John's share",
-			Amount:         30.00,
+			Amount:         types.MoneyFromFloat(30.00),
 			DebtorId:       debtor1.ID,
 			DebtorName:     debtor1.Name,
 			Date:           now.Format(time.DateTime),
-			OriginalAmount: 30.00,
+			OriginalAmount: types.MoneyFromFloat(30.00),
 			Currency:       "USD",
 			Outbound:       true,
 		},
 		{
 			Description:    "Jane's share",
-			Amount:         30.00,
+			Amount:         types.MoneyFromFloat(30.00),
 			DebtorId:       debtor2.ID,
 			DebtorName:     debtor2.Name,
 			Date:           now.Format(time.DateTime),
-			OriginalAmount: 30.00,
+			OriginalAmount: types.MoneyFromFloat(30.00),
 			Currency:       "USD",
 			Outbound:       true,
 		},
 	}
 
-	expenseResult, debtResults, err := postgres.InsertExpenseWithDebts(expense, debts)
+	expenseResult, debtResults, err := testStore.InsertExpenseWithDebts(testCtx, expense, debts)
 	AssertNoError(t, err, "Insert expense with multiple debts")
 
 	// Verify expense
-	AssertFloatEqual(t, 100.00, expenseResult.Expense, 0.01, "Expense amount")
+	AssertFloatEqual(t, 100.00, expenseResult.Expense.Float64(), 0.01, "Expense amount")
 
 	// Verify both debts created
 	AssertEqual(t, 2, len(debtResults), "Should create 2 debts")
-	AssertFloatEqual(t, 30.00, debtResults[0].Amount, 0.01, "First debt amount")
-	AssertFloatEqual(t, 30.00, debtResults[1].Amount, 0.01, "Second debt amount")
+	AssertFloatEqual(t, 30.00, debtResults[0].Amount.Float64(), 0.01, "First debt amount")
+	AssertFloatEqual(t, 30.00, debtResults[1].Amount.Float64(), 0.01, "Second debt amount")
 
 	// Both debts linked to same expense
 	if debtResults[0].ExpenseId == nil || *debtResults[0].ExpenseId != expenseResult.Id {
@@ -242,26 +241,26 @@ func TestExpenseWithDebtTransaction(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Test",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
 	debt := types.Debt{
 		Description:    "Test",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       99999, // Invalid debtor
 		DebtorName:     "Ghost",
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
 
-	_, _, err := postgres.InsertExpenseWithDebt(expense, debt)
+	_, _, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	// Should fail due to foreign key constraint
 	AssertError(t, err, "Should fail with invalid debtor")
 
@@ -288,35 +287,35 @@ func TestExpenseWithDebtZeroAmount(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        0,
+		Expense:        types.MoneyFromFloat(0),
 		Description:    "Zero expense",
 		Method:         "Debit",
-		OriginalAmount: 0,
+		OriginalAmount: types.MoneyFromFloat(0),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
 	debt := types.Debt{
 		Description:    "Zero expense",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
 
-	_, _, err := postgres.InsertExpenseWithDebt(expense, debt)
+	_, _, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertError(t, err, "Should reject zero expense amount")
 
 	// Zero debt should fail
-	expense.Expense = 100.00
-	expense.OriginalAmount = 100.00
-	debt.Amount = 0
-	debt.OriginalAmount = 0
+	expense.Expense = types.MoneyFromFloat(100.00)
+	expense.OriginalAmount = types.MoneyFromFloat(100.00)
+	debt.Amount = types.Money{}
+	debt.OriginalAmount = types.Money{}
 
-	_, _, err = postgres.InsertExpenseWithDebt(expense, debt)
+	_, _, err = testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertError(t, err, "Should reject zero debt amount")
 }
 
@@ -333,7 +332,7 @@ func TestDebtRepaymentCreation(t *testing.T) {
 
 	income := types.Income{
 		Date:        now.Format(time.DateTime),
-		Amount:      50.00,
+		Amount:      types.MoneyFromFloat(50.00),
 		Description: "Repayment from John",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
@@ -342,24 +341,24 @@ func TestDebtRepaymentCreation(t *testing.T) {
 	accountId := testAccount.ID
 	debt := types.Debt{
 		Description:    "Repayment from John",
-		Amount:         50.00,
+		Amount:         types.MoneyFromFloat(50.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 50.00,
+		OriginalAmount: types.MoneyFromFloat(50.00),
 		Currency:       "USD",
 		Outbound:       false, // Inbound = they paid us
 		AccountId:      &accountId,
 	}
 
-	incomeResult, debtResult, err := postgres.RecordDebtRepayment(income, debt)
+	incomeResult, debtResult, err := testStore.RecordDebtRepayment(testCtx, income, debt)
 	AssertNoError(t, err, "Record debt repayment")
 
 	// Verify income created
 	if incomeResult.Id == 0 {
 		t.Error("Income should have an ID")
 	}
-	AssertFloatEqual(t, 50.00, incomeResult.Amount, 0.01, "Income amount")
+	AssertFloatEqual(t, 50.00, incomeResult.Amount.Float64(), 0.01, "Income amount")
 
 	// Verify debt created
 	if debtResult.Id == 0 {
@@ -387,7 +386,7 @@ func TestDebtRepaymentAffectsExpectedBalance(t *testing.T) {
 
 	income := types.Income{
 		Date:        now.Format(time.DateTime),
-		Amount:      75.00,
+		Amount:      types.MoneyFromFloat(75.00),
 		Description: "Repayment",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
@@ -396,17 +395,17 @@ func TestDebtRepaymentAffectsExpectedBalance(t *testing.T) {
 	accountId := testAccount.ID
 	debt := types.Debt{
 		Description:    "Repayment",
-		Amount:         75.00,
+		Amount:         types.MoneyFromFloat(75.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 75.00,
+		OriginalAmount: types.MoneyFromFloat(75.00),
 		Currency:       "USD",
 		Outbound:       false,
 		AccountId:      &accountId,
 	}
 
-	_, _, err := postgres.RecordDebtRepayment(income, debt)
+	_, _, err := testStore.RecordDebtRepayment(testCtx, income, debt)
 	AssertNoError(t, err, "Record debt repayment")
 
 	// Check expected balance increased by income amount
@@ -432,30 +431,30 @@ func TestDebtByDebtorSummary(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Lent to John",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 	debt1 := types.Debt{
 		Description:    "Lent to John",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
-	_, _, err := postgres.InsertExpenseWithDebt(expense1, debt1)
+	_, _, err := testStore.InsertExpenseWithDebt(testCtx, expense1, debt1)
 	AssertNoError(t, err, "Create first debt")
 
 	// John pays back $40
 	income := types.Income{
 		Date:        now.Format(time.DateTime),
-		Amount:      40.00,
+		Amount:      types.MoneyFromFloat(40.00),
 		Description: "Partial repayment",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
@@ -463,20 +462,20 @@ func TestDebtByDebtorSummary(t *testing.T) {
 	accountId := testAccount.ID
 	debt2 := types.Debt{
 		Description:    "Partial repayment",
-		Amount:         40.00,
+		Amount:         types.MoneyFromFloat(40.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 40.00,
+		OriginalAmount: types.MoneyFromFloat(40.00),
 		Currency:       "USD",
 		Outbound:       false,
 		AccountId:      &accountId,
 	}
-	_, _, err = postgres.RecordDebtRepayment(income, debt2)
+	_, _, err = testStore.RecordDebtRepayment(testCtx, income, debt2)
 	AssertNoError(t, err, "Record repayment")
 
 	// Get summary by debtor
-	summary, err := postgres.GetDebtorsWithDebts()
+	summary, err := testStore.GetDebtorsWithDebts(testCtx)
 	AssertNoError(t, err, "Get debts by debtor")
 
 	// Find John's summary
@@ -493,9 +492,9 @@ func TestDebtByDebtorSummary(t *testing.T) {
 	}
 
 	// Verify: lent $100, received $40, net owed = $60
-	AssertFloatEqual(t, 100.00, johnSummary.TotalLent, 0.01, "Total lent")
-	AssertFloatEqual(t, 40.00, johnSummary.TotalReceived, 0.01, "Total received")
-	AssertFloatEqual(t, 60.00, johnSummary.NetOwed, 0.01, "Net owed")
+	AssertFloatEqual(t, 100.00, johnSummary.TotalLent.Float64(), 0.01, "Total lent")
+	AssertFloatEqual(t, 40.00, johnSummary.TotalReceived.Float64(), 0.01, "Total received")
+	AssertFloatEqual(t, 60.00, johnSummary.NetOwed.Float64(), 0.01, "Net owed")
 	AssertEqual(t, 2, int(johnSummary.TransactionCount), "Transaction count")
 }
 
@@ -515,17 +514,17 @@ func TestDebtDoesNotAffectExpectedBalance(t *testing.T) {
 	accountId := testAccount.ID
 	debt := types.Debt{
 		Description:    "Standalone tracking",
-		Amount:         200.00,
+		Amount:         types.MoneyFromFloat(200.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 200.00,
+		OriginalAmount: types.MoneyFromFloat(200.00),
 		Currency:       "USD",
 		Outbound:       true,
 		AccountId:      &accountId,
 	}
 
-	_, err := postgres.InsertDebt(debt)
+	_, err := testStore.InsertDebt(testCtx, debt)
 	AssertNoError(t, err, "Insert standalone debt")
 
 	// Expected balance should be unchanged
@@ -550,24 +549,24 @@ func TestFullDebtLifecycle(t *testing.T) {
 		Date:           now.Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        100.00,
+		Expense:        types.MoneyFromFloat(100.00),
 		Description:    "Lent to John",
 		Method:         "Debit",
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 	debt := types.Debt{
 		Description:    "Lent to John",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       true,
 	}
-	_, _, err := postgres.InsertExpenseWithDebt(expense, debt)
+	_, _, err := testStore.InsertExpenseWithDebt(testCtx, expense, debt)
 	AssertNoError(t, err, "Lend money")
 
 	// Expected balance should be -$100
@@ -577,7 +576,7 @@ func TestFullDebtLifecycle(t *testing.T) {
 	// Step 2: John pays back in full
 	income := types.Income{
 		Date:        now.Format(time.DateTime),
-		Amount:      100.00,
+		Amount:      types.MoneyFromFloat(100.00),
 		Description: "Full repayment from John",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
@@ -585,16 +584,16 @@ func TestFullDebtLifecycle(t *testing.T) {
 	accountId := testAccount.ID
 	repaymentDebt := types.Debt{
 		Description:    "Full repayment",
-		Amount:         100.00,
+		Amount:         types.MoneyFromFloat(100.00),
 		DebtorId:       testDebtor.ID,
 		DebtorName:     testDebtor.Name,
 		Date:           now.Format(time.DateTime),
-		OriginalAmount: 100.00,
+		OriginalAmount: types.MoneyFromFloat(100.00),
 		Currency:       "USD",
 		Outbound:       false,
 		AccountId:      &accountId,
 	}
-	_, _, err = postgres.RecordDebtRepayment(income, repaymentDebt)
+	_, _, err = testStore.RecordDebtRepayment(testCtx, income, repaymentDebt)
 	AssertNoError(t, err, "Full repayment")
 
 	// Expected balance should be back to initial (lent $100, got $100 back)
@@ -602,7 +601,7 @@ func TestFullDebtLifecycle(t *testing.T) {
 	AssertFloatEqual(t, initialExpected, afterRepay, 0.01, "After full repayment, balance restored")
 
 	// Verify net owed is $0
-	summary, err := postgres.GetDebtorsWithDebts()
+	summary, err := testStore.GetDebtorsWithDebts(testCtx)
 	AssertNoError(t, err, "Get summary")
 
 	var johnSummary *types.DebtByDebtor
@@ -617,7 +616,7 @@ func TestFullDebtLifecycle(t *testing.T) {
 		t.Fatal("John's summary not found")
 	}
 
-	AssertFloatEqual(t, 0, johnSummary.NetOwed, 0.01, "Net owed should be $0 after full repayment")
+	AssertFloatEqual(t, 0, johnSummary.NetOwed.Float64(), 0.01, "Net owed should be $0 after full repayment")
 }
 
 // ========== DEBTOR CRUD ==========
@@ -634,7 +633,7 @@ func TestDebtorCreation(t *testing.T) {
 		Description: "Created in test",
 	}
 
-	result, err := postgres.InsertDebtorIntoDatabase(debtor)
+	result, err := testStore.InsertDebtorIntoDatabase(testCtx, debtor)
 	AssertNoError(t, err, "Create debtor")
 
 	if result.Id == 0 {
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -23,22 +24,22 @@ func TestExpenseCreation(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        75.50,
+		Expense:        types.MoneyFromFloat(75.50),
 		Description:    "Groceries",
 		Method:         "Debit",
-		OriginalAmount: 75.50,
+		OriginalAmount: types.MoneyFromFloat(75.50),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
-	result, err := postgres.InsertExpense(expense)
+	result, err := testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Verify returned data matches input
 	if result.Id == 0 {
 		t.Error("Expected expense to have an ID assigned")
 	}
-	AssertFloatEqual(t, expense.Expense, result.Expense, 0.01, "Expense amount")
+	AssertFloatEqual(t, expense.Expense.Float64(), result.Expense.Float64(), 0.01, "Expense amount")
 	AssertEqual(t, expense.Description, result.Description, "Expense description")
 	AssertEqual(t, expense.Category, result.Category, "Expense category")
 	AssertEqual(t, expense.AccountId, result.AccountId, "Expense account ID")
@@ -50,7 +51,7 @@ func TestExpenseCreation(t *testing.T) {
 		`SELECT expense, description FROM expenses WHERE id = $1`, result.Id,
 	).Scan(&dbAmount, &dbDescription)
 	AssertNoError(t, err, "Query inserted expense")
-	AssertFloatEqual(t, expense.Expense, dbAmount, 0.01, "DB expense amount")
+	AssertFloatEqual(t, expense.Expense.Float64(), dbAmount, 0.01, "DB expense amount")
 	AssertEqual(t, expense.Description, dbDescription, "DB expense description")
 }
 
@@ -76,15 +77,15 @@ func TestExpenseDecreasesExpectedBalance(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        expenseAmount,
+		Expense:        types.MoneyFromFloat(expenseAmount),
 		Description:    "Test expense",
 		Method:         "Credit",
-		OriginalAmount: expenseAmount,
+		OriginalAmount: types.MoneyFromFloat(expenseAmount),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
-	_, err := postgres.InsertExpense(expense)
+	_, err := testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Verify expected balance decreased by EXACTLY the expense amount
@@ -112,14 +113,14 @@ func TestMultipleExpensesAccumulate(t *testing.T) {
 			Date:           time.Now().Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        amount,
+			Expense:        types.MoneyFromFloat(amount),
 			Description:    "Expense " + string(rune('A'+i)),
 			Method:         "Debit",
-			OriginalAmount: amount,
+			OriginalAmount: types.MoneyFromFloat(amount),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense")
 		totalExpenses += amount
 	}
@@ -148,14 +149,14 @@ func TestExpenseOnlyAffectsTargetAccount(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        200.00,
+		Expense:        types.MoneyFromFloat(200.00),
 		Description:    "Expense for A only",
 		Method:         "Debit",
-		OriginalAmount: 200.00,
+		OriginalAmount: types.MoneyFromFloat(200.00),
 		AccountId:      accountA.ID,
 		AccountType:    accountA.Type,
 	}
-	_, err := postgres.InsertExpense(expense)
+	_, err := testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Account A should decrease
@@ -183,12 +184,12 @@ func TestIncomeAndExpenseNetEffect(t *testing.T) {
 	// Add income
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      1000.00,
+		Amount:      types.MoneyFromFloat(1000.00),
 		Description: "Paycheck",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Add expenses
@@ -199,14 +200,14 @@ func TestIncomeAndExpenseNetEffect(t *testing.T) {
 			Date:           time.Now().Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        amount,
+			Expense:        types.MoneyFromFloat(amount),
 			Description:    "Spending",
 			Method:         "Debit",
-			OriginalAmount: amount,
+			OriginalAmount: types.MoneyFromFloat(amount),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense")
 		totalExpenses += amount
 	}
@@ -233,15 +234,15 @@ func TestExpenseWithZeroAmount(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        0,
+		Expense:        types.MoneyFromFloat(0),
 		Description:    "Zero expense",
 		Method:         "Debit",
-		OriginalAmount: 0,
+		OriginalAmount: types.MoneyFromFloat(0),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
-	_, err := postgres.InsertExpense(expense)
+	_, err := testStore.InsertExpense(testCtx, expense)
 	AssertError(t, err, "Zero expense should be rejected")
 
 	// Expected balance unchanged
@@ -263,15 +264,15 @@ func TestExpenseWithNegativeAmount(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        -50.00,
+		Expense:        types.MoneyFromFloat(-50.00),
 		Description:    "Negative expense",
 		Method:         "Debit",
-		OriginalAmount: -50.00,
+		OriginalAmount: types.MoneyFromFloat(-50.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
-	_, err := postgres.InsertExpense(expense)
+	_, err := testStore.InsertExpense(testCtx, expense)
 	AssertError(t, err, "Negative expense should be rejected")
 
 	// Expected balance unchanged
@@ -296,14 +297,14 @@ func TestExpenseWithDifferentCategories(t *testing.T) {
 			Date:           time.Now().Format(time.DateTime),
 			Category:       cat.Name,
 			CategoryId:     cat.ID,
-			Expense:        100.00,
+			Expense:        types.MoneyFromFloat(100.00),
 			Description:    "Expense in " + cat.Name,
 			Method:         "Debit",
-			OriginalAmount: 100.00,
+			OriginalAmount: types.MoneyFromFloat(100.00),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		result, err := postgres.InsertExpense(expense)
+		result, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense in "+cat.Name)
 		AssertEqual(t, cat.ID, result.CategoryId, "Category ID preserved")
 		AssertEqual(t, cat.Name, result.Category, "Category name preserved")
@@ -329,17 +330,17 @@ func TestExpenseWithLargeAmount(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        largeAmount,
+		Expense:        types.MoneyFromFloat(largeAmount),
 		Description:    "Large purchase",
 		Method:         "Credit",
-		OriginalAmount: largeAmount,
+		OriginalAmount: types.MoneyFromFloat(largeAmount),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
 
-	result, err := postgres.InsertExpense(expense)
+	result, err := testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert large expense")
-	AssertFloatEqual(t, largeAmount, result.Expense, 0.01, "Large amount preserved")
+	AssertFloatEqual(t, largeAmount, result.Expense.Float64(), 0.01, "Large amount preserved")
 
 	// Verify expected balance (will go negative, which is valid)
 	newExpected := GetAccountExpectedBalance(t, testAccount.ID)
@@ -361,25 +362,25 @@ func TestExpensePagination(t *testing.T) {
 			Date:           time.Now().Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        float64(i+1) * 10,
+			Expense:        types.MoneyFromFloat(float64(i+1) * 10),
 			Description:    "Paginated expense",
 			Method:         "Debit",
-			OriginalAmount: float64(i+1) * 10,
+			OriginalAmount: types.MoneyFromFloat(float64(i+1) * 10),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense for pagination")
 	}
 
 	// Get first page
-	page1, count, err := postgres.GetExpenses(10, 0)
+	page1, count, err := testStore.GetExpenses(testCtx, 10, 0)
 	AssertNoError(t, err, "Get first page")
 	AssertEqual(t, 20, count, "Total count")
 	AssertEqual(t, 10, len(page1), "First page size")
 
 	// Get second page
-	page2, count2, err := postgres.GetExpenses(10, 10)
+	page2, count2, err := testStore.GetExpenses(testCtx, 10, 10)
 	AssertNoError(t, err, "Get second page")
 	AssertEqual(t, 20, count2, "Total count unchanged")
 	AssertEqual(t, 10, len(page2), "Second page size")
@@ -410,19 +411,19 @@ func TestRecentExpenses(t *testing.T) {
 			Date:           time.Now().Format(time.DateTime),
 			Category:       testCategory.Name,
 			CategoryId:     testCategory.ID,
-			Expense:        float64(i+1) * 5,
+			Expense:        types.MoneyFromFloat(float64(i+1) * 5),
 			Description:    "Recent expense test",
 			Method:         "Debit",
-			OriginalAmount: float64(i+1) * 5,
+			OriginalAmount: types.MoneyFromFloat(float64(i+1) * 5),
 			AccountId:      testAccount.ID,
 			AccountType:    testAccount.Type,
 		}
-		_, err := postgres.InsertExpense(expense)
+		_, err := testStore.InsertExpense(testCtx, expense)
 		AssertNoError(t, err, "Insert expense")
 	}
 
 	// Get recent 5
-	recent, err := postgres.GetRecentExpenses(5)
+	recent, err := testStore.GetRecentExpenses(testCtx, 5)
 	AssertNoError(t, err, "Get recent expenses")
 	AssertEqual(t, 5, len(recent), "Recent expenses count")
 }
@@ -451,14 +452,14 @@ func TestExpenseCreatesDiscrepancy(t *testing.T) {
 		Date:           time.Now().Format(time.DateTime),
 		Category:       testCategory.Name,
 		CategoryId:     testCategory.ID,
-		Expense:        300.00,
+		Expense:        types.MoneyFromFloat(300.00),
 		Description:    "Test expense",
 		Method:         "Debit",
-		OriginalAmount: 300.00,
+		OriginalAmount: types.MoneyFromFloat(300.00),
 		AccountId:      testAccount.ID,
 		AccountType:    testAccount.Type,
 	}
-	_, err = postgres.InsertExpense(expense)
+	_, err = testStore.InsertExpense(testCtx, expense)
 	AssertNoError(t, err, "Insert expense")
 
 	// Now discrepancy should be positive (real > expected)
@@ -472,3 +473,186 @@ func TestExpenseCreatesDiscrepancy(t *testing.T) {
 	AssertFloatEqual(t, 300.00, newDiscrepancy, 0.01,
 		"Discrepancy equals real - expected (positive when real > expected)")
 }
+
+// ========== UPDATE / DELETE ==========
+
+// TestExpenseUpdate verifies an update rewrites the row and moves the expected balance
+func TestExpenseUpdate(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testCategory := GetTestCategory(TestCategoryFoodID)
+	otherCategory := GetTestCategory(TestCategoryTransportID)
+	initialExpected := GetAccountExpectedBalance(t, testAccount.ID)
+
+	created, err := testStore.InsertExpense(testCtx, types.Expense{
+		Date:           time.Now().Format(time.DateTime),
+		Category:       testCategory.Name,
+		CategoryId:     testCategory.ID,
+		Expense:        types.MoneyFromFloat(100.00),
+		Description:    "Wrong amount",
+		Method:         "Debit",
+		OriginalAmount: types.MoneyFromFloat(100.00),
+		AccountId:      testAccount.ID,
+		AccountType:    testAccount.Type,
+	})
+	AssertNoError(t, err, "Insert expense")
+
+	created.Expense = types.MoneyFromFloat(40.00)
+	created.OriginalAmount = types.MoneyFromFloat(40.00)
+	created.Category = otherCategory.Name
+	created.CategoryId = otherCategory.ID
+	created.Description = "Fixed amount"
+
+	updated, err := testStore.UpdateExpense(testCtx, created)
+	AssertNoError(t, err, "Update expense")
+	AssertEqual(t, created.Id, updated.Id, "Updated expense ID")
+	AssertFloatEqual(t, 40.00, updated.Expense.Float64(), 0.01, "Updated amount")
+	AssertEqual(t, otherCategory.ID, updated.CategoryId, "Updated category ID")
+	AssertEqual(t, "Fixed amount", updated.Description, "Updated description")
+
+	fetched, err := testStore.GetExpenseById(testCtx, created.Id)
+	AssertNoError(t, err, "Get expense by ID")
+	AssertFloatEqual(t, 40.00, fetched.Expense.Float64(), 0.01, "Fetched amount")
+
+	AssertEqual(t, 1, CountTableRows(t, "expenses"), "Update must not insert a new row")
+	AssertFloatEqual(t, initialExpected-40.00, GetAccountExpectedBalance(t, testAccount.ID), 0.01,
+		"Expected balance reflects the updated amount")
+}
+
+// TestExpenseUpdateRejectsInvalidAmount verifies the update keeps the positive amount rule
+func TestExpenseUpdateRejectsInvalidAmount(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testCategory := GetTestCategory(TestCategoryFoodID)
+
+	created, err := testStore.InsertExpense(testCtx, types.Expense{
+		Date:           time.Now().Format(time.DateTime),
+		Category:       testCategory.Name,
+		CategoryId:     testCategory.ID,
+		Expense:        types.MoneyFromFloat(100.00),
+		Description:    "Groceries",
+		Method:         "Debit",
+		OriginalAmount: types.MoneyFromFloat(100.00),
+		AccountId:      testAccount.ID,
+		AccountType:    testAccount.Type,
+	})
+	AssertNoError(t, err, "Insert expense")
+
+	created.Expense = types.Money{}
+	_, err = testStore.UpdateExpense(testCtx, created)
+	AssertError(t, err, "Zero amount update should be rejected")
+
+	fetched, err := testStore.GetExpenseById(testCtx, created.Id)
+	AssertNoError(t, err, "Get expense by ID")
+	AssertFloatEqual(t, 100.00, fetched.Expense.Float64(), 0.01, "Amount unchanged after rejected update")
+}
+
+// TestExpenseUpdateNotFound verifies updating a missing expense returns ErrNotFound
+func TestExpenseUpdateNotFound(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testCategory := GetTestCategory(TestCategoryFoodID)
+
+	_, err := testStore.UpdateExpense(testCtx, types.Expense{
+		Id:          999999,
+		Date:        time.Now().Format(time.DateTime),
+		Category:    testCategory.Name,
+		CategoryId:  testCategory.ID,
+		Expense:     types.MoneyFromFloat(10.00),
+		Description: "Ghost",
+		AccountId:   testAccount.ID,
+		AccountType: testAccount.Type,
+	})
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	_, err = testStore.GetExpenseById(testCtx, 999999)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from GetExpenseById, got %v", err)
+	}
+}
+
+// TestExpenseDeleteRestoresExpectedBalance verifies delete undoes the expense effect
+func TestExpenseDeleteRestoresExpectedBalance(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testCategory := GetTestCategory(TestCategoryFoodID)
+	initialExpected := GetAccountExpectedBalance(t, testAccount.ID)
+
+	created, err := testStore.InsertExpense(testCtx, types.Expense{
+		Date:           time.Now().Format(time.DateTime),
+		Category:       testCategory.Name,
+		CategoryId:     testCategory.ID,
+		Expense:        types.MoneyFromFloat(250.00),
+		Description:    "Mistake",
+		Method:         "Debit",
+		OriginalAmount: types.MoneyFromFloat(250.00),
+		AccountId:      testAccount.ID,
+		AccountType:    testAccount.Type,
+	})
+	AssertNoError(t, err, "Insert expense")
+
+	deleted, err := testStore.DeleteExpense(testCtx, created.Id)
+	AssertNoError(t, err, "Delete expense")
+	AssertEqual(t, created.Id, deleted.Id, "Deleted expense ID")
+	AssertEqual(t, "Mistake", deleted.Description, "Deleted expense is returned for sheet reconciliation")
+
+	AssertEqual(t, 0, CountTableRows(t, "expenses"), "Expense row removed")
+	AssertFloatEqual(t, initialExpected, GetAccountExpectedBalance(t, testAccount.ID), 0.01,
+		"Expected balance restored after delete")
+
+	_, err = testStore.DeleteExpense(testCtx, created.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+// TestExpenseDeleteRemovesLinkedDebts verifies debts created with the expense go with it
+func TestExpenseDeleteRemovesLinkedDebts(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testCategory := GetTestCategory(TestCategoryFoodID)
+	testDebtor := GetTestDebtor(TestDebtorJohnID)
+	now := time.Now()
+	accountId := testAccount.ID
+
+	expenseResult, _, err := testStore.InsertExpenseWithDebts(testCtx, types.Expense{
+		Date:           now.Format(time.DateTime),
+		Category:       testCategory.Name,
+		CategoryId:     testCategory.ID,
+		Expense:        types.MoneyFromFloat(90.00),
+		Description:    "Dinner",
+		Method:         "Debit",
+		OriginalAmount: types.MoneyFromFloat(90.00),
+		AccountId:      testAccount.ID,
+		AccountType:    testAccount.Type,
+	}, []types.Debt{{
+		Description:    "Dinner",
+		Amount:         types.MoneyFromFloat(30.00),
+		DebtorId:       testDebtor.ID,
+		DebtorName:     testDebtor.Name,
+		Date:           now.Format(time.DateTime),
+		OriginalAmount: types.MoneyFromFloat(30.00),
+		Currency:       "USD",
+		Outbound:       true,
+		AccountId:      &accountId,
+	}})
+	AssertNoError(t, err, "Insert expense with debt")
+	AssertEqual(t, 1, CountTableRows(t, "debts"), "Debt created")
+
+	_, err = testStore.DeleteExpense(testCtx, expenseResult.Id)
+	AssertNoError(t, err, "Delete expense")
+	AssertEqual(t, 0, CountTableRows(t, "expenses"), "Expense removed")
+	AssertEqual(t, 0, CountTableRows(t, "debts"), "Linked debt removed")
+}
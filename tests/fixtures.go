@@ -3,6 +3,12 @@ package tests
 // ========== TEST FIXTURE CONSTANTS ==========
 // Single source of truth for all test data
 
+// The household and user the tests run as; migration 0004 creates both
+const (
+	TestHouseholdID int64 = 1
+	TestUserID      int64 = 1
+)
+
 // Test Account IDs (use high IDs to avoid conflicts with production data)
 const (
 	TestAccountBankID    int32 = 100
@@ -10,6 +16,9 @@ const (
 	TestAccountCOPID     int32 = 102
 )
 
+// TestRateUSDCOP is the seeded rate totals convert TestAccountCOPID's pesos at
+const TestRateUSDCOP = 4000.0
+
 // Test Investment Account IDs
 const (
 	TestInvAccountCryptoID int32 = 100
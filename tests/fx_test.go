@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== EXCHANGE RATES ==========
+
+// TestAccountCurrency verifies account_currency reads the fiat or investment
+// account a transaction names, by its account_type
+func TestAccountCurrency(t *testing.T) {
+	SeedTestData(t)
+
+	var currency string
+	for _, c := range []struct {
+		accountType string
+		accountId   int32
+		expected    string
+	}{
+		{"", TestAccountCOPID, "COP"},
+		{"Fiat", TestAccountBankID, "USD"},
+		{"Broker", TestInvAccountBrokerID, "USD"},
+	} {
+		err := testPool.QueryRow(context.Background(), `SELECT account_currency($1, $2)`, c.accountType, c.accountId).Scan(&currency)
+		AssertNoError(t, err, "account_currency")
+		AssertEqual(t, c.expected, currency, "Currency of "+c.accountType+" account")
+	}
+}
+
+// TestToBase verifies to_base converts at the rate in effect on a date, either
+// way round, and fails rather than taking an unknown currency as the base
+func TestToBase(t *testing.T) {
+	SeedTestData(t)
+	ctx := context.Background()
+	_, err := testPool.Exec(ctx, `DELETE FROM fx_rates WHERE household_id = $1 AND 'EUR' IN (currency, quote)`, TestHouseholdID)
+	AssertNoError(t, err, "Remove EUR rates")
+	_, err = testStore.UpsertFXRates(testCtx, []types.FXRate{{Date: "2026-02-01", Currency: "USD", Quote: "COP", Rate: 5000}})
+	AssertNoError(t, err, "Upsert February rate")
+	defer testPool.Exec(ctx, `DELETE FROM fx_rates WHERE household_id = $1 AND date = '2026-02-01'`, TestHouseholdID)
+
+	var rate float64
+	for _, c := range []struct {
+		currency string
+		date     string
+		expected float64
+	}{
+		{"USD", "2026-01-15 10:00:00", 1},
+		{"COP", "2026-01-15 10:00:00", 1 / TestRateUSDCOP},
+		{"COP", "2026-02-01 00:00:00", 1.0 / 5000},
+		{"COP", "2025-06-01 00:00:00", 1 / TestRateUSDCOP},
+	} {
+		err := testPool.QueryRow(ctx, `SELECT to_base($1, $2, $3)::FLOAT8`, TestHouseholdID, c.currency, c.date).Scan(&rate)
+		AssertNoError(t, err, "to_base")
+		AssertFloatEqual(t, c.expected, rate, 1e-12, c.currency+" on "+c.date)
+	}
+
+	err = testPool.QueryRow(ctx, `SELECT to_base($1, 'EUR', '2026-01-15')::FLOAT8`, TestHouseholdID).Scan(&rate)
+	AssertError(t, err, "to_base without a EUR rate")
+}
+
+// TestTotalsFailWithoutRate verifies a total over an amount no rate converts
+// names the missing rate
+func TestTotalsFailWithoutRate(t *testing.T) {
+	SeedTestData(t)
+	CleanupTables(t)
+	ctx := context.Background()
+	_, err := testPool.Exec(ctx, `DELETE FROM fx_rates WHERE household_id = $1 AND 'EUR' IN (currency, quote)`, TestHouseholdID)
+	AssertNoError(t, err, "Remove EUR rates")
+
+	euros, err := testStore.InsertAccountIntoDatabase(testCtx, types.Account{Name: "Test EUR", Type: "Fiat", Currency: "EUR"})
+	AssertNoError(t, err, "Insert EUR account")
+	defer func() {
+		testPool.Exec(ctx, `DELETE FROM incomes WHERE account_id = $1`, euros.Id)
+		testPool.Exec(ctx, `DELETE FROM accounts WHERE id = $1`, euros.Id)
+	}()
+
+	_, err = testStore.InsertIncome(testCtx, types.Income{
+		Date: "2026-01-10 09:00:00", Amount: types.MoneyFromFloat(100), Description: "Euros", AccountId: euros.Id,
+	})
+	AssertNoError(t, err, "Insert EUR income")
+
+	_, err = testStore.GetIncomeSum(testCtx, types.MonthPeriod(2026, 1))
+	var missing *types.FXRateError
+	if !errors.As(err, &missing) || missing.From != "EUR" || missing.To != "USD" {
+		t.Fatalf("Expected a missing EUR to USD rate, got %v", err)
+	}
+
+	_, err = testStore.UpsertFXRates(testCtx, []types.FXRate{{Date: "2026-01-01", Currency: "EUR", Quote: "USD", Rate: 1.1}})
+	AssertNoError(t, err, "Upsert EUR rate")
+	defer testPool.Exec(ctx, `DELETE FROM fx_rates WHERE household_id = $1 AND currency = 'EUR'`, TestHouseholdID)
+
+	sum, err := testStore.GetIncomeSum(testCtx, types.MonthPeriod(2026, 1))
+	AssertNoError(t, err, "Income sum with a EUR rate")
+	AssertEqual(t, "110.00", sum.String(), "Euros in dollars")
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== HANDLER SHEET WRITES ==========
+
+// SeedSheetConfig points the configs the handlers write through at TestSheet
+func SeedSheetConfig(t *testing.T) {
+	t.Helper()
+	_, err := testPool.Exec(context.Background(), `
+		INSERT INTO config (household_id, type, sheet, range)
+		VALUES
+			($1, 'expenses', 'TestSheet', '!A:I'),
+			($1, 'income', 'TestSheet', '!K:O'),
+			($1, 'income_monthly', 'TestSheet', '!D3'),
+			($1, 'investments', 'TestSheet', '!Q:V'),
+			($1, 'investment_capital', 'Fintrack Config', '!I:L')
+		ON CONFLICT (household_id, type) DO UPDATE SET sheet = EXCLUDED.sheet, range = EXCLUDED.range
+	`, TestHouseholdID)
+	if err != nil {
+		t.Fatalf("Failed to seed sheet config: %v", err)
+	}
+}
+
+// doJSON sends body to the test router and returns the recorded response
+func doJSON(t *testing.T, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	AssertNoError(t, err, "Encode request body")
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	AuthorizeRequest(req)
+	rec := httptest.NewRecorder()
+	NewTestRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestSubmitExpenseAppendsRow verifies the exact row written for a new expense
+func TestSubmitExpenseAppendsRow(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+	SeedSheetConfig(t)
+	ResetMockSheet()
+
+	rec := doJSON(t, "POST", "/api/submit", map[string]interface{}{
+		"category":       "Food",
+		"category_id":    TestCategoryFoodID,
+		"expense":        25.50,
+		"description":    "Lunch",
+		"method":         "card",
+		"originalAmount": 25.50,
+		"account_id":     TestAccountBankID,
+		"account_type":   "bank",
+	})
+	AssertEqual(t, http.StatusOK, rec.Code, "Submit status")
+
+	calls := GetMockSheetCalls()
+	AssertEqual(t, 1, len(calls), "Sheet call count")
+	AssertEqual(t, "append", calls[0].Op, "Expense is appended")
+	AssertEqual(t, "TestSheet!A:I", calls[0].Range, "Expense range")
+
+	row := calls[0].Rows[0]
+	AssertEqual(t, 9, len(row), "Expense row width")
+	expected := []interface{}{"Food", 25.50, "Lunch", "card", 25.50, TestCategoryFoodID, TestAccountBankID, "bank"}
+	for i, value := range expected {
+		AssertEqual(t, fmt.Sprint(value), fmt.Sprint(row[i+1]), fmt.Sprintf("Expense column %d", i+1))
+	}
+}
+
+// TestUpdateExpenseRewritesRow verifies PATCH rewrites the row the expense was appended to
+func TestUpdateExpenseRewritesRow(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+	SeedSheetConfig(t)
+	ResetMockSheet()
+
+	rec := doJSON(t, "POST", "/api/submit", map[string]interface{}{
+		"category":     "Food",
+		"category_id":  TestCategoryFoodID,
+		"expense":      12.00,
+		"description":  "Coffee",
+		"account_id":   TestAccountBankID,
+		"account_type": "bank",
+	})
+	AssertEqual(t, http.StatusOK, rec.Code, "Submit status")
+
+	var id int32
+	err := testPool.QueryRow(context.Background(), `SELECT id FROM expenses ORDER BY id DESC LIMIT 1`).Scan(&id)
+	AssertNoError(t, err, "Get expense id")
+
+	rec = doJSON(t, "PATCH", fmt.Sprintf("/api/expenses/%d", id), map[string]interface{}{"expense": 15.00})
+	AssertEqual(t, http.StatusOK, rec.Code, "Patch status")
+
+	calls := GetMockSheetCalls()
+	AssertEqual(t, 2, len(calls), "Sheet call count")
+	AssertEqual(t, "update", calls[1].Op, "Expense row is updated")
+	AssertEqual(t, "TestSheet!A1:I1", calls[1].Range, "Updated row range")
+	AssertEqual(t, "15", fmt.Sprint(calls[1].Rows[0][2]), "Updated amount")
+}
+
+// TestSubmitIncomeRefreshesMonthlyCell verifies the income row and the monthly sum cell
+func TestSubmitIncomeRefreshesMonthlyCell(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+	SeedSheetConfig(t)
+	ResetMockSheet()
+
+	rec := doJSON(t, "POST", "/api/income", map[string]interface{}{
+		"amount":       1500.00,
+		"description":  "Salary",
+		"account_id":   TestAccountBankID,
+		"account_name": "Test Bank",
+	})
+	AssertEqual(t, http.StatusOK, rec.Code, "Submit status")
+
+	calls := GetMockSheetCalls()
+	AssertEqual(t, 2, len(calls), "Sheet call count")
+	AssertEqual(t, "append", calls[0].Op, "Income is appended")
+	AssertEqual(t, "TestSheet!K:O", calls[0].Range, "Income range")
+	AssertEqual(t, "Salary", calls[0].Rows[0][3], "Income description column")
+
+	now := time.Now()
+	sum, err := testStore.GetIncomeSum(testCtx, types.MonthPeriod(now.Year(), int(now.Month())))
+	AssertNoError(t, err, "Get monthly income sum")
+
+	AssertEqual(t, "cell", calls[1].Op, "Monthly sum is a cell update")
+	AssertEqual(t, googleSS.CalculateMonthlyCellRange("TestSheet", "!D3", int(now.Month())), calls[1].Range, "Monthly cell range")
+	AssertFloatEqual(t, sum.Float64(), calls[1].Value.(float64), 0.01, "Monthly cell value")
+}
+
+// TestSubmitInvestmentRefreshesCapitalCell verifies the capital cell in the account's row of the config tab
+func TestSubmitInvestmentRefreshesCapitalCell(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+	SeedSheetConfig(t)
+	ResetMockSheet()
+
+	account := GetTestInvestmentAccount(TestInvAccountCryptoID)
+	mockSheet.SeedRows("Fintrack Config!I:L", [][]interface{}{{"Stocks"}, {account.Name}})
+	rec := doJSON(t, "POST", "/api/investment", map[string]interface{}{
+		"description":  "Buy BTC",
+		"amount":       200.00,
+		"account_id":   account.ID,
+		"account_name": account.Name,
+		"type":         "deposit",
+	})
+	AssertEqual(t, http.StatusOK, rec.Code, "Submit status")
+
+	calls := GetMockSheetCalls()
+	AssertEqual(t, 2, len(calls), "Sheet call count")
+	AssertEqual(t, "TestSheet!Q:V", calls[0].Range, "Investment range")
+	AssertEqual(t, "deposit", calls[0].Rows[0][5], "Investment type column")
+
+	AssertEqual(t, "cell", calls[1].Op, "Capital is a cell update")
+	AssertEqual(t, "Fintrack Config!L2", calls[1].Range, "Capital cell range")
+	AssertFloatEqual(t, account.StartingCapital+200.00, calls[1].Value.(float64), 0.01, "Capital cell value")
+}
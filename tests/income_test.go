@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -19,20 +20,20 @@ func TestIncomeCreation(t *testing.T) {
 	testAccount := GetTestAccount(TestAccountBankID)
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      1500.50,
+		Amount:      types.MoneyFromFloat(1500.50),
 		Description: "Salary payment",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	result, err := postgres.InsertIncome(income)
+	result, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Verify returned data matches input
 	if result.Id == 0 {
 		t.Error("Expected income to have an ID assigned")
 	}
-	AssertFloatEqual(t, income.Amount, result.Amount, 0.01, "Income amount")
+	AssertFloatEqual(t, income.Amount.Float64(), result.Amount.Float64(), 0.01, "Income amount")
 	AssertEqual(t, income.Description, result.Description, "Income description")
 	AssertEqual(t, income.AccountId, result.AccountId, "Income account ID")
 	AssertEqual(t, income.AccountName, result.AccountName, "Income account name")
@@ -44,7 +45,7 @@ func TestIncomeCreation(t *testing.T) {
 		`SELECT amount, description FROM incomes WHERE id = $1`, result.Id,
 	).Scan(&dbAmount, &dbDescription)
 	AssertNoError(t, err, "Query inserted income")
-	AssertFloatEqual(t, income.Amount, dbAmount, 0.01, "DB income amount")
+	AssertFloatEqual(t, income.Amount.Float64(), dbAmount, 0.01, "DB income amount")
 	AssertEqual(t, income.Description, dbDescription, "DB income description")
 }
 
@@ -67,13 +68,13 @@ func TestIncomeIncreasesExpectedBalance(t *testing.T) {
 	incomeAmount := 500.00
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      incomeAmount,
+		Amount:      types.MoneyFromFloat(incomeAmount),
 		Description: "Test income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Verify expected balance increased by EXACTLY the income amount
@@ -98,12 +99,12 @@ func TestMultipleIncomesAccumulate(t *testing.T) {
 	for i, amount := range amounts {
 		income := types.Income{
 			Date:        time.Now().Format(time.DateTime),
-			Amount:      amount,
+			Amount:      types.MoneyFromFloat(amount),
 			Description: "Income " + string(rune('A'+i)),
 			AccountId:   testAccount.ID,
 			AccountName: testAccount.Name,
 		}
-		_, err := postgres.InsertIncome(income)
+		_, err := testStore.InsertIncome(testCtx, income)
 		AssertNoError(t, err, "Insert income")
 		totalIncome += amount
 	}
@@ -129,12 +130,12 @@ func TestIncomeOnlyAffectsTargetAccount(t *testing.T) {
 	// Add income ONLY to account A
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      1000.00,
+		Amount:      types.MoneyFromFloat(1000.00),
 		Description: "Income for A only",
 		AccountId:   accountA.ID,
 		AccountName: accountA.Name,
 	}
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Account A should increase
@@ -165,20 +166,20 @@ func TestMonthlyIncomeSum(t *testing.T) {
 	for _, amount := range amounts {
 		income := types.Income{
 			Date:        now.Format(time.DateTime),
-			Amount:      amount,
+			Amount:      types.MoneyFromFloat(amount),
 			Description: "Monthly income",
 			AccountId:   testAccount.ID,
 			AccountName: testAccount.Name,
 		}
-		_, err := postgres.InsertIncome(income)
+		_, err := testStore.InsertIncome(testCtx, income)
 		AssertNoError(t, err, "Insert income")
 
 	}
 
 	// Get monthly sum
-	sum, err := postgres.GetMonthlyIncomeSum(now.Year(), int(now.Month()))
+	sum, err := testStore.GetIncomeSum(testCtx, types.MonthPeriod(now.Year(), int(now.Month())))
 	AssertNoError(t, err, "Get monthly sum")
-	AssertFloatEqual(t, expectedSum, sum, 0.01, "Monthly income sum")
+	AssertFloatEqual(t, expectedSum, sum.Float64(), 0.01, "Monthly income sum")
 }
 
 // TestMonthlyIncomeSumEmptyMonth verifies empty month returns 0
@@ -187,57 +188,9 @@ func TestMonthlyIncomeSumEmptyMonth(t *testing.T) {
 	SeedTestData(t)
 
 	// Query for a month with no data (use future date)
-	sum, err := postgres.GetMonthlyIncomeSum(2099, 12)
+	sum, err := testStore.GetIncomeSum(testCtx, types.MonthPeriod(2099, 12))
 	AssertNoError(t, err, "Get empty month sum")
-	AssertFloatEqual(t, 0, sum, 0.01, "Empty month should return 0")
-}
-
-// TestYearlyIncomeSummary verifies monthly breakdown with multiple incomes
-func TestYearlyIncomeSummary(t *testing.T) {
-	CleanupTables(t)
-	SeedTestData(t)
-
-	testAccount := GetTestAccount(TestAccountBankID)
-	now := time.Now()
-
-	// Add multiple incomes for current month from different sources
-	incomeAmounts := []float64{3500.00, 1200.00, 800.50, 250.00}
-	expectedTotal := 0.0
-
-	for i, amount := range incomeAmounts {
-		income := types.Income{
-			Date:        now.Format(time.DateTime),
-			Amount:      amount,
-			Description: []string{"Salary", "Freelance", "Dividends", "Refund"}[i],
-			AccountId:   testAccount.ID,
-			AccountName: testAccount.Name,
-		}
-		_, err := postgres.InsertIncome(income)
-		AssertNoError(t, err, "Insert income")
-		expectedTotal += amount
-	}
-
-	// Get yearly summary
-	summary, err := postgres.GetYearlyIncomeSummary(now.Year())
-	AssertNoError(t, err, "Get yearly summary")
-
-	// Should have at least 1 month
-	if len(summary) == 0 {
-		t.Fatal("Expected at least 1 month in summary")
-	}
-
-	// Find current month and verify total
-	found := false
-	for _, s := range summary {
-		if s.Month == int(now.Month()) {
-			found = true
-			AssertFloatEqual(t, expectedTotal, s.TotalIncome, 0.01,
-				"Current month total should sum all incomes")
-		}
-	}
-	if !found {
-		t.Error("Current month not found in yearly summary")
-	}
+	AssertFloatEqual(t, 0, sum.Float64(), 0.01, "Empty month should return 0")
 }
 
 // ========== EDGE CASES ==========
@@ -252,13 +205,13 @@ func TestIncomeWithZeroAmount(t *testing.T) {
 
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      0,
+		Amount:      types.MoneyFromFloat(0),
 		Description: "Zero amount income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertError(t, err, "Zero income should be rejected")
 
 	// Expected balance should be unchanged (no income was created)
@@ -277,13 +230,13 @@ func TestIncomeWithNegativeAmount(t *testing.T) {
 
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      -100.00,
+		Amount:      types.MoneyFromFloat(-100.00),
 		Description: "Negative income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertError(t, err, "Negative income should be rejected")
 
 	// Expected balance should be unchanged (no income was created)
@@ -301,13 +254,13 @@ func TestIncomeWithEmptyDescription(t *testing.T) {
 
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      100.00,
+		Amount:      types.MoneyFromFloat(100.00),
 		Description: "", // Empty description
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	result, err := postgres.InsertIncome(income)
+	result, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income with empty description")
 	AssertEqual(t, "", result.Description, "Empty description preserved")
 }
@@ -324,15 +277,15 @@ func TestIncomeWithLargeAmount(t *testing.T) {
 	largeAmount := 1000000.00
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      largeAmount,
+		Amount:      types.MoneyFromFloat(largeAmount),
 		Description: "Large income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	result, err := postgres.InsertIncome(income)
+	result, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert large income")
-	AssertFloatEqual(t, largeAmount, result.Amount, 0.01, "Large amount preserved")
+	AssertFloatEqual(t, largeAmount, result.Amount.Float64(), 0.01, "Large amount preserved")
 
 	// Verify expected balance
 	newExpected := GetAccountExpectedBalance(t, testAccount.ID)
@@ -351,15 +304,15 @@ func TestIncomeWithPreciseDecimal(t *testing.T) {
 	preciseAmount := 1234.56
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      preciseAmount,
+		Amount:      types.MoneyFromFloat(preciseAmount),
 		Description: "Precise decimal income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
 
-	result, err := postgres.InsertIncome(income)
+	result, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert precise decimal income")
-	AssertFloatEqual(t, preciseAmount, result.Amount, 0.001, "Precise amount preserved")
+	AssertFloatEqual(t, preciseAmount, result.Amount.Float64(), 0.001, "Precise amount preserved")
 }
 
 // TestIncomePagination verifies pagination works correctly
@@ -373,23 +326,23 @@ func TestIncomePagination(t *testing.T) {
 	for i := 0; i < 15; i++ {
 		income := types.Income{
 			Date:        time.Now().Format(time.DateTime),
-			Amount:      float64(i+1) * 100,
+			Amount:      types.MoneyFromFloat(float64(i+1) * 100),
 			Description: "Paginated income",
 			AccountId:   testAccount.ID,
 			AccountName: testAccount.Name,
 		}
-		_, err := postgres.InsertIncome(income)
+		_, err := testStore.InsertIncome(testCtx, income)
 		AssertNoError(t, err, "Insert income for pagination")
 	}
 
 	// Get first page
-	page1, count, err := postgres.GetIncomes(10, 0)
+	page1, count, err := testStore.GetIncomes(testCtx, 10, 0)
 	AssertNoError(t, err, "Get first page")
 	AssertEqual(t, 15, count, "Total count")
 	AssertEqual(t, 10, len(page1), "First page size")
 
 	// Get second page
-	page2, count2, err := postgres.GetIncomes(10, 10)
+	page2, count2, err := testStore.GetIncomes(testCtx, 10, 10)
 	AssertNoError(t, err, "Get second page")
 	AssertEqual(t, 15, count2, "Total count unchanged")
 	AssertEqual(t, 5, len(page2), "Second page size")
@@ -421,12 +374,12 @@ func TestIncomeDoesNotAffectRealBalance(t *testing.T) {
 	// Add income
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      1000.00,
+		Amount:      types.MoneyFromFloat(1000.00),
 		Description: "Test income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
-	_, err := postgres.InsertIncome(income)
+	_, err := testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Real balance should be UNCHANGED (only expected changes)
@@ -461,12 +414,12 @@ func TestIncomeCreatesDiscrepancy(t *testing.T) {
 	// Add income - this increases expected but not real
 	income := types.Income{
 		Date:        time.Now().Format(time.DateTime),
-		Amount:      500.00,
+		Amount:      types.MoneyFromFloat(500.00),
 		Description: "Test income",
 		AccountId:   testAccount.ID,
 		AccountName: testAccount.Name,
 	}
-	_, err = postgres.InsertIncome(income)
+	_, err = testStore.InsertIncome(testCtx, income)
 	AssertNoError(t, err, "Insert income")
 
 	// Now discrepancy should be negative (real < expected)
@@ -480,3 +433,140 @@ func TestIncomeCreatesDiscrepancy(t *testing.T) {
 	AssertFloatEqual(t, -500.00, newDiscrepancy, 0.01,
 		"Discrepancy equals real - expected (negative when expected > real)")
 }
+
+// ========== UPDATE / DELETE ==========
+
+// TestIncomeUpdate verifies an update rewrites the row and moves the expected balance
+func TestIncomeUpdate(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	initialExpected := GetAccountExpectedBalance(t, testAccount.ID)
+
+	created, err := testStore.InsertIncome(testCtx, types.Income{
+		Date:        time.Now().Format(time.DateTime),
+		Amount:      types.MoneyFromFloat(1000.00),
+		Description: "Salary",
+		AccountId:   testAccount.ID,
+		AccountName: testAccount.Name,
+	})
+	AssertNoError(t, err, "Insert income")
+
+	created.Amount = types.MoneyFromFloat(1200.00)
+	created.Description = "Salary with bonus"
+	updated, err := testStore.UpdateIncome(testCtx, created)
+	AssertNoError(t, err, "Update income")
+	AssertFloatEqual(t, 1200.00, updated.Amount.Float64(), 0.01, "Updated amount")
+	AssertEqual(t, "Salary with bonus", updated.Description, "Updated description")
+
+	AssertEqual(t, 1, CountTableRows(t, "incomes"), "Update must not insert a new row")
+	AssertFloatEqual(t, initialExpected+1200.00, GetAccountExpectedBalance(t, testAccount.ID), 0.01,
+		"Expected balance reflects the updated amount")
+
+	now := time.Now()
+	sum, err := testStore.GetIncomeSum(testCtx, types.MonthPeriod(now.Year(), int(now.Month())))
+	AssertNoError(t, err, "Get monthly sum")
+	AssertFloatEqual(t, 1200.00, sum.Float64(), 0.01, "Monthly sum reflects the updated amount")
+}
+
+// TestIncomeUpdateRejectsInvalidAmount verifies the update keeps the positive amount rule
+func TestIncomeUpdateRejectsInvalidAmount(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+
+	created, err := testStore.InsertIncome(testCtx, types.Income{
+		Date:        time.Now().Format(time.DateTime),
+		Amount:      types.MoneyFromFloat(300.00),
+		Description: "Freelance",
+		AccountId:   testAccount.ID,
+		AccountName: testAccount.Name,
+	})
+	AssertNoError(t, err, "Insert income")
+
+	created.Amount = types.MoneyFromFloat(-10)
+	_, err = testStore.UpdateIncome(testCtx, created)
+	AssertError(t, err, "Negative amount update should be rejected")
+
+	fetched, err := testStore.GetIncomeById(testCtx, created.Id)
+	AssertNoError(t, err, "Get income by ID")
+	AssertFloatEqual(t, 300.00, fetched.Amount.Float64(), 0.01, "Amount unchanged after rejected update")
+}
+
+// TestIncomeDeleteRestoresExpectedBalance verifies delete undoes the income effect
+func TestIncomeDeleteRestoresExpectedBalance(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	initialExpected := GetAccountExpectedBalance(t, testAccount.ID)
+
+	created, err := testStore.InsertIncome(testCtx, types.Income{
+		Date:        time.Now().Format(time.DateTime),
+		Amount:      types.MoneyFromFloat(750.00),
+		Description: "Duplicate salary",
+		AccountId:   testAccount.ID,
+		AccountName: testAccount.Name,
+	})
+	AssertNoError(t, err, "Insert income")
+
+	deleted, err := testStore.DeleteIncome(testCtx, created.Id)
+	AssertNoError(t, err, "Delete income")
+	AssertEqual(t, created.Id, deleted.Id, "Deleted income ID")
+
+	AssertEqual(t, 0, CountTableRows(t, "incomes"), "Income row removed")
+	AssertFloatEqual(t, initialExpected, GetAccountExpectedBalance(t, testAccount.ID), 0.01,
+		"Expected balance restored after delete")
+
+	_, err = testStore.DeleteIncome(testCtx, created.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+// TestIncomeLifecycleFollowsRepaymentDebt verifies a repayment's debt follows its income
+func TestIncomeLifecycleFollowsRepaymentDebt(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testAccount := GetTestAccount(TestAccountBankID)
+	testDebtor := GetTestDebtor(TestDebtorJohnID)
+	now := time.Now()
+	accountId := testAccount.ID
+
+	incomeResult, _, err := testStore.RecordDebtRepayment(testCtx, types.Income{
+		Date:        now.Format(time.DateTime),
+		Amount:      types.MoneyFromFloat(50.00),
+		Description: "Repayment from John",
+		AccountId:   testAccount.ID,
+		AccountName: testAccount.Name,
+	}, types.Debt{
+		Description:    "Repayment from John",
+		Amount:         types.MoneyFromFloat(50.00),
+		DebtorId:       testDebtor.ID,
+		DebtorName:     testDebtor.Name,
+		Date:           now.Format(time.DateTime),
+		OriginalAmount: types.MoneyFromFloat(50.00),
+		Currency:       "USD",
+		Outbound:       false,
+		AccountId:      &accountId,
+	})
+	AssertNoError(t, err, "Record debt repayment")
+
+	incomeResult.Amount = types.MoneyFromFloat(80.00)
+	_, err = testStore.UpdateIncome(testCtx, incomeResult)
+	AssertNoError(t, err, "Update repayment income")
+
+	var debtAmount float64
+	err = testPool.QueryRow(context.Background(),
+		`SELECT amount FROM debts WHERE income_id = $1`, incomeResult.Id,
+	).Scan(&debtAmount)
+	AssertNoError(t, err, "Query linked debt")
+	AssertFloatEqual(t, 80.00, debtAmount, 0.01, "Linked debt follows the income amount")
+
+	_, err = testStore.DeleteIncome(testCtx, incomeResult.Id)
+	AssertNoError(t, err, "Delete repayment income")
+	AssertEqual(t, 0, CountTableRows(t, "debts"), "Linked debt removed with the income")
+}
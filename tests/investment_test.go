@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== CAPITAL INVARIANT ==========
+// CRITICAL: updating or deleting an investment MUST leave capital as if only
+// the final version had ever been inserted
+
+// TestInvestmentUpdateMovesCapital verifies an amount/type change reverses the old capital change
+func TestInvestmentUpdateMovesCapital(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testInvAccount := GetTestInvestmentAccount(TestInvAccountCryptoID)
+	testFiatAccount := GetTestAccount(TestAccountBankID)
+
+	created, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:            time.Now().Format(time.DateTime),
+		Description:     "Deposit",
+		Amount:          types.MoneyFromFloat(500.00),
+		AccountId:       testInvAccount.ID,
+		AccountName:     testInvAccount.Name,
+		Type:            "deposit",
+		SourceAccountId: &testFiatAccount.ID,
+	})
+	AssertNoError(t, err, "Insert investment")
+	AssertFloatEqual(t, testInvAccount.StartingCapital+500.00, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital after deposit")
+
+	// Turn the deposit into a smaller withdrawal
+	created.Amount = types.MoneyFromFloat(200.00)
+	created.Type = "withdrawal"
+	updated, err := testStore.UpdateInvestment(testCtx, created)
+	AssertNoError(t, err, "Update investment")
+	AssertEqual(t, "withdrawal", updated.Type, "Updated type")
+
+	AssertEqual(t, 1, CountTableRows(t, "investments"), "Update must not insert a new row")
+	AssertFloatEqual(t, testInvAccount.StartingCapital-200.00, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital reflects only the updated withdrawal")
+}
+
+// TestInvestmentUpdateChangesAccount verifies capital moves between accounts
+func TestInvestmentUpdateChangesAccount(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	cryptoAccount := GetTestInvestmentAccount(TestInvAccountCryptoID)
+	brokerAccount := GetTestInvestmentAccount(TestInvAccountBrokerID)
+	testFiatAccount := GetTestAccount(TestAccountBankID)
+
+	created, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:            time.Now().Format(time.DateTime),
+		Description:     "Wrong account",
+		Amount:          types.MoneyFromFloat(300.00),
+		AccountId:       cryptoAccount.ID,
+		AccountName:     cryptoAccount.Name,
+		Type:            "deposit",
+		SourceAccountId: &testFiatAccount.ID,
+	})
+	AssertNoError(t, err, "Insert investment")
+
+	created.AccountId = brokerAccount.ID
+	created.AccountName = brokerAccount.Name
+	_, err = testStore.UpdateInvestment(testCtx, created)
+	AssertNoError(t, err, "Update investment")
+
+	AssertFloatEqual(t, cryptoAccount.StartingCapital, GetInvestmentAccountCapital(t, cryptoAccount.ID), 0.01,
+		"Old account capital restored")
+	AssertFloatEqual(t, brokerAccount.StartingCapital+300.00, GetInvestmentAccountCapital(t, brokerAccount.ID), 0.01,
+		"New account capital increased")
+}
+
+// TestInvestmentUpdateRejectsInvalidType verifies a bad type leaves capital untouched
+func TestInvestmentUpdateRejectsInvalidType(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testInvAccount := GetTestInvestmentAccount(TestInvAccountCryptoID)
+
+	created, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:        time.Now().Format(time.DateTime),
+		Description: "Deposit",
+		Amount:      types.MoneyFromFloat(100.00),
+		AccountId:   testInvAccount.ID,
+		AccountName: testInvAccount.Name,
+		Type:        "deposit",
+	})
+	AssertNoError(t, err, "Insert investment")
+
+	created.Type = "transfer"
+	_, err = testStore.UpdateInvestment(testCtx, created)
+	AssertError(t, err, "Invalid type should be rejected")
+	AssertFloatEqual(t, testInvAccount.StartingCapital+100.00, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital unchanged after rejected update")
+}
+
+// TestInvestmentUpdateRejectsInvalidAmount verifies a zero amount is refused as
+// invalid and leaves capital untouched
+func TestInvestmentUpdateRejectsInvalidAmount(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testInvAccount := GetTestInvestmentAccount(TestInvAccountCryptoID)
+
+	created, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:        time.Now().Format(time.DateTime),
+		Description: "Deposit",
+		Amount:      types.MoneyFromFloat(100.00),
+		AccountId:   testInvAccount.ID,
+		AccountName: testInvAccount.Name,
+		Type:        "deposit",
+	})
+	AssertNoError(t, err, "Insert investment")
+
+	created.Amount = types.Money{}
+	_, err = testStore.UpdateInvestment(testCtx, created)
+	if !errors.Is(err, types.ErrInvalid) {
+		t.Errorf("Zero amount should be invalid, got %v", err)
+	}
+	AssertFloatEqual(t, testInvAccount.StartingCapital+100.00, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital unchanged after rejected update")
+}
+
+// TestInvestmentDeleteReversesCapital verifies delete undoes deposits and withdrawals
+func TestInvestmentDeleteReversesCapital(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	testInvAccount := GetTestInvestmentAccount(TestInvAccountBrokerID)
+
+	deposit, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:        time.Now().Format(time.DateTime),
+		Description: "Deposit",
+		Amount:      types.MoneyFromFloat(1000.00),
+		AccountId:   testInvAccount.ID,
+		AccountName: testInvAccount.Name,
+		Type:        "deposit",
+	})
+	AssertNoError(t, err, "Insert deposit")
+
+	withdrawal, err := testStore.InsertInvestment(testCtx, types.Investment{
+		Date:        time.Now().Format(time.DateTime),
+		Description: "Withdrawal",
+		Amount:      types.MoneyFromFloat(400.00),
+		AccountId:   testInvAccount.ID,
+		AccountName: testInvAccount.Name,
+		Type:        "withdrawal",
+	})
+	AssertNoError(t, err, "Insert withdrawal")
+
+	_, err = testStore.DeleteInvestment(testCtx, withdrawal.Id)
+	AssertNoError(t, err, "Delete withdrawal")
+	AssertFloatEqual(t, testInvAccount.StartingCapital+1000.00, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital after deleting withdrawal")
+
+	_, err = testStore.DeleteInvestment(testCtx, deposit.Id)
+	AssertNoError(t, err, "Delete deposit")
+	AssertFloatEqual(t, testInvAccount.StartingCapital, GetInvestmentAccountCapital(t, testInvAccount.ID), 0.01,
+		"Capital back to starting capital")
+	AssertEqual(t, 0, CountTableRows(t, "investments"), "Investment rows removed")
+
+	_, err = testStore.DeleteInvestment(testCtx, deposit.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
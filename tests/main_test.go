@@ -4,14 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"testing"
 
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+	"github.com/carlosdimatteo/fintrack-backend-go/api"
+	"github.com/carlosdimatteo/fintrack-backend-go/auth"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var testPool *pgxpool.Pool
 
+// testStore is the postgres store the tests and the test router share testPool through
+var testStore *postgres.Store
+
+// testCtx scopes store calls to the test household, as its owner would see it
+var testCtx = types.WithScope(context.Background(), types.Scope{HouseholdId: TestHouseholdID, UserId: TestUserID})
+
 // TestMain runs before all tests - sets up DB connection
 func TestMain(m *testing.M) {
 	// Get database URL - fallback to local postgres
@@ -36,8 +49,24 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Unable to ping database: %v", err)
 	}
 
+	testStore = postgres.NewWithPool(testPool)
 	fmt.Println("[test] Connected to test database")
 
+	// Bring the schema up to date from the embedded migrations
+	applied, err := testStore.MigrateUp(testCtx)
+	if err != nil {
+		log.Fatalf("Unable to migrate test database: %v", err)
+	}
+	for _, m := range applied {
+		fmt.Printf("[test] Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+
+	// Issue the key test requests authenticate with
+	testAPIKey, _, err = auth.IssueAPIKey(context.Background(), testStore, TestUserID, "tests")
+	if err != nil {
+		log.Fatalf("Unable to issue test API key: %v", err)
+	}
+
 	// Run tests
 	code := m.Run()
 
@@ -97,14 +126,14 @@ func SeedTestData(t *testing.T) {
 	// Seed accounts from fixtures
 	for _, acc := range TestAccounts {
 		_, err := testPool.Exec(ctx, `
-			INSERT INTO accounts (id, name, description, type, currency, balance, starting_balance, starting_date)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			INSERT INTO accounts (id, name, description, type, currency, balance, starting_balance, starting_date, household_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 			ON CONFLICT (id) DO UPDATE SET 
 				name = EXCLUDED.name,
 				balance = EXCLUDED.balance,
 				starting_balance = EXCLUDED.starting_balance`,
 			acc.ID, acc.Name, acc.Description, acc.Type, acc.Currency,
-			acc.Balance, acc.StartingBalance, acc.StartingDate)
+			acc.Balance, acc.StartingBalance, acc.StartingDate, TestHouseholdID)
 		if err != nil {
 			t.Fatalf("Failed to seed account %s: %v", acc.Name, err)
 		}
@@ -113,15 +142,15 @@ func SeedTestData(t *testing.T) {
 	// Seed investment accounts from fixtures
 	for _, acc := range TestInvestmentAccounts {
 		_, err := testPool.Exec(ctx, `
-			INSERT INTO investment_accounts (id, name, description, type, currency, balance, capital, starting_capital, starting_date)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			INSERT INTO investment_accounts (id, name, description, type, currency, balance, capital, starting_capital, starting_date, household_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			ON CONFLICT (id) DO UPDATE SET 
 				name = EXCLUDED.name,
 				balance = EXCLUDED.balance,
 				capital = EXCLUDED.capital,
 				starting_capital = EXCLUDED.starting_capital`,
 			acc.ID, acc.Name, acc.Description, acc.Type, acc.Currency,
-			acc.Balance, acc.Capital, acc.StartingCapital, acc.StartingDate)
+			acc.Balance, acc.Capital, acc.StartingCapital, acc.StartingDate, TestHouseholdID)
 		if err != nil {
 			t.Fatalf("Failed to seed investment account %s: %v", acc.Name, err)
 		}
@@ -130,10 +159,10 @@ func SeedTestData(t *testing.T) {
 	// Seed categories from fixtures
 	for _, cat := range TestCategories {
 		_, err := testPool.Exec(ctx, `
-			INSERT INTO categories (id, name, description, is_essential)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO categories (id, name, description, is_essential, household_id)
+			VALUES ($1, $2, $3, $4, $5)
 			ON CONFLICT (id) DO NOTHING`,
-			cat.ID, cat.Name, cat.Description, cat.IsEssential)
+			cat.ID, cat.Name, cat.Description, cat.IsEssential, TestHouseholdID)
 		if err != nil {
 			t.Fatalf("Failed to seed category %s: %v", cat.Name, err)
 		}
@@ -142,24 +171,34 @@ func SeedTestData(t *testing.T) {
 	// Seed debtors from fixtures
 	for _, d := range TestDebtors {
 		_, err := testPool.Exec(ctx, `
-			INSERT INTO debtors (id, name, first_name, last_name)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO debtors (id, name, first_name, last_name, household_id)
+			VALUES ($1, $2, $3, $4, $5)
 			ON CONFLICT (id) DO NOTHING`,
-			d.ID, d.Name, d.FirstName, d.LastName)
+			d.ID, d.Name, d.FirstName, d.LastName, TestHouseholdID)
 		if err != nil {
 			t.Fatalf("Failed to seed debtor %s: %v", d.Name, err)
 		}
 	}
 
-	// Ensure config entries exist for tests
+	// Seed the rate the COP account is totalled at; without one totals fail
 	_, err := testPool.Exec(ctx, `
-		INSERT INTO config (type, sheet, range)
+		INSERT INTO fx_rates (household_id, date, currency, quote, rate)
+		VALUES ($1, '2026-01-01', 'USD', 'COP', $2)
+		ON CONFLICT (household_id, currency, quote, date) DO UPDATE SET rate = EXCLUDED.rate`,
+		TestHouseholdID, TestRateUSDCOP)
+	if err != nil {
+		t.Fatalf("Failed to seed exchange rate: %v", err)
+	}
+
+	// Ensure config entries exist for tests
+	_, err = testPool.Exec(ctx, `
+		INSERT INTO config (household_id, type, sheet, range)
 		VALUES 
-			('test_expenses', 'TestSheet', 'A1'),
-			('test_income', 'TestSheet', 'B1'),
-			('test_investments', 'TestSheet', 'C1')
-		ON CONFLICT (type) DO NOTHING
-	`)
+			($1, 'test_expenses', 'TestSheet', 'A1'),
+			($1, 'test_income', 'TestSheet', 'B1'),
+			($1, 'test_investments', 'TestSheet', 'C1')
+		ON CONFLICT (household_id, type) DO NOTHING
+	`, TestHouseholdID)
 	if err != nil {
 		t.Fatalf("Failed to seed config: %v", err)
 	}
@@ -264,32 +303,57 @@ func CountTableRows(t *testing.T, table string) int {
 
 // ========== MOCK GOOGLE SHEETS ==========
 
-// MockSheetUpdates tracks sheet update calls for verification
-type MockSheetUpdates struct {
-	Calls []SheetUpdateCall
-}
+// mockSheet records every sheet write made by handlers mounted with NewTestRouter
+var mockSheet = googleSS.NewRecordingSink()
 
 type SheetUpdateCall struct {
+	Op    string // "append", "update", "cell" or "clear"
 	Range string
-	Value interface{}
+	Value interface{} // the single value of a cell update
+	Rows  [][]interface{}
 }
 
-var mockSheet = &MockSheetUpdates{}
-
 // ResetMockSheet clears all recorded calls
 func ResetMockSheet() {
-	mockSheet.Calls = nil
+	mockSheet.Reset()
 }
 
 // GetMockSheetCalls returns all recorded sheet update calls
 func GetMockSheetCalls() []SheetUpdateCall {
-	return mockSheet.Calls
+	var calls []SheetUpdateCall
+	for _, call := range mockSheet.Calls() {
+		recorded := SheetUpdateCall{Op: call.Op, Range: call.Range, Rows: call.Rows}
+		if len(call.Rows) == 1 && len(call.Rows[0]) == 1 {
+			recorded.Value = call.Rows[0][0]
+		}
+		calls = append(calls, recorded)
+	}
+	return calls
 }
 
 // RecordSheetUpdate records a sheet update (call this from mocked sheet functions)
 func RecordSheetUpdate(sheetRange string, value interface{}) {
-	mockSheet.Calls = append(mockSheet.Calls, SheetUpdateCall{
-		Range: sheetRange,
-		Value: value,
-	})
+	mockSheet.UpdateCell(sheetRange, value)
+}
+
+// testAuthSecret signs the sessions of the test router
+var testAuthSecret = []byte("integration-test-secret-at-least-32-bytes")
+
+// testAPIKey is issued in TestMain; send it with AuthorizeRequest
+var testAPIKey string
+
+// NewTestRouter mounts the API with sheet writes going straight to mockSheet
+func NewTestRouter() *mux.Router {
+	authenticator, err := auth.NewAuthenticator(testStore, testAuthSecret, 0)
+	if err != nil {
+		log.Fatalf("Unable to create authenticator: %v", err)
+	}
+	router := mux.NewRouter()
+	api.LoadRoutes(router, api.NewHandler(testStore, googleSS.NewOutbox(nil, mockSheet), authenticator))
+	return router
+}
+
+// AuthorizeRequest adds the test API key to req
+func AuthorizeRequest(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 }
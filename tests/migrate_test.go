@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+)
+
+// ========== MIGRATIONS ==========
+
+// TestMigrationsAreEmbedded verifies every version has both directions and versions don't skip
+func TestMigrationsAreEmbedded(t *testing.T) {
+	migrations, err := postgres.Migrations()
+	AssertNoError(t, err, "Load migrations")
+
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for i, m := range migrations {
+		AssertEqual(t, i+1, m.Version, "Migration versions are sequential")
+	}
+}
+
+// TestSchemaIsCurrentAfterMigrateUp verifies TestMain left nothing pending
+func TestSchemaIsCurrentAfterMigrateUp(t *testing.T) {
+	ctx := context.Background()
+	AssertNoError(t, testStore.CheckSchema(ctx), "Check schema")
+
+	statuses, err := testStore.MigrationStatus(ctx)
+	AssertNoError(t, err, "Migration status")
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("Migration %04d_%s is pending", s.Version, s.Name)
+		}
+	}
+
+	applied, err := testStore.MigrateUp(ctx)
+	AssertNoError(t, err, "Migrate up again")
+	AssertEqual(t, 0, len(applied), "Migrate up is idempotent")
+}
+
+// TestMigrateDownAndUp verifies reverting the latest migration makes the schema
+// out of date until it is applied again
+func TestMigrateDownAndUp(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := postgres.Migrations()
+	AssertNoError(t, err, "Load migrations")
+	latest := migrations[len(migrations)-1]
+
+	reverted, ok, err := testStore.MigrateDown(ctx)
+	AssertNoError(t, err, "Migrate down")
+	AssertEqual(t, true, ok, "A migration was reverted")
+	AssertEqual(t, latest.Version, reverted.Version, "Latest migration is reverted first")
+
+	err = testStore.CheckSchema(ctx)
+	if !errors.Is(err, postgres.ErrSchemaOutOfDate) {
+		t.Errorf("CheckSchema after down: expected ErrSchemaOutOfDate, got %v", err)
+	}
+
+	applied, err := testStore.MigrateUp(ctx)
+	AssertNoError(t, err, "Migrate up")
+	AssertEqual(t, 1, len(applied), "Only the reverted migration is applied")
+	AssertNoError(t, testStore.CheckSchema(ctx), "Check schema after up")
+}
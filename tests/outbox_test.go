@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+)
+
+// ========== SHEET OUTBOX ==========
+
+// resetSheetOutbox empties sheet_outbox
+func resetSheetOutbox(t *testing.T) *postgres.Store {
+	t.Helper()
+	_, err := testPool.Exec(context.Background(), `TRUNCATE TABLE sheet_outbox RESTART IDENTITY`)
+	if err != nil {
+		t.Fatalf("Failed to truncate sheet_outbox: %v", err)
+	}
+	return testStore
+}
+
+// TestSheetOutboxClaimLeasesOldestJob verifies a claimed job is not handed out twice while leased
+func TestSheetOutboxClaimLeasesOldestJob(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+
+	first, err := outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!A1","rows":[[1]]}`))
+	AssertNoError(t, err, "Enqueue first job")
+	AssertEqual(t, "pending", first.Status, "New job status")
+	_, err = outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!A1","rows":[[2]]}`))
+	AssertNoError(t, err, "Enqueue second job")
+
+	claimed, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim job")
+	if claimed == nil {
+		t.Fatal("Expected a job to be claimed")
+	}
+	AssertEqual(t, first.Id, claimed.Id, "Oldest job is claimed first")
+	AssertEqual(t, "processing", claimed.Status, "Claimed job status")
+
+	again, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim while leased")
+	if again != nil {
+		t.Errorf("Expected no job while the head is leased, got job %d", again.Id)
+	}
+
+	AssertNoError(t, outbox.CompleteSheetJob(testCtx, claimed.Id), "Complete job")
+	AssertEqual(t, 1, CountTableRows(t, "sheet_outbox"), "Completed job is removed")
+}
+
+// TestSheetOutboxExpiredLeaseIsReclaimed verifies a job survives a worker dying mid-write
+func TestSheetOutboxExpiredLeaseIsReclaimed(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+
+	job, err := outbox.EnqueueSheetJob(testCtx, "append_rows", []byte(`{"range":"TestSheet!A1","rows":[["x"]]}`))
+	AssertNoError(t, err, "Enqueue job")
+
+	claimed, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim job")
+	if claimed == nil {
+		t.Fatal("Expected a job to be claimed")
+	}
+
+	_, err = testPool.Exec(context.Background(),
+		`UPDATE sheet_outbox SET locked_until = NOW() - INTERVAL '1 second' WHERE id = $1`, job.Id)
+	AssertNoError(t, err, "Expire lease")
+
+	reclaimed, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Reclaim job")
+	if reclaimed == nil {
+		t.Fatal("Expected the expired job to be claimed again")
+	}
+	AssertEqual(t, job.Id, reclaimed.Id, "Same job is reclaimed")
+}
+
+// TestSheetOutboxRetryBlocksNewerJobs verifies jobs run in order while the head
+// backs off or is failed, until it is requeued or discarded
+func TestSheetOutboxRetryBlocksNewerJobs(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+
+	first, err := outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!B1","rows":[[1]]}`))
+	AssertNoError(t, err, "Enqueue first job")
+	second, err := outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!B1","rows":[[2]]}`))
+	AssertNoError(t, err, "Enqueue second job")
+
+	_, err = outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim first job")
+	AssertNoError(t, outbox.RetrySheetJob(testCtx, first.Id, 1, time.Now().Add(time.Hour), "quota exceeded"), "Retry first job")
+
+	blocked, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim while head backs off")
+	if blocked != nil {
+		t.Errorf("Expected no job while the head backs off, got job %d", blocked.Id)
+	}
+
+	AssertNoError(t, outbox.FailSheetJob(testCtx, first.Id, 8, "quota exceeded"), "Fail first job")
+
+	blocked, err = outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim after head failed")
+	if blocked != nil {
+		t.Errorf("Expected no job while the head is failed, got job %d", blocked.Id)
+	}
+
+	// Requeued, the head runs before the job queued after it
+	_, err = outbox.RequeueSheetJob(testCtx, first.Id)
+	AssertNoError(t, err, "Requeue first job")
+	next, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim after requeue")
+	if next == nil {
+		t.Fatal("Expected the requeued head")
+	}
+	AssertEqual(t, first.Id, next.Id, "Requeued head keeps its place")
+
+	// Discarded, it lets the second job through
+	AssertNoError(t, outbox.FailSheetJob(testCtx, first.Id, 8, "quota exceeded"), "Fail first job again")
+	_, err = outbox.DiscardSheetJob(testCtx, second.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Discard pending job: expected ErrNotFound, got %v", err)
+	}
+	_, err = outbox.DiscardSheetJob(testCtx, first.Id)
+	AssertNoError(t, err, "Discard first job")
+	next, err = outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim after discard")
+	if next == nil {
+		t.Fatal("Expected the second job once the head was discarded")
+	}
+	AssertEqual(t, second.Id, next.Id, "Discarded head no longer blocks the queue")
+}
+
+// TestSheetOutboxBackoffOnlyBlocksItsHousehold verifies a household's failing
+// spreadsheet doesn't hold up another household's writes
+func TestSheetOutboxBackoffOnlyBlocksItsHousehold(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+	other := newHouseholdScope(t, fmt.Sprintf("Outbox %d", time.Now().UnixNano()), "other-spreadsheet")
+
+	first, err := outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!C1","rows":[[1]]}`))
+	AssertNoError(t, err, "Enqueue first job")
+	_, err = outbox.EnqueueSheetJob(testCtx, "update_range", []byte(`{"range":"TestSheet!C1","rows":[[2]]}`))
+	AssertNoError(t, err, "Enqueue second job")
+	others, err := outbox.EnqueueSheetJob(other, "update_range", []byte(`{"range":"TestSheet!C1","rows":[[3]]}`))
+	AssertNoError(t, err, "Enqueue other household's job")
+
+	claimed, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim first job")
+	AssertEqual(t, first.Id, claimed.Id, "Oldest job is claimed first")
+	AssertNoError(t, outbox.RetrySheetJob(testCtx, first.Id, 1, time.Now().Add(time.Hour), "permission denied"), "Retry first job")
+
+	next, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim while the first household backs off")
+	if next == nil {
+		t.Fatal("Expected the other household's job to be claimed")
+	}
+	AssertEqual(t, others.Id, next.Id, "Other household's job runs")
+
+	blocked, err := outbox.ClaimSheetJob(testCtx, time.Minute)
+	AssertNoError(t, err, "Claim again")
+	if blocked != nil {
+		t.Errorf("Expected the first household's second job to wait, got job %d", blocked.Id)
+	}
+}
+
+// TestSheetOutboxRequeueFailedJob verifies only failed jobs can be requeued
+func TestSheetOutboxRequeueFailedJob(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+
+	job, err := outbox.EnqueueSheetJob(testCtx, "clear_expense_row", []byte(`{"config":{},"match":{}}`))
+	AssertNoError(t, err, "Enqueue job")
+
+	_, err = outbox.RequeueSheetJob(testCtx, job.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Requeue pending job: expected ErrNotFound, got %v", err)
+	}
+
+	AssertNoError(t, outbox.FailSheetJob(testCtx, job.Id, 3, "sheet row not found"), "Fail job")
+
+	failed, err := outbox.ListSheetJobs(testCtx, []string{"failed"}, 10)
+	AssertNoError(t, err, "List failed jobs")
+	AssertEqual(t, 1, len(failed), "Failed jobs listed")
+	AssertEqual(t, "sheet row not found", failed[0].LastError, "Last error is kept")
+
+	requeued, err := outbox.RequeueSheetJob(testCtx, job.Id)
+	AssertNoError(t, err, "Requeue failed job")
+	AssertEqual(t, "pending", requeued.Status, "Requeued status")
+	AssertEqual(t, 0, requeued.Attempts, "Attempts reset")
+
+	failed, err = outbox.ListSheetJobs(testCtx, []string{"failed"}, 10)
+	AssertNoError(t, err, "List failed jobs")
+	if failed == nil || len(failed) != 0 {
+		t.Errorf("Expected an empty list once the job is requeued, got %#v", failed)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	googleSS "github.com/carlosdimatteo/fintrack-backend-go/adapters/google"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== SHEET ERRORS ==========
+
+// TestSheetsDisabledWithoutCredentials verifies a missing credentials.json degrades
+// the adapter instead of exiting the process
+func TestSheetsDisabledWithoutCredentials(t *testing.T) {
+	t.Setenv("GO_ENV", "production")
+
+	_, err := googleSS.NewSheetsSink(context.Background())
+	if !errors.Is(err, googleSS.ErrSheetsDisabled) {
+		t.Fatalf("NewSheetsSink without credentials: expected ErrSheetsDisabled, got %v", err)
+	}
+
+	outbox := googleSS.NewOutbox(nil, googleSS.DisabledSink{Reason: err})
+	if !errors.Is(outbox.Disabled(), googleSS.ErrSheetsDisabled) {
+		t.Errorf("Disabled() should report the missing credentials")
+	}
+
+	err = outbox.EnqueueExpenseRow(testCtx, types.Expense{Expense: types.MoneyFromFloat(10)}, types.Config{Sheet: "TestSheet", A1Range: "!A:I"})
+	if !errors.Is(err, googleSS.ErrSheetsDisabled) {
+		t.Errorf("EnqueueExpenseRow while disabled: expected ErrSheetsDisabled, got %v", err)
+	}
+	err = outbox.Check("", "TestSheet!A1")
+	if !errors.Is(err, googleSS.ErrSheetsDisabled) {
+		t.Errorf("Check while disabled: expected ErrSheetsDisabled, got %v", err)
+	}
+}
+
+// TestSheetErrorKinds verifies callers can match on the error kind and the cause
+func TestSheetErrorKinds(t *testing.T) {
+	cause := errors.New("429 Too Many Requests")
+	var err error = &googleSS.SheetError{Op: "append", Range: "TestSheet!A1", Kind: googleSS.ErrQuota, Err: cause}
+
+	AssertEqual(t, true, errors.Is(err, googleSS.ErrQuota), "Kind matches")
+	AssertEqual(t, true, errors.Is(err, cause), "Cause matches")
+	AssertEqual(t, false, errors.Is(err, googleSS.ErrAuth), "Other kinds don't match")
+	AssertEqual(t, true, googleSS.IsTransient(err), "Quota errors are retried")
+
+	err = &googleSS.SheetError{Op: "update", Range: "Nope!ZZ", Kind: googleSS.ErrBadRange, Err: errors.New("Unable to parse range")}
+	AssertEqual(t, false, googleSS.IsTransient(err), "Bad ranges are not retried")
+	AssertEqual(t, false, googleSS.IsTransient(googleSS.ErrRowNotFound), "Missing rows are not retried")
+}
+
+// ========== EXPENSE ROW RECONCILIATION ==========
+
+// TestExpenseRowReconciliation verifies update and delete target the row that was
+// appended, telling apart expenses that only differ by date, written or read back
+// from Sheets as a serial number
+func TestExpenseRowReconciliation(t *testing.T) {
+	sink := googleSS.NewRecordingSink()
+	outbox := googleSS.NewOutbox(nil, sink)
+	config := types.Config{Sheet: "TestSheet", A1Range: "!A:I"}
+
+	first := types.Expense{Id: 1, Date: "2025-01-10 12:00:00", Category: "Food", CategoryId: TestCategoryFoodID,
+		Expense: types.MoneyFromFloat(25.50), Description: "Lunch", Method: "card", OriginalAmount: types.MoneyFromFloat(25.50), AccountId: TestAccountBankID, AccountType: "bank"}
+	second := first
+	second.Id = 2
+	second.Description = "Dinner"
+	nextDay := first
+	nextDay.Id = 3
+	nextDay.Date = "2025-01-11 12:00:00"
+	serialDay := first
+	serialDay.Id = 4
+	serialDay.Date = "2025-01-12 18:00:00"
+
+	AssertNoError(t, outbox.EnqueueExpenseRow(testCtx, first, config), "Append first row")
+	AssertNoError(t, outbox.EnqueueExpenseRow(testCtx, second, config), "Append second row")
+	AssertNoError(t, outbox.EnqueueExpenseRow(testCtx, nextDay, config), "Append next day's row")
+	// 2025-01-12 18:00 as Sheets reads it back: days since 1899-12-30
+	AssertNoError(t, sink.AppendRows("TestSheet!A:I", [][]interface{}{
+		{45669.75, "Food", 25.50, "Lunch", "card", 25.50, TestCategoryFoodID, TestAccountBankID, "bank"},
+	}), "Append serial-dated row")
+
+	updated := second
+	updated.Expense = types.MoneyFromFloat(40.00)
+	AssertNoError(t, outbox.EnqueueExpenseRowUpdate(testCtx, second, updated, config), "Update second row")
+	AssertNoError(t, outbox.EnqueueExpenseRowDelete(testCtx, first, config), "Clear first row")
+	AssertNoError(t, outbox.EnqueueExpenseRowDelete(testCtx, serialDay, config), "Clear serial-dated row")
+
+	calls := sink.Calls()
+	AssertEqual(t, 7, len(calls), "Sheet call count")
+	AssertEqual(t, "append", calls[0].Op, "First call appends")
+	AssertEqual(t, "TestSheet!A:I", calls[0].Range, "Append uses the config range")
+	AssertEqual(t, "update", calls[4].Op, "Update call")
+	AssertEqual(t, "TestSheet!A2:I2", calls[4].Range, "Update targets the second row")
+	AssertEqual(t, 40.00, calls[4].Rows[0][2].(float64), "Updated amount is written")
+	AssertEqual(t, "clear", calls[5].Op, "Delete clears")
+	AssertEqual(t, "TestSheet!A1:I1", calls[5].Range, "Delete targets the first row, not the next day's")
+	AssertEqual(t, "TestSheet!A4:I4", calls[6].Range, "Delete matches a serial date")
+
+	err := outbox.EnqueueExpenseRowDelete(testCtx, first, config)
+	if !errors.Is(err, googleSS.ErrRowNotFound) {
+		t.Errorf("Clearing a cleared row: expected ErrRowNotFound, got %v", err)
+	}
+}
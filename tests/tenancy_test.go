@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== HOUSEHOLDS ==========
+
+// newHouseholdScope creates a household with one user and returns its scope
+func newHouseholdScope(t *testing.T, name string, spreadsheetId string) context.Context {
+	t.Helper()
+	household, err := testStore.CreateHousehold(context.Background(), name, spreadsheetId)
+	AssertNoError(t, err, "Create household")
+	user, err := testStore.CreateUser(context.Background(), household.Id, name+" member")
+	AssertNoError(t, err, "Create user")
+	return types.WithScope(context.Background(), types.Scope{HouseholdId: household.Id, UserId: user.Id})
+}
+
+// TestHouseholdsAreIsolated verifies a household can't read or change another household's data
+func TestHouseholdsAreIsolated(t *testing.T) {
+	SeedTestData(t)
+	CleanupTables(t)
+	other := newHouseholdScope(t, fmt.Sprintf("Other %d", time.Now().UnixNano()), "")
+
+	account, err := testStore.InsertAccountIntoDatabase(other, types.Account{
+		Name: "Other bank", Type: "Fiat", Currency: "USD", Balance: types.MoneyFromFloat(50), StartingBalance: types.MoneyFromFloat(50),
+	})
+	AssertNoError(t, err, "Insert other household's account")
+
+	accounts, err := testStore.GetAccounts(testCtx)
+	AssertNoError(t, err, "Get accounts")
+	for _, a := range accounts {
+		if a.Id == account.Id {
+			t.Errorf("Account %d of another household is visible", account.Id)
+		}
+	}
+
+	_, err = testStore.InsertExpense(testCtx, types.Expense{
+		Category: "Food", CategoryId: TestCategoryFoodID, Expense: types.MoneyFromFloat(5),
+		AccountId: account.Id, AccountType: "Fiat",
+	})
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Expense on another household's account: expected ErrNotFound, got %v", err)
+	}
+
+	expense, err := testStore.InsertExpense(testCtx, types.Expense{
+		Category: "Food", CategoryId: TestCategoryFoodID, Expense: types.MoneyFromFloat(5),
+		AccountId: TestAccountBankID, AccountType: "Fiat",
+	})
+	AssertNoError(t, err, "Insert expense")
+
+	_, err = testStore.GetExpenseById(other, expense.Id)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Reading another household's expense: expected ErrNotFound, got %v", err)
+	}
+	_, err = testStore.DeleteExpense(other, expense.Id)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Deleting another household's expense: expected ErrNotFound, got %v", err)
+	}
+	AssertEqual(t, 1, CountTableRows(t, "expenses"), "Expense survives the other household's delete")
+}
+
+// TestPrivateAccountsAreHiddenFromHousehold verifies a private account is only seen by its owner
+func TestPrivateAccountsAreHiddenFromHousehold(t *testing.T) {
+	SeedTestData(t)
+	CleanupTables(t)
+
+	partner, err := testStore.CreateUser(context.Background(), TestHouseholdID, "Partner")
+	AssertNoError(t, err, "Create partner")
+	partnerCtx := types.WithScope(context.Background(), types.Scope{HouseholdId: TestHouseholdID, UserId: partner.Id})
+
+	owner := partner.Id
+	private, err := testStore.InsertAccountIntoDatabase(partnerCtx, types.Account{
+		Name: "Partner savings", Type: "Fiat", Currency: "USD", OwnerId: &owner,
+	})
+	AssertNoError(t, err, "Insert private account")
+
+	_, err = testStore.InsertAccountIntoDatabase(testCtx, types.Account{Name: "Not mine", Type: "Fiat", OwnerId: &owner})
+	AssertError(t, err, "Creating an account private to someone else")
+
+	seen := func(ctx context.Context) bool {
+		accounts, err := testStore.GetAccounts(ctx)
+		AssertNoError(t, err, "Get accounts")
+		for _, a := range accounts {
+			if a.Id == private.Id {
+				return true
+			}
+		}
+		return false
+	}
+	AssertEqual(t, true, seen(partnerCtx), "Owner sees the private account")
+	AssertEqual(t, false, seen(testCtx), "Other members don't see the private account")
+
+	income, err := testStore.InsertIncome(partnerCtx, types.Income{
+		Amount: types.MoneyFromFloat(20), Description: "Private income", AccountId: private.Id, AccountName: private.Name,
+	})
+	AssertNoError(t, err, "Insert private income")
+	_, err = testStore.GetIncomeById(testCtx, income.Id)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Reading a private income: expected ErrNotFound, got %v", err)
+	}
+
+	// The CLI's household-wide scope sees every member's private rows
+	householdCtx := types.WithScope(context.Background(), types.HouseholdScope(TestHouseholdID))
+	AssertEqual(t, true, seen(householdCtx), "Household-wide scope sees the private account")
+	_, err = testStore.GetIncomeById(householdCtx, income.Id)
+	AssertNoError(t, err, "Read a private income household-wide")
+}
+
+// TestNewHouseholdCategories verifies a new household can add the categories it
+// budgets with, and neither sees nor budgets another household's
+func TestNewHouseholdCategories(t *testing.T) {
+	SeedTestData(t)
+	CleanupTables(t)
+	other := newHouseholdScope(t, fmt.Sprintf("Other %d", time.Now().UnixNano()), "")
+
+	categories, err := testStore.GetCategories(other)
+	AssertNoError(t, err, "Get categories")
+	AssertEqual(t, 0, len(categories), "A new household starts without categories")
+
+	rent, err := testStore.InsertCategory(other, types.Category{Name: "Rent", IsEssential: true})
+	AssertNoError(t, err, "Insert category")
+	_, err = testStore.InsertBudgetsIntoDatabase(other, []types.Budget{{CategoryId: rent.Id, Amount: types.MoneyFromFloat(900), Month: "2024-03"}})
+	AssertNoError(t, err, "Budget the new category")
+
+	// A batch with another household's category is refused as a whole
+	_, err = testStore.InsertBudgetsIntoDatabase(other, []types.Budget{
+		{CategoryId: rent.Id, Amount: types.MoneyFromFloat(1000), Month: "2024-03"},
+		{CategoryId: TestCategoryFoodID, Amount: types.MoneyFromFloat(300), Month: "2024-03"},
+	})
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Budgeting another household's category: expected ErrNotFound, got %v", err)
+	}
+	budgets, err := testStore.GetBudgets(other, []types.Period{types.MonthPeriod(2024, 3)})
+	AssertNoError(t, err, "Get budgets")
+	AssertEqual(t, 1, len(budgets[0]), "Budgets after the refused batch")
+	AssertEqual(t, "900.00", budgets[0][0].Amount.String(), "The refused batch saves nothing")
+
+	categories, err = testStore.GetCategories(testCtx)
+	AssertNoError(t, err, "Get categories")
+	for _, c := range categories {
+		if c.Id == rent.Id {
+			t.Errorf("Category %d leaked into the test household", rent.Id)
+		}
+	}
+}
+
+// TestStoreRequiresScope verifies an unscoped call is refused rather than seeing every household
+func TestStoreRequiresScope(t *testing.T) {
+	_, err := testStore.GetAccounts(context.Background())
+	if !errors.Is(err, types.ErrNoScope) {
+		t.Errorf("Expected ErrNoScope, got %v", err)
+	}
+}
+
+// TestSheetJobsUseHouseholdSpreadsheet verifies queued writes carry their household's spreadsheet
+func TestSheetJobsUseHouseholdSpreadsheet(t *testing.T) {
+	outbox := resetSheetOutbox(t)
+	other := newHouseholdScope(t, fmt.Sprintf("Sheets %d", time.Now().UnixNano()), "other-spreadsheet")
+
+	_, err := outbox.EnqueueSheetJob(other, "update_range", []byte(`{"range":"TestSheet!A1","rows":[[1]]}`))
+	AssertNoError(t, err, "Enqueue job")
+
+	claimed, err := outbox.ClaimSheetJob(context.Background(), time.Minute)
+	AssertNoError(t, err, "Claim job")
+	AssertEqual(t, "other-spreadsheet", claimed.SpreadsheetId, "Job spreadsheet")
+
+	jobs, err := outbox.ListSheetJobs(testCtx, []string{"pending", "running"}, 10)
+	AssertNoError(t, err, "List jobs")
+	AssertEqual(t, 0, len(jobs), "Other household's jobs are not listed")
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/adapters/postgres"
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// ========== UPDATE / DELETE ==========
+
+// TestTransferUpdateRecalculatesRate verifies the derived exchange rate follows the amounts
+func TestTransferUpdateRecalculatesRate(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	source := GetTestAccount(TestAccountBankID)
+	dest := GetTestAccount(TestAccountCOPID)
+
+	created, err := testStore.InsertTransfer(testCtx, types.Transfer{
+		Date:            time.Now().Format(time.DateTime),
+		Description:     "USD to COP",
+		SourceAccountId: source.ID,
+		SourceAmount:    types.MoneyFromFloat(100.00),
+		DestAccountId:   dest.ID,
+		DestAmount:      types.MoneyFromFloat(400000.00),
+	})
+	AssertNoError(t, err, "Insert transfer")
+	AssertFloatEqual(t, 4000.00, created.ExchangeRate, 0.01, "Initial exchange rate")
+
+	created.DestAmount = types.MoneyFromFloat(410000.00)
+	updated, err := testStore.UpdateTransfer(testCtx, created)
+	AssertNoError(t, err, "Update transfer")
+	AssertFloatEqual(t, 410000.00, updated.DestAmount.Float64(), 0.01, "Updated dest amount")
+	AssertFloatEqual(t, 4100.00, updated.ExchangeRate, 0.01, "Exchange rate recalculated")
+
+	AssertFloatEqual(t, dest.StartingBalance+410000.00, GetAccountExpectedBalance(t, dest.ID), 0.01,
+		"Destination expected balance reflects the updated amount")
+	AssertEqual(t, 1, CountTableRows(t, "transfers"), "Update must not insert a new row")
+}
+
+// TestTransferDeleteRestoresExpectedBalances verifies both legs are undone
+func TestTransferDeleteRestoresExpectedBalances(t *testing.T) {
+	CleanupTables(t)
+	SeedTestData(t)
+
+	source := GetTestAccount(TestAccountBankID)
+	dest := GetTestAccount(TestAccountSavingsID)
+
+	created, err := testStore.InsertTransfer(testCtx, types.Transfer{
+		Date:            time.Now().Format(time.DateTime),
+		Description:     "To savings",
+		SourceAccountId: source.ID,
+		SourceAmount:    types.MoneyFromFloat(200.00),
+		DestAccountId:   dest.ID,
+		DestAmount:      types.MoneyFromFloat(200.00),
+	})
+	AssertNoError(t, err, "Insert transfer")
+
+	deleted, err := testStore.DeleteTransfer(testCtx, created.Id)
+	AssertNoError(t, err, "Delete transfer")
+	AssertEqual(t, created.Id, deleted.Id, "Deleted transfer ID")
+
+	AssertFloatEqual(t, source.StartingBalance, GetAccountExpectedBalance(t, source.ID), 0.01,
+		"Source expected balance restored")
+	AssertFloatEqual(t, dest.StartingBalance, GetAccountExpectedBalance(t, dest.ID), 0.01,
+		"Destination expected balance restored")
+
+	_, err = testStore.GetTransferById(testCtx, created.Id)
+	if !errors.Is(err, postgres.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// ========== TRANSACTION DATES ==========
+
+// Transaction dates are stored as text in DateLayout, so they sort and compare
+// as strings: a month is every date from its first day up to the next month's.
+// They are wall-clock times in the household's timezone, so the month a date
+// falls in is the month its household saw it happen in.
+
+// DateLayout is the stored form of a transaction date
+const DateLayout = time.DateTime
+
+// dateLayouts are the forms clients may send a transaction date in
+var dateLayouts = []string{time.DateTime, time.DateOnly, time.RFC3339Nano}
+
+// ParseDate parses a date sent by a client: "2006-01-02", "2006-01-02 15:04:05"
+// or RFC 3339
+func ParseDate(value string) (time.Time, error) {
+	return ParseDateIn(value, time.UTC)
+}
+
+// ParseDateIn is ParseDate for a client in loc: a date without an offset is a
+// wall-clock time there, one with an offset is converted to it
+func ParseDateIn(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+}
+
+// LoadTimezone loads a household timezone, an IANA name like
+// "America/Bogota"; empty is UTC. "Local" is refused: it would be whatever
+// the server runs in.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("invalid timezone: %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %q", name)
+	}
+	return loc, nil
+}
+
+// FormatDate is t in the stored form
+func FormatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
+
+// MonthLayout is the form of a budget month
+const MonthLayout = "2006-01"
+
+// ParseMonth parses a budget month, "2006-01"
+func ParseMonth(value string) (year int, month int, err error) {
+	t, err := time.Parse(MonthLayout, value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid month: %q", value)
+	}
+	return t.Year(), int(t.Month()), nil
+}
+
+// FormatMonth is year/month as a budget month
+func FormatMonth(year int, month int) string {
+	return fmt.Sprintf("%04d-%02d", year, month)
+}
+
+// MonthRange is the stored-form bounds [from, to) of the dates in year/month
+func MonthRange(year int, month int) (string, string) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return FormatDate(start), FormatDate(start.AddDate(0, 1, 0))
+}
+
+// YearRange is the stored-form bounds [from, to) of the dates in year
+func YearRange(year int) (string, string) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return FormatDate(start), FormatDate(start.AddDate(1, 0, 0))
+}
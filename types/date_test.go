@@ -0,0 +1,39 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// TestParseDateIn verifies dates without an offset are wall-clock times in the
+// household's timezone and dates with one are converted to it
+func TestParseDateIn(t *testing.T) {
+	bogota, err := types.LoadTimezone("America/Bogota")
+	if err != nil {
+		t.Fatalf("Load timezone: %v", err)
+	}
+
+	cases := map[string]string{
+		"2024-01-31":                "2024-01-31 00:00:00",
+		"2024-01-31 23:30:00":       "2024-01-31 23:30:00",
+		"2024-02-01T04:30:00Z":      "2024-01-31 23:30:00",
+		"2024-01-31T23:30:00-05:00": "2024-01-31 23:30:00",
+	}
+	for value, expected := range cases {
+		parsed, err := types.ParseDateIn(value, bogota)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", value, err)
+			continue
+		}
+		if got := types.FormatDate(parsed); got != expected {
+			t.Errorf("%s: expected %s, got %s", value, expected, got)
+		}
+	}
+
+	for _, name := range []string{"Mars/Olympus", "Local"} {
+		if _, err := types.LoadTimezone(name); err == nil {
+			t.Errorf("%s: expected an invalid timezone", name)
+		}
+	}
+}
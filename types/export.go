@@ -0,0 +1,33 @@
+package types
+
+// ========== EXPORTS ==========
+
+// Export formats
+const (
+	ExportCSV       = "csv"
+	ExportJSON      = "json"
+	ExportLedger    = "ledger"
+	ExportBeancount = "beancount"
+)
+
+// Transactions are every transaction recorded in a period, oldest first
+type Transactions struct {
+	Expenses    []Expense    `json:"expenses"`
+	Incomes     []Income     `json:"incomes"`
+	Transfers   []Transfer   `json:"transfers"`
+	Investments []Investment `json:"investments"`
+	Debts       []Debt       `json:"debts"`
+}
+
+// Export is a household's data: the transactions of a period and the
+// accounts, categories, debtors and rates they refer to
+type Export struct {
+	Household          Household           `json:"household"`
+	Period             Period              `json:"period"` // an empty start or end is open
+	Categories         []Category          `json:"categories"`
+	Accounts           []Account           `json:"accounts"`
+	InvestmentAccounts []InvestmentAccount `json:"investment_accounts"`
+	Debtors            []Debtor            `json:"debtors"`
+	FXRates            FXRates             `json:"fx_rates"`
+	Transactions
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ========== EXCHANGE RATES ==========
+
+// An amount is in the currency of the account it was recorded against. Every
+// total (sums, budgets, the dashboard, net worth) is in the household's base
+// currency instead, converting each amount at the rate in effect on its date:
+// the latest rate dated on or before it, or the earliest one when there is none
+// before. A total that needs a rate nobody stored fails with an *FXRateError
+// rather than counting the amount as if it were in the base currency.
+
+// FXSourceManual and FXSourceCSV tell where a rate came from
+const (
+	FXSourceManual = "manual"
+	FXSourceCSV    = "csv"
+)
+
+// FXRate is what one unit of Currency was worth in Quote on Date
+type FXRate struct {
+	Id        int32     `json:"id,omitempty"`
+	Date      string    `json:"date"` // 2006-01-02
+	Currency  string    `json:"currency"`
+	Quote     string    `json:"quote"`
+	Rate      float64   `json:"rate"`
+	Source    string    `json:"source,omitempty"` // manual or csv
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// FXRates are the rates conversions are made with
+type FXRates []FXRate
+
+// FXRateError is a conversion between two currencies without any rate
+type FXRateError struct {
+	From string
+	To   string
+}
+
+func (e *FXRateError) Error() string {
+	return fmt.Sprintf("no exchange rate from %s to %s", e.From, e.To)
+}
+
+// Rate is how much one unit of from is worth in to on date (a stored-form
+// date or 2006-01-02). It is 1 when from and to are the same or from is empty,
+// and an *FXRateError when no rate between them is known.
+func (rates FXRates) Rate(from string, to string, date string) (float64, error) {
+	if from == "" || from == to {
+		return 1, nil
+	}
+	if rate, ok := rates.Lookup(from, to, date); ok {
+		return rate, nil
+	}
+	return 0, &FXRateError{From: from, To: to}
+}
+
+// Lookup is the rate in effect on date from a rate between from and to in
+// either direction; false when they are the same currency or no rate is known
+func (rates FXRates) Lookup(from string, to string, date string) (float64, bool) {
+	if from == "" || to == "" || from == to {
+		return 0, false
+	}
+	day := date
+	if len(day) > len(time.DateOnly) {
+		day = day[:len(time.DateOnly)]
+	}
+
+	var rate float64
+	var best string
+	before := false
+	for _, r := range rates {
+		var value float64
+		switch {
+		case r.Currency == from && r.Quote == to:
+			value = r.Rate
+		case r.Currency == to && r.Quote == from && r.Rate != 0:
+			value = 1 / r.Rate
+		default:
+			continue
+		}
+
+		// The latest on or before day wins; failing that, the earliest after it
+		if r.Date <= day {
+			if !before || r.Date > best {
+				rate, best, before = value, r.Date, true
+			}
+		} else if !before && (best == "" || r.Date < best) {
+			rate, best = value, r.Date
+		}
+	}
+	return rate, best != ""
+}
+
+// Convert is amount in from converted to to at the rate in effect on date. An
+// amount that carries a currency other than from is an error.
+func (rates FXRates) Convert(amount Money, from string, to string, date string) (Money, error) {
+	if err := amount.Err(); err != nil {
+		return Money{}, err
+	}
+	if amount.currency != "" && amount.currency != from {
+		return Money{}, fmt.Errorf("converting a %s amount from %s", amount.currency, from)
+	}
+	rate, err := rates.Rate(from, to, date)
+	if err != nil {
+		return Money{}, err
+	}
+	return amount.Times(rate).In(to), nil
+}
+
+// CostAgainst sets t's reference rate and what converting at its own rate cost
+// against it, in the destination currency
+func (t *Transfer) CostAgainst(rate float64) {
+	t.ReferenceRate = rate
+	t.FXCost = t.SourceAmount.Times(rate).Sub(t.DestAmount)
+}
+
+// Times is m multiplied by factor, rounded to the nearest minor unit, in m's currency
+func (m Money) Times(factor float64) Money {
+	m.minor = int64(math.Round(float64(m.minor) * factor))
+	return m
+}
+
+// ParseFXRatesCSV reads rates from CSV rows of date, currency, quote and rate
+// ("2024-03-01,COP,USD,0.000256"). A first row that isn't a rate is taken as a
+// header and skipped.
+func ParseFXRatesCSV(r io.Reader) ([]FXRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []FXRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading rates: %w", err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+		rates = append(rates, FXRate{
+			Date:     strings.TrimSpace(record[0]),
+			Currency: strings.ToUpper(strings.TrimSpace(record[1])),
+			Quote:    strings.ToUpper(strings.TrimSpace(record[2])),
+			Rate:     value,
+			Source:   FXSourceCSV,
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package types_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// TestFXRate verifies the rate in effect on a date is the latest before it,
+// either way round, the earliest one for dates before every rate, and none
+// without any rate
+func TestFXRate(t *testing.T) {
+	rates := types.FXRates{
+		{Date: "2024-01-01", Currency: "USD", Quote: "COP", Rate: 4000},
+		{Date: "2024-02-01", Currency: "USD", Quote: "COP", Rate: 5000},
+	}
+
+	cases := []struct {
+		from, to, date string
+		expected       float64
+	}{
+		{"USD", "COP", "2024-01-31 23:59:59", 4000},
+		{"USD", "COP", "2024-02-01 00:00:00", 5000},
+		{"USD", "COP", "2023-06-01", 4000},
+		{"COP", "USD", "2024-03-01", 0.0002},
+		{"USD", "USD", "2024-03-01", 1},
+		{"", "USD", "2024-03-01", 1},
+	}
+	for _, c := range cases {
+		if got, err := rates.Rate(c.from, c.to, c.date); err != nil || got != c.expected {
+			t.Errorf("%s to %s on %s: expected %v, got %v (%v)", c.from, c.to, c.date, c.expected, got, err)
+		}
+	}
+
+	if got, err := rates.Convert(types.MoneyFromFloat(100000), "COP", "USD", "2024-01-15"); err != nil || got.String() != "25.00" {
+		t.Errorf("Expected 100000 COP to be 25.00 USD, got %s (%v)", got, err)
+	}
+
+	var missing *types.FXRateError
+	if _, err := rates.Convert(types.MoneyFromFloat(10), "EUR", "USD", "2024-03-01"); !errors.As(err, &missing) || missing.From != "EUR" || missing.To != "USD" {
+		t.Errorf("Expected EUR without a rate to fail, got %v", err)
+	}
+}
+
+// TestParseFXRatesCSV verifies a header is skipped and a bad rate is reported by line
+func TestParseFXRatesCSV(t *testing.T) {
+	rates, err := types.ParseFXRatesCSV(strings.NewReader("date,currency,quote,rate\n2024-03-01, cop ,usd,0.000256\n"))
+	if err != nil {
+		t.Fatalf("Parse rates: %v", err)
+	}
+	if len(rates) != 1 || rates[0].Currency != "COP" || rates[0].Quote != "USD" || rates[0].Rate != 0.000256 || rates[0].Source != types.FXSourceCSV {
+		t.Errorf("Unexpected rates: %+v", rates)
+	}
+
+	_, err = types.ParseFXRatesCSV(strings.NewReader("2024-03-01,COP,USD,0.1\n2024-03-02,COP,USD,abc\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error on line 2, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package types
+
+import "time"
+
+// ========== STATEMENT IMPORTS ==========
+
+// A bank statement is imported in two steps: a preview reads it into rows, money
+// out of the account as expenses and money in as incomes, flagging those that
+// look already recorded; a commit records the rows the client kept, all of them
+// or none.
+
+// Statement formats
+const (
+	ImportCSV = "csv"
+	ImportOFX = "ofx"
+	ImportQFX = "qfx"
+	ImportQIF = "qif"
+)
+
+// Kinds of ImportRow
+const (
+	ImportExpense = "expense"
+	ImportIncome  = "income"
+)
+
+// ImportMapping is how the columns of an account's CSV statements are read.
+// Columns are named by their header. A statement has either one signed amount
+// column or separate debit and credit columns.
+type ImportMapping struct {
+	AccountId    int32     `json:"account_id"`
+	Date         string    `json:"date"`                    // date column
+	DateFormat   string    `json:"date_format,omitempty"`   // Go layout like 02/01/2006; empty is 2006-01-02
+	Amount       string    `json:"amount,omitempty"`        // signed amount column: below zero is money out
+	Debit        string    `json:"debit,omitempty"`         // money out column
+	Credit       string    `json:"credit,omitempty"`        // money in column
+	Description  string    `json:"description"`             // description column
+	Negate       bool      `json:"negate,omitempty"`        // amounts are positive for money out, as on most card statements
+	Delimiter    string    `json:"delimiter,omitempty"`     // a single character; empty is a comma
+	DecimalComma bool      `json:"decimal_comma,omitempty"` // amounts are written 1.234,56
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// ImportRow is one transaction of a statement, as it would be recorded
+type ImportRow struct {
+	Line        int    `json:"line"` // where it is in the statement, counting from 1
+	Kind        string `json:"kind"` // expense or income
+	Date        string `json:"date"` // 2006-01-02
+	Amount      Money  `json:"amount"`
+	Description string `json:"description"`
+	CategoryId  int32  `json:"category_id,omitempty"` // expenses
+	Duplicate   bool   `json:"duplicate"`             // the account already has a transaction of the same kind, day and amount; not recorded on commit
+}
+
+// ImportPreview asks for a statement to be read into rows for account_id.
+// Expenses get category_id; a CSV is read with mapping, or else the mapping
+// saved for the account.
+type ImportPreview struct {
+	AccountId  int32          `json:"account_id"`
+	Format     string         `json:"format,omitempty"` // csv, ofx, qfx or qif; guessed from the content when empty
+	Content    string         `json:"content"`
+	CategoryId int32          `json:"category_id,omitempty"`
+	Mapping    *ImportMapping `json:"mapping,omitempty"`
+}
+
+// ImportCommit records the rows of a preview against account_id, as edited by
+// the client; duplicates are skipped
+type ImportCommit struct {
+	AccountId int32       `json:"account_id"`
+	Rows      []ImportRow `json:"rows"`
+}
+
+// ImportResult is what a commit recorded
+type ImportResult struct {
+	Expenses []Expense `json:"expenses"`
+	Incomes  []Income  `json:"incomes"`
+	Skipped  int       `json:"skipped"` // duplicates
+}
+
+// SheetHistory is the transactions read back from the sheet's ranges, to be
+// recorded as history: without touching account balances or capital
+type SheetHistory struct {
+	Expenses    []Expense    `json:"expenses"`
+	Incomes     []Income     `json:"incomes"`
+	Investments []Investment `json:"investments"`
+	Debts       []Debt       `json:"debts"`
+}
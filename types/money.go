@@ -0,0 +1,233 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in minor units (hundredths) of a currency. It
+// encodes to JSON as a plain decimal number, so clients see the same 25.5 a
+// float64 used to produce, and round-trips through NUMERIC columns as text.
+//
+// Totals carry the household's base currency and conversions the currency
+// they convert to (In attaches one); an amount without one is in that of the
+// record carrying it (Account.Currency, Debt.Currency, ...). Adding amounts of
+// two currencies gives a mixed amount, which Err reports and which can't be
+// encoded or stored.
+//
+// Rates and percentages (Transfer.ExchangeRate, SavingsRate) stay float64:
+// each is one division of exact amounts, never summed.
+type Money struct {
+	minor    int64
+	currency string
+	mixed    bool
+}
+
+// ErrMixedCurrencies is reported for an amount made from two currencies
+var ErrMixedCurrencies = errors.New("amounts of different currencies combined without converting")
+
+const moneyScale = 100
+
+// MinorUnits is an amount of n hundredths
+func MinorUnits(n int64) Money {
+	return Money{minor: n}
+}
+
+// MoneyFromFloat rounds f to the nearest minor unit. Use it only at the edges
+// (sheet cells, literals); arithmetic should stay in Money.
+func MoneyFromFloat(f float64) Money {
+	return Money{minor: int64(math.Round(f * moneyScale))}
+}
+
+// ParseMoney parses a decimal ("12.34", "-0.5", "1e3"), rounding half away from
+// zero to the nearest minor unit
+func ParseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Money{}, fmt.Errorf("invalid money amount: %q", s)
+	}
+	r.Mul(r, big.NewRat(moneyScale, 1))
+
+	// Round half away from zero: add or subtract 1/2, then truncate
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		half.Neg(half)
+	}
+	r.Add(r, half)
+	minor := new(big.Int).Quo(r.Num(), r.Denom())
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("money amount out of range: %q", s)
+	}
+	return Money{minor: minor.Int64()}, nil
+}
+
+// MinorUnits is m in hundredths
+func (m Money) MinorUnits() int64 {
+	return m.minor
+}
+
+// In is m in currency (an ISO code such as "USD")
+func (m Money) In(currency string) Money {
+	m.currency = currency
+	m.mixed = false
+	return m
+}
+
+// Currency is m's currency, or "" when it is that of the record carrying m
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// Err is ErrMixedCurrencies, naming the currencies, when m was made from
+// amounts of two of them
+func (m Money) Err() error {
+	if m.mixed {
+		return fmt.Errorf("%w: %s", ErrMixedCurrencies, m.currency)
+	}
+	return nil
+}
+
+// combine is the currency of an amount made from m and other: the one either
+// of them has, mixed when they have different ones
+func combine(minor int64, m Money, other Money) Money {
+	result := Money{minor: minor, currency: m.currency, mixed: m.mixed || other.mixed}
+	switch {
+	case other.currency == "" || other.currency == m.currency:
+	case m.currency == "":
+		result.currency = other.currency
+	default:
+		result.currency = m.currency + "+" + other.currency
+		result.mixed = true
+	}
+	return result
+}
+
+// Add is m plus other
+func (m Money) Add(other Money) Money {
+	return combine(m.minor+other.minor, m, other)
+}
+
+// Sub is m minus other
+func (m Money) Sub(other Money) Money {
+	return combine(m.minor-other.minor, m, other)
+}
+
+// Neg is -m
+func (m Money) Neg() Money {
+	m.minor = -m.minor
+	return m
+}
+
+// Cmp is -1, 0 or +1 as m is less than, equal to or more than other
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.minor < other.minor:
+		return -1
+	case m.minor > other.minor:
+		return 1
+	}
+	return 0
+}
+
+// Equal tells whether m and other are the same amount of the same currency;
+// an amount without a currency equals the same amount in any
+func (m Money) Equal(other Money) bool {
+	return m.Cmp(other) == 0 && combine(0, m, other).Err() == nil
+}
+
+// Sign is -1, 0 or +1 as m is below, at or above zero
+func (m Money) Sign() int {
+	return m.Cmp(Money{})
+}
+
+// IsZero tells whether m is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// Float64 converts to a float for ratios and for writing sheet cells
+func (m Money) Float64() float64 {
+	return float64(m.minor) / moneyScale
+}
+
+// Ratio returns m / other, or 0 when other is zero
+func (m Money) Ratio(other Money) float64 {
+	if other.minor == 0 {
+		return 0
+	}
+	return float64(m.minor) / float64(other.minor)
+}
+
+// String formats m with two decimals ("-12.30")
+func (m Money) String() string {
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyScale, minor%moneyScale)
+}
+
+// MarshalJSON writes the bare amount; the currency is named by the record or
+// the response carrying it. A mixed amount is an error.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if err := m.Err(); err != nil {
+		return nil, err
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a number or a numeric string; null leaves m unchanged.
+// The currency m had is kept.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	m.minor = parsed.minor
+	return nil
+}
+
+// Scan implements sql.Scanner; NUMERIC columns arrive as text. The currency m
+// had is kept.
+func (m *Money) Scan(src interface{}) error {
+	var parsed Money
+	var err error
+	switch v := src.(type) {
+	case nil:
+	case string:
+		parsed, err = ParseMoney(v)
+	case []byte:
+		parsed, err = ParseMoney(string(v))
+	case int64:
+		parsed = MinorUnits(v * moneyScale)
+	case float64:
+		parsed = MoneyFromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	m.minor = parsed.minor
+	return err
+}
+
+// Value implements driver.Valuer so amounts are written to NUMERIC columns
+// exactly. A mixed amount is an error.
+func (m Money) Value() (driver.Value, error) {
+	if err := m.Err(); err != nil {
+		return nil, err
+	}
+	return m.String(), nil
+}
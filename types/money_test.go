@@ -0,0 +1,124 @@
+package types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// TestParseMoney verifies decimals parse exactly and round half away from zero
+func TestParseMoney(t *testing.T) {
+	cases := map[string]types.Money{
+		"12.34":  types.MinorUnits(1234),
+		"0.1":    types.MinorUnits(10),
+		"-0.5":   types.MinorUnits(-50),
+		"1e3":    types.MinorUnits(100000),
+		"0.125":  types.MinorUnits(13),
+		"-0.125": types.MinorUnits(-13),
+		" 7 ":    types.MinorUnits(700),
+	}
+	for input, expected := range cases {
+		got, err := types.ParseMoney(input)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): unexpected error: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("ParseMoney(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+
+	if _, err := types.ParseMoney("twelve"); err == nil {
+		t.Error("ParseMoney should reject non-numeric input")
+	}
+}
+
+// TestMoneySumsWithoutDrift verifies repeated addition stays exact where float64 drifts
+func TestMoneySumsWithoutDrift(t *testing.T) {
+	var total types.Money
+	for i := 0; i < 10; i++ {
+		total = total.Add(types.MoneyFromFloat(0.1))
+	}
+	if total.String() != "1.00" {
+		t.Errorf("Ten dimes: expected 1.00, got %s", total)
+	}
+}
+
+// TestMoneyJSON verifies amounts stay plain JSON numbers and accept strings
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(types.Expense{Expense: types.MinorUnits(2550), OriginalAmount: types.MinorUnits(-5)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Unmarshal raw: %v", err)
+	}
+	if raw["expense"] != 25.5 || raw["originalAmount"] != -0.05 {
+		t.Errorf("Amounts should encode as numbers: %s", data)
+	}
+
+	var expense types.Expense
+	if err := json.Unmarshal([]byte(`{"expense": "19.99", "originalAmount": 0.3}`), &expense); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if expense.Expense != types.MinorUnits(1999) || expense.OriginalAmount != types.MinorUnits(30) {
+		t.Errorf("Unexpected amounts: %s, %s", expense.Expense, expense.OriginalAmount)
+	}
+}
+
+// TestMoneyScan verifies the values pgx hands a sql.Scanner for NUMERIC columns
+func TestMoneyScan(t *testing.T) {
+	var m types.Money
+	if err := m.Scan("1234.56"); err != nil || m != types.MinorUnits(123456) {
+		t.Errorf("Scan string: got %s, %v", m, err)
+	}
+	if err := m.Scan(nil); err != nil || m != types.MinorUnits(0) {
+		t.Errorf("Scan nil: got %s, %v", m, err)
+	}
+	if err := m.Scan(int64(3)); err != nil || m != types.MinorUnits(300) {
+		t.Errorf("Scan int64: got %s, %v", m, err)
+	}
+	value, err := types.MinorUnits(-1205).Value()
+	if err != nil || value != "-12.05" {
+		t.Errorf("Value: got %v, %v", value, err)
+	}
+}
+
+// TestMoneyCurrency verifies an amount keeps its currency through arithmetic
+// and conversion, and one mixing two currencies is an error rather than a sum
+func TestMoneyCurrency(t *testing.T) {
+	eur := types.MoneyFromFloat(10).In("EUR")
+	sum := eur.Add(types.MoneyFromFloat(2.5))
+	if sum.Currency() != "EUR" || sum.String() != "12.50" || sum.Err() != nil {
+		t.Errorf("Adding an amount without a currency: got %s %s, %v", sum, sum.Currency(), sum.Err())
+	}
+	if err := sum.Scan("3.25"); err != nil || sum.Currency() != "EUR" || sum.String() != "3.25" {
+		t.Errorf("Scanning keeps the currency: got %s %s, %v", sum, sum.Currency(), err)
+	}
+
+	mixed := eur.Sub(types.MoneyFromFloat(1).In("USD")).Add(types.MoneyFromFloat(1))
+	if !errors.Is(mixed.Err(), types.ErrMixedCurrencies) {
+		t.Errorf("EUR minus USD should be mixed, got %v", mixed.Err())
+	}
+	if _, err := json.Marshal(mixed); !errors.Is(err, types.ErrMixedCurrencies) {
+		t.Errorf("A mixed amount shouldn't encode, got %v", err)
+	}
+	if _, err := mixed.Value(); !errors.Is(err, types.ErrMixedCurrencies) {
+		t.Errorf("A mixed amount shouldn't be stored, got %v", err)
+	}
+	if eur.Equal(types.MoneyFromFloat(10).In("USD")) || !eur.Equal(types.MoneyFromFloat(10)) {
+		t.Error("Equal should tell currencies apart and accept an amount without one")
+	}
+
+	rates := types.FXRates{{Date: "2024-01-01", Currency: "EUR", Quote: "USD", Rate: 1.1}}
+	usd, err := rates.Convert(eur, "EUR", "USD", "2024-03-01")
+	if err != nil || usd.Currency() != "USD" || usd.String() != "11.00" {
+		t.Errorf("Convert: got %s %s, %v", usd, usd.Currency(), err)
+	}
+	if _, err := rates.Convert(eur, "USD", "EUR", "2024-03-01"); err == nil {
+		t.Error("Converting a EUR amount as USD should fail")
+	}
+}
@@ -0,0 +1,120 @@
+package types
+
+import "time"
+
+// ========== BUDGET PERIODS ==========
+
+// A household budgets by calendar month unless it sets a BudgetPeriod: months
+// starting on a fixed day (payday) or two-week periods. Budgets, the dashboard's
+// current period and the income summary are all counted in it.
+
+// Kinds of BudgetPeriod
+const (
+	PeriodCalendarMonth = "calendar_month"
+	PeriodMonthly       = "monthly"
+	PeriodBiweekly      = "biweekly"
+)
+
+// Budget modes of a household
+const (
+	BudgetStandard = "standard"
+	BudgetEnvelope = "envelope"
+)
+
+// BudgetPeriod is how a household splits time into budget periods
+type BudgetPeriod struct {
+	Kind     string `json:"kind"`                // calendar_month (the default), monthly or biweekly
+	StartDay int    `json:"start_day,omitempty"` // monthly: the day of the month a period starts on, 1-28
+	Anchor   string `json:"anchor,omitempty"`    // biweekly: the first day of any one period, 2006-01-02
+}
+
+// Period is one budget period: the stored-form dates from Start up to, but not
+// including, End
+type Period struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// MonthPeriod is the calendar month year/month
+func MonthPeriod(year int, month int) Period {
+	start, end := MonthRange(year, month)
+	return Period{Start: start, End: end}
+}
+
+// Containing is the period t falls in; t is read as a wall-clock time, so it
+// should already be in the household's timezone
+func (b BudgetPeriod) Containing(t time.Time) Period {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start, end time.Time
+	switch b.Kind {
+	case PeriodMonthly:
+		start = time.Date(day.Year(), day.Month(), b.StartDay, 0, 0, 0, 0, time.UTC)
+		if day.Day() < b.StartDay {
+			start = start.AddDate(0, -1, 0)
+		}
+		end = start.AddDate(0, 1, 0)
+	case PeriodBiweekly:
+		anchor, err := time.Parse(time.DateOnly, b.Anchor)
+		if err != nil {
+			anchor = time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC) // a Monday
+		}
+		days := int(day.Sub(anchor).Hours() / 24)
+		offset := days % 14
+		if offset < 0 {
+			offset += 14
+		}
+		start = day.AddDate(0, 0, -offset)
+		end = start.AddDate(0, 0, 14)
+	default:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 1, 0)
+	}
+	return Period{Start: FormatDate(start), End: FormatDate(end)}
+}
+
+// InYear are the periods that start in year, in order
+func (b BudgetPeriod) InYear(year int) []Period {
+	first, last := YearRange(year)
+	return b.Starting(first, last)
+}
+
+// Starting are the periods that start from the stored-form date from up to,
+// but not including, to, in order
+func (b BudgetPeriod) Starting(from string, to string) []Period {
+	var periods []Period
+	next, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return nil
+	}
+	for {
+		period := b.Containing(next)
+		if period.Start >= to {
+			return periods
+		}
+		if period.Start >= from {
+			periods = append(periods, period)
+		}
+		next, _ = time.Parse(DateLayout, period.End)
+	}
+}
+
+// Month is the budget month of a period: the one it starts in
+func (p Period) Month() string {
+	return p.Start[:len(MonthLayout)]
+}
+
+// Rules of a budget period: the start day or anchor its kind needs
+func (b BudgetPeriod) Rules() []Rule {
+	rules := []Rule{
+		OneOf("kind", b.Kind, PeriodCalendarMonth, PeriodMonthly, PeriodBiweekly),
+	}
+	switch b.Kind {
+	case PeriodMonthly:
+		rules = append(rules, Check("start_day", b.StartDay >= 1 && b.StartDay <= 28, "must be a day between 1 and 28"))
+	case PeriodBiweekly:
+		_, err := time.Parse(time.DateOnly, b.Anchor)
+		rules = append(rules, Check("anchor", err == nil, "must be the first day of a period, like 2006-01-02"))
+	}
+	return rules
+}
@@ -0,0 +1,42 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// TestBudgetPeriodContaining verifies each kind of period starts and ends where expected
+func TestBudgetPeriodContaining(t *testing.T) {
+	payday := types.BudgetPeriod{Kind: types.PeriodMonthly, StartDay: 25}
+	biweekly := types.BudgetPeriod{Kind: types.PeriodBiweekly, Anchor: "2024-01-01"}
+
+	cases := []struct {
+		name   string
+		period types.BudgetPeriod
+		date   string
+		start  string
+		end    string
+	}{
+		{"calendar", types.BudgetPeriod{Kind: types.PeriodCalendarMonth}, "2024-02-29", "2024-02-01", "2024-03-01"},
+		{"before payday", payday, "2024-01-24", "2023-12-25", "2024-01-25"},
+		{"on payday", payday, "2024-01-25", "2024-01-25", "2024-02-25"},
+		{"biweekly", biweekly, "2024-01-20", "2024-01-15", "2024-01-29"},
+		{"biweekly before anchor", biweekly, "2023-12-31", "2023-12-18", "2024-01-01"},
+	}
+	for _, c := range cases {
+		date, _ := time.Parse(time.DateOnly, c.date)
+		got := c.period.Containing(date)
+		if got.Start != c.start+" 00:00:00" || got.End != c.end+" 00:00:00" {
+			t.Errorf("%s: expected %s to %s, got %+v", c.name, c.start, c.end, got)
+		}
+	}
+
+	if n := len(payday.InYear(2024)); n != 12 {
+		t.Errorf("Expected 12 payday periods in 2024, got %d", n)
+	}
+	if n := len(biweekly.InYear(2024)); n != 27 {
+		t.Errorf("Expected 27 biweekly periods in 2024, got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoScope is returned by store calls made without a Scope in their context
+var ErrNoScope = errors.New("no household scope in context")
+
+// Scope is who a store call runs for: it sees its household's rows that are
+// shared or owned by UserId, and writes rows into that household
+type Scope struct {
+	HouseholdId int64
+	UserId      int64
+	Location    *time.Location // the household's timezone; nil is UTC
+	// BaseCurrency is the household's, which totals are tagged with
+	BaseCurrency string
+	// AllUsers sees every user's private rows too, for the CLI's household-wide commands
+	AllUsers bool
+}
+
+// Now is the current time in the scope's timezone. "This month" and "this
+// year" are always taken from it, never from the server clock.
+func (s Scope) Now() time.Time {
+	if s.Location == nil {
+		return time.Now().UTC()
+	}
+	return time.Now().In(s.Location)
+}
+
+// HouseholdScope is a household-wide scope, seeing the rows of every user
+func HouseholdScope(householdId int64) Scope {
+	return Scope{HouseholdId: householdId, AllUsers: true}
+}
+
+type scopeKey struct{}
+
+// WithScope returns a copy of ctx carrying scope
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom returns the scope stored in ctx by WithScope
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	return scope, ok
+}
+
+// Now is the current time in the timezone of ctx's scope, UTC without one
+func Now(ctx context.Context) time.Time {
+	scope, _ := ScopeFrom(ctx)
+	return scope.Now()
+}
@@ -1,6 +1,28 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is wrapped by store lookups, updates and deletes that match no row
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalid is wrapped by store writes refused because of what was sent, like
+// a non-positive amount; unlike other store errors their message is for the client
+var ErrInvalid = errors.New("invalid")
+
+type invalidError string
+
+func (e invalidError) Error() string { return string(e) }
+func (e invalidError) Unwrap() error { return ErrInvalid }
+
+// Invalid is an error wrapping ErrInvalid whose message is only the formatted text
+func Invalid(format string, args ...interface{}) error {
+	return invalidError(fmt.Sprintf(format, args...))
+}
 
 type Response struct {
 	Success bool   `json:"success"`
@@ -15,39 +37,58 @@ type Category struct {
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 }
 
+// BudgetMove moves money between two envelopes in the budget period its date
+// falls in
+type BudgetMove struct {
+	Id             int32     `json:"id,omitempty"`
+	Date           string    `json:"date"`
+	FromCategoryId int32     `json:"from_category_id"`
+	ToCategoryId   int32     `json:"to_category_id"`
+	Amount         Money     `json:"amount"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at,omitempty"`
+}
+
+// Budget is the amount budgeted for a category from Month on, until a later
+// month's budget for the category replaces it
 type Budget struct {
-	Id         int32   `json:"id,omitempty"`
-	CategoryId int32   `json:"category_id"`
-	Amount     float64 `json:"amount"`
+	Id         int32  `json:"id,omitempty"`
+	CategoryId int32  `json:"category_id"`
+	Amount     Money  `json:"amount"`
+	Month      string `json:"month,omitempty"` // 2006-01; defaults to the current budget month
 }
 
 type Expense struct {
-	Id             int32   `json:"id,omitempty"`
-	Date           string  `json:"date"`
-	Category       string  `json:"category"`
-	CategoryId     int32   `json:"category_id"`
-	Expense        float64 `json:"expense"`
-	Description    string  `json:"description"`
-	Method         string  `json:"method"`
-	OriginalAmount float64 `json:"originalAmount"`
-	AccountId      int32   `json:"account_id"`
-	AccountType    string  `json:"account_type"`
+	Id             int32  `json:"id,omitempty"`
+	Date           string `json:"date"`
+	Category       string `json:"category"`
+	CategoryId     int32  `json:"category_id"`
+	Expense        Money  `json:"expense"`
+	Description    string `json:"description"`
+	Method         string `json:"method"`
+	OriginalAmount Money  `json:"originalAmount"`
+	AccountId      int32  `json:"account_id"`
+	AccountType    string `json:"account_type"`
 }
 
 type BudgetByCategory struct {
-	Amount       float64 `json:"amount"`
-	Spent        float64 `json:"spent"`
-	CategoryName string  `json:"category_name"`
-	CategoryId   int32   `json:"category_id"`
+	Amount       Money  `json:"amount"`
+	Carryover    Money  `json:"carryover"` // envelope: what was left (below zero, overspent) from earlier periods
+	Moved        Money  `json:"moved"`     // envelope: money moved into the envelope this period, less money moved out
+	Available    Money  `json:"available"` // amount plus carryover and moved
+	Spent        Money  `json:"spent"`
+	Variance     Money  `json:"variance"` // available minus spent: below zero is over budget
+	CategoryName string `json:"category_name"`
+	CategoryId   int32  `json:"category_id"`
 }
 
 type DebtByDebtor struct {
-	DebtorId         int32   `json:"debtor_id"`
-	DebtorName       string  `json:"debtor_name"`
-	TotalLent        float64 `json:"total_lent"`     // Phase 1B - renamed
-	TotalReceived    float64 `json:"total_received"` // Phase 1B - renamed
-	NetOwed          float64 `json:"net_owed"`       // Phase 1B - renamed (positive = they owe you)
-	TransactionCount int32   `json:"transaction_count"`
+	DebtorId         int32  `json:"debtor_id"`
+	DebtorName       string `json:"debtor_name"`
+	TotalLent        Money  `json:"total_lent"`     // Phase 1B - renamed
+	TotalReceived    Money  `json:"total_received"` // Phase 1B - renamed
+	NetOwed          Money  `json:"net_owed"`       // Phase 1B - renamed (positive = they owe you)
+	TransactionCount int32  `json:"transaction_count"`
 }
 
 type Config struct {
@@ -59,12 +100,12 @@ type Config struct {
 type Debt struct {
 	Id             int32     `json:"id,omitempty"`
 	Description    string    `json:"description"`
-	Amount         float64   `json:"amount"`
+	Amount         Money     `json:"amount"`
 	DebtorId       int32     `json:"debtor_id"`
 	DebtorName     string    `json:"debtor_name"`
 	Date           string    `json:"date"`
 	CreatedAt      time.Time `json:"created_at,omitempty"`
-	OriginalAmount float64   `json:"original_amount"`
+	OriginalAmount Money     `json:"original_amount"`
 	Currency       string    `json:"currency"`
 	Outbound       bool      `json:"outbound"`
 	// Phase 1B additions
@@ -74,20 +115,20 @@ type Debt struct {
 }
 
 type Investment struct {
-	Id              int32   `json:"id,omitempty"`
-	Description     string  `json:"description"`
-	Amount          float64 `json:"amount"`
-	AccountId       int32   `json:"account_id"`   // Investment account ID
-	AccountName     string  `json:"account_name"` // Investment account name
-	Date            string  `json:"date"`
-	Type            string  `json:"type"`                        // "deposit" or "withdrawal"
-	SourceAccountId *int32  `json:"source_account_id,omitempty"` // Fiat account funds come from/go to
+	Id              int32  `json:"id,omitempty"`
+	Description     string `json:"description"`
+	Amount          Money  `json:"amount"`
+	AccountId       int32  `json:"account_id"`   // Investment account ID
+	AccountName     string `json:"account_name"` // Investment account name
+	Date            string `json:"date"`
+	Type            string `json:"type"`                        // "deposit" or "withdrawal"
+	SourceAccountId *int32 `json:"source_account_id,omitempty"` // Fiat account funds come from/go to
 }
 
 type Income struct {
 	Id          int32     `json:"id,omitempty"`
 	Date        string    `json:"date,omitempty"`
-	Amount      float64   `json:"amount"`
+	Amount      Money     `json:"amount"`
 	Description string    `json:"description"`
 	AccountId   int32     `json:"account_id"`
 	AccountName string    `json:"account_name"`
@@ -100,9 +141,10 @@ type Account struct {
 	Description     string    `json:"description"`
 	Type            string    `json:"type"`
 	Currency        string    `json:"currency"`
-	Balance         float64   `json:"balance"`
-	StartingBalance float64   `json:"starting_balance,omitempty"` // Phase 1 addition
-	StartingDate    time.Time `json:"starting_date,omitempty"`    // Phase 1 addition
+	Balance         Money     `json:"balance"`
+	StartingBalance Money     `json:"starting_balance"`        // Phase 1 addition
+	StartingDate    time.Time `json:"starting_date,omitempty"` // Phase 1 addition
+	OwnerId         *int64    `json:"owner_id,omitempty"`      // private to this user; nil is shared with the household
 }
 
 type InvestmentAccount struct {
@@ -111,10 +153,11 @@ type InvestmentAccount struct {
 	Description     string    `json:"description"`
 	Type            string    `json:"type"`
 	Currency        string    `json:"currency"`
-	Balance         float64   `json:"balance"`                    // Real balance from reconciliation
-	Capital         float64   `json:"capital,omitempty"`          // Running total capital
-	StartingCapital float64   `json:"starting_capital,omitempty"` // Phase 1 - fixed starting point
-	StartingDate    time.Time `json:"starting_date,omitempty"`    // Phase 1 - when tracking started
+	Balance         Money     `json:"balance"`                 // Real balance from reconciliation
+	Capital         Money     `json:"capital"`                 // Running total capital
+	StartingCapital Money     `json:"starting_capital"`        // Phase 1 - fixed starting point
+	StartingDate    time.Time `json:"starting_date,omitempty"` // Phase 1 - when tracking started
+	OwnerId         *int64    `json:"owner_id,omitempty"`      // private to this user; nil is shared with the household
 }
 
 type Debtor struct {
@@ -130,11 +173,14 @@ type RealBalanceByAccounts struct {
 	InvestmentAccounts []InvestmentAccount `json:"investment_accounts,omitempty"`
 }
 
-// MonthlyIncomeSummary represents aggregated income for a month (from view)
+// MonthlyIncomeSummary is the income of one budget period, labelled with the
+// year and month it starts in
 type MonthlyIncomeSummary struct {
-	Year        int     `json:"year"`
-	Month       int     `json:"month"`
-	TotalIncome float64 `json:"total_income"`
+	Year        int    `json:"year"`
+	Month       int    `json:"month"`
+	Start       string `json:"start,omitempty"`
+	End         string `json:"end,omitempty"`
+	TotalIncome Money  `json:"total_income"`
 }
 
 // Transfer represents a fiat-to-fiat money transfer (Phase 1B)
@@ -145,11 +191,35 @@ type Transfer struct {
 	Description       string    `json:"description"`
 	SourceAccountId   int32     `json:"source_account_id"`
 	SourceAccountName string    `json:"source_account_name,omitempty"`
-	SourceAmount      float64   `json:"source_amount"`
+	SourceAmount      Money     `json:"source_amount"`
 	DestAccountId     int32     `json:"dest_account_id"`
 	DestAccountName   string    `json:"dest_account_name,omitempty"`
-	DestAmount        float64   `json:"dest_amount"`
-	ExchangeRate      float64   `json:"exchange_rate,omitempty"` // dest_amount / source_amount
+	DestAmount        Money     `json:"dest_amount"`
+	ExchangeRate      float64   `json:"exchange_rate,omitempty"` // dest_amount / source_amount, from the exact amounts
+	// Between two currencies with a known rate: the rate in effect on the
+	// transfer's date, and what converting at exchange_rate instead cost in the
+	// destination currency (below zero, what it gained)
+	ReferenceRate float64 `json:"reference_rate,omitempty"`
+	FXCost        Money   `json:"fx_cost"`
+}
+
+// TransferFXCost is what the transfers from one account to another in another
+// currency cost in one month against the reference rate
+type TransferFXCost struct {
+	Month             string  `json:"month"` // 2006-01
+	SourceAccountId   int32   `json:"source_account_id"`
+	SourceAccountName string  `json:"source_account_name"`
+	SourceCurrency    string  `json:"source_currency"`
+	DestAccountId     int32   `json:"dest_account_id"`
+	DestAccountName   string  `json:"dest_account_name"`
+	DestCurrency      string  `json:"dest_currency"`
+	Transfers         int     `json:"transfers"`
+	SourceAmount      Money   `json:"source_amount"`
+	DestAmount        Money   `json:"dest_amount"`
+	ReferenceAmount   Money   `json:"reference_amount"` // what dest_amount would have been at the reference rate
+	Cost              Money   `json:"cost"`             // reference_amount - dest_amount, in the destination currency
+	BaseCost          Money   `json:"base_cost"`        // cost in the base currency
+	SpreadPercent     float64 `json:"spread_percent"`   // cost as a percentage of reference_amount
 }
 
 // AccountExpectedBalance from the view (Phase 1B)
@@ -157,17 +227,17 @@ type AccountExpectedBalance struct {
 	Id                         int32     `json:"id"`
 	Name                       string    `json:"name"`
 	Currency                   string    `json:"currency"`
-	StartingBalance            float64   `json:"starting_balance"`
+	StartingBalance            Money     `json:"starting_balance"`
 	StartingDate               time.Time `json:"starting_date"`
-	TotalIncome                float64   `json:"total_income"`
-	TotalExpenses              float64   `json:"total_expenses"`
-	TotalInvestmentDeposits    float64   `json:"total_investment_deposits"`
-	TotalInvestmentWithdrawals float64   `json:"total_investment_withdrawals"`
-	TotalTransfersOut          float64   `json:"total_transfers_out"`
-	TotalTransfersIn           float64   `json:"total_transfers_in"`
-	ExpectedBalance            float64   `json:"expected_balance"`
-	RealBalance                float64   `json:"real_balance"`
-	Discrepancy                float64   `json:"discrepancy"`
+	TotalIncome                Money     `json:"total_income"`
+	TotalExpenses              Money     `json:"total_expenses"`
+	TotalInvestmentDeposits    Money     `json:"total_investment_deposits"`
+	TotalInvestmentWithdrawals Money     `json:"total_investment_withdrawals"`
+	TotalTransfersOut          Money     `json:"total_transfers_out"`
+	TotalTransfersIn           Money     `json:"total_transfers_in"`
+	ExpectedBalance            Money     `json:"expected_balance"`
+	RealBalance                Money     `json:"real_balance"`
+	Discrepancy                Money     `json:"discrepancy"`
 }
 
 // InvestmentAccountSummary from the view (Phase 1)
@@ -176,26 +246,26 @@ type InvestmentAccountSummary struct {
 	Name            string  `json:"name"`
 	Type            string  `json:"type"`
 	Currency        string  `json:"currency"`
-	RealBalance     float64 `json:"real_balance"`
-	TotalCapital    float64 `json:"total_capital"`
-	StartingCapital float64 `json:"starting_capital"`
-	PnL             float64 `json:"pnl"`
-	PnLPercent      float64 `json:"pnl_percent"`
+	RealBalance     Money   `json:"real_balance"`
+	TotalCapital    Money   `json:"total_capital"`
+	StartingCapital Money   `json:"starting_capital"`
+	PnL             Money   `json:"pnl"`
+	PnLPercent      float64 `json:"pnl_percent"` // pnl / total_capital * 100, from the exact amounts
 }
 
 // InvestmentAccountExpectedCapital shows the breakdown of capital calculation
 type InvestmentAccountExpectedCapital struct {
-	Id              int32   `json:"id"`
-	Name            string  `json:"name"`
-	Type            string  `json:"type"`
-	Currency        string  `json:"currency"`
-	StartingCapital float64 `json:"starting_capital"`
-	TotalDeposits   float64 `json:"total_deposits"`
-	TotalWithdrawals float64 `json:"total_withdrawals"`
-	TotalExpenses   float64 `json:"total_expenses"`
-	ExpectedCapital float64 `json:"expected_capital"`
-	RealBalance     float64 `json:"real_balance"`
-	Discrepancy     float64 `json:"discrepancy"` // real_balance - expected_capital = PnL
+	Id               int32  `json:"id"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	Currency         string `json:"currency"`
+	StartingCapital  Money  `json:"starting_capital"`
+	TotalDeposits    Money  `json:"total_deposits"`
+	TotalWithdrawals Money  `json:"total_withdrawals"`
+	TotalExpenses    Money  `json:"total_expenses"`
+	ExpectedCapital  Money  `json:"expected_capital"`
+	RealBalance      Money  `json:"real_balance"`
+	Discrepancy      Money  `json:"discrepancy"` // real_balance - expected_capital = PnL
 }
 
 // YearlyGoals represents annual financial goals (Phase 4)
@@ -203,40 +273,87 @@ type YearlyGoals struct {
 	Id              int32     `json:"id,omitempty"`
 	CreatedAt       time.Time `json:"created_at,omitempty"`
 	Year            int       `json:"year"`
-	SavingsGoal     float64   `json:"savings_goal"`
-	InvestmentGoal  float64   `json:"investment_goal"`
-	IdealInvestment float64   `json:"ideal_investment"`
+	SavingsGoal     Money     `json:"savings_goal"`
+	InvestmentGoal  Money     `json:"investment_goal"`
+	IdealInvestment Money     `json:"ideal_investment"`
 }
 
 // NetWorthSnapshot represents a monthly snapshot of net worth (Phase 4)
 type NetWorthSnapshot struct {
-	Id                     int32     `json:"id,omitempty"`
-	CreatedAt              time.Time `json:"created_at,omitempty"`
-	Date                   time.Time `json:"date"`
-	Year                   int       `json:"year"`
-	Month                  int       `json:"month"`
+	Id        int32     `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Date      time.Time `json:"date"`
+	Year      int       `json:"year"`
+	Month     int       `json:"month"`
 	// Real balances (from accounting/reconciliation)
-	TotalFiatBalance       float64 `json:"total_fiat_balance"`
-	CryptoBalance          float64 `json:"crypto_balance"`
-	CryptoCapital          float64 `json:"crypto_capital"`
-	BrokerBalance          float64 `json:"broker_balance"`
-	BrokerCapital          float64 `json:"broker_capital"`
-	TotalInvestmentBalance float64 `json:"total_investment_balance"`
-	TotalInvestmentCapital float64 `json:"total_investment_capital"`
-	TotalRealNetWorth      float64 `json:"total_real_net_worth"`
-	TotalPnL               float64 `json:"total_pnl"`
+	TotalFiatBalance       Money `json:"total_fiat_balance"`
+	CryptoBalance          Money `json:"crypto_balance"`
+	CryptoCapital          Money `json:"crypto_capital"`
+	BrokerBalance          Money `json:"broker_balance"`
+	BrokerCapital          Money `json:"broker_capital"`
+	TotalInvestmentBalance Money `json:"total_investment_balance"`
+	TotalInvestmentCapital Money `json:"total_investment_capital"`
+	TotalRealNetWorth      Money `json:"total_real_net_worth"`
+	TotalPnL               Money `json:"total_pnl"`
 	// Expected balances (from transactions)
-	ExpectedFiatBalance float64 `json:"expected_fiat_balance"`
-	ExpectedNetWorth    float64 `json:"expected_net_worth"`
+	ExpectedFiatBalance Money `json:"expected_fiat_balance"`
+	ExpectedNetWorth    Money `json:"expected_net_worth"`
 	// Discrepancy
-	FiatDiscrepancy     float64 `json:"fiat_discrepancy"`
-	TotalDiscrepancy    float64 `json:"total_discrepancy"`
+	FiatDiscrepancy  Money `json:"fiat_discrepancy"`
+	TotalDiscrepancy Money `json:"total_discrepancy"`
 	// Percentages
 	FiatPercent   float64 `json:"fiat_percent"`
 	CryptoPercent float64 `json:"crypto_percent"`
 	BrokerPercent float64 `json:"broker_percent"`
 }
 
+// SheetJob is a Google Sheets write persisted in the sheet_outbox table until it succeeds
+type SheetJob struct {
+	Id            int64           `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Kind          string          `json:"kind"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"` // "pending", "processing" or "failed"
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	HouseholdId   int64           `json:"household_id"`
+	SpreadsheetId string          `json:"-"` // the household's; empty means the server default
+}
+
+// APIKey is an API key as stored; Hash is the SHA-256 of the key, which is only
+// shown once when it is issued
+type APIKey struct {
+	Id          int64      `json:"id"`
+	UserId      int64      `json:"user_id"`
+	HouseholdId int64      `json:"household_id"` // the user's
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Hash        string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Household is a group of users sharing accounts, categories, budgets and a spreadsheet
+type Household struct {
+	Id            int64        `json:"id"`
+	Name          string       `json:"name"`
+	SpreadsheetId string       `json:"spreadsheet_id"` // empty uses the server's SPREADSHEET_ID
+	Timezone      string       `json:"timezone"`       // IANA name months and years are counted in; empty is UTC
+	BudgetPeriod  BudgetPeriod `json:"budget_period"`  // the periods budgets are counted in
+	BudgetMode    string       `json:"budget_mode"`    // standard, or envelope to roll leftovers into the next period
+	EnvelopeSince string       `json:"envelope_since"` // envelope: the budget month leftovers roll over from, 2006-01
+	BaseCurrency  string       `json:"base_currency"`  // the currency every total is reported in
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type User struct {
+	Id          int64     `json:"id"`
+	HouseholdId int64     `json:"household_id"`
+	Name        string    `json:"name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 var ConfigType map[string]string
 
 func init() {
@@ -0,0 +1,463 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ========== VALIDATION ==========
+
+// Every request body declares what makes it valid by listing Rules; Validate
+// runs them all, then looks up the ids it refers to, and reports every field
+// that failed at once rather than the first one.
+
+// RefKind is what a request field refers to by id
+type RefKind string
+
+const (
+	RefAccount           RefKind = "account"
+	RefInvestmentAccount RefKind = "investment_account"
+	RefCategory          RefKind = "category"
+	RefDebtor            RefKind = "debtor"
+)
+
+// RefChecker tells whether an id refers to a row the caller can see.
+// Implemented by the stores.
+type RefChecker interface {
+	RefExists(ctx context.Context, kind RefKind, id int32) (bool, error)
+}
+
+// Rule is one check of one field: either a condition the request settles on its
+// own, or a reference (Ref set) that has to be looked up
+type Rule struct {
+	Field   string
+	Message string // why the field is invalid, when Failed
+	Failed  bool
+	Ref     RefKind
+	Id      int32
+}
+
+// Validatable is a request body that declares its rules
+type Validatable interface {
+	Rules() []Rule
+}
+
+// FieldError is one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Validate checks v's rules and returns a *ValidationError listing the fields
+// that failed. References are only looked up for fields that passed their other
+// rules; refs may be nil when v has none.
+func Validate(ctx context.Context, v Validatable, refs RefChecker) error {
+	rules := v.Rules()
+
+	var fields []FieldError
+	failed := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Failed && !failed[rule.Field] {
+			fields = append(fields, FieldError{Field: rule.Field, Message: rule.Message})
+			failed[rule.Field] = true
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Ref == "" || rule.Id == 0 || failed[rule.Field] {
+			continue
+		}
+		exists, err := refs.RefExists(ctx, rule.Ref, rule.Id)
+		if err != nil {
+			return fmt.Errorf("error checking %s %d: %w", rule.Ref, rule.Id, err)
+		}
+		if !exists {
+			fields = append(fields, FieldError{Field: rule.Field, Message: fmt.Sprintf("%s %d does not exist", rule.Ref, rule.Id)})
+			failed[rule.Field] = true
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// Check fails field with message unless ok
+func Check(field string, ok bool, message string) Rule {
+	return Rule{Field: field, Message: message, Failed: !ok}
+}
+
+const requiredMessage = "is required"
+
+// Required fails when a text field is blank
+func Required(field string, value string) Rule {
+	return Check(field, strings.TrimSpace(value) != "", requiredMessage)
+}
+
+// RequiredId fails when an id field is missing
+func RequiredId(field string, id int32) Rule {
+	return Check(field, id > 0, requiredMessage)
+}
+
+// RequiredFields are the top-level fields v can't be valid without: those its
+// Required and RequiredId rules reject when v is the zero value
+func RequiredFields(v Validatable) []string {
+	var fields []string
+	for _, rule := range v.Rules() {
+		if rule.Failed && rule.Message == requiredMessage && !strings.ContainsAny(rule.Field, ".[") {
+			fields = append(fields, rule.Field)
+		}
+	}
+	return fields
+}
+
+// Positive fails unless amount is greater than zero
+func Positive(field string, amount Money) Rule {
+	return Check(field, amount.Sign() > 0, "must be greater than 0")
+}
+
+// NonNegative fails when amount is below zero
+func NonNegative(field string, amount Money) Rule {
+	return Check(field, amount.Sign() >= 0, "must not be negative")
+}
+
+// OneOf fails unless value is one of allowed
+func OneOf(field string, value string, allowed ...string) Rule {
+	for _, a := range allowed {
+		if value == a {
+			return Rule{Field: field}
+		}
+	}
+	return Check(field, false, "must be one of: "+strings.Join(allowed, ", "))
+}
+
+// Date fails when a transaction date is set but isn't in a form ParseDate
+// accepts. Dates may be in the past or the future.
+func Date(field string, value string) Rule {
+	if value == "" {
+		return Rule{Field: field}
+	}
+	_, err := ParseDate(value)
+	return Check(field, err == nil, "must be a date like 2006-01-02 or 2006-01-02 15:04:05")
+}
+
+// Month fails when a budget month is set but isn't like 2006-01
+func Month(field string, value string) Rule {
+	if value == "" {
+		return Rule{Field: field}
+	}
+	_, _, err := ParseMonth(value)
+	return Check(field, err == nil, "must be a month like 2006-01")
+}
+
+// Timezone fails unless value is empty or an IANA timezone name
+func Timezone(field string, value string) Rule {
+	_, err := LoadTimezone(value)
+	return Check(field, err == nil, "must be a timezone like America/Bogota")
+}
+
+// Currency fails unless value is a currency code like USD
+func Currency(field string, value string) Rule {
+	ok := len(value) >= 3 && len(value) <= 5
+	for _, c := range value {
+		ok = ok && (c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+	}
+	return Check(field, ok, "must be a currency code like USD")
+}
+
+// Ref fails when id is set but doesn't refer to a visible row of kind. A zero
+// id is not checked; pair it with RequiredId when the field is mandatory.
+func Ref(field string, kind RefKind, id int32) Rule {
+	return Rule{Field: field, Ref: kind, Id: id}
+}
+
+// OptionalRef is Ref for a field that may be absent
+func OptionalRef(field string, kind RefKind, id *int32) Rule {
+	if id == nil {
+		return Rule{Field: field}
+	}
+	return Ref(field, kind, *id)
+}
+
+// Nested prefixes the fields of rules, for a value inside a request ("debts[0].")
+func Nested(prefix string, rules []Rule) []Rule {
+	for i := range rules {
+		rules[i].Field = prefix + rules[i].Field
+	}
+	return rules
+}
+
+// ExpenseAccountKind is the account an expense's account_id refers to;
+// account_type tells the two tables apart
+func ExpenseAccountKind(accountType string) RefKind {
+	switch accountType {
+	case "Investment", "Crypto", "Broker":
+		return RefInvestmentAccount
+	}
+	return RefAccount
+}
+
+// ========== RULES ==========
+
+func (e Expense) Rules() []Rule {
+	return []Rule{
+		Date("date", e.Date),
+		RequiredId("category_id", e.CategoryId),
+		Ref("category_id", RefCategory, e.CategoryId),
+		Positive("expense", e.Expense),
+		NonNegative("originalAmount", e.OriginalAmount),
+		RequiredId("account_id", e.AccountId),
+		Ref("account_id", ExpenseAccountKind(e.AccountType), e.AccountId),
+	}
+}
+
+func (i Income) Rules() []Rule {
+	return []Rule{
+		Date("date", i.Date),
+		Positive("amount", i.Amount),
+		RequiredId("account_id", i.AccountId),
+		Ref("account_id", RefAccount, i.AccountId),
+	}
+}
+
+func (d Debt) Rules() []Rule {
+	return []Rule{
+		Date("date", d.Date),
+		Positive("amount", d.Amount),
+		NonNegative("original_amount", d.OriginalAmount),
+		RequiredId("debtor_id", d.DebtorId),
+		Ref("debtor_id", RefDebtor, d.DebtorId),
+		OptionalRef("account_id", RefAccount, d.AccountId),
+	}
+}
+
+func (i Investment) Rules() []Rule {
+	return []Rule{
+		Date("date", i.Date),
+		Positive("amount", i.Amount),
+		OneOf("type", i.Type, "deposit", "withdrawal"),
+		RequiredId("account_id", i.AccountId),
+		Ref("account_id", RefInvestmentAccount, i.AccountId),
+		OptionalRef("source_account_id", RefAccount, i.SourceAccountId),
+	}
+}
+
+func (t Transfer) Rules() []Rule {
+	return []Rule{
+		Date("date", t.Date),
+		RequiredId("source_account_id", t.SourceAccountId),
+		Ref("source_account_id", RefAccount, t.SourceAccountId),
+		Positive("source_amount", t.SourceAmount),
+		RequiredId("dest_account_id", t.DestAccountId),
+		Check("dest_account_id", t.DestAccountId != t.SourceAccountId, "must differ from source_account_id"),
+		Ref("dest_account_id", RefAccount, t.DestAccountId),
+		Positive("dest_amount", t.DestAmount),
+		Check("exchange_rate", t.ExchangeRate >= 0, "must not be negative"),
+	}
+}
+
+func (b Budget) Rules() []Rule {
+	return []Rule{
+		RequiredId("category_id", b.CategoryId),
+		Ref("category_id", RefCategory, b.CategoryId),
+		NonNegative("amount", b.Amount),
+		Month("month", b.Month),
+	}
+}
+
+func (m BudgetMove) Rules() []Rule {
+	return []Rule{
+		Date("date", m.Date),
+		RequiredId("from_category_id", m.FromCategoryId),
+		Ref("from_category_id", RefCategory, m.FromCategoryId),
+		RequiredId("to_category_id", m.ToCategoryId),
+		Check("to_category_id", m.ToCategoryId != m.FromCategoryId, "must differ from from_category_id"),
+		Ref("to_category_id", RefCategory, m.ToCategoryId),
+		Positive("amount", m.Amount),
+	}
+}
+
+func (r FXRate) Rules() []Rule {
+	_, err := time.Parse(time.DateOnly, r.Date)
+	return []Rule{
+		Check("date", err == nil, "must be a day like 2006-01-02"),
+		Currency("currency", r.Currency),
+		Currency("quote", r.Quote),
+		Check("quote", r.Quote != r.Currency, "must differ from currency"),
+		Check("rate", r.Rate > 0, "must be greater than 0"),
+	}
+}
+
+func (rates FXRates) Rules() []Rule {
+	var rules []Rule
+	for i, rate := range rates {
+		rules = append(rules, Nested(fmt.Sprintf("[%d].", i), rate.Rules())...)
+	}
+	return rules
+}
+
+func (m ImportMapping) Rules() []Rule {
+	return append([]Rule{
+		RequiredId("account_id", m.AccountId),
+		Ref("account_id", RefAccount, m.AccountId),
+	}, m.columnRules()...)
+}
+
+// columnRules are the columns a CSV transaction can't be read without
+func (m ImportMapping) columnRules() []Rule {
+	return []Rule{
+		Required("date", m.Date),
+		Required("description", m.Description),
+		Check("amount", m.Amount != "" || m.Debit != "" && m.Credit != "", "is required unless debit and credit are set"),
+		Check("delimiter", len([]rune(m.Delimiter)) <= 1, "must be a single character"),
+	}
+}
+
+func (p ImportPreview) Rules() []Rule {
+	rules := []Rule{
+		RequiredId("account_id", p.AccountId),
+		Ref("account_id", RefAccount, p.AccountId),
+		OneOf("format", p.Format, "", ImportCSV, ImportOFX, ImportQFX, ImportQIF),
+		Required("content", p.Content),
+		Ref("category_id", RefCategory, p.CategoryId),
+	}
+	if p.Mapping != nil {
+		rules = append(rules, Nested("mapping.", p.Mapping.columnRules())...)
+	}
+	return rules
+}
+
+func (r ImportRow) Rules() []Rule {
+	rules := []Rule{
+		OneOf("kind", r.Kind, ImportExpense, ImportIncome),
+		Date("date", r.Date),
+		Required("date", r.Date),
+		Positive("amount", r.Amount),
+	}
+	if r.Kind == ImportExpense && !r.Duplicate {
+		rules = append(rules, RequiredId("category_id", r.CategoryId))
+	}
+	return append(rules, Ref("category_id", RefCategory, r.CategoryId))
+}
+
+func (c ImportCommit) Rules() []Rule {
+	rules := []Rule{
+		RequiredId("account_id", c.AccountId),
+		Ref("account_id", RefAccount, c.AccountId),
+	}
+	for i, row := range c.Rows {
+		rules = append(rules, Nested(fmt.Sprintf("rows[%d].", i), row.Rules())...)
+	}
+	return rules
+}
+
+func (c Config) Rules() []Rule {
+	return []Rule{
+		Required("type", c.Type),
+		Required("sheet", c.Sheet),
+		Required("range", c.A1Range),
+	}
+}
+
+func (a Account) Rules() []Rule {
+	return []Rule{
+		Required("name", a.Name),
+		Required("type", a.Type),
+	}
+}
+
+func (a InvestmentAccount) Rules() []Rule {
+	return []Rule{
+		Required("name", a.Name),
+		Required("type", a.Type),
+	}
+}
+
+func (c Category) Rules() []Rule {
+	return []Rule{
+		Required("name", c.Name),
+	}
+}
+
+func (d Debtor) Rules() []Rule {
+	return []Rule{
+		Required("name", d.Name),
+	}
+}
+
+func (g YearlyGoals) Rules() []Rule {
+	return []Rule{
+		Check("year", g.Year >= 1900 && g.Year <= 9999, "must be a four-digit year"),
+		NonNegative("savings_goal", g.SavingsGoal),
+		NonNegative("investment_goal", g.InvestmentGoal),
+		NonNegative("ideal_investment", g.IdealInvestment),
+	}
+}
+
+// Rules of a reconciliation: every account has to exist, its balance may be
+// anything (a credit card is usually below zero)
+func (b RealBalanceByAccounts) Rules() []Rule {
+	var rules []Rule
+	for i, a := range b.Accounts {
+		rules = append(rules, Nested(fmt.Sprintf("accounts[%d].", i), []Rule{
+			RequiredId("id", a.Id),
+			Ref("id", RefAccount, a.Id),
+		})...)
+	}
+	for i, a := range b.InvestmentAccounts {
+		rules = append(rules, Nested(fmt.Sprintf("investment_accounts[%d].", i), []Rule{
+			RequiredId("id", a.Id),
+			Ref("id", RefInvestmentAccount, a.Id),
+		})...)
+	}
+	return rules
+}
+
+func (h Household) Rules() []Rule {
+	rules := []Rule{
+		Required("name", h.Name),
+		Timezone("timezone", h.Timezone),
+		OneOf("budget_mode", h.BudgetMode, BudgetStandard, BudgetEnvelope),
+		Month("envelope_since", h.EnvelopeSince),
+		Currency("base_currency", h.BaseCurrency),
+	}
+	return append(rules, Nested("budget_period.", h.BudgetPeriod.Rules())...)
+}
+
+// Budgets is a batch of budgets, validated as a whole
+type Budgets []Budget
+
+func (b Budgets) Rules() []Rule {
+	var rules []Rule
+	for i, budget := range b {
+		rules = append(rules, Nested(fmt.Sprintf("[%d].", i), budget.Rules())...)
+	}
+	return rules
+}
+
+// Configs is a batch of config rows, validated as a whole
+type Configs []Config
+
+func (c Configs) Rules() []Rule {
+	var rules []Rule
+	for i, config := range c {
+		rules = append(rules, Nested(fmt.Sprintf("[%d].", i), config.Rules())...)
+	}
+	return rules
+}
@@ -0,0 +1,58 @@
+package types_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/carlosdimatteo/fintrack-backend-go/types"
+)
+
+// refs is a RefChecker that knows a fixed set of ids per kind
+type refs map[types.RefKind][]int32
+
+func (r refs) RefExists(ctx context.Context, kind types.RefKind, id int32) (bool, error) {
+	for _, known := range r[kind] {
+		if known == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TestValidate verifies every failing field is reported once and references are
+// only looked up for fields that passed their other rules
+func TestValidate(t *testing.T) {
+	known := refs{types.RefAccount: {1}, types.RefInvestmentAccount: {2}, types.RefCategory: {3}}
+
+	valid := types.Expense{CategoryId: 3, Expense: types.MinorUnits(1000), AccountId: 2, AccountType: "Crypto"}
+	if err := types.Validate(context.Background(), valid, known); err != nil {
+		t.Errorf("Valid expense: unexpected error: %v", err)
+	}
+
+	invalid := types.Expense{CategoryId: 9, Expense: types.MinorUnits(0), AccountId: 2, AccountType: "Fiat"}
+	err := types.Validate(context.Background(), invalid, known)
+	var validation *types.ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	expected := map[string]string{
+		"category_id": "category 9 does not exist",
+		"expense":     "must be greater than 0",
+		"account_id":  "account 2 does not exist",
+	}
+	if len(validation.Fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %+v", len(expected), validation.Fields)
+	}
+	for _, field := range validation.Fields {
+		if expected[field.Field] != field.Message {
+			t.Errorf("%s: expected %q, got %q", field.Field, expected[field.Field], field.Message)
+		}
+	}
+
+	// A missing id is reported as required, not looked up
+	err = types.Validate(context.Background(), types.Income{Amount: types.MinorUnits(100)}, nil)
+	if !errors.As(err, &validation) || len(validation.Fields) != 1 || validation.Fields[0].Message != "is required" {
+		t.Errorf("Expected account_id to be required, got %v", err)
+	}
+}